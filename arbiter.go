@@ -1,32 +1,48 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/cs"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/mainchain"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/sidechain"
+	"github.com/elastos/Elastos.ELA.Arbiter/audit"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/servers"
 	"github.com/elastos/Elastos.ELA.Arbiter/net/servers/httpjsonrpc"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/servers/httprestful"
 	"github.com/elastos/Elastos.ELA.Arbiter/password"
 	"github.com/elastos/Elastos.ELA.Arbiter/sideauxpow"
+	"github.com/elastos/Elastos.ELA.Arbiter/signer"
 	"github.com/elastos/Elastos.ELA.Arbiter/store"
 
 	"github.com/elastos/Elastos.ELA.SPV/interface"
 	"github.com/elastos/Elastos.ELA/account"
 	"github.com/elastos/Elastos.ELA/dpos/p2p/peer"
 	"github.com/elastos/Elastos.ELA/utils/elalog"
+
+	"github.com/urfave/cli"
 )
 
 var (
 	LogsPath             = filepath.Join(config.DataPath, config.LogDir)
 	ArbiterLogOutputPath = filepath.Join(LogsPath, config.ArbiterDir)
 	SpvLogOutputPath     = filepath.Join(LogsPath, config.SpvDir)
+
+	// dataDirLock is held by the running arbiter until it exits.
+	dataDirLock *store.DataDirLock
 )
 
 const (
@@ -37,7 +53,22 @@ const (
 	defaultArbiterMaxLogsFolderSize int64 = 2 * 1024
 )
 
-func init() {
+// spvLogLevel changes the level of the spv logger through the log modules,
+// the levels of elalog are the same as the arbiter log.
+type spvLogLevel struct {
+	elalog.Logger
+}
+
+func (l spvLogLevel) PrintLevel() uint8 {
+	return uint8(l.Level())
+}
+
+func (l spvLogLevel) SetPrintLevel(level uint8) {
+	l.SetLevel(elalog.Level(level))
+}
+
+// setupLog initializes the loggers of the arbiter and the spv module.
+func setupLog() {
 	spvMaxPerLogFileSize := defaultSpvMaxPerLogFileSize
 	spvMaxLogsFolderSize := defaultSpvMaxLogsFolderSize
 	if config.Parameters.MaxPerLogSize > 0 {
@@ -57,6 +88,7 @@ func init() {
 
 	spvslog := backend.Logger("SPVS", level)
 	_interface.UseLogger(spvslog)
+	log.RegisterModule(log.ModuleSPV, spvLogLevel{spvslog})
 
 	arbiterMaxPerLogFileSize := defaultArbiterMaxPerLogFileSize
 	arbiterMaxLogsFolderSize := defaultArbiterMaxLogsFolderSize
@@ -73,33 +105,103 @@ func init() {
 		arbiterMaxPerLogFileSize,
 		arbiterMaxLogsFolderSize,
 	)
+	if format := config.Parameters.Log.Format; format != "" {
+		if err := log.SetFormat(format); err != nil {
+			log.Warn(err)
+		}
+	}
+	for module, level := range config.Parameters.Log.Levels.Modules() {
+		if err := log.SetModuleLevel(module, level); err != nil {
+			log.Warn(err)
+		}
+	}
+}
 
-	log.Info("Init wallet.")
-	passwd, err := password.GetAccountPassword()
-	if err != nil {
-		log.Fatal("Get password error.")
-		os.Exit(1)
+// openSigner connects to the signer daemon of the config, or opens the
+// keystore by the password read from the source given by the flags or the
+// config.
+func openSigner(c *cli.Context) (signer.Signer, error) {
+	signerConfig := config.Parameters.Signer
+	if signerConfig.Socket == "" {
+		client, err := openKeystore(c, sideauxpow.DefaultKeystoreFile, config.Parameters.PasswordSource)
+		if err != nil {
+			return nil, err
+		}
+		return signer.NewLocal(client), nil
 	}
-	c, err := account.Open(sideauxpow.DefaultKeystoreFile, passwd)
-	if err != nil || c == nil {
-		log.Fatal("error: open wallet failed, ", err)
-		os.Exit(1)
+
+	if flagString(c, "password") != "" || flagContext(c, passwordSourceFlag.Name) != nil {
+		return nil, errors.New("--password and --password-source are not used with Signer.Socket")
 	}
+	log.Info("Connect signer", signerConfig.Socket)
+	return signer.NewRemote(signerConfig.Socket, signerConfig.Timeout*time.Millisecond)
+}
 
-	sideauxpow.Init(c)
-	arbitrator.Init(c)
-	sidechain.Init()
+// openKeystore opens the keystore file by the password given by --password,
+// or read from the source given by --password-source or defaultSource. The
+// password is wiped after the keystore is opened.
+func openKeystore(c *cli.Context, file, defaultSource string) (*account.Client, error) {
+	passwd, err := readPassword(c, defaultSource, false)
+	if err != nil {
+		return nil, err
+	}
+	defer password.Wipe(passwd)
+
+	client, err := account.Open(file, passwd)
+	if err != nil || client == nil {
+		return nil, fmt.Errorf("open wallet failed, %v", err)
+	}
+	return client, nil
 }
 
-func setSideChainAccountMonitor(arbitrator arbitrator.Arbitrator) {
-	monitor := sidechain.SideChainAccountMonitorImpl{ParentArbitrator: arbitrator}
+// readPassword reads the wallet password given by --password, or from the
+// source given by --password-source or defaultSource. The password typed in
+// the terminal is asked twice if confirm is true.
+func readPassword(c *cli.Context, defaultSource string, confirm bool) ([]byte, error) {
+	if flagPassword := flagString(c, "password"); flagPassword != "" {
+		if flagContext(c, passwordSourceFlag.Name) != nil {
+			return nil, errors.New("--password and --password-source can not be used together")
+		}
+		return []byte(flagPassword), nil
+	}
 
-	for _, side := range arbitrator.GetSideChainManager().GetAllChains() {
-		monitor.AddListener(side)
+	source := defaultSource
+	if set := flagContext(c, passwordSourceFlag.Name); set != nil {
+		source = set.String(passwordSourceFlag.Name)
+	}
+	return readPasswordSource(source, confirm)
+}
+
+// readPasswordSource reads the password from the source, the password typed
+// in the terminal is asked twice if confirm is true.
+func readPasswordSource(source string, confirm bool) ([]byte, error) {
+	s, err := password.ParseSource(source)
+	if err != nil {
+		return nil, fmt.Errorf("get password error: %v", err)
 	}
+	if confirm && s.Kind == password.SourcePrompt {
+		return password.GetConfirmedPassword()
+	}
+	passwd, err := s.Read()
+	if err != nil {
+		return nil, fmt.Errorf("get password error: %v", err)
+	}
+	return passwd, nil
+}
 
-	for _, node := range config.Parameters.SideNodeList {
-		go monitor.SyncChainData(node)
+// reloadSideChainsOnHangup reloads the side chains from the configuration
+// each time SIGHUP is received.
+func reloadSideChainsOnHangup() {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	for range hangup {
+		result, err := sidechain.Reload()
+		if err != nil {
+			log.Error("Reload side chains error:", err)
+			continue
+		}
+		log.Infof("Reloaded side chains, added %v, removed %v, updated %v",
+			result.Added, result.Removed, result.Updated)
 	}
 }
 
@@ -125,10 +227,90 @@ func initP2P(arbitrator arbitrator.Arbitrator) error {
 }
 
 func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func newApp() *cli.App {
+	app := cli.NewApp()
+	app.Name = "arbiter"
+	app.Version = config.Version
+	app.HelpName = "arbiter"
+	app.Usage = "arbiter of the elastos side chains"
+	app.UsageText = "arbiter [global options] [command [command options] [args]]"
+	app.Flags = append([]cli.Flag{accountPasswordFlag, passwordSourceFlag}, configFlags...)
+	app.Action = runNode
+	app.Commands = []cli.Command{
+		{
+			Name:   "run",
+			Usage:  "Run the arbiter, the default command",
+			Flags:  append([]cli.Flag{accountPasswordFlag, passwordSourceFlag}, configFlags...),
+			Action: runNode,
+		},
+		*newConfigCommand(),
+		*newDBCommand(),
+		*newMigrateCommand(),
+		*newWalletCommand(),
+		*newSignerCommand(),
+		*newVerifyAuditCommand(),
+		*newStatusCommand(),
+		{
+			Name:  "version",
+			Usage: "Show the version of the arbiter",
+			Action: func(c *cli.Context) error {
+				fmt.Println("Arbiter version:", config.Version)
+				fmt.Println("Go version:", runtime.Version())
+				return nil
+			},
+		},
+	}
+	return app
+}
+
+// runNode runs the arbiter until it is killed.
+func runNode(c *cli.Context) error {
+	if err := setupConfig(c); err != nil {
+		return err
+	}
+	setupLog()
+
+	// the lock is held until the arbiter exits, so the databases are not
+	// restored under a running arbiter
+	var err error
+	if dataDirLock, err = store.LockDataDir(); err != nil {
+		log.Fatalf("Lock data directory %s error: %s", store.LockFileName, err)
+		os.Exit(1)
+	}
+
+	log.Info("Init wallet.")
+	keySigner, err := openSigner(c)
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+	if err := audit.Init(audit.DefaultFile); err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+	sideauxpow.Init(keySigner)
+	if err := sideauxpow.CheckMiningAccounts(config.SideNodes()); err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+	arbitrator.Init(keySigner)
+	sidechain.Init()
+
+	startNode()
+	return nil
+}
+
+func startNode() {
 	log.Info("Arbiter version: ", config.Version)
 
 	log.Info("1. Init chain utxo cache.")
-	dataStore, err := store.OpenDataStore()
+	dataStore, finishedDataStore, err := store.OpenStores(config.Parameters.StorageBackend)
 	if err != nil {
 		log.Fatalf("Data store open failed error: [s%]", err.Error())
 		os.Exit(1)
@@ -136,12 +318,16 @@ func main() {
 	store.DbCache = *dataStore
 
 	log.Info("2. Init finished transaction cache.")
-	finishedDataStore, err := store.OpenFinishedTxsDataStore()
+	store.FinishedTxsDbCache = finishedDataStore
+
+	report, err := finishedDataStore.CheckConsistency(context.Background())
 	if err != nil {
-		log.Fatalf("Side chain monitor setup error: [s%]", err.Error())
+		log.Fatalf("Check consistency of transaction caches error: [%s]", err.Error())
 		os.Exit(1)
 	}
-	store.FinishedTxsDbCache = finishedDataStore
+	if *report != (store.ConsistencyReport{}) {
+		log.Warnf("Repaired half-applied transitions of transaction caches: %+v", *report)
+	}
 
 	currentArbitrator := arbitrator.ArbitratorGroupSingleton.GetCurrentArbitrator()
 
@@ -151,7 +337,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	setSideChainAccountMonitor(currentArbitrator)
+	sidechain.InitAccountMonitor(currentArbitrator)
 
 	log.Info("4. Init configurations.")
 	if err := arbitrator.ArbitratorGroupSingleton.InitArbitrators(); err != nil {
@@ -169,8 +355,18 @@ func main() {
 	go arbitrator.ArbitratorGroupSingleton.SyncLoop()
 
 	log.Info("7. Start servers.")
+	if err := servers.LoadClientFilter(config.Parameters.RpcConfiguration); err != nil {
+		log.Fatal("invalid RpcConfiguration: ", err)
+		os.Exit(1)
+	}
 	pServer := new(http.Server)
 	go httpjsonrpc.StartRPCServer(pServer)
+	if config.Parameters.HttpRestPort != 0 {
+		go httprestful.StartRESTServer(new(http.Server))
+	}
+	if address := config.Parameters.Debug.ListenAddress; address != "" {
+		go servers.StartDebugServer(address)
+	}
 
 	log.Info("8. Start check and remove cross chain transactions from db.")
 	go currentArbitrator.CheckAndRemoveCrossChainTransactionsFromDBLoop()
@@ -178,5 +374,14 @@ func main() {
 	log.Info("9. Start side chain account divide.")
 	go sideauxpow.SidechainAccountDivide()
 
+	log.Info("10. Reload side chains on SIGHUP.")
+	go reloadSideChainsOnHangup()
+
+	retention := config.Parameters.FinishedTxsRetention
+	if retention.SucceedDays > 0 || retention.FailedDays > 0 {
+		log.Info("11. Start prune finished transactions.")
+		go store.PruneLoop(retention)
+	}
+
 	select {}
 }
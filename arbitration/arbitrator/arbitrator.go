@@ -2,6 +2,8 @@ package arbitrator
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"path/filepath"
 	"sync"
 	"time"
@@ -9,11 +11,12 @@ import (
 	. "github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/metrics"
 	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
+	"github.com/elastos/Elastos.ELA.Arbiter/signer"
 	"github.com/elastos/Elastos.ELA.Arbiter/store"
 
 	. "github.com/elastos/Elastos.ELA.SPV/interface"
-	"github.com/elastos/Elastos.ELA/account"
 	"github.com/elastos/Elastos.ELA/common"
 	"github.com/elastos/Elastos.ELA/core/types"
 	"github.com/elastos/Elastos.ELA/core/types/payload"
@@ -32,14 +35,14 @@ type Arbitrator interface {
 
 	GetComplainSolving() ComplainSolving
 
-	Sign(content []byte) ([]byte, error)
+	GetSigner() signer.Signer
 
 	IsOnDutyOfMain() bool
 	GetArbitratorGroup() ArbitratorGroup
 	GetSideChainManager() SideChainManager
 	GetMainChain() MainChain
 
-	InitAccount(client *account.Client)
+	InitAccount(s signer.Signer)
 	StartSpvModule() error
 
 	//deposit
@@ -63,7 +66,7 @@ type ArbitratorImpl struct {
 	mainChainImpl        MainChain
 	mainChainClientImpl  MainChainClient
 	sideChainManagerImpl SideChainManager
-	client               *account.Client
+	signer               signer.Signer
 }
 
 func (ar *ArbitratorImpl) GetSideChainManager() SideChainManager {
@@ -71,9 +74,7 @@ func (ar *ArbitratorImpl) GetSideChainManager() SideChainManager {
 }
 
 func (ar *ArbitratorImpl) GetPublicKey() *crypto.PublicKey {
-	mainAccount := ar.client.GetMainAccount()
-
-	return mainAccount.PubKey()
+	return ar.signer.PublicKey()
 }
 
 func (ar *ArbitratorImpl) OnDutyArbitratorChanged(onDuty bool) {
@@ -82,18 +83,18 @@ func (ar *ArbitratorImpl) OnDutyArbitratorChanged(onDuty bool) {
 	ar.mainOnDutyMux.Unlock()
 
 	if onDuty {
-		log.Info("[OnDutyArbitratorChanged] I am on duty of main")
+		logger.Infow("on duty of main changed", "onduty", true)
 		ar.ProcessDepositTransactions()
 		ar.processWithdrawTransactions()
 		ar.ProcessSideChainPowTransaction()
 	} else {
-		log.Info("[OnDutyArbitratorChanged] I became not on duty of main")
+		logger.Infow("on duty of main changed", "onduty", false)
 	}
 }
 
 func (ar *ArbitratorImpl) ProcessDepositTransactions() {
 	if err := ar.mainChainImpl.SyncMainChainCachedTxs(); err != nil {
-		log.Warn(err)
+		logger.Warn(err)
 	}
 }
 
@@ -111,10 +112,8 @@ func (ar *ArbitratorImpl) GetComplainSolving() ComplainSolving {
 	return nil
 }
 
-func (ar *ArbitratorImpl) Sign(content []byte) ([]byte, error) {
-	mainAccount := ar.client.GetMainAccount()
-
-	return mainAccount.Sign(content)
+func (ar *ArbitratorImpl) GetSigner() signer.Signer {
+	return ar.signer
 }
 
 func (ar *ArbitratorImpl) IsOnDutyOfMain() bool {
@@ -133,11 +132,11 @@ func (ar *ArbitratorImpl) CreateWithdrawTransaction(withdrawTxs []*WithdrawTx,
 	withdrawTransaction, err := ar.mainChainImpl.CreateWithdrawTransaction(
 		sideChain, withdrawTxs, mcFunc)
 	if err != nil {
-		log.Warn(err.Error())
+		logger.Warn(err.Error())
 		return nil
 	}
 	if withdrawTransaction == nil {
-		log.Warn("Created an empty withdraw transaction.")
+		logger.Warn("Created an empty withdraw transaction.")
 		return nil
 	}
 
@@ -156,66 +155,90 @@ func (ar *ArbitratorImpl) SendDepositTransactions(spvTxs []*SpvTransaction, gene
 	var succeedGenesisAddresses []string
 	sideChain, ok := ArbitratorGroupSingleton.GetCurrentArbitrator().GetSideChainManager().GetChain(genesisAddress)
 	if !ok {
-		log.Error("[SyncMainChainCachedTxs] Get side chain from genesis address failed, genesis address:", genesisAddress)
+		logger.Errorw("get side chain from genesis address failed", log.KeySideChain, genesisAddress)
 		return
 	}
+	metrics.DepositsSeen.With(genesisAddress).Add(float64(len(spvTxs)))
+	var events []*store.TransactionEvent
 	for _, tx := range spvTxs {
 		hash := tx.MainChainTransaction.Hash()
+		txLogger := logger.With(log.KeySideChain, genesisAddress, log.KeyTx, hash)
 		resp, err := sideChain.SendTransaction(&hash)
+		newEvent := func(event string) *store.TransactionEvent {
+			return &store.TransactionEvent{
+				TransactionHash:     hash.String(),
+				GenesisBlockAddress: genesisAddress,
+				Type:                store.TxTypeDeposit,
+				Event:               event,
+			}
+		}
+		if err == nil {
+			metrics.DepositsSent.With(genesisAddress).Inc()
+			events = append(events, newEvent(store.TxEventSent))
+		}
 		if err != nil || resp.Error != nil && resp.Code != ErrInvalidMainchainTx {
-			log.Warn("Send deposit transaction failed, move to finished db, main chain tx hash:", hash.String())
 			failedMainChainTxHashes = append(failedMainChainTxHashes, hash.String())
 			failedGenesisAddresses = append(failedGenesisAddresses, genesisAddress)
+			event := newEvent(store.TxEventFailed)
+			if err != nil {
+				event.Reason = err.Error()
+			} else {
+				event.Reason = resp.Message
+			}
+			txLogger.Warnw("send deposit transaction failed, move to finished db", "reason", event.Reason)
+			events = append(events, event)
 		} else if resp.Error == nil && resp.Result != nil || resp.Error != nil && resp.Code == SCErrMainchainTxDuplicate {
+			event := newEvent(store.TxEventSucceeded)
 			if resp.Error != nil {
-				log.Info("Send deposit found transaction has been processed, move to finished db, main chain tx hash:", hash.String())
+				txLogger.Info("deposit transaction has been processed by side chain, move to finished db")
+				event.Reason = "already processed by side chain"
 			} else {
-				log.Info("Send deposit transaction succeed, move to finished db, main chain tx hash:", hash.String())
 				if txHash, ok := resp.Result.(string); ok {
-					log.Info("Send deposit transaction succeed, move to finished db, side chain tx hash:", txHash)
+					txLogger.Infow("send deposit transaction succeed, move to finished db", "sidechaintx", txHash)
+					event.ResultHash = txHash
 				} else {
-					log.Info("Send deposit transaction, received invalid response")
+					txLogger.Infow("send deposit transaction succeed, move to finished db, received invalid response",
+						"result", resp.Result)
 				}
 			}
 			succeedMainChainTxHashes = append(succeedMainChainTxHashes, hash.String())
 			succeedGenesisAddresses = append(succeedGenesisAddresses, genesisAddress)
+			events = append(events, event)
 		} else {
-			log.Warn("Send deposit transaction failed, need to resend, main chain tx hash:", hash.String())
+			reason := "empty result"
+			if resp.Error != nil {
+				reason = resp.Message
+			}
+			txLogger.Warnw("send deposit transaction failed, need to resend", "reason", reason)
 		}
 	}
+	store.RecordTransactionEvents(events)
 
-	for i := 0; i < len(failedMainChainTxHashes); i++ {
-		err := store.DbCache.MainChainStore.RemoveMainChainTxs(failedMainChainTxHashes, failedGenesisAddresses)
-		if err != nil {
-			log.Warn("Remove faild transaction from db failed")
-		}
-		err = store.FinishedTxsDbCache.AddFailedDepositTxs(failedMainChainTxHashes, failedGenesisAddresses)
-		if err != nil {
-			log.Warn("Add faild transaction to finished db failed")
-		}
+	metrics.DepositsFailed.With(genesisAddress).Add(float64(len(failedMainChainTxHashes)))
+	metrics.DepositsSucceeded.With(genesisAddress).Add(float64(len(succeedMainChainTxHashes)))
+
+	err := store.FinishedTxsDbCache.MoveFailedDepositTxs(context.Background(), failedMainChainTxHashes, failedGenesisAddresses)
+	if err != nil {
+		logger.Warnw("move failed deposit transactions to finished db failed", log.KeySideChain, genesisAddress,
+			log.KeyError, err)
 	}
-	for i := 0; i < len(succeedMainChainTxHashes); i++ {
-		err := store.DbCache.MainChainStore.RemoveMainChainTxs(succeedMainChainTxHashes, succeedGenesisAddresses)
-		if err != nil {
-			log.Warn("Remove succeed deposit transaction from db failed")
-		}
-		err = store.FinishedTxsDbCache.AddSucceedDepositTxs(succeedMainChainTxHashes, succeedGenesisAddresses)
-		if err != nil {
-			log.Warn("Add succeed deposit transaction to finished db failed")
-		}
+	err = store.FinishedTxsDbCache.MoveSucceedDepositTxs(context.Background(), succeedMainChainTxHashes, succeedGenesisAddresses)
+	if err != nil {
+		logger.Warnw("move succeed deposit transactions to finished db failed", log.KeySideChain, genesisAddress,
+			log.KeyError, err)
 	}
 }
 
 func (ar *ArbitratorImpl) BroadcastWithdrawProposal(txn *types.Transaction) {
 	err := ar.mainChainImpl.BroadcastWithdrawProposal(txn)
 	if err != nil {
-		log.Warn(err.Error())
+		logger.Warn(err.Error())
 	}
 }
 
 func (ar *ArbitratorImpl) BroadcastSidechainIllegalData(data *payload.SidechainIllegalData) {
 	if err := ar.mainChainImpl.BroadcastSidechainIllegalData(data); err != nil {
-		log.Warn(err.Error())
+		logger.Warn(err.Error())
 	}
 }
 
@@ -225,13 +248,14 @@ func (ar *ArbitratorImpl) SendWithdrawTransaction(txn *types.Transaction) (rpc.R
 		return rpc.Response{}, err
 	}
 
-	log.Info("[Rpc-sendrawtransaction] Withdraw transaction to main chain：",
-		config.Parameters.MainNode.Rpc.IpAddress, ":", config.Parameters.MainNode.Rpc.HttpJsonPort)
+	mainNode := fmt.Sprintf("%s:%d", config.Parameters.MainNode.Rpc.IpAddress,
+		config.Parameters.MainNode.Rpc.HttpJsonPort)
+	logger.Infow("send withdraw transaction to main chain", log.KeyProposal, txn.Hash(), "node", mainNode)
 	resp, err := rpc.CallAndUnmarshalResponse("sendrawtransaction",
 		rpc.Param("data", content), config.Parameters.MainNode.Rpc)
 	if err != nil {
-		log.Error("[Rpc-sendrawtransaction] Withdraw transaction to main "+
-			"chain error:", err)
+		logger.Errorw("send withdraw transaction to main chain failed", log.KeyProposal, txn.Hash(),
+			"node", mainNode, log.KeyError, err)
 		return rpc.Response{}, err
 	}
 
@@ -266,8 +290,8 @@ func (ar *ArbitratorImpl) SetSideChainManager(manager SideChainManager) {
 	ar.sideChainManagerImpl = manager
 }
 
-func (ar *ArbitratorImpl) InitAccount(client *account.Client) {
-	ar.client = client
+func (ar *ArbitratorImpl) InitAccount(s signer.Signer) {
+	ar.signer = s
 }
 
 func (ar *ArbitratorImpl) StartSpvModule() error {
@@ -285,25 +309,16 @@ func (ar *ArbitratorImpl) StartSpvModule() error {
 		return err
 	}
 
-	for _, sideNode := range config.Parameters.SideNodeList {
-		if sideNode.PowChain {
-			log.Info("[StartSpvModule] register auxpow listener:", sideNode.MiningAddr)
-			auxpowListener := &AuxpowListener{ListenAddress: sideNode.MiningAddr}
-			auxpowListener.start()
-			err = SpvService.RegisterTransactionListener(auxpowListener)
-			if err != nil {
-				return err
-			}
-		}
-
-		log.Info("[StartSpvModule] register dposit listener:", sideNode.GenesisBlockAddress)
-		dpListener := &DepositListener{ListenAddress: sideNode.GenesisBlockAddress}
-		dpListener.start()
-		err = SpvService.RegisterTransactionListener(dpListener)
+	for _, listener := range spvListeners.update(config.SideNodes()) {
+		logger.Infow("register spv listener", log.KeySideChain, listener.Address(), "type", listener.Type().Name())
+		err = SpvService.RegisterTransactionListener(listener)
 		if err != nil {
 			return err
 		}
 	}
+	if err = SpvService.RegisterBlockListener(spvListeners); err != nil {
+		return err
+	}
 
 	go SpvService.Start()
 
@@ -324,13 +339,13 @@ func (ar *ArbitratorImpl) CheckAndRemoveCrossChainTransactionsFromDBLoop() {
 	for {
 		err := ar.mainChainImpl.CheckAndRemoveDepositTransactionsFromDB()
 		if err != nil {
-			log.Warn("Check and remove deposit transactions from db error:", err)
+			logger.Warn("Check and remove deposit transactions from db error:", err)
 		}
 		err = ar.GetSideChainManager().CheckAndRemoveWithdrawTransactionsFromDB()
 		if err != nil {
-			log.Warn("Check and remove withdraw transactions from db error:", err)
+			logger.Warn("Check and remove withdraw transactions from db error:", err)
 		}
-		log.Info("Check and remove cross chain transactions from dbcache finished")
+		logger.Info("Check and remove cross chain transactions from dbcache finished")
 		time.Sleep(time.Millisecond * config.Parameters.ClearTransactionInterval)
 	}
 }
@@ -9,7 +9,7 @@ import (
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
 	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
-	"github.com/elastos/Elastos.ELA/account"
+	"github.com/elastos/Elastos.ELA.Arbiter/signer"
 	"github.com/elastos/Elastos.ELA/crypto"
 )
 
@@ -50,7 +50,7 @@ func (group *ArbitratorGroupImpl) SyncLoop() {
 	for {
 		err := group.SyncFromMainNode()
 		if err != nil {
-			log.Error("Arbitrator group sync error: ", err)
+			logger.Error("Arbitrator group sync error: ", err)
 		}
 
 		time.Sleep(time.Millisecond * config.Parameters.SyncInterval)
@@ -72,13 +72,13 @@ func (group *ArbitratorGroupImpl) InitArbitratorsByStrings(arbiters []string, on
 func (group *ArbitratorGroupImpl) SyncFromMainNode() error {
 	currentTime := uint64(time.Now().UnixNano())
 	if group.lastSyncTime != nil && (currentTime-*group.lastSyncTime)*uint64(time.Millisecond) < group.timeoutLimit {
-		log.Info("[SyncFromMainNode] less than timeout limit")
+		logger.Info("sync arbitrator group: less than timeout limit")
 		return nil
 	}
 
 	height, err := rpc.GetCurrentHeight(config.Parameters.MainNode.Rpc)
 	if err != nil {
-		log.Info("[SyncFromMainNode] rpc get current height failed")
+		logger.Infow("sync arbitrator group: rpc get current height failed", log.KeyError, err)
 		return err
 	}
 
@@ -95,7 +95,8 @@ func (group *ArbitratorGroupImpl) SyncFromMainNode() error {
 	}
 	groupInfo, err := rpc.GetArbitratorGroupInfoByHeight(currentHeight)
 	if err != nil {
-		log.Info("[SyncFromMainNode] get arbitrator group info failed")
+		logger.Infow("sync arbitrator group: get arbitrator group info failed", log.KeyHeight, currentHeight,
+			log.KeyError, err)
 		return err
 	}
 
@@ -180,7 +181,7 @@ func (group *ArbitratorGroupImpl) SetListener(listener ArbitratorGroupListener)
 	group.isListenerOnDuty = false
 }
 
-func Init(client *account.Client) {
+func Init(s signer.Signer) {
 	ArbitratorGroupSingleton = &ArbitratorGroupImpl{
 		timeoutLimit:     1000,
 		currentHeight:    new(uint32),
@@ -189,7 +190,7 @@ func Init(client *account.Client) {
 	}
 
 	currentArbitrator := &ArbitratorImpl{mainOnDutyMux: new(sync.Mutex)}
-	currentArbitrator.InitAccount(client)
+	currentArbitrator.InitAccount(s)
 
 	ArbitratorGroupSingleton.currentArbitrator = currentArbitrator
 	ArbitratorGroupSingleton.SetListener(currentArbitrator)
@@ -2,6 +2,7 @@ package arbitrator
 
 import (
 	"bytes"
+	"strings"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
@@ -16,6 +17,8 @@ import (
 )
 
 type AuxpowListener struct {
+	listenerState
+
 	ListenAddress string
 
 	notifyQueue chan *notifyTask
@@ -36,8 +39,13 @@ func (l *AuxpowListener) Flags() uint64 {
 func (l *AuxpowListener) Rollback(height uint32) {}
 
 func (l *AuxpowListener) Notify(id common.Uint256, proof bloom.MerkleProof, tx types.Transaction) {
+	if !l.isEnabled() {
+		logger.Debugw("side chain removed, skip side aux pow transaction",
+			log.KeySideChain, l.ListenAddress, log.KeyTx, tx.Hash())
+		return
+	}
 	l.notifyQueue <- &notifyTask{id, &proof, &tx}
-	log.Info("[Notify-Auxpow][", l.ListenAddress, "] find side aux pow transaction, hash:", tx.Hash().String())
+	logger.Infow("find side aux pow transaction", log.KeySideChain, l.ListenAddress, log.KeyTx, tx.Hash())
 	err := SpvService.SubmitTransactionReceipt(id, tx.Hash())
 	if err != nil {
 		return
@@ -46,17 +54,18 @@ func (l *AuxpowListener) Notify(id common.Uint256, proof bloom.MerkleProof, tx t
 
 func (l *AuxpowListener) ProcessNotifyData(tasks []*notifyTask) {
 	task := tasks[len(tasks)-1]
-	log.Info("[Notify-ProcessNotifyData][", l.ListenAddress, "] process hash:", task.tx.Hash().String(), "len tasks:", len(tasks))
+	txLogger := logger.With(log.KeySideChain, l.ListenAddress, log.KeyTx, task.tx.Hash())
+	txLogger.Infow("process side aux pow transaction", "tasks", len(tasks))
 	err := SpvService.VerifyTransaction(*task.proof, *task.tx)
 	if err != nil {
-		log.Error("verify transaction error: ", err)
+		txLogger.Errorw("verify transaction failed", log.KeyError, err)
 		return
 	}
 
 	// Get Header from main chain
 	header, err := SpvService.HeaderStore().Get(&task.proof.BlockHash)
 	if err != nil {
-		log.Error("can not get block from main chain")
+		txLogger.Errorw("can not get block from main chain", log.KeyError, err)
 		return
 	}
 
@@ -71,38 +80,38 @@ func (l *AuxpowListener) ProcessNotifyData(tasks []*notifyTask) {
 	txId := task.tx.Hash()
 	merkleBranch, err := bloom.GetTxMerkleBranch(merkleBlock, &txId)
 	if err != nil {
-		log.Error("can not get merkle branch")
+		txLogger.Errorw("can not get merkle branch", log.KeyError, err)
 		return
 	}
 
 	// serialize main chain tx
 	buf := new(bytes.Buffer)
 	if err := task.tx.Serialize(buf); err != nil {
-		log.Error("invalid payload tx")
+		txLogger.Errorw("invalid payload tx", log.KeyError, err)
 		return
 	}
 
 	// serialize merkle branch
 	if err := common.WriteUint32(buf, uint32(len(merkleBranch.Branches))); err != nil {
-		log.Error("serialize merkle branch count failed:", err.Error())
+		txLogger.Errorw("serialize merkle branch count failed", log.KeyError, err)
 		return
 	}
 	for _, branch := range merkleBranch.Branches {
 		err = branch.Serialize(buf)
 		if err != nil {
-			log.Error("serialize merkle branch failed:", err.Error())
+			txLogger.Errorw("serialize merkle branch failed", log.KeyError, err)
 			return
 		}
 	}
 	if err := common.WriteUint32(buf, uint32(merkleBranch.Index)); err != nil {
-		log.Error("serialize merkle branch index failed:", err.Error())
+		txLogger.Errorw("serialize merkle branch index failed", log.KeyError, err)
 		return
 	}
 
 	// serialize ela header
 	elaHeader := header.BlockHeader.(*iutil.Header)
 	if err := elaHeader.Serialize(buf); err != nil {
-		log.Error("invalid elaHeader")
+		txLogger.Errorw("invalid elaHeader", log.KeyError, err)
 		return
 	}
 
@@ -110,7 +119,7 @@ func (l *AuxpowListener) ProcessNotifyData(tasks []*notifyTask) {
 
 	p, ok := task.tx.Payload.(*payload.SideChainPow)
 	if !ok {
-		log.Error("invalid payload type")
+		txLogger.Error("invalid payload type")
 		return
 	}
 	blockhashString := p.SideBlockHash.String()
@@ -118,23 +127,23 @@ func (l *AuxpowListener) ProcessNotifyData(tasks []*notifyTask) {
 	blockHeight := p.BlockHeight
 
 	var sideChain SideChain
-	for _, sideNode := range config.Parameters.SideNodeList {
-		log.Info("side node genesis block:", sideNode.GenesisBlock,
-			"side aux pow tx genesis hash:", genesishashString)
+	for _, sideNode := range config.SideNodes() {
+		txLogger.Infow("match side node", "genesisblock", sideNode.GenesisBlock,
+			"sidegenesishash", genesishashString)
 		if sideNode.GenesisBlock == genesishashString {
 			sc, ok := ArbitratorGroupSingleton.GetCurrentArbitrator().
 				GetSideChainManager().GetChain(sideNode.GenesisBlockAddress)
 			if ok {
 				currentHeight, err := sc.GetCurrentHeight()
 				if err != nil {
-					log.Error("side chain GetCurrentHeight failed")
+					txLogger.Errorw("side chain GetCurrentHeight failed", log.KeyError, err)
 					return
 				}
 				if currentHeight == blockHeight {
 					sideChain = sc
 				} else {
-					log.Warn("No need to submit auxpow, current side chain height:",
-						currentHeight, " block height:", blockHeight)
+					txLogger.Warnw("no need to submit auxpow", "currentheight", currentHeight,
+						log.KeyHeight, blockHeight)
 					return
 				}
 			}
@@ -142,25 +151,29 @@ func (l *AuxpowListener) ProcessNotifyData(tasks []*notifyTask) {
 	}
 
 	if sideChain == nil {
-		log.Error("arbiter not find side chain")
 		allChains := ArbitratorGroupSingleton.GetCurrentArbitrator().GetSideChainManager().GetAllChains()
-		for index, chain := range allChains {
-			log.Error("side chain", index, ":", chain.GetKey())
+		keys := make([]string, 0, len(allChains))
+		for _, chain := range allChains {
+			keys = append(keys, chain.GetKey())
 		}
-
-		log.Error("can not find side chain from genesis block hash: [", genesishashString, "]")
+		txLogger.Errorw("can not find side chain from genesis block hash",
+			"sidegenesishash", genesishashString, "sidechains", strings.Join(keys, ","))
 		return
 	}
 
 	sideChain.UpdateLastNotifySideMiningHeight(p.SideGenesisHash)
 	err = sideChain.SubmitAuxpow(genesishashString, blockhashString, sideAuxpowString)
 	if err != nil {
-		log.Error("[Notify-Auxpow] submit SideAuxpow error: ", err)
+		txLogger.Errorw("submit SideAuxpow failed", log.KeyError, err)
 		return
 	}
 	sideChain.UpdateLastSubmitAuxpowHeight(p.SideGenesisHash)
 }
 
+func (l *AuxpowListener) queueDepth() (int, int) {
+	return len(l.notifyQueue), cap(l.notifyQueue)
+}
+
 func (l *AuxpowListener) start() {
 	l.notifyQueue = make(chan *notifyTask, 10000)
 	go func() {
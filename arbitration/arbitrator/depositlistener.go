@@ -1,6 +1,7 @@
 package arbitrator
 
 import (
+	"context"
 	. "github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
 	"github.com/elastos/Elastos.ELA.Arbiter/store"
@@ -12,6 +13,8 @@ import (
 )
 
 type DepositListener struct {
+	listenerState
+
 	ListenAddress string
 	notifyQueue   chan *notifyTask
 }
@@ -29,12 +32,18 @@ func (l *DepositListener) Flags() uint64 {
 }
 
 func (l *DepositListener) Notify(id common.Uint256, proof bloom.MerkleProof, tx types.Transaction) {
-	log.Info("[Notify-Deposit] find deposit transaction and add into channel, transaction hash:", tx.Hash().String())
+	if !l.isEnabled() {
+		logger.Debugw("side chain removed, skip deposit transaction",
+			log.KeySideChain, l.ListenAddress, log.KeyTx, tx.Hash())
+		return
+	}
+	logger.Infow("find deposit transaction", log.KeySideChain, l.ListenAddress, log.KeyTx, tx.Hash(),
+		log.KeyHeight, proof.Height)
 	l.notifyQueue <- &notifyTask{id, &proof, &tx}
 }
 
 func (l *DepositListener) ProcessNotifyData(tasks []*notifyTask) {
-	log.Info("[Notify-Process] deal with", len(tasks), "transactions")
+	logger.Infow("process deposit transactions", log.KeySideChain, l.ListenAddress, "count", len(tasks))
 
 	var ids []common.Uint256
 	var txs []*MainChainTransaction
@@ -48,18 +57,33 @@ func (l *DepositListener) ProcessNotifyData(tasks []*notifyTask) {
 		})
 	}
 
-	result, err := store.DbCache.MainChainStore.AddMainChainTxs(txs)
+	result, err := store.DbCache.MainChainStore.AddMainChainTxs(context.Background(), txs)
 	if err != nil {
-		log.Error("[Notify-Process] AddMainChainTx error:", err)
+		logger.Errorw("add deposit transactions failed", log.KeySideChain, l.ListenAddress, log.KeyError, err)
 		return
 	}
 
+	var events []*store.TransactionEvent
+	for i := 0; i < len(result); i++ {
+		if result[i] {
+			events = append(events, &store.TransactionEvent{
+				TransactionHash:     txs[i].TransactionHash,
+				GenesisBlockAddress: l.ListenAddress,
+				Type:                store.TxTypeDeposit,
+				Event:               store.TxEventDetected,
+				Height:              txs[i].Proof.Height,
+				Source:              store.TxSourceDepositListener,
+			})
+		}
+	}
+	store.RecordTransactionEvents(events)
+
 	for i := 0; i < len(ids); i++ {
 		SpvService.SubmitTransactionReceipt(ids[i], txs[i].Transaction.Hash())
 	}
 
 	if !ArbitratorGroupSingleton.GetCurrentArbitrator().IsOnDutyOfMain() {
-		log.Warn("[Notify-Process] i am not onduty")
+		logger.Warnw("not on duty, skip sending deposit transactions", log.KeySideChain, l.ListenAddress)
 		return
 	}
 
@@ -69,9 +93,9 @@ func (l *DepositListener) ProcessNotifyData(tasks []*notifyTask) {
 			spvTxs = append(spvTxs, &SpvTransaction{MainChainTransaction: txs[i].Transaction, Proof: txs[i].Proof})
 		}
 	}
-	log.Info("[Notify-Process] find deposit transaction, create and send deposit transaction, size of txs:", len(spvTxs))
-	for index, spvTx := range spvTxs {
-		log.Info("[Notify-Process] tx hash[", index, "]:", spvTx.MainChainTransaction.Hash().String())
+	for _, spvTx := range spvTxs {
+		logger.Infow("send deposit transaction", log.KeySideChain, l.ListenAddress,
+			log.KeyTx, spvTx.MainChainTransaction.Hash())
 	}
 	ArbitratorGroupSingleton.GetCurrentArbitrator().SendDepositTransactions(spvTxs, l.ListenAddress)
 }
@@ -85,6 +109,10 @@ type notifyTask struct {
 	tx    *types.Transaction
 }
 
+func (l *DepositListener) queueDepth() (int, int) {
+	return len(l.notifyQueue), cap(l.notifyQueue)
+}
+
 func (l *DepositListener) start() {
 	l.notifyQueue = make(chan *notifyTask, 10000)
 	go func() {
@@ -94,7 +122,7 @@ func (l *DepositListener) start() {
 			case data, ok := <-l.notifyQueue:
 				if ok {
 					tasks = append(tasks, data)
-					log.Info("[DepositListener] len tasks:", len(tasks))
+					logger.Infow("deposit task queued", log.KeySideChain, l.ListenAddress, "tasks", len(tasks))
 					if len(tasks) >= 10000 {
 						l.ProcessNotifyData(tasks)
 						tasks = make([]*notifyTask, 0)
@@ -108,7 +136,7 @@ func (l *DepositListener) start() {
 				data, ok := <-l.notifyQueue
 				if ok {
 					tasks = append(tasks, data)
-					log.Info("[DepositListener] len tasks:", len(tasks))
+					logger.Infow("deposit task queued", log.KeySideChain, l.ListenAddress, "tasks", len(tasks))
 				}
 			}
 		}
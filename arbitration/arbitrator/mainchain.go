@@ -1,6 +1,7 @@
 package arbitrator
 
 import (
+	"context"
 	"errors"
 	"math"
 
@@ -49,7 +50,7 @@ func (dbFunc *MainChainFuncImpl) GetWithdrawUTXOsByAmount(
 		return nil, errors.New("get spender's UTXOs failed, err:" + err.Error())
 	}
 	var availableUTXOs []*store.AddressUTXO
-	var currentHeight = store.DbCache.MainChainStore.CurrentHeight(
+	var currentHeight = store.DbCache.MainChainStore.CurrentHeight(context.Background(),
 		store.QueryHeightCode)
 	for _, utxo := range utxos {
 		if utxo.Input.Sequence > 0 {
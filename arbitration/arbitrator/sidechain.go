@@ -13,6 +13,7 @@ type SideChain interface {
 
 	GetExistDepositTransactions(txs []string) ([]string, error)
 	GetWithdrawTransaction(txHash string) (*base.WithdrawTxInfo, error)
+	GetWithdrawTransactions(txHashes []string) ([]*base.WithdrawTxInfo, error)
 	CheckIllegalEvidence(evidence *base.SidechainIllegalDataInfo) (bool, error)
 }
 
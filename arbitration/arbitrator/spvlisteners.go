@@ -0,0 +1,177 @@
+package arbitrator
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+
+	spv "github.com/elastos/Elastos.ELA.SPV/interface"
+	"github.com/elastos/Elastos.ELA.SPV/util"
+)
+
+var spvListeners = &sideChainListeners{listeners: make(map[string]sideChainListener)}
+
+// sideChainListener is the spv listener of a side chain.
+type sideChainListener interface {
+	spv.TransactionListener
+	start()
+	setEnabled(enabled bool)
+	isEnabled() bool
+	queueDepth() (int, int)
+}
+
+// listenerState tells if a side chain listener is enabled.
+type listenerState struct {
+	mux      sync.RWMutex
+	disabled bool
+}
+
+func (s *listenerState) setEnabled(enabled bool) {
+	s.mux.Lock()
+	s.disabled = !enabled
+	s.mux.Unlock()
+}
+
+func (s *listenerState) isEnabled() bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return !s.disabled
+}
+
+// sideChainListeners keeps the spv listeners of the side chains. The spv
+// service can not unregister a listener, so the listeners of removed side
+// chains are disabled, and enabled again if the side chains are added back.
+// A disabled listener does not submit the receipts, the spv service notifies
+// it again later.
+//
+// The spv service does not lock its listeners, so the listeners added after
+// it started are queued and registered in NotifyBlock, which the spv service
+// calls in the goroutine notifying the listeners.
+type sideChainListeners struct {
+	mux       sync.Mutex
+	listeners map[string]sideChainListener
+	pending   []sideChainListener
+}
+
+func listenerKey(listener spv.TransactionListener) string {
+	return listener.Address() + "-" + listener.Type().Name()
+}
+
+// update enables the listeners of the side nodes and disables the others,
+// the listeners not registered before are returned.
+func (s *sideChainListeners) update(nodes []*config.SideNodeConfig) []sideChainListener {
+	wanted := make(map[string]sideChainListener)
+	for _, node := range nodes {
+		if node.PowChain {
+			listener := &AuxpowListener{ListenAddress: node.MiningAddr}
+			wanted[listenerKey(listener)] = listener
+		}
+		listener := &DepositListener{ListenAddress: node.GenesisBlockAddress}
+		wanted[listenerKey(listener)] = listener
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for key, listener := range s.listeners {
+		_, ok := wanted[key]
+		listener.setEnabled(ok)
+	}
+
+	var added []sideChainListener
+	for key, listener := range wanted {
+		if _, ok := s.listeners[key]; ok {
+			continue
+		}
+		listener.start()
+		s.listeners[key] = listener
+		added = append(added, listener)
+	}
+	return added
+}
+
+// UpdateSideChainListeners registers the spv listeners of the new side nodes
+// and disables the listeners of the removed ones. The new listeners are
+// registered when the next block is committed, the transactions in the
+// blocks before are not notified, like the side chains added by a restart.
+func UpdateSideChainListeners(nodes []*config.SideNodeConfig) {
+	added := spvListeners.update(nodes)
+	if len(added) == 0 {
+		return
+	}
+	spvListeners.mux.Lock()
+	spvListeners.pending = append(spvListeners.pending, added...)
+	spvListeners.mux.Unlock()
+}
+
+// registerPending registers the queued listeners to the spv service, and
+// sends the new filter of the addresses to the peers.
+func (s *sideChainListeners) registerPending() {
+	s.mux.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mux.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	for _, listener := range pending {
+		logger.Infow("register spv listener", log.KeySideChain, listener.Address(), "type", listener.Type().Name())
+		if err := SpvService.RegisterTransactionListener(listener); err != nil {
+			logger.Errorw("register spv listener failed", log.KeySideChain, listener.Address(), log.KeyError, err)
+		}
+	}
+	if service, ok := SpvService.(interface{ UpdateFilter() }); ok {
+		service.UpdateFilter()
+	}
+}
+
+// ListenerQueue is the notify queue of a spv listener, Length is the number
+// of the transactions waiting to be processed.
+type ListenerQueue struct {
+	Address  string `json:"address"`
+	Type     string `json:"type"`
+	Enabled  bool   `json:"enabled"`
+	Length   int    `json:"length"`
+	Capacity int    `json:"capacity"`
+}
+
+// ListenerQueues returns the notify queues of the spv listeners ordered by
+// the address and the type.
+func ListenerQueues() []ListenerQueue {
+	spvListeners.mux.Lock()
+	defer spvListeners.mux.Unlock()
+
+	keys := make([]string, 0, len(spvListeners.listeners))
+	for key := range spvListeners.listeners {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	queues := make([]ListenerQueue, 0, len(keys))
+	for _, key := range keys {
+		listener := spvListeners.listeners[key]
+		length, capacity := listener.queueDepth()
+		queues = append(queues, ListenerQueue{
+			Address:  listener.Address(),
+			Type:     listener.Type().Name(),
+			Enabled:  listener.isEnabled(),
+			Length:   length,
+			Capacity: capacity,
+		})
+	}
+	return queues
+}
+
+func (s *sideChainListeners) NotifyBlock(block *util.Block) {
+	s.registerPending()
+}
+
+func (s *sideChainListeners) BlockHeight() uint32 {
+	return 0
+}
+
+func (s *sideChainListeners) StoreAuxBlock(block interface{}) {}
+
+func (s *sideChainListeners) RegisterFunc(handleFunc func(block interface{}) error) {}
@@ -2,6 +2,7 @@ package complain
 
 import (
 	"bytes"
+	"context"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/cs"
@@ -53,7 +54,7 @@ func (comp *ComplainSolvingImpl) BroadcastComplainSolving([]byte) error {
 }
 
 func (comp *ComplainSolvingImpl) GetComplainStatus(transactionHash common.Uint256) uint {
-	txs, err := store.DbCache.SideChainStore.GetSideChainTxsFromHashes([]string{transactionHash.String()})
+	txs, err := store.DbCache.SideChainStore.GetSideChainTxsFromHashes(context.Background(), []string{transactionHash.String()})
 	if err == nil && len(txs) != 0 {
 		return Solving
 	}
@@ -63,7 +64,7 @@ func (comp *ComplainSolvingImpl) GetComplainStatus(transactionHash common.Uint25
 		return Solving
 	}*/
 
-	succeedList, _, err := store.FinishedTxsDbCache.GetDepositTxByHash(transactionHash.String())
+	succeedList, _, err := store.FinishedTxsDbCache.GetDepositTxByHash(context.Background(), transactionHash.String())
 	if err == nil && len(succeedList) != 0 {
 		for _, succeed := range succeedList {
 			if succeed {
@@ -73,7 +74,7 @@ func (comp *ComplainSolvingImpl) GetComplainStatus(transactionHash common.Uint25
 		return Rejected
 	}
 
-	succeed, _, err := store.FinishedTxsDbCache.GetWithdrawTxByHash(transactionHash.String())
+	succeed, _, err := store.FinishedTxsDbCache.GetWithdrawTxByHash(context.Background(), transactionHash.String())
 	if err == nil {
 		if succeed {
 			return Done
@@ -2,16 +2,20 @@ package cs
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
+	"github.com/elastos/Elastos.ELA.Arbiter/audit"
 	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
 
 	"github.com/elastos/Elastos.ELA/common"
 	"github.com/elastos/Elastos.ELA/core/contract"
 	"github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
 	"github.com/elastos/Elastos.ELA/crypto"
 )
 
@@ -83,14 +87,19 @@ func (item *DistributedItem) Sign(arbitrator arbitrator.Arbitrator, isFeedback b
 		return errors.New("Invalid multi sign signer")
 	}
 	// Sign transaction
-	buf := new(bytes.Buffer)
-	err = item.ItemContent.SerializeUnsigned(buf)
+	var newSign []byte
+	switch content := item.ItemContent.(type) {
+	case *TxDistributedContent:
+		newSign, err = arbitrator.GetSigner().SignWithdrawProposal(content.Tx)
+	case *IllegalDistributedContent:
+		newSign, err = arbitrator.GetSigner().SignIllegalEvidence(content.Evidence)
+	default:
+		err = fmt.Errorf("unknown distributed content %T", item.ItemContent)
+	}
 	if err != nil {
 		return err
 	}
-
-	newSign, err := arbitrator.Sign(buf.Bytes())
-	if err != nil {
+	if _, err = audit.Append(item.auditRecord()); err != nil {
 		return err
 	}
 	// Append signature
@@ -102,6 +111,39 @@ func (item *DistributedItem) Sign(arbitrator arbitrator.Arbitrator, isFeedback b
 	return nil
 }
 
+// auditRecord describes the signature of the item content for the audit log.
+func (item *DistributedItem) auditRecord() *audit.Record {
+	record := &audit.Record{ContentHash: item.ItemContent.Hash().String()}
+	if item.TargetArbitratorPublicKey != nil {
+		if publicKey, err := item.TargetArbitratorPublicKey.EncodePoint(true); err == nil {
+			record.Proposer = hex.EncodeToString(publicKey)
+		}
+	}
+
+	switch content := item.ItemContent.(type) {
+	case *TxDistributedContent:
+		record.ContentType = audit.ContentWithdraw
+		record.Summary = audit.TransactionSummary(content.Tx)
+		if withdraw, ok := content.Tx.Payload.(*payload.WithdrawFromSideChain); ok {
+			record.Summary.SideChain = withdraw.GenesisBlockAddress
+			record.Summary.Height = withdraw.BlockHeight
+			record.Summary.Detail = fmt.Sprintf("%d side chain transactions",
+				len(withdraw.SideChainTransactionHashes))
+		}
+	case *IllegalDistributedContent:
+		record.ContentType = audit.ContentIllegalData
+		record.Summary = audit.Summary{
+			SideChain: content.Evidence.GenesisBlockAddress,
+			Height:    content.Evidence.Height,
+			Detail: fmt.Sprintf("illegal type %d, signer %s", content.Evidence.IllegalType,
+				hex.EncodeToString(content.Evidence.IllegalSigner)),
+		}
+	default:
+		record.ContentType = fmt.Sprintf("%T", item.ItemContent)
+	}
+	return record
+}
+
 func (item *DistributedItem) GetSignedData() []byte {
 	return item.signedData
 }
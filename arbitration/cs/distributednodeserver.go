@@ -2,14 +2,16 @@ package cs
 
 import (
 	"bytes"
-	"encoding/hex"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/metrics"
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
 
 	"github.com/elastos/Elastos.ELA/common"
 	"github.com/elastos/Elastos.ELA/core/contract"
@@ -28,6 +30,7 @@ type DistributedNodeServer struct {
 	withdrawMux               *sync.Mutex
 	unsolvedContents          map[common.Uint256]base.DistributedContent
 	unsolvedContentsSignature map[common.Uint256]map[common.Uint160]bool
+	unsolvedContentsTime      map[common.Uint256]time.Time
 }
 
 func (dns *DistributedNodeServer) tryInit() {
@@ -43,6 +46,21 @@ func (dns *DistributedNodeServer) tryInit() {
 	if dns.unsolvedContentsSignature == nil {
 		dns.unsolvedContentsSignature = make(map[common.Uint256]map[common.Uint160]bool)
 	}
+	if dns.unsolvedContentsTime == nil {
+		dns.unsolvedContentsTime = make(map[common.Uint256]time.Time)
+	}
+}
+
+// proposalKind returns the kind label of the proposal in metrics.
+func proposalKind(content base.DistributedContent) string {
+	switch content.(type) {
+	case *TxDistributedContent:
+		return "withdraw"
+	case *IllegalDistributedContent:
+		return "illegal"
+	default:
+		return "unknown"
+	}
 }
 
 func (dns *DistributedNodeServer) UnsolvedTransactions() map[common.Uint256]base.DistributedContent {
@@ -86,15 +104,20 @@ func (dns *DistributedNodeServer) sendToArbitrator(content []byte) {
 	}
 
 	P2PClientSingleton.BroadcastMessage(msg)
-	log.Info("[sendToArbitrator] Send withdraw transaction to arbiters for multi sign")
+	logger.Info("send proposal to arbiters for multi sign")
 }
 
 func (dns *DistributedNodeServer) BroadcastWithdrawProposal(txn *types.Transaction) error {
 
-	proposal, err := dns.generateDistributedProposal(&TxDistributedContent{Tx: txn}, &DistrubutedItemFuncImpl{})
+	content := &TxDistributedContent{Tx: txn}
+	proposal, err := dns.generateDistributedProposal(content, &DistrubutedItemFuncImpl{})
 	if err != nil {
 		return err
 	}
+	store.RecordTransactionEvents(content.transactionEvents(store.TransactionEvent{
+		Event:      store.TxEventProposed,
+		Signatures: 1,
+	}))
 
 	dns.sendToArbitrator(proposal)
 
@@ -157,6 +180,8 @@ func (dns *DistributedNodeServer) generateDistributedProposal(itemContent base.D
 	signs := make(map[common.Uint160]bool)
 	signs[programHash.ToCodeHash()] = true
 	dns.unsolvedContentsSignature[itemContent.Hash()] = signs
+	dns.unsolvedContentsTime[itemContent.Hash()] = time.Now()
+	metrics.ProposalsCreated.With(proposalKind(itemContent)).Inc()
 
 	return buf.Bytes(), nil
 }
@@ -175,7 +200,7 @@ func (dns *DistributedNodeServer) ReceiveProposalFeedback(content []byte) error
 		return err
 	}
 	if msg != "" {
-		log.Warn(msg)
+		logger.Warn(msg)
 		return nil
 	}
 
@@ -192,10 +217,12 @@ func (dns *DistributedNodeServer) ReceiveProposalFeedback(content []byte) error
 	}
 	dns.mux.Unlock()
 	targetCodeHash := transactionItem.TargetArbitratorProgramHash.ToCodeHash()
+	pk, _ := transactionItem.TargetArbitratorPublicKey.EncodePoint(true)
+	proposalLogger := logger.With(log.KeyProposal, hash, log.KeyPeer, pk)
 
 	signs := dns.unsolvedContentsSignature[hash]
 	if _, ok := signs[targetCodeHash]; ok {
-		log.Warn("arbiter already signed ")
+		proposalLogger.Warn("arbiter already signed")
 		return nil
 	}
 	signedCount, err := txn.MergeSign(newSign, &targetCodeHash)
@@ -203,16 +230,29 @@ func (dns *DistributedNodeServer) ReceiveProposalFeedback(content []byte) error
 		return err
 	}
 	signs[targetCodeHash] = true
-	pk, _ := transactionItem.TargetArbitratorPublicKey.EncodePoint(true)
-	log.Info("receive signature from ", hex.EncodeToString(pk))
+	if content, ok := txn.(*TxDistributedContent); ok {
+		store.RecordTransactionEvents(content.transactionEvents(store.TransactionEvent{
+			Event:      store.TxEventSigned,
+			Signatures: signedCount,
+		}))
+	}
+	kind := proposalKind(txn)
+	metrics.ProposalSignatures.With(kind).Inc()
+	proposalLogger.Infow("receive proposal signature", "signatures", signedCount)
 	if signedCount >= getTransactionAgreementArbitratorsCount(len(arbitrator.ArbitratorGroupSingleton.GetAllArbitrators())) {
 		dns.mux.Lock()
+		if created, ok := dns.unsolvedContentsTime[hash]; ok {
+			metrics.ProposalTimeToThreshold.With(kind).ObserveSince(created)
+		}
 		delete(dns.unsolvedContents, hash)
 		delete(dns.unsolvedContentsSignature, hash)
+		delete(dns.unsolvedContentsTime, hash)
 		dns.mux.Unlock()
 
-		if err = txn.Submit(); err != nil {
-			log.Warn(err.Error())
+		err = txn.Submit()
+		metrics.ProposalSubmissions.With(kind, metrics.Result(err)).Inc()
+		if err != nil {
+			proposalLogger.Warnw("submit proposal failed", log.KeyError, err)
 			return err
 		}
 	}
@@ -1,6 +1,7 @@
 package cs
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
 	"math/rand"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
+	"github.com/elastos/Elastos.ELA.Arbiter/audit"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
 	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
@@ -51,10 +53,10 @@ func (n *arbitratorsNetwork) AddMainchainListener(listener base.MainchainMsgList
 func (n *arbitratorsNetwork) Start() {
 	n.p2pServer.Start()
 
-	currentHeight := store.DbCache.MainChainStore.CurrentHeight(store.QueryHeightCode)
+	currentHeight := store.DbCache.MainChainStore.CurrentHeight(context.Background(), store.QueryHeightCode)
 	peers, err := rpc.GetActiveDposPeers(currentHeight)
 	if err != nil {
-		log.Error("Get active dpos peers error when start, details: ", err)
+		logger.Errorw("get active dpos peers failed", log.KeyHeight, currentHeight, log.KeyError, err)
 		os.Exit(1)
 	}
 	n.UpdatePeers(peers)
@@ -83,7 +85,7 @@ func (n *arbitratorsNetwork) SendMessageToPeer(id peer.PID, msg elap2p.Message)
 
 func (n *arbitratorsNetwork) BroadcastMessage(msg elap2p.Message) {
 	n.peersLock.Lock()
-	log.Info("[BroadcastMessage] current connected peers:", len(n.connectedPeers))
+	logger.Infow("broadcast message", "peers", len(n.connectedPeers))
 	n.peersLock.Unlock()
 
 	n.p2pServer.BroadcastMessage(msg)
@@ -100,6 +102,12 @@ func (n *arbitratorsNetwork) UpdatePeers(connectedPeers []peer.PID) {
 	n.p2pServer.ConnectPeers(n.connectedPeers)
 }
 
+// MessageQueueDepth returns the number of the messages waiting to be
+// processed and the capacity of the queue.
+func (n *arbitratorsNetwork) MessageQueueDepth() (int, int) {
+	return len(n.messageQueue), cap(n.messageQueue)
+}
+
 func (n *arbitratorsNetwork) notifyFlag(flag p2p.NotifyFlag) {
 }
 
@@ -125,7 +133,18 @@ func (n *arbitratorsNetwork) getNonce(pid peer.PID) uint64 {
 }
 
 func (n *arbitratorsNetwork) sign(data []byte) []byte {
-	sign, _ := arbitrator.ArbitratorGroupSingleton.GetCurrentArbitrator().Sign(data)
+	sign, err := arbitrator.ArbitratorGroupSingleton.GetCurrentArbitrator().GetSigner().SignHandshake(data)
+	if err != nil {
+		return nil
+	}
+	if _, err := audit.Append(&audit.Record{
+		ContentType: audit.ContentHandshake,
+		ContentHash: audit.HashData(data),
+		Summary:     audit.Summary{Detail: "P2P handshake nonce"},
+	}); err != nil {
+		logger.Errorw("handshake signature not used", log.KeyError, err)
+		return nil
+	}
 	return sign
 }
 
@@ -2,9 +2,11 @@ package cs
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
@@ -41,8 +43,20 @@ func (d *TxDistributedContent) Submit() error {
 		transactionHashes = append(transactionHashes, hash.String())
 	}
 
+	txLogger := logger.With(log.KeySideChain, withdrawPayload.GenesisBlockAddress, log.KeyProposal, d.Tx.Hash())
 	if err != nil || resp.Error != nil && resp.Code != MCErrDoubleSpend {
-		log.Warn("send withdraw transaction failed, move to finished db, txHash:", d.Tx.Hash().String(), ", code: ", resp.Code, ", result:", resp.Result)
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Message
+		}
+		txLogger.Warnw("send withdraw transaction failed, move to finished db", "reason", reason,
+			"sidechaintxs", strings.Join(transactionHashes, ","))
+		store.RecordTransactionEvents(d.transactionEvents(store.TransactionEvent{
+			Event:  store.TxEventFailed,
+			Reason: reason,
+		}))
 
 		buf := new(bytes.Buffer)
 		err := d.Tx.Serialize(buf)
@@ -50,40 +64,56 @@ func (d *TxDistributedContent) Submit() error {
 			return errors.New("send withdraw transaction faild, invalid transaction")
 		}
 
-		err = store.DbCache.SideChainStore.RemoveSideChainTxs(transactionHashes)
+		err = store.FinishedTxsDbCache.MoveFailedWithdrawTxs(context.Background(), transactionHashes, buf.Bytes())
 		if err != nil {
-			return errors.New("remove failed withdraw transaction from db failed")
-		}
-		err = store.FinishedTxsDbCache.AddFailedWithdrawTxs(transactionHashes, buf.Bytes())
-		if err != nil {
-			return errors.New("add failed withdraw transaction into finished db failed")
+			return errors.New("move failed withdraw transaction into finished db failed")
 		}
 	} else if resp.Error == nil && resp.Result != nil || resp.Error != nil && resp.Code == MCErrSidechainTxDuplicate {
+		event := store.TransactionEvent{
+			Event:      store.TxEventSucceeded,
+			ResultHash: d.Tx.Hash().String(),
+		}
 		if resp.Error != nil {
-			log.Info("send withdraw transaction found has been processed, move to finished db, txHash:", d.Tx.Hash().String())
+			txLogger.Info("withdraw transaction has been processed by main chain, move to finished db")
+			event.Reason = "already processed by main chain"
 		} else {
-			log.Info("send withdraw transaction succeed, move to finished db, txHash:", d.Tx.Hash().String())
+			txLogger.Infow("send withdraw transaction succeed, move to finished db",
+				"sidechaintxs", strings.Join(transactionHashes, ","))
 		}
+		store.RecordTransactionEvents(d.transactionEvents(event))
 		var newUsedUtxos []types.OutPoint
 		for _, input := range d.Tx.Inputs {
 			newUsedUtxos = append(newUsedUtxos, input.Previous)
 		}
 
-		err = store.DbCache.SideChainStore.RemoveSideChainTxs(transactionHashes)
+		err = store.FinishedTxsDbCache.MoveSucceedWithdrawTxs(context.Background(), transactionHashes)
 		if err != nil {
-			return errors.New("remove succeed withdraw transaction from db failed")
-		}
-		err = store.FinishedTxsDbCache.AddSucceedWithdrawTxs(transactionHashes)
-		if err != nil {
-			return errors.New("add succeed withdraw transaction into finished db failed")
+			return errors.New("move succeed withdraw transaction into finished db failed")
 		}
 	} else {
-		log.Warn("send withdraw transaction failed, need to resend")
+		txLogger.Warn("send withdraw transaction failed, need to resend")
 	}
 
 	return nil
 }
 
+// transactionEvents returns the events of the side chain transactions in the
+// withdraw transaction, the events are copied from the template.
+func (d *TxDistributedContent) transactionEvents(template store.TransactionEvent) []*store.TransactionEvent {
+	withdrawPayload, ok := d.Tx.Payload.(*payload.WithdrawFromSideChain)
+	if !ok {
+		return nil
+	}
+	var transactionHashes []string
+	for _, hash := range withdrawPayload.SideChainTransactionHashes {
+		transactionHashes = append(transactionHashes, hash.String())
+	}
+	template.GenesisBlockAddress = withdrawPayload.GenesisBlockAddress
+	template.Type = store.TxTypeWithdraw
+	template.ProposalHash = d.Tx.Hash().String()
+	return store.NewTransactionEvents(transactionHashes, template)
+}
+
 func (d *TxDistributedContent) MergeSign(newSign []byte, targetCodeHash *common.Uint160) (int, error) {
 	var signerIndex = -1
 	codeHashes, err := account.GetCorssChainSigners(d.Tx.Programs[0].Code)
@@ -170,16 +200,16 @@ func checkWithdrawTransaction(txn *types.Transaction,
 	// check if withdraw transactions exist in db, if not found then will check
 	// by the rpc interface of the side chain.
 	var txs []*base.WithdrawTx
-	sideChainTxs, err := store.DbCache.SideChainStore.GetSideChainTxsFromHashesAndGenesisAddress(
+	sideChainTxs, err := store.DbCache.SideChainStore.GetSideChainTxsFromHashesAndGenesisAddress(context.Background(),
 		transactionHashes, payloadWithdraw.GenesisBlockAddress)
 	if err != nil || len(sideChainTxs) != len(payloadWithdraw.SideChainTransactionHashes) {
-		log.Info("[checkWithdrawTransaction], need to get side chain transaction from rpc")
-		for _, txHash := range payloadWithdraw.SideChainTransactionHashes {
-			tx, err := sideChain.GetWithdrawTransaction(txHash.String())
-			if err != nil {
-				return errors.New("[checkWithdrawTransaction] failed, unknown side chain transactions")
-			}
-
+		logger.Infow("side chain transactions of withdraw proposal not cached, get them from rpc",
+			log.KeySideChain, payloadWithdraw.GenesisBlockAddress, log.KeyProposal, txn.Hash())
+		withdrawTxs, err := sideChain.GetWithdrawTransactions(transactionHashes)
+		if err != nil {
+			return errors.New("[checkWithdrawTransaction] failed, unknown side chain transactions")
+		}
+		for _, tx := range withdrawTxs {
 			txID, err := common.Uint256FromHexString(tx.TxID)
 			if err != nil {
 				return errors.New("[checkWithdrawTransaction] failed, invalid txID")
@@ -265,8 +295,8 @@ func checkWithdrawTransaction(txn *types.Transaction,
 	}
 
 	if inputTotalAmount != outputTotalAmount+totalFee {
-		log.Info("inputTotalAmount-", inputTotalAmount,
-			" outputTotalAmount-", outputTotalAmount, " totalFee-", totalFee)
+		logger.Infow("withdraw proposal amount mismatch", log.KeyProposal, txn.Hash(),
+			"inputtotal", inputTotalAmount, "outputtotal", outputTotalAmount, "fee", totalFee)
 		return errors.New("check withdraw transaction failed, input " +
 			"amount not equal output amount")
 	}
@@ -302,7 +332,8 @@ func checkWithdrawTransaction(txn *types.Transaction,
 	}
 
 	if oriOutputAmount != withdrawOutputAmount {
-		log.Info("oriOutputAmount-", oriOutputAmount, " withdrawOutputAmount-", withdrawOutputAmount)
+		logger.Infow("withdraw proposal exchange rate mismatch", log.KeyProposal, txn.Hash(),
+			"crosschainoutput", oriOutputAmount, "withdrawoutput", withdrawOutputAmount)
 		return errors.New("check withdraw transaction failed, exchange rate verify failed")
 	}
 
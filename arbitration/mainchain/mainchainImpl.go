@@ -1,6 +1,7 @@
 package mainchain
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"strconv"
@@ -25,10 +26,10 @@ type MainChainImpl struct {
 }
 
 func (mc *MainChainImpl) SyncMainChainCachedTxs() error {
-	log.Info("[SyncMainChainCachedTxs] start")
-	defer log.Info("[SyncMainChainCachedTxs] end")
+	logger.Info("sync main chain cached transactions start")
+	defer logger.Info("sync main chain cached transactions end")
 
-	txs, err := store.DbCache.MainChainStore.GetAllMainChainTxs()
+	txs, err := store.DbCache.MainChainStore.GetAllMainChainTxs(context.Background())
 	if err != nil {
 		return errors.New("[SyncMainChainCachedTxs]" + err.Error())
 	}
@@ -41,7 +42,8 @@ func (mc *MainChainImpl) SyncMainChainCachedTxs() error {
 	for _, tx := range txs {
 		sc, ok := arbitrator.ArbitratorGroupSingleton.GetCurrentArbitrator().GetSideChainManager().GetChain(tx.GenesisBlockAddress)
 		if !ok {
-			log.Warn("[SyncMainChainCachedTxs] Get side chain from genesis address failed")
+			logger.Warnw("get side chain from genesis address failed", log.KeySideChain, tx.GenesisBlockAddress,
+				log.KeyTx, tx.TransactionHash)
 			continue
 		}
 
@@ -69,7 +71,7 @@ func (mc *MainChainImpl) SyncMainChainCachedTxs() error {
 func (mc *MainChainImpl) createAndSendDepositTransactionsInDB(sideChain arbitrator.SideChain, txHashes []string) {
 	receivedTxs, err := sideChain.GetExistDepositTransactions(txHashes)
 	if err != nil {
-		log.Warn("[SyncMainChainCachedTxs] Get exist deposit transactions failed, err:", err.Error())
+		logger.Warnw("get exist deposit transactions failed", log.KeySideChain, sideChain.GetKey(), log.KeyError, err)
 		return
 	}
 	unsolvedTxs := base.SubstractTransactionHashes(txHashes, receivedTxs)
@@ -77,18 +79,16 @@ func (mc *MainChainImpl) createAndSendDepositTransactionsInDB(sideChain arbitrat
 	for i := 0; i < len(receivedTxs); i++ {
 		addresses = append(addresses, sideChain.GetKey())
 	}
-	err = store.DbCache.MainChainStore.RemoveMainChainTxs(receivedTxs, addresses)
+	err = store.FinishedTxsDbCache.MoveSucceedDepositTxs(context.Background(), receivedTxs, addresses)
 	if err != nil {
-		log.Warn("[SyncMainChainCachedTxs] Remove main chain txs failed, err:", err.Error())
-	}
-	err = store.FinishedTxsDbCache.AddSucceedDepositTxs(receivedTxs, addresses)
-	if err != nil {
-		log.Error("[SyncMainChainCachedTxs] Add succeed deposit transactions into finished db failed, err:", err.Error())
+		logger.Errorw("move succeed deposit transactions into finished db failed", log.KeySideChain, sideChain.GetKey(),
+			log.KeyError, err)
 	}
 
-	spvTxs, err := store.DbCache.MainChainStore.GetMainChainTxsFromHashes(unsolvedTxs, sideChain.GetKey())
+	spvTxs, err := store.DbCache.MainChainStore.GetMainChainTxsFromHashes(context.Background(), unsolvedTxs, sideChain.GetKey())
 	if err != nil {
-		log.Error("[SyncMainChainCachedTxs] Get main chain txs from hashes failed, err:", err.Error())
+		logger.Errorw("get main chain transactions from hashes failed", log.KeySideChain, sideChain.GetKey(),
+			log.KeyError, err)
 		return
 	}
 
@@ -97,7 +97,7 @@ func (mc *MainChainImpl) createAndSendDepositTransactionsInDB(sideChain arbitrat
 
 func (mc *MainChainImpl) OnReceivedSignMsg(id peer2.PID, content []byte) {
 	if err := mc.ReceiveProposalFeedback(content); err != nil {
-		log.Error("[OnReceivedSignMsg] mainchain received distributed item message error: ", err)
+		logger.Errorw("process proposal feedback failed", log.KeyPeer, id[:], log.KeyError, err)
 	}
 }
 
@@ -220,17 +220,17 @@ func (mc *MainChainImpl) CreateWithdrawTransaction(
 func (mc *MainChainImpl) SyncChainData() uint32 {
 	chainHeight, currentHeight, needSync := mc.needSyncBlocks()
 	if !needSync {
-		log.Debug("No need sync, chain height:", chainHeight, "current height:", currentHeight)
+		logger.Debugw("no need to sync main chain", log.KeyHeight, chainHeight, "currentheight", currentHeight)
 		return currentHeight
 	}
-	log.Info("[arbitrator] Main chain height: ", chainHeight)
+	logger.Infow("sync main chain", log.KeyHeight, chainHeight)
 	err := mc.updatePeers(chainHeight)
 	if err != nil {
-		log.Error("update peers failed", err.Error())
+		logger.Errorw("update peers failed", log.KeyHeight, chainHeight, log.KeyError, err)
 	}
 
 	// Update wallet height
-	currentHeight = store.DbCache.MainChainStore.CurrentHeight(chainHeight)
+	currentHeight = store.DbCache.MainChainStore.CurrentHeight(context.Background(), chainHeight)
 
 	return currentHeight
 }
@@ -251,7 +251,7 @@ func (mc *MainChainImpl) needSyncBlocks() (uint32, uint32, bool) {
 		return 0, 0, false
 	}
 
-	currentHeight := store.DbCache.MainChainStore.CurrentHeight(store.QueryHeightCode)
+	currentHeight := store.DbCache.MainChainStore.CurrentHeight(context.Background(), store.QueryHeightCode)
 
 	if currentHeight >= chainHeight {
 		return chainHeight, currentHeight, false
@@ -261,7 +261,7 @@ func (mc *MainChainImpl) needSyncBlocks() (uint32, uint32, bool) {
 }
 
 func (mc *MainChainImpl) containGenesisBlockAddress(address string) bool {
-	for _, node := range config.Parameters.SideNodeList {
+	for _, node := range config.SideNodes() {
 		if node.GenesisBlockAddress == address {
 			return true
 		}
@@ -271,7 +271,7 @@ func (mc *MainChainImpl) containGenesisBlockAddress(address string) bool {
 
 func (mc *MainChainImpl) CheckAndRemoveDepositTransactionsFromDB() error {
 	//remove deposit transactions if exist on side chain
-	txs, err := store.DbCache.MainChainStore.GetAllMainChainTxs()
+	txs, err := store.DbCache.MainChainStore.GetAllMainChainTxs(context.Background())
 	if err != nil {
 		return err
 	}
@@ -284,7 +284,8 @@ func (mc *MainChainImpl) CheckAndRemoveDepositTransactionsFromDB() error {
 	for _, tx := range txs {
 		sc, ok := arbitrator.ArbitratorGroupSingleton.GetCurrentArbitrator().GetSideChainManager().GetChain(tx.GenesisBlockAddress)
 		if !ok {
-			log.Warn("[CheckAndRemoveDepositTransactionsFromDB] Get chain from genesis addres failed.")
+			logger.Warnw("get side chain from genesis address failed", log.KeySideChain, tx.GenesisBlockAddress,
+				log.KeyTx, tx.TransactionHash)
 			continue
 		}
 
@@ -305,21 +306,23 @@ func (mc *MainChainImpl) CheckAndRemoveDepositTransactionsFromDB() error {
 	for k, v := range allSideChainTxHashes {
 		receivedTxs, err := k.GetExistDepositTransactions(v)
 		if err != nil {
-			log.Warn("[CheckAndRemoveDepositTransactionsFromDB] Get exist deposit transactions failed:", err.Error())
+			logger.Warnw("get exist deposit transactions failed", log.KeySideChain, k.GetKey(), log.KeyError, err)
 			continue
 		}
 		finalGenesisAddresses := make([]string, 0)
 		for i := 0; i < len(receivedTxs); i++ {
 			finalGenesisAddresses = append(finalGenesisAddresses, k.GetKey())
 		}
-		err = store.DbCache.MainChainStore.RemoveMainChainTxs(receivedTxs, finalGenesisAddresses)
+		err = store.FinishedTxsDbCache.MoveSucceedDepositTxs(context.Background(), receivedTxs, finalGenesisAddresses)
 		if err != nil {
 			return err
 		}
-		err = store.FinishedTxsDbCache.AddSucceedDepositTxs(receivedTxs, finalGenesisAddresses)
-		if err != nil {
-			log.Error("[CheckAndRemoveDepositTransactionsFromDB] Add succeed deposit transactions into finished db failed")
-		}
+		store.RecordTransactionEvents(store.NewTransactionEvents(receivedTxs, store.TransactionEvent{
+			GenesisBlockAddress: k.GetKey(),
+			Type:                store.TxTypeDeposit,
+			Event:               store.TxEventSucceeded,
+			Reason:              "found on side chain",
+		}))
 	}
 
 	return nil
@@ -13,6 +13,6 @@ type MainChainClientImpl struct {
 
 func (client *MainChainClientImpl) OnReceivedSignMsg(id peer.PID, content []byte) {
 	if err := client.OnReceivedProposal(id, content); err != nil {
-		log.Error("[OnReceivedSignMsg] mainchain client received distributed item message error: ", err)
+		logger.Errorw("process proposal failed", log.KeyPeer, id[:], log.KeyError, err)
 	}
 }
@@ -0,0 +1,144 @@
+package sidechain
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/sideauxpow"
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
+)
+
+var (
+	// reloadMux serializes the reloads of the side chains.
+	reloadMux sync.Mutex
+
+	accountMonitor *SideChainAccountMonitorImpl
+)
+
+// ReloadResult lists the genesis block addresses of the side chains changed
+// by a reload.
+type ReloadResult struct {
+	Added   []string
+	Removed []string
+	Updated []string
+}
+
+// InitAccountMonitor creates the account monitor of the side chains, and
+// starts syncing the data of the side chains in the configuration.
+func InitAccountMonitor(ar arbitrator.Arbitrator) {
+	monitor := &SideChainAccountMonitorImpl{ParentArbitrator: ar}
+	for _, side := range ar.GetSideChainManager().GetAllChains() {
+		monitor.AddListener(side)
+	}
+	for _, node := range config.SideNodes() {
+		monitor.StartSyncChainData(node.GenesisBlockAddress)
+	}
+	accountMonitor = monitor
+}
+
+// Reload reads the side nodes from the configuration again and applies them
+// to the running side chains.
+func Reload() (*ReloadResult, error) {
+	nodes, err := config.LoadSideNodes()
+	if err != nil {
+		return nil, err
+	}
+	return ReloadSideNodes(nodes)
+}
+
+// ReloadSideNodes applies the side nodes to the running side chains, their
+// mining accounts should be in the keystore. The side chains added get
+// registered with a sync loop and the spv listeners. The side chains removed
+// stop syncing after the blocks being synced are processed, then they are
+// unregistered and their spv listeners disabled, their transactions in the
+// caches are kept. The configs of the side chains changed, like the rpc
+// endpoints and the exchange rates, are replaced as a whole.
+func ReloadSideNodes(nodes []*config.SideNodeConfig) (*ReloadResult, error) {
+	reloadMux.Lock()
+	defer reloadMux.Unlock()
+
+	if accountMonitor == nil {
+		return nil, errors.New("side chains are not started")
+	}
+	manager, ok := accountMonitor.ParentArbitrator.GetSideChainManager().(*SideChainManagerImpl)
+	if !ok {
+		return nil, errors.New("side chain manager can not be reloaded")
+	}
+
+	if err := sideauxpow.CheckMiningAccounts(nodes); err != nil {
+		return nil, err
+	}
+
+	result := diffSideNodes(config.SideNodes(), nodes)
+	for _, address := range result.Added {
+		if err := store.DbCache.SideChainStore.AddSideChain(context.Background(), address); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, address := range result.Removed {
+		logger.Infow("reload: remove side chain", log.KeySideChain, address)
+		accountMonitor.StopSyncChainData(address)
+		if err := accountMonitor.RemoveListener(address); err != nil {
+			logger.Warnw("reload: remove listener of side chain failed", log.KeySideChain, address, log.KeyError, err)
+		}
+		manager.RemoveChain(address)
+	}
+
+	config.SetSideNodes(nodes)
+	for _, node := range nodes {
+		if chain, ok := manager.GetChain(node.GenesisBlockAddress); ok {
+			if side, ok := chain.(*SideChainImpl); ok {
+				side.setCurrentConfig(node)
+			}
+			continue
+		}
+
+		logger.Infow("reload: add side chain", log.KeySideChain, node.GenesisBlockAddress)
+		side := &SideChainImpl{
+			Key:           node.GenesisBlockAddress,
+			CurrentConfig: node,
+		}
+		manager.AddChain(node.GenesisBlockAddress, side)
+		accountMonitor.AddListener(side)
+		accountMonitor.StartSyncChainData(node.GenesisBlockAddress)
+	}
+	arbitrator.UpdateSideChainListeners(nodes)
+
+	for _, address := range result.Updated {
+		logger.Infow("reload: update side chain", log.KeySideChain, address)
+	}
+	return result, nil
+}
+
+// diffSideNodes compares the side nodes by the genesis block addresses.
+func diffSideNodes(current, nodes []*config.SideNodeConfig) *ReloadResult {
+	currentNodes := make(map[string]*config.SideNodeConfig)
+	for _, node := range current {
+		currentNodes[node.GenesisBlockAddress] = node
+	}
+
+	result := &ReloadResult{Added: []string{}, Removed: []string{}, Updated: []string{}}
+	for _, node := range nodes {
+		old, ok := currentNodes[node.GenesisBlockAddress]
+		if !ok {
+			result.Added = append(result.Added, node.GenesisBlockAddress)
+			continue
+		}
+		if !reflect.DeepEqual(old, node) {
+			result.Updated = append(result.Updated, node.GenesisBlockAddress)
+		}
+		delete(currentNodes, node.GenesisBlockAddress)
+	}
+	for address := range currentNodes {
+		result.Removed = append(result.Removed, address)
+	}
+	sort.Strings(result.Removed)
+	return result
+}
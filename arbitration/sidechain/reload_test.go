@@ -0,0 +1,46 @@
+package sidechain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+)
+
+func TestDiffSideNodes(t *testing.T) {
+	node := func(address, ip string, rate float64) *config.SideNodeConfig {
+		return &config.SideNodeConfig{
+			GenesisBlockAddress: address,
+			Rpc:                 &config.RpcConfig{IpAddress: ip, HttpJsonPort: 20606},
+			ExchangeRate:        rate,
+		}
+	}
+	current := []*config.SideNodeConfig{
+		node("A", "127.0.0.1", 1),
+		node("B", "127.0.0.1", 1),
+		node("C", "127.0.0.1", 1),
+		node("D", "127.0.0.1", 1),
+	}
+	nodes := []*config.SideNodeConfig{
+		node("A", "127.0.0.1", 1),
+		node("B", "10.0.0.2", 1),
+		node("D", "127.0.0.1", 2),
+		node("E", "127.0.0.1", 1),
+	}
+
+	result := diffSideNodes(current, nodes)
+	if strings.Join(result.Added, ",") != "E" {
+		t.Error("Unexpected added side chains:", result.Added)
+	}
+	if strings.Join(result.Removed, ",") != "C" {
+		t.Error("Unexpected removed side chains:", result.Removed)
+	}
+	if strings.Join(result.Updated, ",") != "B,D" {
+		t.Error("Unexpected updated side chains:", result.Updated)
+	}
+
+	result = diffSideNodes(current, current)
+	if len(result.Added) != 0 || len(result.Removed) != 0 || len(result.Updated) != 0 {
+		t.Error("Same side nodes should not be changed:", result)
+	}
+}
@@ -1,7 +1,9 @@
 package sidechain
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -9,6 +11,7 @@ import (
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/metrics"
 	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
 	"github.com/elastos/Elastos.ELA.Arbiter/store"
 
@@ -22,20 +25,35 @@ type SideChainAccountMonitorImpl struct {
 
 	ParentArbitrator   arbitrator.Arbitrator
 	accountListenerMap map[string]base.AccountListener
+	syncLoops          map[string]*syncLoop
+}
+
+// syncLoop is the loop syncing the data of a side chain, quit is closed to
+// stop it and done is closed after it stopped.
+type syncLoop struct {
+	quit chan struct{}
+	done chan struct{}
 }
 
 func (monitor *SideChainAccountMonitorImpl) tryInit() {
 	if monitor.accountListenerMap == nil {
 		monitor.accountListenerMap = make(map[string]base.AccountListener)
 	}
+	if monitor.syncLoops == nil {
+		monitor.syncLoops = make(map[string]*syncLoop)
+	}
 }
 
 func (monitor *SideChainAccountMonitorImpl) AddListener(listener base.AccountListener) {
+	monitor.mux.Lock()
+	defer monitor.mux.Unlock()
 	monitor.tryInit()
 	monitor.accountListenerMap[listener.GetAccountAddress()] = listener
 }
 
 func (monitor *SideChainAccountMonitorImpl) RemoveListener(account string) error {
+	monitor.mux.Lock()
+	defer monitor.mux.Unlock()
 	if monitor.accountListenerMap == nil {
 		return nil
 	}
@@ -47,12 +65,18 @@ func (monitor *SideChainAccountMonitorImpl) RemoveListener(account string) error
 	return nil
 }
 
-func (monitor *SideChainAccountMonitorImpl) fireUTXOChanged(withdrawTxs []*base.WithdrawTx, genesisBlockAddress string, blockHeight uint32) error {
+func (monitor *SideChainAccountMonitorImpl) getListener(account string) (base.AccountListener, bool) {
+	monitor.mux.Lock()
+	defer monitor.mux.Unlock()
 	if monitor.accountListenerMap == nil {
-		return nil
+		return nil, false
 	}
+	item, ok := monitor.accountListenerMap[account]
+	return item, ok
+}
 
-	item, ok := monitor.accountListenerMap[genesisBlockAddress]
+func (monitor *SideChainAccountMonitorImpl) fireUTXOChanged(withdrawTxs []*base.WithdrawTx, genesisBlockAddress string, blockHeight uint32) error {
+	item, ok := monitor.getListener(genesisBlockAddress)
 	if !ok {
 		return errors.New("fired unknown listener")
 	}
@@ -61,11 +85,7 @@ func (monitor *SideChainAccountMonitorImpl) fireUTXOChanged(withdrawTxs []*base.
 }
 
 func (monitor *SideChainAccountMonitorImpl) fireIllegalEvidenceFound(evidence *payload.SidechainIllegalData) error {
-	if monitor.accountListenerMap == nil {
-		return nil
-	}
-
-	item, ok := monitor.accountListenerMap[evidence.GenesisBlockAddress]
+	item, ok := monitor.getListener(evidence.GenesisBlockAddress)
 	if !ok {
 		return errors.New("fired unknown listener")
 	}
@@ -73,87 +93,169 @@ func (monitor *SideChainAccountMonitorImpl) fireIllegalEvidenceFound(evidence *p
 	return item.OnIllegalEvidenceFound(evidence)
 }
 
-func (monitor *SideChainAccountMonitorImpl) SyncChainData(sideNode *config.SideNodeConfig) {
+// StartSyncChainData starts the loop syncing the data of the side chain, it
+// does nothing if the loop is running.
+func (monitor *SideChainAccountMonitorImpl) StartSyncChainData(genesisBlockAddress string) {
+	monitor.mux.Lock()
+	defer monitor.mux.Unlock()
+	monitor.tryInit()
+	if _, ok := monitor.syncLoops[genesisBlockAddress]; ok {
+		return
+	}
+	loop := &syncLoop{quit: make(chan struct{}), done: make(chan struct{})}
+	monitor.syncLoops[genesisBlockAddress] = loop
+	go func() {
+		defer close(loop.done)
+		monitor.SyncChainData(genesisBlockAddress, loop.quit)
+	}()
+}
+
+// StopSyncChainData stops the loop syncing the data of the side chain, and
+// waits until the blocks being synced are processed.
+func (monitor *SideChainAccountMonitorImpl) StopSyncChainData(genesisBlockAddress string) {
+	monitor.mux.Lock()
+	loop, ok := monitor.syncLoops[genesisBlockAddress]
+	delete(monitor.syncLoops, genesisBlockAddress)
+	monitor.mux.Unlock()
+	if !ok {
+		return
+	}
+	close(loop.quit)
+	<-loop.done
+}
+
+// SyncChainData syncs the withdraw transactions and the illegal evidences of
+// the side chain until quit is closed, the rpc config of the side chain is
+// read again in every round so the reloaded one is used.
+func (monitor *SideChainAccountMonitorImpl) SyncChainData(genesisBlockAddress string, quit <-chan struct{}) {
 	for {
+		sideNode, ok := config.SideNode(genesisBlockAddress)
+		if !ok {
+			logger.Warnw("side chain is not in the configuration", log.KeySideChain, genesisBlockAddress)
+			return
+		}
 		chainHeight, currentHeight, needSync := monitor.needSyncBlocks(sideNode.GenesisBlockAddress, sideNode.Rpc)
+		if chainHeight > 0 {
+			updateHeightMetrics(sideNode.GenesisBlockAddress, chainHeight, currentHeight)
+		}
 
+		stopped := false
 		if needSync {
-			log.Info("currentHeight:", currentHeight, " chainHeight:", chainHeight)
+			logger.Infow("sync side chain", log.KeySideChain, sideNode.GenesisBlockAddress,
+				log.KeyHeight, chainHeight, "currentheight", currentHeight)
 			for currentHeight < chainHeight {
-				if currentHeight >= 6 {
-					transactions, err := rpc.GetWithdrawTransactionByHeight(currentHeight+1-6, sideNode.Rpc)
-					if err != nil {
-						log.Error("get destroyed transaction at height:", currentHeight+1-6, "failed\n"+
-							"rpc:", sideNode.Rpc.IpAddress, ":", sideNode.Rpc.HttpJsonPort, "\n"+
-							"error:", err)
-						break
-					}
-					monitor.processTransactions(transactions, sideNode.GenesisBlockAddress, currentHeight+1-6)
+				select {
+				case <-quit:
+					stopped = true
+				default:
 				}
-
-				evidences, err := rpc.GetIllegalEvidenceByHeight(currentHeight+1, sideNode.Rpc)
-				if err != nil {
-					log.Error("get illegal evidence at height:", currentHeight+1, "failed\n"+
-						"rpc:", sideNode.Rpc.IpAddress, ":", sideNode.Rpc.HttpJsonPort, "\n"+
-						"error:", err)
+				if stopped {
 					break
 				}
 
-				// process illegal evidences
-				for _, e := range evidences {
-					se, err := common.Uint256FromHexString(e.Evidence)
-					if err != nil {
-						log.Error("invalid evidence:", err.Error())
-						continue
-					}
-					sce, err := common.Uint256FromHexString(e.CompareEvidence)
-					if err != nil {
-						log.Error("invalid evidence:", err.Error())
-						continue
-					}
-					illegalSigner, err := common.HexStringToBytes(e.IllegalSigner)
-					if err != nil {
-						log.Error("invalid illegal signer:", err.Error())
-						continue
-					}
+				count := chainHeight - currentHeight
+				if count > rpc.MaxBatchSize/2 {
+					count = rpc.MaxBatchSize / 2
+				}
 
-					evidence := &payload.SidechainIllegalData{
-						IllegalType:         payload.IllegalDataType(e.IllegalType),
-						Height:              currentHeight + 1,
-						IllegalSigner:       illegalSigner,
-						Evidence:            payload.SidechainIllegalEvidence{*se},
-						CompareEvidence:     payload.SidechainIllegalEvidence{*sce},
-						GenesisBlockAddress: sideNode.GenesisBlockAddress,
-					}
-					if se.String() > sce.String() {
-						evidence.Evidence =
-							payload.SidechainIllegalEvidence{*sce}
-						evidence.CompareEvidence =
-							payload.SidechainIllegalEvidence{*se}
+				// withdraw transactions are processed after 6 confirmations
+				var withdrawHeights, evidenceHeights []uint32
+				for height := currentHeight + 1; height <= currentHeight+count; height++ {
+					if height > 6 {
+						withdrawHeights = append(withdrawHeights, height-6)
 					}
+					evidenceHeights = append(evidenceHeights, height)
+				}
 
-					if err := monitor.fireIllegalEvidenceFound(
-						evidence); err != nil {
-						log.Error("fire illegal evidence found error:",
-							err.Error())
+				transactions, evidences, err := rpc.GetWithdrawTransactionsAndEvidencesByHeights(
+					withdrawHeights, evidenceHeights, sideNode.Rpc)
+				if err != nil {
+					logger.Errorw("get destroyed transactions and illegal evidences failed",
+						log.KeySideChain, sideNode.GenesisBlockAddress, "from", currentHeight+1, "to", currentHeight+count,
+						"node", fmt.Sprintf("%s:%d", sideNode.Rpc.IpAddress, sideNode.Rpc.HttpJsonPort),
+						log.KeyError, err)
+					break
+				}
+
+				withdrawOffset := len(evidenceHeights) - len(withdrawHeights)
+				for i, height := range evidenceHeights {
+					if i >= withdrawOffset {
+						monitor.processTransactions(transactions[i-withdrawOffset], sideNode.GenesisBlockAddress, height-6)
 					}
+					monitor.processIllegalEvidences(evidences[i], sideNode.GenesisBlockAddress, height)
+					currentHeight++
 				}
-				currentHeight++
 			}
 			// Update wallet height
-			currentHeight = store.DbCache.SideChainStore.CurrentSideHeight(sideNode.GenesisBlockAddress, currentHeight)
-			log.Info(" [SyncSideChain] Side chain [", sideNode.GenesisBlockAddress, "] height: ", currentHeight)
+			currentHeight = store.DbCache.SideChainStore.CurrentSideHeight(context.Background(), sideNode.GenesisBlockAddress, currentHeight)
+			logger.Infow("side chain synced", log.KeySideChain, sideNode.GenesisBlockAddress, log.KeyHeight, currentHeight)
+			updateHeightMetrics(sideNode.GenesisBlockAddress, chainHeight, currentHeight)
+			if stopped {
+				return
+			}
 
 			if arbitrator.ArbitratorGroupSingleton.GetCurrentArbitrator().IsOnDutyOfMain() {
 				sideChain, ok := arbitrator.ArbitratorGroupSingleton.GetCurrentArbitrator().GetSideChainManager().GetChain(sideNode.GenesisBlockAddress)
 				if ok {
 					sideChain.StartSideChainMining()
-					log.Info("[SyncSideChain] Start side chain mining, genesis address: [", sideNode.GenesisBlockAddress, "]")
+					logger.Infow("side chain mining started", log.KeySideChain, sideNode.GenesisBlockAddress)
 				}
 			}
 		}
 
-		time.Sleep(time.Millisecond * config.Parameters.SideChainMonitorScanInterval)
+		select {
+		case <-quit:
+			return
+		case <-time.After(time.Millisecond * config.Parameters.SideChainMonitorScanInterval):
+		}
+	}
+}
+
+func updateHeightMetrics(genesisAddress string, chainHeight, currentHeight uint32) {
+	metrics.SideChainHeight.With(genesisAddress).Set(float64(chainHeight))
+	metrics.SideChainSyncedHeight.With(genesisAddress).Set(float64(currentHeight))
+	metrics.SideChainLag.With(genesisAddress).Set(float64(chainHeight) - float64(currentHeight))
+}
+
+func (monitor *SideChainAccountMonitorImpl) processIllegalEvidences(evidences []*base.SidechainIllegalDataInfo,
+	genesisAddress string, height uint32) {
+	for _, e := range evidences {
+		evidenceLogger := logger.With(log.KeySideChain, genesisAddress, log.KeyHeight, height)
+		se, err := common.Uint256FromHexString(e.Evidence)
+		if err != nil {
+			evidenceLogger.Errorw("invalid evidence", log.KeyError, err)
+			continue
+		}
+		sce, err := common.Uint256FromHexString(e.CompareEvidence)
+		if err != nil {
+			evidenceLogger.Errorw("invalid compare evidence", log.KeyError, err)
+			continue
+		}
+		illegalSigner, err := common.HexStringToBytes(e.IllegalSigner)
+		if err != nil {
+			evidenceLogger.Errorw("invalid illegal signer", log.KeyError, err)
+			continue
+		}
+
+		evidence := &payload.SidechainIllegalData{
+			IllegalType:         payload.IllegalDataType(e.IllegalType),
+			Height:              height,
+			IllegalSigner:       illegalSigner,
+			Evidence:            payload.SidechainIllegalEvidence{*se},
+			CompareEvidence:     payload.SidechainIllegalEvidence{*sce},
+			GenesisBlockAddress: genesisAddress,
+		}
+		if se.String() > sce.String() {
+			evidence.Evidence =
+				payload.SidechainIllegalEvidence{*sce}
+			evidence.CompareEvidence =
+				payload.SidechainIllegalEvidence{*se}
+		}
+
+		if err := monitor.fireIllegalEvidenceFound(
+			evidence); err != nil {
+			evidenceLogger.Errorw("fire illegal evidence found failed", "evidence", se, log.KeyError, err)
+		}
 	}
 }
 
@@ -164,7 +266,7 @@ func (monitor *SideChainAccountMonitorImpl) needSyncBlocks(genesisBlockAddress s
 		return 0, 0, false
 	}
 
-	currentHeight := store.DbCache.SideChainStore.CurrentSideHeight(genesisBlockAddress, store.QueryHeightCode)
+	currentHeight := store.DbCache.SideChainStore.CurrentSideHeight(context.Background(), genesisBlockAddress, store.QueryHeightCode)
 
 	if currentHeight >= chainHeight {
 		return chainHeight, currentHeight, false
@@ -176,15 +278,16 @@ func (monitor *SideChainAccountMonitorImpl) needSyncBlocks(genesisBlockAddress s
 func (monitor *SideChainAccountMonitorImpl) processTransactions(transactions []*base.WithdrawTxInfo, genesisAddress string, blockHeight uint32) {
 	var withdrawTxs []*base.WithdrawTx
 	for _, txn := range transactions {
+		txLogger := logger.With(log.KeySideChain, genesisAddress, log.KeyTx, txn.TxID, log.KeyHeight, blockHeight)
 		txnBytes, err := common.HexStringToBytes(txn.TxID)
 		if err != nil {
-			log.Warn("Find output to destroy address, but transaction hash to transaction bytes failed")
+			txLogger.Warn("find output to destroy address, but transaction hash to transaction bytes failed")
 			continue
 		}
 		reversedTxnBytes := common.BytesReverse(txnBytes)
 		hash, err := common.Uint256FromBytes(reversedTxnBytes)
 		if err != nil {
-			log.Warn("Find output to destroy address, but reversed transaction hash bytes to transaction hash failed")
+			txLogger.Warn("find output to destroy address, but reversed transaction hash bytes to transaction hash failed")
 			continue
 		}
 
@@ -192,27 +295,29 @@ func (monitor *SideChainAccountMonitorImpl) processTransactions(transactions []*
 		for _, withdraw := range txn.CrossChainAssets {
 			opAmount, err := common.StringToFixed64(withdraw.OutputAmount)
 			if err != nil {
-				log.Warn("Find output to destroy address, but have invlaid corss chain output amount")
+				txLogger.Warnw("find output to destroy address, but have invalid cross chain output amount",
+					"amount", withdraw.OutputAmount)
 				continue
 			}
 			csAmount, err := common.StringToFixed64(withdraw.CrossChainAmount)
 			if err != nil {
-				log.Warn("Find output to destroy address, but have invlaid corss chain amount")
+				txLogger.Warnw("find output to destroy address, but have invalid cross chain amount",
+					"amount", withdraw.CrossChainAmount)
 				continue
 			}
 			programHash, err := common.Uint168FromAddress(withdraw.CrossChainAddress)
 			if err != nil {
-				log.Warn("invalid withdraw cross chain address:", withdraw.CrossChainAddress)
+				txLogger.Warnw("invalid withdraw cross chain address", "address", withdraw.CrossChainAddress)
 				continue
 			}
 			addr, err := programHash.ToAddress()
 			if err != nil || addr != withdraw.CrossChainAddress {
-				log.Warn("invalid withdraw cross chain address:", withdraw.CrossChainAddress)
+				txLogger.Warnw("invalid withdraw cross chain address", "address", withdraw.CrossChainAddress)
 				continue
 			}
 			if contract.PrefixType(programHash[0]) != contract.PrefixStandard &&
 				contract.PrefixType(programHash[0]) != contract.PrefixMultiSig {
-				log.Warn("invalid withdraw cross chain address:", withdraw.CrossChainAddress)
+				txLogger.Warnw("invalid withdraw cross chain address", "address", withdraw.CrossChainAddress)
 				continue
 			}
 
@@ -231,14 +336,15 @@ func (monitor *SideChainAccountMonitorImpl) processTransactions(transactions []*
 		}
 
 		reversedTxnHash := common.BytesToHexString(reversedTxnBytes)
-		if ok, err := store.DbCache.SideChainStore.HasSideChainTx(reversedTxnHash); err != nil || !ok {
+		if ok, err := store.DbCache.SideChainStore.HasSideChainTx(context.Background(), reversedTxnHash); err != nil || !ok {
 			withdrawTxs = append(withdrawTxs, withdrawTx)
 		}
 	}
 	if len(withdrawTxs) != 0 {
 		err := monitor.fireUTXOChanged(withdrawTxs, genesisAddress, blockHeight)
 		if err != nil {
-			log.Error("[fireUTXOChanged] err:", err.Error())
+			logger.Errorw("process withdraw transactions failed", log.KeySideChain, genesisAddress,
+				log.KeyHeight, blockHeight, log.KeyError, err)
 		}
 	}
 }
@@ -2,7 +2,10 @@ package sidechain
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
@@ -34,7 +37,7 @@ func (sc *SideChainImpl) getCurrentConfig() *config.SideNodeConfig {
 	sc.mux.Lock()
 	defer sc.mux.Unlock()
 	if sc.CurrentConfig == nil {
-		for _, sideConfig := range config.Parameters.SideNodeList {
+		for _, sideConfig := range config.SideNodes() {
 			if sc.GetKey() == sideConfig.GenesisBlockAddress {
 				sc.CurrentConfig = sideConfig
 				break
@@ -44,16 +47,25 @@ func (sc *SideChainImpl) getCurrentConfig() *config.SideNodeConfig {
 	return sc.CurrentConfig
 }
 
+// setCurrentConfig replaces the config of the side chain, the config is not
+// changed after set, so the callers of getCurrentConfig see either the old
+// or the new one.
+func (sc *SideChainImpl) setCurrentConfig(sideConfig *config.SideNodeConfig) {
+	sc.mux.Lock()
+	sc.CurrentConfig = sideConfig
+	sc.mux.Unlock()
+}
+
 func (sc *SideChainImpl) GetExchangeRate() (float64, error) {
 	con := sc.getCurrentConfig()
 	if con == nil {
 		return 0, errors.New("get exchange rate failed, side chain has no config")
 	}
-	if sc.getCurrentConfig().ExchangeRate <= 0 {
+	if con.ExchangeRate <= 0 {
 		return 0, errors.New("get exchange rate failed, invalid exchange rate")
 	}
 
-	return sc.getCurrentConfig().ExchangeRate, nil
+	return con.ExchangeRate, nil
 }
 
 func (sc *SideChainImpl) GetCurrentHeight() (uint32, error) {
@@ -65,17 +77,19 @@ func (sc *SideChainImpl) GetBlockByHeight(height uint32) (*base.BlockInfo, error
 }
 
 func (sc *SideChainImpl) SendTransaction(txHash *common.Uint256) (rpc.Response, error) {
-	log.Info("[Rpc-sendtransactioninfo] Deposit transaction to side chain：", sc.CurrentConfig.Rpc.IpAddress, ":", sc.CurrentConfig.Rpc.HttpJsonPort)
-	response, err := rpc.CallAndUnmarshalResponse("sendrechargetransaction", rpc.Param("txid", txHash.String()), sc.CurrentConfig.Rpc)
+	rpcConfig := sc.getCurrentConfig().Rpc
+	txLogger := logger.With(log.KeySideChain, sc.GetKey(), log.KeyTx, txHash)
+	txLogger.Infow("send deposit transaction to side chain",
+		"node", fmt.Sprintf("%s:%d", rpcConfig.IpAddress, rpcConfig.HttpJsonPort))
+	response, err := rpc.CallAndUnmarshalResponse("sendrechargetransaction", rpc.Param("txid", txHash.String()), rpcConfig)
 	if err != nil {
 		return rpc.Response{}, err
 	}
-	log.Info("[Rpc-sendtransactioninfo] Deposit transaction finished")
 
 	if response.Error != nil {
-		log.Info("response: ", response.Error.Message)
+		txLogger.Infow("send deposit transaction finished", "code", response.Code, "reason", response.Message)
 	} else {
-		log.Info("response:", response)
+		txLogger.Infow("send deposit transaction finished", "result", response.Result)
 	}
 
 	return response, nil
@@ -94,7 +108,8 @@ func (sc *SideChainImpl) OnUTXOChanged(withdrawTxs []*base.WithdrawTx, blockHeig
 	for _, withdrawTx := range withdrawTxs {
 		buf := new(bytes.Buffer)
 		if err := withdrawTx.Serialize(buf); err != nil {
-			log.Error("[OnUTXOChanged] received withdrawTx, but is invalid tx,", err.Error())
+			logger.Errorw("received invalid withdraw transaction", log.KeySideChain, sc.GetKey(),
+				log.KeyTx, withdrawTx.Txid, log.KeyError, err)
 			continue
 		}
 
@@ -106,11 +121,26 @@ func (sc *SideChainImpl) OnUTXOChanged(withdrawTxs []*base.WithdrawTx, blockHeig
 		})
 	}
 
-	if err := store.DbCache.SideChainStore.AddSideChainTxs(txs); err != nil {
+	if err := store.DbCache.SideChainStore.AddSideChainTxs(context.Background(), txs); err != nil {
 		return err
 	}
+	var events []*store.TransactionEvent
+	for _, tx := range txs {
+		events = append(events, &store.TransactionEvent{
+			TransactionHash:     tx.TransactionHash,
+			GenesisBlockAddress: tx.GenesisBlockAddress,
+			Type:                store.TxTypeWithdraw,
+			Event:               store.TxEventDetected,
+			Height:              tx.BlockHeight,
+			Source:              store.TxSourceSideChainMonitor,
+		})
+	}
+	store.RecordTransactionEvents(events)
 
-	log.Info("[OnUTXOChanged] find ", len(txs), "withdraw transaction, add into db cache")
+	for _, tx := range txs {
+		logger.Infow("find withdraw transaction, add into db cache", log.KeySideChain, tx.GenesisBlockAddress,
+			log.KeyTx, tx.TransactionHash, log.KeyHeight, tx.BlockHeight)
+	}
 	return nil
 }
 
@@ -120,11 +150,12 @@ func (sc *SideChainImpl) OnIllegalEvidenceFound(evidence *payload.SidechainIlleg
 }
 
 func (sc *SideChainImpl) StartSideChainMining() {
-	if sc.CurrentConfig.PowChain {
-		log.Info("[OnDutyChanged] Start side chain mining: genesis address [", sc.Key, "]")
-		sideauxpow.StartSideChainMining(sc.CurrentConfig)
+	sideConfig := sc.getCurrentConfig()
+	if sideConfig.PowChain {
+		logger.Infow("start side chain mining", log.KeySideChain, sc.Key)
+		sideauxpow.StartSideChainMining(sideConfig)
 	} else {
-		log.Debug("[StartSideChainMining] side chain is not pow chain, no need to mining")
+		logger.Debugw("side chain is not pow chain, no need to mining", log.KeySideChain, sc.Key)
 	}
 }
 
@@ -141,7 +172,7 @@ func (sc *SideChainImpl) UpdateLastSubmitAuxpowHeight(genesisBlockHash common.Ui
 }
 
 func (sc *SideChainImpl) GetExistDepositTransactions(txs []string) ([]string, error) {
-	receivedTxs, err := rpc.GetExistDepositTransactions(txs, sc.CurrentConfig.Rpc)
+	receivedTxs, err := rpc.GetExistDepositTransactions(txs, sc.getCurrentConfig().Rpc)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +180,7 @@ func (sc *SideChainImpl) GetExistDepositTransactions(txs []string) ([]string, er
 }
 
 func (sc *SideChainImpl) GetWithdrawTransaction(txHash string) (*base.WithdrawTxInfo, error) {
-	txInfo, err := rpc.GetTransactionInfoByHash(txHash, sc.CurrentConfig.Rpc)
+	txInfo, err := rpc.GetTransactionInfoByHash(txHash, sc.getCurrentConfig().Rpc)
 	if err != nil {
 		return nil, err
 	}
@@ -157,22 +188,32 @@ func (sc *SideChainImpl) GetWithdrawTransaction(txHash string) (*base.WithdrawTx
 	return txInfo, nil
 }
 
+func (sc *SideChainImpl) GetWithdrawTransactions(txHashes []string) ([]*base.WithdrawTxInfo, error) {
+	txInfos, err := rpc.GetTransactionInfosByHashes(txHashes, sc.getCurrentConfig().Rpc)
+	if err != nil {
+		return nil, err
+	}
+
+	return txInfos, nil
+}
+
 func (sc *SideChainImpl) CheckIllegalEvidence(evidence *base.SidechainIllegalDataInfo) (bool, error) {
-	return rpc.CheckIllegalEvidence(evidence, sc.CurrentConfig.Rpc)
+	return rpc.CheckIllegalEvidence(evidence, sc.getCurrentConfig().Rpc)
 }
 
 func (sc *SideChainImpl) SendCachedWithdrawTxs() {
-	log.Info("[SendCachedWithdrawTxs] start")
-	defer log.Info("[SendCachedWithdrawTxs] end")
+	scLogger := logger.With(log.KeySideChain, sc.GetKey())
+	scLogger.Info("send cached withdraw transactions start")
+	defer scLogger.Info("send cached withdraw transactions end")
 
-	txHashes, blockHeights, err := store.DbCache.SideChainStore.GetAllSideChainTxHashesAndHeights(sc.GetKey())
+	txHashes, blockHeights, err := store.DbCache.SideChainStore.GetAllSideChainTxHashesAndHeights(context.Background(), sc.GetKey())
 	if err != nil {
-		log.Errorf("[SendCachedWithdrawTxs] %s", err.Error())
+		scLogger.Errorw("get cached withdraw transactions failed", log.KeyError, err)
 		return
 	}
 
 	if len(txHashes) == 0 {
-		log.Info("No cached withdraw transaction need to send")
+		scLogger.Info("no cached withdraw transaction need to send")
 		return
 	}
 
@@ -182,7 +223,7 @@ func (sc *SideChainImpl) SendCachedWithdrawTxs() {
 
 	receivedTxs, err := rpc.GetExistWithdrawTransactions(txHashes)
 	if err != nil {
-		log.Errorf("[SendCachedWithdrawTxs] %s", err.Error())
+		scLogger.Errorw("get exist withdraw transactions failed", log.KeyError, err)
 		return
 	}
 
@@ -190,27 +231,27 @@ func (sc *SideChainImpl) SendCachedWithdrawTxs() {
 	if len(unsolvedTxs) != 0 {
 		err := sc.CreateAndBroadcastWithdrawProposal(unsolvedTxs)
 		if err != nil {
-			log.Error("[ReceiveSendLastArbiterUsedUtxos] CreateAndBroadcastWithdrawProposal failed")
+			scLogger.Errorw("create and broadcast withdraw proposal failed", log.KeyError, err)
 		}
 	}
 
 	if len(receivedTxs) != 0 {
-		err = store.DbCache.SideChainStore.RemoveSideChainTxs(receivedTxs)
+		err = store.FinishedTxsDbCache.MoveSucceedWithdrawTxs(context.Background(), receivedTxs)
 		if err != nil {
-			log.Errorf("[SendCachedWithdrawTxs] %s", err.Error())
-			return
-		}
-
-		err = store.FinishedTxsDbCache.AddSucceedWithdrawTxs(receivedTxs)
-		if err != nil {
-			log.Errorf("[SendCachedWithdrawTxs] %s", err.Error())
+			scLogger.Errorw("move succeed withdraw transactions into finished db failed", log.KeyError, err)
 			return
 		}
+		store.RecordTransactionEvents(store.NewTransactionEvents(receivedTxs, store.TransactionEvent{
+			GenesisBlockAddress: sc.GetKey(),
+			Type:                store.TxTypeWithdraw,
+			Event:               store.TxEventSucceeded,
+			Reason:              "found on main chain",
+		}))
 	}
 }
 
 func (sc *SideChainImpl) CreateAndBroadcastWithdrawProposal(txnHashes []string) error {
-	unsolvedTransactions, err := store.DbCache.SideChainStore.GetSideChainTxsFromHashes(txnHashes)
+	unsolvedTransactions, err := store.DbCache.SideChainStore.GetSideChainTxsFromHashes(context.Background(), txnHashes)
 	if err != nil {
 		return err
 	}
@@ -244,7 +285,14 @@ func (sc *SideChainImpl) CreateAndBroadcastWithdrawProposal(txnHashes []string)
 		return errors.New("[CreateAndBroadcastWithdrawProposal] failed")
 	}
 	currentArbitrator.BroadcastWithdrawProposal(wTx)
-	log.Info("[CreateAndBroadcastWithdrawProposal] transactions count: ", targetIndex)
+	var sideChainTxHashes []string
+	if withdrawPayload, ok := wTx.Payload.(*payload.WithdrawFromSideChain); ok {
+		for _, hash := range withdrawPayload.SideChainTransactionHashes {
+			sideChainTxHashes = append(sideChainTxHashes, hash.String())
+		}
+	}
+	logger.Infow("broadcast withdraw proposal", log.KeySideChain, sc.GetKey(), log.KeyProposal, wTx.Hash(),
+		"sidechaintxs", strings.Join(sideChainTxHashes, ","))
 
 	return nil
 }
@@ -1,6 +1,9 @@
 package sidechain
 
 import (
+	"context"
+	"sync"
+
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
@@ -8,19 +11,34 @@ import (
 )
 
 type SideChainManagerImpl struct {
+	mux sync.RWMutex
+
 	SideChains map[string]arbitrator.SideChain
 }
 
 func (sideManager *SideChainManagerImpl) AddChain(key string, chain arbitrator.SideChain) {
+	sideManager.mux.Lock()
+	defer sideManager.mux.Unlock()
 	sideManager.SideChains[key] = chain
 }
 
+// RemoveChain unregisters the side chain of the key.
+func (sideManager *SideChainManagerImpl) RemoveChain(key string) {
+	sideManager.mux.Lock()
+	defer sideManager.mux.Unlock()
+	delete(sideManager.SideChains, key)
+}
+
 func (sideManager *SideChainManagerImpl) GetChain(key string) (arbitrator.SideChain, bool) {
+	sideManager.mux.RLock()
+	defer sideManager.mux.RUnlock()
 	elem, ok := sideManager.SideChains[key]
 	return elem, ok
 }
 
 func (sideManager *SideChainManagerImpl) GetAllChains() []arbitrator.SideChain {
+	sideManager.mux.RLock()
+	defer sideManager.mux.RUnlock()
 	var chains []arbitrator.SideChain
 	for _, v := range sideManager.SideChains {
 		chains = append(chains, v)
@@ -29,13 +47,13 @@ func (sideManager *SideChainManagerImpl) GetAllChains() []arbitrator.SideChain {
 }
 
 func (sideManager *SideChainManagerImpl) StartSideChainMining() {
-	for _, sc := range sideManager.SideChains {
+	for _, sc := range sideManager.GetAllChains() {
 		go sc.StartSideChainMining()
 	}
 }
 
 func (sideManager *SideChainManagerImpl) CheckAndRemoveWithdrawTransactionsFromDB() error {
-	txHashes, err := store.DbCache.SideChainStore.GetAllSideChainTxHashes()
+	txHashes, err := store.DbCache.SideChainStore.GetAllSideChainTxHashes(context.Background())
 	if err != nil {
 		return err
 	}
@@ -48,15 +66,15 @@ func (sideManager *SideChainManagerImpl) CheckAndRemoveWithdrawTransactionsFromD
 	}
 
 	if len(receivedTxs) != 0 {
-		err = store.DbCache.SideChainStore.RemoveSideChainTxs(receivedTxs)
-		if err != nil {
-			return err
-		}
-
-		err = store.FinishedTxsDbCache.AddSucceedWithdrawTxs(receivedTxs)
+		err = store.FinishedTxsDbCache.MoveSucceedWithdrawTxs(context.Background(), receivedTxs)
 		if err != nil {
 			return err
 		}
+		store.RecordTransactionEvents(store.NewTransactionEvents(receivedTxs, store.TransactionEvent{
+			Type:   store.TxTypeWithdraw,
+			Event:  store.TxEventSucceeded,
+			Reason: "found on main chain",
+		}))
 	}
 
 	return nil
@@ -69,7 +87,7 @@ func Init() {
 	}
 
 	sideChainManager := &SideChainManagerImpl{SideChains: make(map[string]arbitrator.SideChain)}
-	for _, sideConfig := range config.Parameters.SideNodeList {
+	for _, sideConfig := range config.SideNodes() {
 		side := &SideChainImpl{
 			Key:           sideConfig.GenesisBlockAddress,
 			CurrentConfig: sideConfig,
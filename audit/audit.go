@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"sync"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/types"
+)
+
+// Content types of the signed contents.
+const (
+	// ContentWithdraw is the signature of a withdraw transaction proposal.
+	ContentWithdraw = "withdraw"
+
+	// ContentIllegalData is the signature of the illegal evidence of a side
+	// chain.
+	ContentIllegalData = "illegaldata"
+
+	// ContentAuxpow is the signature of the payload of a side chain pow
+	// transaction.
+	ContentAuxpow = "auxpow"
+
+	// ContentTransaction is the signature of a transaction sent by the
+	// arbiter, like the side chain pow and the divide transactions.
+	ContentTransaction = "transaction"
+
+	// ContentHandshake is the signature of the nonce of a P2P handshake.
+	ContentHandshake = "handshake"
+)
+
+// DefaultFile is the audit log of the arbiter.
+var DefaultFile = filepath.Join(config.DataPath, config.DataDir, config.ArbiterDir, "audit.log")
+
+// ErrNotOpened is returned if a signature is recorded before the audit log
+// is opened.
+var ErrNotOpened = errors.New("audit log is not opened")
+
+var (
+	mux        sync.RWMutex
+	defaultLog *Log
+)
+
+// Record is a signature to be appended to the audit log.
+type Record struct {
+	ContentType string
+
+	// ContentHash is the hash of the transaction or the illegal data, the
+	// sha256 of the signed data for the others.
+	ContentHash string
+
+	Summary Summary
+
+	// Proposer is the public key of the arbiter proposing the content, empty
+	// if it is unknown.
+	Proposer string
+}
+
+// Summary tells what is signed.
+type Summary struct {
+	SideChain string   `json:",omitempty"`
+	Height    uint32   `json:",omitempty"`
+	Amount    string   `json:",omitempty"`
+	Outputs   []Output `json:",omitempty"`
+	Detail    string   `json:",omitempty"`
+}
+
+// Output is an output of a signed transaction.
+type Output struct {
+	Address string
+	Amount  string
+}
+
+// Init opens the audit log of the signatures of the arbiter.
+func Init(file string) error {
+	l, err := Open(file)
+	if err != nil {
+		return err
+	}
+	mux.Lock()
+	defaultLog = l
+	mux.Unlock()
+	return nil
+}
+
+// Append appends the signature to the audit log opened by Init, the
+// signature should not be used if it fails.
+func Append(record *Record) (*Entry, error) {
+	mux.RLock()
+	l := defaultLog
+	mux.RUnlock()
+	if l == nil {
+		return nil, ErrNotOpened
+	}
+	return l.Append(record)
+}
+
+// Recent returns the last count entries of the audit log opened by Init, the
+// latest first.
+func Recent(count int) ([]*Entry, error) {
+	mux.RLock()
+	l := defaultLog
+	mux.RUnlock()
+	if l == nil {
+		return nil, ErrNotOpened
+	}
+	return l.Recent(count), nil
+}
+
+// TransactionSummary summarizes the outputs of the transaction, Amount is
+// the total of the outputs.
+func TransactionSummary(txn *types.Transaction) Summary {
+	var total common.Fixed64
+	outputs := make([]Output, 0, len(txn.Outputs))
+	for _, output := range txn.Outputs {
+		address, err := output.ProgramHash.ToAddress()
+		if err != nil {
+			address = output.ProgramHash.String()
+		}
+		outputs = append(outputs, Output{Address: address, Amount: output.Value.String()})
+		total += output.Value
+	}
+	return Summary{Amount: total.String(), Outputs: outputs}
+}
+
+// HashData returns the content hash of the signed data.
+func HashData(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRecent is the number of the latest entries kept in memory.
+const maxRecent = 1000
+
+// GenesisHash is the previous hash of the first entry.
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// Entry is a line of the audit log. Hash is the sha256 of PrevHash and the
+// JSON of the entry with an empty Hash, so changing, removing or reordering
+// an entry breaks the hashes of all the entries after it.
+type Entry struct {
+	Seq         uint64
+	Time        string
+	ContentType string
+	ContentHash string
+	Summary     Summary
+	Proposer    string `json:",omitempty"`
+	PrevHash    string
+	Hash        string
+}
+
+// computeHash returns the hash of the entry.
+func (e *Entry) computeHash() (string, error) {
+	entry := *e
+	entry.Hash = ""
+	data, err := json.Marshal(&entry)
+	if err != nil {
+		return "", err
+	}
+	prevHash, err := hex.DecodeString(e.PrevHash)
+	if err != nil {
+		return "", fmt.Errorf("invalid PrevHash: %v", err)
+	}
+	hash := sha256.Sum256(append(prevHash, data...))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Log is an append only audit log of the signatures, an entry a line.
+type Log struct {
+	mux    sync.Mutex
+	file   *os.File
+	size   int64
+	last   *Entry
+	recent []*Entry
+}
+
+// Open opens the audit log and verifies its hash chain, it is created if it
+// does not exist. An incomplete last line, left by a crash while appending,
+// is removed, the signature of it was not used.
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Log{file: file}
+	result, err := verify(file, func(e *Entry) {
+		l.recent = append(l.recent, e)
+		if len(l.recent) > maxRecent {
+			l.recent = l.recent[1:]
+		}
+		l.last = e
+	})
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit log %s is broken, %v", path, err)
+	}
+	l.size = result.Size
+	if result.Incomplete {
+		if err := file.Truncate(l.size); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	if _, err := file.Seek(l.size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Append appends an entry of the record and syncs it to the disk. If it
+// fails, the log is truncated to the entries before.
+func (l *Log) Append(record *Record) (*Entry, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	entry := &Entry{
+		Time:        time.Now().UTC().Format(time.RFC3339Nano),
+		ContentType: record.ContentType,
+		ContentHash: record.ContentHash,
+		Summary:     record.Summary,
+		Proposer:    record.Proposer,
+		PrevHash:    GenesisHash,
+	}
+	if l.last != nil {
+		entry.Seq = l.last.Seq + 1
+		entry.PrevHash = l.last.Hash
+	}
+	var err error
+	if entry.Hash, err = entry.computeHash(); err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	line = append(line, '\n')
+
+	if _, err = l.file.Write(line); err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		l.file.Truncate(l.size)
+		l.file.Seek(l.size, io.SeekStart)
+		return nil, fmt.Errorf("append audit log error: %v", err)
+	}
+	l.size += int64(len(line))
+	l.last = entry
+	l.recent = append(l.recent, entry)
+	if len(l.recent) > maxRecent {
+		l.recent = l.recent[1:]
+	}
+	return entry, nil
+}
+
+// Recent returns the last count entries, the latest first. At most the last
+// maxRecent entries are kept in memory.
+func (l *Log) Recent(count int) []*Entry {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	entries := make([]*Entry, 0, count)
+	for i := len(l.recent) - 1; i >= 0 && len(entries) < count; i-- {
+		entries = append(entries, l.recent[i])
+	}
+	return entries
+}
+
+// Close closes the audit log.
+func (l *Log) Close() error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.file.Close()
+}
+
+// VerifyResult is the audit log verified.
+type VerifyResult struct {
+	Entries uint64
+	Last    *Entry
+
+	// Size is the size of the complete lines.
+	Size int64
+
+	// Incomplete tells if the last line is not complete.
+	Incomplete bool
+}
+
+// Verify checks the hash chain of the audit log.
+func Verify(path string) (*VerifyResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return verify(file, nil)
+}
+
+// verify checks the hash chain of the entries read from r, the valid entries
+// are passed to fn.
+func verify(r io.Reader, fn func(e *Entry)) (*VerifyResult, error) {
+	result := &VerifyResult{}
+	reader := bufio.NewReader(r)
+	prevHash := GenesisHash
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			result.Incomplete = len(line) > 0
+			return result, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var entry Entry
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("line %d: invalid entry: %v", lineNumber, err)
+		}
+		if entry.Seq != result.Entries {
+			return nil, fmt.Errorf("line %d: Seq is %d, expected %d", lineNumber, entry.Seq, result.Entries)
+		}
+		if entry.PrevHash != prevHash {
+			return nil, fmt.Errorf("line %d: PrevHash does not match the hash of the entry before", lineNumber)
+		}
+		hash, err := entry.computeHash()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		if entry.Hash != hash {
+			return nil, fmt.Errorf("line %d: Hash does not match the entry", lineNumber)
+		}
+
+		prevHash = entry.Hash
+		result.Entries++
+		result.Last = &entry
+		result.Size += int64(len(line))
+		if fn != nil {
+			fn(&entry)
+		}
+	}
+}
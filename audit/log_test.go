@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func appendRecords(t *testing.T, l *Log, count int) {
+	for i := 0; i < count; i++ {
+		_, err := l.Append(&Record{
+			ContentType: ContentWithdraw,
+			ContentHash: strings.Repeat("ab", 32),
+			Summary: Summary{
+				SideChain: "XKUh4GLhFJiqAMTF6HyWQrV9pK9HcGUdfJ",
+				Amount:    "1.5",
+				Outputs:   []Output{{Address: "EXxxx", Amount: "1.5"}},
+			},
+			Proposer: "02aa",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data", "audit.log")
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, l, 3)
+	l.Close()
+
+	l, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, l, 2)
+
+	recent := l.Recent(10)
+	if len(recent) != 5 {
+		t.Fatalf("got %d recent entries, expected 5", len(recent))
+	}
+	if recent[0].Seq != 4 || recent[4].Seq != 0 || recent[4].PrevHash != GenesisHash {
+		t.Errorf("recent entries are not the latest first: %d ... %d", recent[0].Seq, recent[4].Seq)
+	}
+	if recent[0].PrevHash != recent[1].Hash {
+		t.Error("entry appended after reopen is not chained")
+	}
+	if got := l.Recent(2); len(got) != 2 || got[0].Seq != 4 {
+		t.Errorf("Recent(2) returned %d entries", len(got))
+	}
+	l.Close()
+
+	result, err := Verify(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Entries != 5 || result.Last.Hash != recent[0].Hash || result.Incomplete {
+		t.Errorf("unexpected verify result %+v", result)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("audit log mode %v, expected 0600", info.Mode().Perm())
+	}
+}
+
+func TestLog_Incomplete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, l, 2)
+	l.Close()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"Seq":2,"Time":"20`)
+	file.Close()
+
+	result, err := Verify(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Entries != 2 || !result.Incomplete {
+		t.Errorf("unexpected verify result %+v", result)
+	}
+
+	l, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, l, 1)
+	l.Close()
+	if result, err = Verify(path); err != nil || result.Entries != 3 || result.Incomplete {
+		t.Errorf("incomplete line is not removed: %+v %v", result, err)
+	}
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, l, 3)
+	l.Close()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"changed", lines[0] + strings.Replace(lines[1], `"Amount":"1.5"`, `"Amount":"15"`, 1) + lines[2],
+			"line 2: Hash does not match"},
+		{"removed", lines[0] + lines[2], "line 2: Seq is 2, expected 1"},
+		{"reordered", lines[1] + lines[0] + lines[2], "line 1: Seq is 1, expected 0"},
+		{"field", lines[0] + strings.Replace(lines[1], `"Seq"`, `"Extra":1,"Seq"`, 1), "line 2: invalid entry"},
+	}
+	for _, test := range tests {
+		if err := ioutil.WriteFile(path, []byte(test.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Verify(path); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, expected %q", test.name, err, test.err)
+		}
+		if _, err := Open(path); err == nil {
+			t.Errorf("%s: broken audit log is opened", test.name)
+		}
+	}
+}
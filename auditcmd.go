@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/audit"
+
+	"github.com/urfave/cli"
+)
+
+var auditFileFlag = cli.StringFlag{
+	Name:  "file",
+	Usage: "audit log `<file>` path",
+	Value: audit.DefaultFile,
+}
+
+func newVerifyAuditCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "verify-audit",
+		Usage:  "Verify the hash chain of the audit log of the signatures",
+		Flags:  []cli.Flag{auditFileFlag},
+		Action: verifyAudit,
+	}
+}
+
+func verifyAudit(c *cli.Context) error {
+	file := c.String(auditFileFlag.Name)
+	result, err := audit.Verify(file)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("audit log %s does not exist", file)
+	} else if err != nil {
+		return fmt.Errorf("audit log %s is broken: %v", file, err)
+	}
+
+	fmt.Println("Audit log:", file)
+	fmt.Println("Entries:  ", result.Entries)
+	if result.Last != nil {
+		fmt.Println("Last:     ", result.Last.Seq, result.Last.Time, result.Last.ContentType)
+		fmt.Println("Last hash:", result.Last.Hash)
+	}
+	if result.Incomplete {
+		fmt.Println("The last line is incomplete, it is removed when the arbiter starts.")
+	}
+	fmt.Println("The hash chain is intact.")
+	return nil
+}
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
 
 	"github.com/elastos/Elastos.ELA/common"
 	elacfg "github.com/elastos/Elastos.ELA/common/config"
@@ -35,9 +38,49 @@ var (
 )
 
 type RpcConfiguration struct {
-	User        string   `json:"User"`
-	Pass        string   `json:"Pass"`
-	WhiteIPList []string `json:"WhiteIPList"`
+	User        string    `json:"User"`
+	Pass        string    `json:"Pass"`
+	Users       []RpcUser `json:"Users"`
+	WhiteIPList []string  `json:"WhiteIPList"`
+
+	// DenyIPList takes precedence over WhiteIPList, X-Forwarded-For header is
+	// only trusted in requests from TrustedProxyList.
+	DenyIPList       []string `json:"DenyIPList"`
+	TrustedProxyList []string `json:"TrustedProxyList"`
+
+	TLS RpcTLSConfiguration `json:"TLS"`
+}
+
+// RpcTLSConfiguration enables TLS of the rpc servers if CertFile is set,
+// MinVersion is one of "1.0", "1.1", "1.2" and "1.3", client certificates
+// signed by ClientCAFile are required if it is set.
+type RpcTLSConfiguration struct {
+	CertFile     string `json:"CertFile"`
+	KeyFile      string `json:"KeyFile"`
+	MinVersion   string `json:"MinVersion"`
+	ClientCAFile string `json:"ClientCAFile"`
+}
+
+// RpcUser is a credential of the rpc servers, Role is one of "readonly",
+// "operator" and "admin".
+type RpcUser struct {
+	User string `json:"User"`
+	Pass string `json:"Pass"`
+	Role string `json:"Role"`
+}
+
+// HasAdminCredential tells if a credential of the admin role is set, the
+// legacy User and Pass have the admin role.
+func (c *RpcConfiguration) HasAdminCredential() bool {
+	if c.User != "" || c.Pass != "" {
+		return true
+	}
+	for _, user := range c.Users {
+		if strings.EqualFold(user.Role, "admin") {
+			return true
+		}
+	}
+	return false
 }
 
 type Configuration struct {
@@ -49,29 +92,115 @@ type Configuration struct {
 	MainNode     *MainNodeConfig   `json:"MainNode"`
 	SideNodeList []*SideNodeConfig `json:"SideNodeList"`
 
-	SyncInterval  time.Duration `json:"SyncInterval"`
-	HttpJsonPort  int           `json:"HttpJsonPort"`
-	HttpRestPort  uint16        `json:"HttpRestPort"`
-	PrintLevel    uint8         `json:"PrintLevel"`
-	SPVPrintLevel uint8         `json:"SPVPrintLevel"`
-	MaxLogsSize   int64         `json:"MaxLogsSize"`
-	MaxPerLogSize int64         `json:"MaxPerLogSize"`
-
-	SideChainMonitorScanInterval time.Duration    `json:"SideChainMonitorScanInterval"`
-	ClearTransactionInterval     time.Duration    `json:"ClearTransactionInterval"`
-	MinOutbound                  int              `json:"MinOutbound"`
-	MaxConnections               int              `json:"MaxConnections"`
-	SideAuxPowFee                int              `json:"SideAuxPowFee"`
-	MinThreshold                 int              `json:"MinThreshold"`
-	DepositAmount                int              `json:"DepositAmount"`
-	CRCOnlyDPOSHeight            uint32           `json:"CRCOnlyDPOSHeight"`
-	CRClaimDPOSNodeStartHeight   uint32           `json:"CRClaimDPOSNodeStartHeight"`
-	NewP2PProtocolVersionHeight  uint64           `json:"NewP2PProtocolVersionHeight"`
-	MaxTxsPerWithdrawTx          int              `json:"MaxTxsPerWithdrawTx"`
-	OriginCrossChainArbiters     []string         `json:"OriginCrossChainArbiters"`
-	CRCCrossChainArbiters        []string         `json:"CRCCrossChainArbiters"`
-	RpcConfiguration             RpcConfiguration `json:"RpcConfiguration"`
-	DPoSNetAddress               string           `json:"DPoSNetAddress"`
+	SyncInterval  time.Duration    `json:"SyncInterval"`
+	HttpJsonPort  int              `json:"HttpJsonPort"`
+	HttpRestPort  uint16           `json:"HttpRestPort"`
+	PrintLevel    uint8            `json:"PrintLevel"`
+	SPVPrintLevel uint8            `json:"SPVPrintLevel"`
+	MaxLogsSize   int64            `json:"MaxLogsSize"`
+	MaxPerLogSize int64            `json:"MaxPerLogSize"`
+	Log           LogConfiguration `json:"Log"`
+
+	SideChainMonitorScanInterval time.Duration            `json:"SideChainMonitorScanInterval"`
+	ClearTransactionInterval     time.Duration            `json:"ClearTransactionInterval"`
+	MinOutbound                  int                      `json:"MinOutbound"`
+	MaxConnections               int                      `json:"MaxConnections"`
+	SideAuxPowFee                int                      `json:"SideAuxPowFee"`
+	MinThreshold                 int                      `json:"MinThreshold"`
+	DepositAmount                int                      `json:"DepositAmount"`
+	CRCOnlyDPOSHeight            uint32                   `json:"CRCOnlyDPOSHeight"`
+	CRClaimDPOSNodeStartHeight   uint32                   `json:"CRClaimDPOSNodeStartHeight"`
+	NewP2PProtocolVersionHeight  uint64                   `json:"NewP2PProtocolVersionHeight"`
+	MaxTxsPerWithdrawTx          int                      `json:"MaxTxsPerWithdrawTx"`
+	OriginCrossChainArbiters     []string                 `json:"OriginCrossChainArbiters"`
+	CRCCrossChainArbiters        []string                 `json:"CRCCrossChainArbiters"`
+	RpcConfiguration             RpcConfiguration         `json:"RpcConfiguration"`
+	DPoSNetAddress               string                   `json:"DPoSNetAddress"`
+	HealthCheck                  HealthCheckConfiguration `json:"HealthCheck"`
+
+	PasswordSource       string                            `json:"PasswordSource"`
+	Signer               SignerConfiguration               `json:"Signer"`
+	StorageBackend       string                            `json:"StorageBackend"`
+	FinishedTxsRetention FinishedTxsRetentionConfiguration `json:"FinishedTxsRetention"`
+	Debug                DebugConfiguration                `json:"Debug"`
+}
+
+// HealthCheckConfiguration holds the thresholds of the readiness checks, lags
+// are in blocks and CheckTimeout is in milliseconds.
+type HealthCheckConfiguration struct {
+	MaxSPVLag         uint32        `json:"MaxSPVLag"`
+	MaxMainChainLag   uint32        `json:"MaxMainChainLag"`
+	MaxSideChainLag   uint32        `json:"MaxSideChainLag"`
+	MinConnectedPeers int           `json:"MinConnectedPeers"`
+	CheckTimeout      time.Duration `json:"CheckTimeout"`
+}
+
+// SignerConfiguration is the signer daemon keeping the keys, the keystore is
+// opened by the arbiter if Socket is empty. Timeout is in milliseconds.
+type SignerConfiguration struct {
+	Socket  string        `json:"Socket"`
+	Timeout time.Duration `json:"Timeout"`
+}
+
+// LogConfiguration is the format of the log lines, "text" or "json", and the
+// print levels of the module loggers overriding PrintLevel.
+type LogConfiguration struct {
+	Format string    `json:"Format"`
+	Levels LogLevels `json:"Levels"`
+}
+
+// LogLevels are the print levels of the module loggers, nil follows
+// PrintLevel.
+type LogLevels struct {
+	Arbitrator *uint8 `json:"Arbitrator"`
+	CS         *uint8 `json:"CS"`
+	MainChain  *uint8 `json:"MainChain"`
+	SideChain  *uint8 `json:"SideChain"`
+	SideAuxPow *uint8 `json:"SideAuxPow"`
+	Store      *uint8 `json:"Store"`
+	Rpc        *uint8 `json:"Rpc"`
+	Servers    *uint8 `json:"Servers"`
+	Signer     *uint8 `json:"Signer"`
+}
+
+// Modules returns the levels set by the names of the module loggers.
+func (l *LogLevels) Modules() map[string]uint8 {
+	levels := make(map[string]uint8)
+	for name, level := range map[string]*uint8{
+		log.ModuleArbitrator: l.Arbitrator,
+		log.ModuleCS:         l.CS,
+		log.ModuleMainChain:  l.MainChain,
+		log.ModuleSideChain:  l.SideChain,
+		log.ModuleSideAuxPow: l.SideAuxPow,
+		log.ModuleStore:      l.Store,
+		log.ModuleRpc:        l.Rpc,
+		log.ModuleServers:    l.Servers,
+		log.ModuleSigner:     l.Signer,
+	} {
+		if level != nil {
+			levels[name] = *level
+		}
+	}
+	return levels
+}
+
+// FinishedTxsRetentionConfiguration is the retention policy of the finished
+// transactions, days of 0 keep the transactions forever and intervals are in
+// milliseconds.
+type FinishedTxsRetentionConfiguration struct {
+	SucceedDays    int           `json:"SucceedDays"`
+	FailedDays     int           `json:"FailedDays"`
+	ArchiveDir     string        `json:"ArchiveDir"`
+	PruneInterval  time.Duration `json:"PruneInterval"`
+	VacuumInterval time.Duration `json:"VacuumInterval"`
+	DryRun         bool          `json:"DryRun"`
+}
+
+// DebugConfiguration is the listener serving pprof, the goroutine dump and the
+// queue depths to the admin rpc users, it is disabled if ListenAddress is
+// empty.
+type DebugConfiguration struct {
+	ListenAddress string `json:"ListenAddress"`
 }
 
 type RpcConfig struct {
@@ -101,6 +230,18 @@ type SideNodeConfig struct {
 	PowChain            bool    `json:"PowChain"`
 }
 
+// UnmarshalJSON decodes the side node config, PowChain is true if it is not
+// given.
+func (c *SideNodeConfig) UnmarshalJSON(data []byte) error {
+	type sideNodeConfig SideNodeConfig
+	node := sideNodeConfig{PowChain: true}
+	if err := json.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	*c = SideNodeConfig(node)
+	return nil
+}
+
 type ConfigFile struct {
 	ConfigFile Configuration `json:"Configuration"`
 }
@@ -110,7 +251,7 @@ type configParams struct {
 }
 
 func GetRpcConfig(genesisBlockHash string) (*RpcConfig, bool) {
-	for _, node := range Parameters.SideNodeList {
+	for _, node := range SideNodes() {
 		if node.GenesisBlock == genesisBlockHash {
 			return node.Rpc, true
 		}
@@ -157,83 +298,112 @@ func GetSpvChainParams() *elacfg.Params {
 	return params
 }
 
-func init() {
-	file, e := ioutil.ReadFile(DefaultConfigFilename)
-	if e != nil {
-		fmt.Printf("File error: %v\n", e)
-		return
+// Load reads the configuration in layers into Parameters: the defaults of the
+// active net, the file if filename is not empty, then the overrides in order.
+// All the invalid values are returned at once as ConfigErrors, and Parameters
+// is not changed then.
+func Load(filename string, overrides ...Override) error {
+	file, err := readConfigFile(filename)
+	if err != nil {
+		return err
 	}
-	i := ConfigFile{}
-	// Remove the UTF-8 Byte Order Mark
-	file = bytes.TrimPrefix(file, []byte("\xef\xbb\xbf"))
-
-	e = json.Unmarshal(file, &i)
-	var config ConfigFile
-	switch strings.ToLower(i.ConfigFile.ActiveNet) {
-	case "testnet", "test":
-		config = testnet
-	case "regnet", "reg":
-		config = regnet
-	default:
-		config = mainnet
+	conf, err := parseConfig(file, overrides...)
+	if err != nil {
+		return err
 	}
+	Parameters.Configuration = conf
+	loadedFile, loadedOverrides = filename, overrides
+	return nil
+}
 
-	Parameters.Configuration = &(config.ConfigFile)
-
-	e = json.Unmarshal(file, &config)
-	if e != nil {
-		fmt.Printf("Unmarshal json file erro %v", e)
-		os.Exit(1)
+// readConfigFile reads the config file, an empty JSON object if filename is
+// empty.
+func readConfigFile(filename string) ([]byte, error) {
+	if filename == "" {
+		return []byte("{}"), nil
 	}
-
-	for _, side := range config.ConfigFile.SideNodeList {
-		side.PowChain = true
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("File error: %v", err)
 	}
+	// Remove the UTF-8 Byte Order Mark
+	return bytes.TrimPrefix(file, []byte("\xef\xbb\xbf")), nil
+}
 
-	e = json.Unmarshal(file, &config)
-	if e != nil {
-		fmt.Printf("Unmarshal json file erro %v", e)
-		os.Exit(1)
+// parseConfig decodes the config file, applies the overrides and validates
+// the result.
+func parseConfig(file []byte, overrides ...Override) (*Configuration, error) {
+	raw, err := decodeConfigFile(file)
+	if err != nil {
+		return nil, err
 	}
-
-	//Parameters.Configuration = &(config.ConfigFile)
-
-	var out bytes.Buffer
-	err := json.Indent(&out, file, "", "")
+	v := newValidator()
+	for _, o := range overrides {
+		raw = v.override(raw, o)
+	}
+	raw, _ = v.normalize("", raw, reflect.TypeOf(ConfigFile{}))
+	normalized, err := json.Marshal(raw)
 	if err != nil {
-		fmt.Printf("Config file error: %v\n", e)
-		os.Exit(1)
+		return nil, err
 	}
 
-	if Parameters.Configuration.MainNode == nil {
-		fmt.Printf("Need to set main node in config file\n")
-		return
+	i := ConfigFile{}
+	json.Unmarshal(normalized, &i)
+	var defaults ConfigFile
+	switch strings.ToLower(i.ConfigFile.ActiveNet) {
+	case "testnet", "test":
+		defaults = testnet
+	case "regnet", "reg":
+		defaults = regnet
+	default:
+		defaults = mainnet
 	}
 
-	if Parameters.Configuration.SideNodeList == nil {
-		fmt.Printf("Need to set side node list in config file\n")
-		return
+	// copy the defaults deeply, the file may change their nodes
+	config := ConfigFile{}
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(normalized, &config); err != nil {
+		return nil, fmt.Errorf("Unmarshal json file error: %v", err)
 	}
 
-	for _, node := range Parameters.SideNodeList {
+	conf := &config.ConfigFile
+	v.validate(conf)
+	for i, node := range conf.SideNodeList {
+		if node == nil {
+			continue
+		}
+		path := "Configuration.SideNodeList[" + strconv.Itoa(i) + "].GenesisBlock"
+		if _, failed := v.paths[path]; failed {
+			continue
+		}
 		genesisBytes, err := common.HexStringToBytes(node.GenesisBlock)
 		if err != nil {
-			fmt.Printf("Side node genesis block hash error: %v\n", e)
-			return
+			v.errorf(path, "Side node genesis block hash error: %v", err)
+			continue
 		}
 		reversedGenesisBytes := common.BytesReverse(genesisBytes)
 		reversedGenesisStr := common.BytesToHexString(reversedGenesisBytes)
 		genesisBlockHash, err := common.Uint256FromHexString(reversedGenesisStr)
 		if err != nil {
-			fmt.Printf("Side node genesis block hash reverse error: %v\n", e)
-			return
+			v.errorf(path, "Side node genesis block hash reverse error: %v", err)
+			continue
 		}
 		address, err := base.GetGenesisAddress(*genesisBlockHash)
 		if err != nil {
-			fmt.Printf("Side node genesis block hash to address error: %v\n", e)
-			return
+			v.errorf(path, "Side node genesis block hash to address error: %v", err)
+			continue
 		}
 		node.GenesisBlockAddress = address
 		node.GenesisBlock = reversedGenesisStr
 	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return conf, nil
 }
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -38,3 +40,73 @@ func TestGetRpcConfig(t *testing.T) {
 		t.Error("Found wrong config")
 	}
 }
+
+func TestLoad(t *testing.T) {
+	defer InitMockConfig()
+
+	file, err := ioutil.TempFile("", "arbiter-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"Configuration": {"ActiveNet": "testnet", "HttpJsonPort": 30000,
+		"MainNode": {"Rpc": {"IpAddress": "127.0.0.1", "HttpJsonPort": 21336}},
+		"SideNodeList": []}}`)
+	file.Close()
+
+	if err := Load(file.Name()); err != nil {
+		t.Fatal("Load config error:", err)
+	}
+	if Parameters.HttpJsonPort != 30000 || Parameters.MainNode.Rpc.HttpJsonPort != 21336 {
+		t.Error("Config file values not loaded")
+	}
+	if Parameters.Magic != testnet.ConfigFile.Magic || Parameters.StorageBackend != "sqlite" {
+		t.Error("Defaults of testnet not loaded")
+	}
+
+	if err := Load(file.Name() + ".missing"); err == nil {
+		t.Error("Load of a missing config file should fail")
+	}
+}
+
+func TestLoadSideNodes(t *testing.T) {
+	defer InitMockConfig()
+
+	file, err := ioutil.TempFile("", "arbiter-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(validTestConfig)
+	file.Close()
+
+	if err := Load(file.Name(), Override{Name: "--rpcport", Path: []string{"HttpJsonPort"}, Value: "30000"}); err != nil {
+		t.Fatal("Load config error:", err)
+	}
+	if err := ioutil.WriteFile(file.Name(), []byte(strings.Replace(validTestConfig,
+		`"HttpJsonPort": 20616`, `"HttpJsonPort": 20617`, 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := LoadSideNodes()
+	if err != nil {
+		t.Fatal("Load side nodes error:", err)
+	}
+	if len(nodes) != 2 || nodes[1].Rpc.HttpJsonPort != 20617 || nodes[1].GenesisBlockAddress == "" {
+		t.Error("Side nodes not reloaded from the config file")
+	}
+	if SideNodes()[1].Rpc.HttpJsonPort != 20616 || Parameters.HttpJsonPort != 30000 {
+		t.Error("Loading side nodes should not change the configuration")
+	}
+	SetSideNodes(nodes)
+	if node, ok := SideNode(nodes[1].GenesisBlockAddress); !ok || node.Rpc.HttpJsonPort != 20617 {
+		t.Error("Side nodes not replaced")
+	}
+
+	if err := ioutil.WriteFile(file.Name(), []byte(`{"Configuration": {"NodePort": 0}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSideNodes(); err == nil {
+		t.Error("Invalid configuration should not be reloaded")
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/password"
+)
+
+const (
+	// EnvPrefix is the prefix of the environment variables overriding the
+	// config values, like ARBITER_SIDENODELIST_0_RPC_HTTPJSONPORT.
+	EnvPrefix = "ARBITER_"
+
+	// EnvConfigFile is the environment variable of the config file path, it
+	// is not a config value.
+	EnvConfigFile = EnvPrefix + "CONFIG_FILE"
+)
+
+// envNotOverrides are the ARBITER_* environment variables which are not
+// config values.
+var envNotOverrides = map[string]struct{}{
+	EnvConfigFile:       {},
+	password.DefaultEnv: {},
+}
+
+// Override sets the config value at Path, a list of field names and list
+// indexes under Configuration. Names are case insensitive. Name tells where
+// the override comes from in the errors.
+type Override struct {
+	Name  string
+	Path  []string
+	Value string
+}
+
+// EnvOverrides returns the overrides of the ARBITER_* environment variables
+// in environ, the path segments are separated by underscores.
+func EnvOverrides(environ []string) []Override {
+	var overrides []Override
+	for _, env := range environ {
+		i := strings.Index(env, "=")
+		if i < 0 || !strings.HasPrefix(env[:i], EnvPrefix) {
+			continue
+		}
+		if _, ok := envNotOverrides[env[:i]]; ok {
+			continue
+		}
+		name := env[:i]
+		overrides = append(overrides, Override{
+			Name:  name,
+			Path:  strings.Split(strings.TrimPrefix(name, EnvPrefix), "_"),
+			Value: env[i+1:],
+		})
+	}
+	return overrides
+}
+
+// ParseOverride parses an override given like
+// SideNodeList.0.Rpc.HttpJsonPort=20606.
+func ParseOverride(name, set string) (Override, bool) {
+	i := strings.Index(set, "=")
+	if i <= 0 {
+		return Override{}, false
+	}
+	return Override{
+		Name:  name + " " + set[:i],
+		Path:  strings.Split(set[:i], "."),
+		Value: set[i+1:],
+	}, true
+}
+
+// override sets the value of the override into the decoded config file.
+func (v *validator) override(raw interface{}, o Override) interface{} {
+	file, ok := raw.(map[string]interface{})
+	if !ok {
+		file = make(map[string]interface{})
+	}
+	key := objectKey(file, "Configuration")
+	if value, ok := v.setValue(o, "Configuration", file[key],
+		reflect.TypeOf(Configuration{}), o.Path); ok {
+		file[key] = value
+	}
+	return file
+}
+
+func (v *validator) setValue(o Override, path string, value interface{}, t reflect.Type,
+	segments []string) (interface{}, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if len(segments) == 0 {
+		return v.overrideValue(o, t, o.Value)
+	}
+	if t == durationType {
+		v.errorf(o.Name, "%s is not an object or a list", path)
+		return nil, false
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			object = make(map[string]interface{})
+		}
+		field, ok := structField(t, segments[0])
+		if !ok {
+			v.errorf(o.Name, "unknown config field %s", joinPath(path, segments[0]))
+			return nil, false
+		}
+		key := objectKey(object, field.Name)
+		child, ok := v.setValue(o, joinPath(path, field.Name), object[key], field.Type, segments[1:])
+		if !ok {
+			return nil, false
+		}
+		object[key] = child
+		return object, true
+
+	case reflect.Slice:
+		array, _ := value.([]interface{})
+		index, err := strconv.Atoi(segments[0])
+		if err != nil || index < 0 {
+			v.errorf(o.Name, "%s should be followed by a list index, not %q", path, segments[0])
+			return nil, false
+		}
+		if index > len(array) {
+			v.errorf(o.Name, "index %d of %s is out of range, the list has %d items",
+				index, path, len(array))
+			return nil, false
+		}
+		if index == len(array) {
+			array = append(array, nil)
+		}
+		child, ok := v.setValue(o, path+"["+segments[0]+"]", array[index], t.Elem(), segments[1:])
+		if !ok {
+			return nil, false
+		}
+		array[index] = child
+		return array, true
+
+	default:
+		v.errorf(o.Name, "%s is not an object or a list", path)
+		return nil, false
+	}
+}
+
+// overrideValue converts the string of an override to the JSON value of the
+// type, the type of the value is checked later by normalize. Objects and
+// lists are given as JSON, lists of strings and numbers can also be given
+// separated by commas.
+func (v *validator) overrideValue(o Override, t reflect.Type, value string) (interface{}, bool) {
+	if t == durationType {
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return json.Number(value), true
+		}
+		return value, true
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b, true
+		}
+		return value, true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return json.Number(strings.TrimSpace(value)), true
+
+	case reflect.Struct, reflect.Slice:
+		trimmed := strings.TrimSpace(value)
+		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			decoder := json.NewDecoder(bytes.NewReader([]byte(trimmed)))
+			decoder.UseNumber()
+			var decoded interface{}
+			if err := decoder.Decode(&decoded); err != nil {
+				v.errorf(o.Name, "invalid JSON: %v", err)
+				return nil, false
+			}
+			return decoded, true
+		}
+		if t.Kind() == reflect.Struct || t.Elem().Kind() == reflect.Struct ||
+			t.Elem().Kind() == reflect.Ptr || t.Elem().Kind() == reflect.Slice {
+			v.errorf(o.Name, "should be JSON")
+			return nil, false
+		}
+		array := make([]interface{}, 0)
+		if trimmed == "" {
+			return array, true
+		}
+		for _, item := range strings.Split(trimmed, ",") {
+			converted, ok := v.overrideValue(o, t.Elem(), strings.TrimSpace(item))
+			if !ok {
+				return nil, false
+			}
+			array = append(array, converted)
+		}
+		return array, true
+	}
+	return value, true
+}
+
+// objectKey returns the key of the field in the object like encoding/json
+// matches it, the name itself if the object does not have the field.
+func objectKey(object map[string]interface{}, name string) string {
+	if _, ok := object[name]; ok {
+		return name
+	}
+	for key := range object {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return name
+}
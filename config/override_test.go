@@ -0,0 +1,77 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	overrides := EnvOverrides([]string{
+		"PATH=/usr/bin",
+		EnvConfigFile + "=/etc/arbiter/config.json",
+		"ARBITER_PASSWORD=secret",
+		"ARBITER_SIDENODELIST_0_RPC_HTTPJSONPORT=20606",
+		"ARBITER_RPCCONFIGURATION_PASS=a=b",
+	})
+	if len(overrides) != 2 {
+		t.Fatal("Unexpected overrides:", overrides)
+	}
+	if o := overrides[0]; o.Name != "ARBITER_SIDENODELIST_0_RPC_HTTPJSONPORT" ||
+		len(o.Path) != 4 || o.Path[1] != "0" || o.Value != "20606" {
+		t.Error("Unexpected override:", o)
+	}
+	if o := overrides[1]; o.Value != "a=b" {
+		t.Error("Unexpected override:", o)
+	}
+}
+
+func TestParseConfig_Overrides(t *testing.T) {
+	override := func(name, value string) Override {
+		o, ok := ParseOverride("--set", name+"="+value)
+		if !ok {
+			t.Fatal("Parse override error:", name)
+		}
+		return o
+	}
+	conf, err := parseConfig([]byte(validTestConfig),
+		Override{Name: "ARBITER_SIDENODELIST_1_RPC_IPADDRESS", Path: []string{"SIDENODELIST", "1", "RPC", "IPADDRESS"}, Value: "10.0.0.2"},
+		override("SideNodeList.2", `{"Rpc": {"IpAddress": "127.0.0.1", "HttpJsonPort": 20626}, "ExchangeRate": 2,
+			"GenesisBlock": "7c1a76281736d40599d6ae347d1bad924ab02b06c6cf9acd84f519dfdeb78d33"}`),
+		override("CRCCrossChainArbiters", "03e435ccd6073813917c2d841a0815d21301ec3286bc1412bb5b099178c68a10b6, 038a1829b4b2bee784a99bebabbfecfec53f33dadeeeff21b460f8b4fc7c2ca771"),
+		override("SyncInterval", "5s"),
+		override("HttpJsonPort", "30000"),
+		override("HttpJsonPort", "30001"),
+	)
+	if err != nil {
+		t.Fatal("Parse config error:", err)
+	}
+	if conf.SideNodeList[1].Rpc.IpAddress != "10.0.0.2" || conf.SideNodeList[1].Rpc.HttpJsonPort != 20616 {
+		t.Error("Nested override not applied:", *conf.SideNodeList[1].Rpc)
+	}
+	if len(conf.SideNodeList) != 3 || conf.SideNodeList[2].ExchangeRate != 2 {
+		t.Error("List item not appended")
+	}
+	if len(conf.CRCCrossChainArbiters) != 2 {
+		t.Error("Comma separated list not applied:", conf.CRCCrossChainArbiters)
+	}
+	if conf.SyncInterval != 5000 || conf.HttpJsonPort != 30001 {
+		t.Error("Later overrides should win:", conf.SyncInterval, conf.HttpJsonPort)
+	}
+
+	_, err = parseConfig([]byte(validTestConfig),
+		override("Foo", "1"),
+		override("SideNodeList.5.ExchangeRate", "1"),
+		override("MainNode.Rpc", "localhost"),
+		override("NodePort", "abc"),
+	)
+	errs, ok := err.(ConfigErrors)
+	if !ok || len(errs) != 4 {
+		t.Fatal("Parse config should fail with 4 errors:", err)
+	}
+	expected := []string{"--set Foo", "--set MainNode.Rpc", "--set SideNodeList.5.ExchangeRate",
+		"Configuration.NodePort"}
+	for i, path := range expected {
+		if errs[i].Path != path {
+			t.Errorf("Expected error of %s, got %v", path, errs[i])
+		}
+	}
+}
@@ -41,6 +41,18 @@ var (
 				Pass:        "",
 				WhiteIPList: []string{"127.0.0.1"},
 			},
+			HealthCheck: HealthCheckConfiguration{
+				MaxSPVLag:         6,
+				MaxMainChainLag:   6,
+				MaxSideChainLag:   20,
+				MinConnectedPeers: 1,
+				CheckTimeout:      5000,
+			},
+			StorageBackend: "sqlite",
+			FinishedTxsRetention: FinishedTxsRetentionConfiguration{
+				PruneInterval:  3600000,
+				VacuumInterval: 604800000,
+			},
 			DPoSNetAddress:    "127.0.0.1:22339",
 			CRCOnlyDPOSHeight: 211000,
 			CRCCrossChainArbiters: []string{
@@ -109,6 +121,18 @@ var (
 				Pass:        "",
 				WhiteIPList: []string{"0.0.0.0"},
 			},
+			HealthCheck: HealthCheckConfiguration{
+				MaxSPVLag:         6,
+				MaxMainChainLag:   6,
+				MaxSideChainLag:   20,
+				MinConnectedPeers: 1,
+				CheckTimeout:      5000,
+			},
+			StorageBackend: "sqlite",
+			FinishedTxsRetention: FinishedTxsRetentionConfiguration{
+				PruneInterval:  3600000,
+				VacuumInterval: 604800000,
+			},
 			DPoSNetAddress:    "127.0.0.1:21339",
 			CRCOnlyDPOSHeight: 211000,
 			CRCCrossChainArbiters: []string{
@@ -180,6 +204,18 @@ var (
 				Pass:        "",
 				WhiteIPList: []string{"0.0.0.0"},
 			},
+			HealthCheck: HealthCheckConfiguration{
+				MaxSPVLag:         6,
+				MaxMainChainLag:   6,
+				MaxSideChainLag:   20,
+				MinConnectedPeers: 1,
+				CheckTimeout:      5000,
+			},
+			StorageBackend: "sqlite",
+			FinishedTxsRetention: FinishedTxsRetentionConfiguration{
+				PruneInterval:  3600000,
+				VacuumInterval: 604800000,
+			},
 			DPoSNetAddress:    "127.0.0.1:20339",
 			CRCOnlyDPOSHeight: 343400,
 			CRCCrossChainArbiters: []string{
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"sync"
+)
+
+var (
+	// sideNodesMux guards Parameters.SideNodeList, it is replaced when the
+	// side chains are reloaded while the arbiter is running.
+	sideNodesMux sync.RWMutex
+
+	// loadedFile and loadedOverrides are the sources of the configuration
+	// given to Load, the side nodes are reloaded from them.
+	loadedFile      string
+	loadedOverrides []Override
+)
+
+// SideNodes returns the side nodes of the configuration, the list is not
+// changed after returned, a reload replaces it.
+func SideNodes() []*SideNodeConfig {
+	sideNodesMux.RLock()
+	defer sideNodesMux.RUnlock()
+	return Parameters.SideNodeList
+}
+
+// SideNode returns the side node of the genesis block address.
+func SideNode(genesisBlockAddress string) (*SideNodeConfig, bool) {
+	for _, node := range SideNodes() {
+		if node.GenesisBlockAddress == genesisBlockAddress {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// SetSideNodes replaces the side nodes of the configuration.
+func SetSideNodes(nodes []*SideNodeConfig) {
+	sideNodesMux.Lock()
+	defer sideNodesMux.Unlock()
+	Parameters.SideNodeList = nodes
+}
+
+// LoadSideNodes reads the configuration again from the file and the
+// overrides given to Load, and returns its side nodes. The whole
+// configuration is validated, but only the side nodes are returned, the other
+// values are not reloaded.
+func LoadSideNodes() ([]*SideNodeConfig, error) {
+	file, err := readConfigFile(loadedFile)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := parseConfig(file, loadedOverrides...)
+	if err != nil {
+		return nil, err
+	}
+	return conf.SideNodeList, nil
+}
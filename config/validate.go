@@ -0,0 +1,577 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/ipfilter"
+	"github.com/elastos/Elastos.ELA.Arbiter/password"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+const (
+	// maxPrintLevel is above the fatal level of the arbiter log, it disables
+	// the log.
+	maxPrintLevel = 5
+	// maxSPVPrintLevel is the off level of the spv log.
+	maxSPVPrintLevel = 6
+
+	minLoopInterval = 100 * time.Millisecond
+	maxLoopInterval = 24 * time.Hour
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+
+	rpcRoles       = []string{"readonly", "operator", "admin"}
+	tlsMinVersions = []string{"1.0", "1.1", "1.2", "1.3"}
+)
+
+// ConfigError is an invalid value of the config file, Path is the JSON path
+// of the value like Configuration.SideNodeList[0].Rpc.HttpJsonPort.
+type ConfigError struct {
+	Path    string
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ConfigErrors are all the invalid values found in the config file.
+type ConfigErrors []*ConfigError
+
+func (e ConfigErrors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("%d invalid config values:", len(e)))
+	for _, err := range e {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validator collects the errors of the config, only the first error of a
+// path is kept.
+type validator struct {
+	errors ConfigErrors
+	paths  map[string]struct{}
+}
+
+func newValidator() *validator {
+	return &validator{paths: make(map[string]struct{})}
+}
+
+func (v *validator) errorf(path, format string, a ...interface{}) {
+	if _, ok := v.paths[path]; ok {
+		return
+	}
+	v.paths[path] = struct{}{}
+	v.errors = append(v.errors, &ConfigError{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		return v.errors[i].Path < v.errors[j].Path
+	})
+	return v.errors
+}
+
+// decodeConfigFile decodes the config file into a generic JSON value, syntax
+// errors are reported by their line and column.
+func decodeConfigFile(file []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(file))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line, column := position(file, syntaxErr.Offset)
+			return nil, fmt.Errorf("Config file syntax error at line %d column %d: %v",
+				line, column, err)
+		}
+		return nil, fmt.Errorf("Config file error: %v", err)
+	}
+	return raw, nil
+}
+
+// position returns the line and column of the last byte read before offset.
+func position(file []byte, offset int64) (int, int) {
+	if offset > int64(len(file)) {
+		offset = int64(len(file))
+	}
+	before := file[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n') - 1
+	return line, column
+}
+
+// normalize checks the decoded JSON value against the type it is decoded
+// into. Unknown fields and values of wrong types are reported, and durations
+// given as strings like "30s" are converted to the milliseconds the duration
+// fields hold. It returns false if the value should be dropped.
+func (v *validator) normalize(path string, value interface{}, t reflect.Type) (interface{}, bool) {
+	if value == nil {
+		return nil, true
+	}
+	if t == durationType {
+		return v.normalizeDuration(path, value)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return v.normalize(path, value, t.Elem())
+
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.errorf(path, "should be an object")
+			return nil, false
+		}
+		for key, item := range object {
+			field, ok := structField(t, key)
+			if !ok {
+				v.errorf(joinPath(path, key), "unknown field")
+				delete(object, key)
+				continue
+			}
+			if normalized, ok := v.normalize(joinPath(path, field.Name), item, field.Type); ok {
+				object[key] = normalized
+			} else {
+				delete(object, key)
+			}
+		}
+		return object, true
+
+	case reflect.Slice:
+		array, ok := value.([]interface{})
+		if !ok {
+			v.errorf(path, "should be an array")
+			return nil, false
+		}
+		for i, item := range array {
+			array[i], _ = v.normalize(path+"["+strconv.Itoa(i)+"]", item, t.Elem())
+		}
+		return array, true
+
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			v.errorf(path, "should be a string")
+			return nil, false
+		}
+
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			v.errorf(path, "should be true or false")
+			return nil, false
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, ok := value.(json.Number)
+		if !ok {
+			v.errorf(path, "should be a number")
+			return nil, false
+		}
+		if _, err := strconv.ParseInt(number.String(), 10, t.Bits()); err != nil {
+			v.errorf(path, "should be an integer of %d bits", t.Bits())
+			return nil, false
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, ok := value.(json.Number)
+		if !ok {
+			v.errorf(path, "should be a number")
+			return nil, false
+		}
+		if _, err := strconv.ParseUint(number.String(), 10, t.Bits()); err != nil {
+			v.errorf(path, "should be an integer between 0 and %d", uint64(1)<<uint(t.Bits())-1)
+			return nil, false
+		}
+
+	case reflect.Float32, reflect.Float64:
+		number, ok := value.(json.Number)
+		if !ok {
+			v.errorf(path, "should be a number")
+			return nil, false
+		}
+		if _, err := strconv.ParseFloat(number.String(), t.Bits()); err != nil {
+			v.errorf(path, "should be a number")
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// normalizeDuration converts a duration string to milliseconds.
+func (v *validator) normalizeDuration(path string, value interface{}) (interface{}, bool) {
+	switch duration := value.(type) {
+	case json.Number:
+		if _, err := duration.Int64(); err != nil {
+			v.errorf(path, "should be milliseconds or a duration like \"30s\"")
+			return nil, false
+		}
+		return duration, true
+	case string:
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			v.errorf(path, "invalid duration %q, should be like \"1m30s\"", duration)
+			return nil, false
+		}
+		return json.Number(strconv.FormatInt(int64(d/time.Millisecond), 10)), true
+	default:
+		v.errorf(path, "should be milliseconds or a duration like \"30s\"")
+		return nil, false
+	}
+}
+
+// structField finds the field of the JSON key like encoding/json does, which
+// prefers an exact match of the name and falls back to a case insensitive
+// match.
+func structField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var folded *reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		field.Name = name
+		if name == key {
+			return field, true
+		}
+		if folded == nil && strings.EqualFold(name, key) {
+			folded = &field
+		}
+	}
+	if folded != nil {
+		return *folded, true
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Validate checks the values of the configuration, all the invalid values
+// are returned as ConfigErrors.
+func (c *Configuration) Validate() error {
+	v := newValidator()
+	v.validate(c)
+	return v.err()
+}
+
+func (v *validator) validate(c *Configuration) {
+	const root = "Configuration"
+
+	switch strings.ToLower(c.ActiveNet) {
+	case "", "mainnet", "main", "testnet", "test", "regnet", "reg":
+	default:
+		v.errorf(root+".ActiveNet", "unknown net %q, should be mainnet, testnet or regnet", c.ActiveNet)
+	}
+	v.checkPort(root+".NodePort", int(c.NodePort), false)
+	v.checkPort(root+".HttpJsonPort", c.HttpJsonPort, false)
+	v.checkPort(root+".HttpRestPort", int(c.HttpRestPort), true)
+	if c.PrintLevel > maxPrintLevel {
+		v.errorf(root+".PrintLevel", "should be between 0 and %d", maxPrintLevel)
+	}
+	if c.SPVPrintLevel > maxSPVPrintLevel {
+		v.errorf(root+".SPVPrintLevel", "should be between 0 and %d", maxSPVPrintLevel)
+	}
+	if c.MaxLogsSize < 0 {
+		v.errorf(root+".MaxLogsSize", "should not be negative")
+	}
+	if c.MaxPerLogSize < 0 {
+		v.errorf(root+".MaxPerLogSize", "should not be negative")
+	}
+	switch c.Log.Format {
+	case "", log.FormatText, log.FormatJSON:
+	default:
+		v.errorf(root+".Log.Format", "unknown format %q, should be %s or %s",
+			c.Log.Format, log.FormatText, log.FormatJSON)
+	}
+	levels := reflect.ValueOf(c.Log.Levels)
+	for i := 0; i < levels.NumField(); i++ {
+		if level := levels.Field(i); !level.IsNil() && uint8(level.Elem().Uint()) > maxPrintLevel {
+			v.errorf(root+".Log.Levels."+levels.Type().Field(i).Name, "should be between 0 and %d", maxPrintLevel)
+		}
+	}
+
+	v.checkInterval(root+".SyncInterval", c.SyncInterval, minLoopInterval, maxLoopInterval)
+	v.checkInterval(root+".SideChainMonitorScanInterval", c.SideChainMonitorScanInterval,
+		minLoopInterval, maxLoopInterval)
+	v.checkInterval(root+".ClearTransactionInterval", c.ClearTransactionInterval,
+		minLoopInterval, maxLoopInterval)
+
+	if c.MinOutbound < 0 {
+		v.errorf(root+".MinOutbound", "should not be negative")
+	}
+	if c.MaxConnections < 0 {
+		v.errorf(root+".MaxConnections", "should not be negative")
+	} else if c.MaxConnections < c.MinOutbound {
+		v.errorf(root+".MaxConnections", "should not be less than MinOutbound %d", c.MinOutbound)
+	}
+	if c.SideAuxPowFee <= 0 {
+		v.errorf(root+".SideAuxPowFee", "should be greater than 0")
+	}
+	if c.MinThreshold < 0 {
+		v.errorf(root+".MinThreshold", "should not be negative")
+	}
+	if c.DepositAmount <= 0 {
+		v.errorf(root+".DepositAmount", "should be greater than 0")
+	}
+	if c.MaxTxsPerWithdrawTx <= 0 {
+		v.errorf(root+".MaxTxsPerWithdrawTx", "should be greater than 0")
+	}
+
+	v.checkPublicKeys(root+".OriginCrossChainArbiters", c.OriginCrossChainArbiters)
+	v.checkPublicKeys(root+".CRCCrossChainArbiters", c.CRCCrossChainArbiters)
+	if c.DPoSNetAddress != "" {
+		v.checkHostPort(root+".DPoSNetAddress", c.DPoSNetAddress)
+	}
+
+	if c.MainNode == nil {
+		v.errorf(root+".MainNode", "is required")
+	} else {
+		v.validateMainNode(root+".MainNode", c.MainNode)
+	}
+	if c.SideNodeList == nil {
+		v.errorf(root+".SideNodeList", "is required")
+	}
+	genesisBlocks := make(map[string]int)
+	for i, node := range c.SideNodeList {
+		path := root + ".SideNodeList[" + strconv.Itoa(i) + "]"
+		if node == nil {
+			v.errorf(path, "should be an object")
+			continue
+		}
+		v.validateSideNode(path, node)
+		if j, ok := genesisBlocks[strings.ToLower(node.GenesisBlock)]; ok && node.GenesisBlock != "" {
+			v.errorf(path+".GenesisBlock", "duplicates SideNodeList[%d]", j)
+		}
+		genesisBlocks[strings.ToLower(node.GenesisBlock)] = i
+	}
+
+	v.validateRpcConfiguration(root+".RpcConfiguration", &c.RpcConfiguration)
+
+	health := c.HealthCheck
+	if health.MinConnectedPeers < 0 {
+		v.errorf(root+".HealthCheck.MinConnectedPeers", "should not be negative")
+	}
+	if health.CheckTimeout != 0 {
+		v.checkInterval(root+".HealthCheck.CheckTimeout", health.CheckTimeout,
+			minLoopInterval, time.Minute)
+	}
+
+	if source, err := password.ParseSource(c.PasswordSource); err != nil {
+		v.errorf(root+".PasswordSource", "%v", err)
+	} else if source.Kind == password.SourceEnv && strings.HasPrefix(source.Value, EnvPrefix) &&
+		source.Value != password.DefaultEnv {
+		v.errorf(root+".PasswordSource", "environment variable %s is a config value, use %s or a name without %s",
+			source.Value, password.DefaultEnv, EnvPrefix)
+	}
+
+	if c.Signer.Socket == "" && c.Signer.Timeout != 0 {
+		v.errorf(root+".Signer.Timeout", "is not used without Signer.Socket")
+	}
+	if c.Signer.Timeout != 0 {
+		v.checkInterval(root+".Signer.Timeout", c.Signer.Timeout, minLoopInterval, time.Minute)
+	}
+
+	switch c.StorageBackend {
+	case "", "sqlite", "leveldb":
+	default:
+		v.errorf(root+".StorageBackend", "unknown backend %q, should be sqlite or leveldb", c.StorageBackend)
+	}
+
+	retention := c.FinishedTxsRetention
+	if retention.SucceedDays < 0 {
+		v.errorf(root+".FinishedTxsRetention.SucceedDays", "should not be negative")
+	}
+	if retention.FailedDays < 0 {
+		v.errorf(root+".FinishedTxsRetention.FailedDays", "should not be negative")
+	}
+	if retention.PruneInterval != 0 {
+		v.checkInterval(root+".FinishedTxsRetention.PruneInterval", retention.PruneInterval,
+			time.Second, maxLoopInterval)
+	}
+	if retention.VacuumInterval < 0 {
+		v.errorf(root+".FinishedTxsRetention.VacuumInterval", "should not be negative")
+	}
+
+	if c.Debug.ListenAddress != "" {
+		if !c.RpcConfiguration.HasAdminCredential() {
+			v.errorf(root+".Debug.ListenAddress", "requires an admin user in RpcConfiguration")
+		} else {
+			v.checkHostPort(root+".Debug.ListenAddress", c.Debug.ListenAddress)
+		}
+	}
+}
+
+func (v *validator) validateMainNode(path string, node *MainNodeConfig) {
+	if node.Rpc == nil {
+		v.errorf(path+".Rpc", "is required")
+	} else {
+		v.validateRpc(path+".Rpc", node.Rpc)
+	}
+	for i, seed := range node.SpvSeedList {
+		if strings.TrimSpace(seed) == "" {
+			v.errorf(path+".SpvSeedList["+strconv.Itoa(i)+"]", "should not be empty")
+		}
+	}
+	if node.DefaultPort != 0 {
+		v.checkPort(path+".DefaultPort", int(node.DefaultPort), false)
+	}
+	if node.FoundationAddress != "" {
+		v.checkAddress(path+".FoundationAddress", node.FoundationAddress)
+	}
+}
+
+func (v *validator) validateSideNode(path string, node *SideNodeConfig) {
+	if node.Rpc == nil {
+		v.errorf(path+".Rpc", "is required")
+	} else {
+		v.validateRpc(path+".Rpc", node.Rpc)
+	}
+	if node.ExchangeRate <= 0 {
+		v.errorf(path+".ExchangeRate", "should be greater than 0")
+	}
+	if genesis, err := common.HexStringToBytes(node.GenesisBlock); err != nil || len(genesis) != common.UINT256SIZE {
+		v.errorf(path+".GenesisBlock", "should be a block hash of 64 hex characters")
+	}
+	if node.MiningAddr != "" {
+		v.checkAddress(path+".MiningAddr", node.MiningAddr)
+	}
+	if node.PayToAddr != "" {
+		v.checkAddress(path+".PayToAddr", node.PayToAddr)
+	}
+}
+
+func (v *validator) validateRpc(path string, rpc *RpcConfig) {
+	if rpc.IpAddress == "" {
+		v.errorf(path+".IpAddress", "is required")
+	}
+	v.checkPort(path+".HttpJsonPort", rpc.HttpJsonPort, false)
+}
+
+func (v *validator) validateRpcConfiguration(path string, conf *RpcConfiguration) {
+	for i, user := range conf.Users {
+		userPath := path + ".Users[" + strconv.Itoa(i) + "]"
+		if user.User == "" {
+			v.errorf(userPath+".User", "is required")
+		}
+		if user.Pass == "" {
+			v.errorf(userPath+".Pass", "is required")
+		}
+		if !containsFold(rpcRoles, user.Role) {
+			v.errorf(userPath+".Role", "unknown role %q, should be one of %s",
+				user.Role, strings.Join(rpcRoles, ", "))
+		}
+	}
+	v.checkIPRules(path+".WhiteIPList", conf.WhiteIPList, true)
+	v.checkIPRules(path+".DenyIPList", conf.DenyIPList, false)
+	v.checkIPRules(path+".TrustedProxyList", conf.TrustedProxyList, false)
+
+	tls := conf.TLS
+	if tls.CertFile != "" && tls.KeyFile == "" {
+		v.errorf(path+".TLS.KeyFile", "is required with CertFile")
+	}
+	if tls.CertFile == "" && (tls.KeyFile != "" || tls.ClientCAFile != "") {
+		v.errorf(path+".TLS.CertFile", "is required with KeyFile and ClientCAFile")
+	}
+	if tls.MinVersion != "" && !containsFold(tlsMinVersions, tls.MinVersion) {
+		v.errorf(path+".TLS.MinVersion", "unsupported version %q, should be one of %s",
+			tls.MinVersion, strings.Join(tlsMinVersions, ", "))
+	}
+}
+
+func (v *validator) checkPort(path string, port int, zeroAllowed bool) {
+	if port == 0 && zeroAllowed {
+		return
+	}
+	if port <= 0 || port > 65535 {
+		v.errorf(path, "should be a port between 1 and 65535")
+	}
+}
+
+func (v *validator) checkHostPort(path, address string) {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		v.errorf(path, "should be host:port, %v", err)
+		return
+	}
+	if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+		v.errorf(path, "should be host:port with a port between 1 and 65535")
+	}
+}
+
+// checkInterval checks the interval in milliseconds is within [min, max].
+func (v *validator) checkInterval(path string, interval, min, max time.Duration) {
+	d := interval * time.Millisecond
+	if d < min || d > max {
+		v.errorf(path, "should be between %v and %v, got %v", min, max, d)
+	}
+}
+
+func (v *validator) checkAddress(path, address string) {
+	if _, err := common.Uint168FromAddress(address); err != nil {
+		v.errorf(path, "invalid address %q", address)
+	}
+}
+
+func (v *validator) checkPublicKeys(path string, publicKeys []string) {
+	seen := make(map[string]int)
+	for i, publicKey := range publicKeys {
+		keyPath := path + "[" + strconv.Itoa(i) + "]"
+		data, err := common.HexStringToBytes(publicKey)
+		if err == nil {
+			_, err = crypto.DecodePoint(data)
+		}
+		if err != nil {
+			v.errorf(keyPath, "invalid public key %q", publicKey)
+			continue
+		}
+		if j, ok := seen[strings.ToLower(publicKey)]; ok {
+			v.errorf(keyPath, "duplicates %s[%d]", path[strings.LastIndex(path, ".")+1:], j)
+		}
+		seen[strings.ToLower(publicKey)] = i
+	}
+}
+
+func (v *validator) checkIPRules(path string, rules []string, allowAll bool) {
+	for i, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" || (allowAll && rule == "0.0.0.0") {
+			continue
+		}
+		if _, err := ipfilter.ParseRule(rule); err != nil {
+			v.errorf(path+"["+strconv.Itoa(i)+"]", "%v", err)
+		}
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
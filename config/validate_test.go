@@ -0,0 +1,138 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const validTestConfig = `{"Configuration": {
+	"ActiveNet": "testnet",
+	"SyncInterval": "2s",
+	"SpvPrintLevel": 1,
+	"MainNode": {"Rpc": {"IpAddress": "127.0.0.1", "HttpJsonPort": 21336}},
+	"SideNodeList": [
+		{"Rpc": {"IpAddress": "127.0.0.1", "HttpJsonPort": 20606}, "ExchangeRate": 1.0,
+			"GenesisBlock": "56be936978c261b2e649d58dbfaf3f23d4a868274f5522cd2adb4308a955c4a3"},
+		{"Rpc": {"IpAddress": "127.0.0.1", "HttpJsonPort": 20616}, "ExchangeRate": 1.0, "PowChain": false,
+			"GenesisBlock": "7c1a76281736d40599d6ae347d1bad924ab02b06c6cf9acd84f519dfdeb78d16"}
+	]
+}}`
+
+func TestParseConfig(t *testing.T) {
+	conf, err := parseConfig([]byte(validTestConfig))
+	if err != nil {
+		t.Fatal("Parse config error:", err)
+	}
+	if conf.SyncInterval != 2000 {
+		t.Error("Duration string not converted to milliseconds:", conf.SyncInterval)
+	}
+	if conf.SPVPrintLevel != 1 {
+		t.Error("Case insensitive field not decoded")
+	}
+	if !conf.SideNodeList[0].PowChain || conf.SideNodeList[1].PowChain {
+		t.Error("PowChain should be true only if it is not set")
+	}
+	if conf.SideNodeList[0].GenesisBlockAddress == "" {
+		t.Error("Genesis block address not set")
+	}
+}
+
+func TestParseConfig_Errors(t *testing.T) {
+	_, err := parseConfig([]byte(`{"Configuration": {
+		"ActiveNet": "testnet",
+		"NodePort": 70000,
+		"HttpJsonPort": "20606",
+		"Foo": 1,
+		"ClearTransactionInterval": "5ms",
+		"SideChainMonitorScanInterval": "abc",
+		"CRCCrossChainArbiters": ["zz"],
+		"MainNode": {"Rpc": {"IpAddress": "127.0.0.1", "HttpJsonPort": 0}, "FoundationAddress": "bad"},
+		"SideNodeList": [{"Rpc": {"IpAddress": "127.0.0.1", "HttpJsonPort": 20606},
+			"ExchangeRate": 0, "GenesisBlock": "abcd", "MiningAddr": "Exx", "Extra": true}],
+		"RpcConfiguration": {"Users": [{"User": "a", "Pass": "b", "Role": "root"}]},
+		"PasswordSource": "env:ARBITER_NODEPORT",
+		"Signer": {"Timeout": "5s"},
+		"Log": {"Format": "xml", "Levels": {"CS": 0, "Store": 6, "P2P": 1}},
+		"Debug": {"ListenAddress": "localhost"}
+	}}`))
+	errs, ok := err.(ConfigErrors)
+	if !ok {
+		t.Fatal("Parse config should fail with ConfigErrors:", err)
+	}
+
+	expected := []string{
+		"Configuration.CRCCrossChainArbiters[0]",
+		"Configuration.ClearTransactionInterval",
+		"Configuration.Debug.ListenAddress",
+		"Configuration.Foo",
+		"Configuration.HttpJsonPort",
+		"Configuration.Log.Format",
+		"Configuration.Log.Levels.P2P",
+		"Configuration.Log.Levels.Store",
+		"Configuration.MainNode.FoundationAddress",
+		"Configuration.MainNode.Rpc.HttpJsonPort",
+		"Configuration.NodePort",
+		"Configuration.PasswordSource",
+		"Configuration.RpcConfiguration.Users[0].Role",
+		"Configuration.SideChainMonitorScanInterval",
+		"Configuration.SideNodeList[0].ExchangeRate",
+		"Configuration.SideNodeList[0].Extra",
+		"Configuration.SideNodeList[0].GenesisBlock",
+		"Configuration.SideNodeList[0].MiningAddr",
+		"Configuration.Signer.Timeout",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+	for i, path := range expected {
+		if errs[i].Path != path {
+			t.Errorf("Expected error of %s, got %v", path, errs[i])
+		}
+	}
+}
+
+func TestParseConfig_SyntaxError(t *testing.T) {
+	_, err := parseConfig([]byte("{\n  \"Configuration\": {\n    \"A\" 1}}"))
+	if err == nil || err.Error() != "Config file syntax error at line 3 column 9: invalid character '1' after object key" {
+		t.Error("Unexpected syntax error:", err)
+	}
+}
+
+func TestParseConfig_Samples(t *testing.T) {
+	for _, sample := range []string{"../config.json.sample", "../docs/mainnet_config.json.sample"} {
+		file, err := ioutil.ReadFile(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parseConfig(bytes.TrimPrefix(file, []byte("\xef\xbb\xbf"))); err != nil {
+			t.Errorf("Sample %s is invalid: %v", sample, err)
+		}
+	}
+}
+
+func TestParseConfig_DebugRequiresAdmin(t *testing.T) {
+	cases := []struct {
+		rpc   string
+		valid bool
+	}{
+		{`{}`, false},
+		{`{"WhiteIPList": ["0.0.0.0"]}`, false},
+		{`{"Users": [{"User": "a", "Pass": "b", "Role": "operator"}]}`, false},
+		{`{"Users": [{"User": "a", "Pass": "b", "Role": "Admin"}]}`, true},
+		{`{"User": "a", "Pass": "b"}`, true},
+	}
+	for _, c := range cases {
+		conf := strings.Replace(validTestConfig, `"ActiveNet": "testnet",`,
+			`"ActiveNet": "testnet", "Debug": {"ListenAddress": "127.0.0.1:20539"}, "RpcConfiguration": `+c.rpc+`,`, 1)
+		_, err := parseConfig([]byte(conf))
+		if c.valid && err != nil {
+			t.Errorf("Debug listener with %s should be valid: %v", c.rpc, err)
+		}
+		if errs, ok := err.(ConfigErrors); !c.valid && (!ok || len(errs) != 1 ||
+			errs[0].Path != "Configuration.Debug.ListenAddress") {
+			t.Errorf("Debug listener with %s should require an admin user, got %v", c.rpc, err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/urfave/cli"
+)
+
+// redacted replaces the secrets printed by config show.
+const redacted = "******"
+
+// secretKeys are the keys of the config values redacted by config show.
+var secretKeys = map[string]struct{}{
+	"Pass": {},
+}
+
+func newConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Validate and show the configuration",
+		Subcommands: []cli.Command{
+			{
+				Name:  "validate",
+				Usage: "Check the config file and the flags, the node is not started",
+				Action: func(c *cli.Context) error {
+					if err := setupConfig(c); err != nil {
+						return err
+					}
+					fmt.Println("Configuration is valid")
+					return nil
+				},
+			},
+			{
+				Name:  "show",
+				Usage: "Print the config file or the effective configuration with the secrets redacted",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "effective",
+						Usage: "print the configuration merged from the defaults, the file, the environment and the flags",
+					},
+				},
+				Action: showConfig,
+			},
+		},
+	}
+}
+
+func showConfig(c *cli.Context) error {
+	if err := setupConfig(c); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	if c.Bool("effective") {
+		data, err = json.Marshal(config.ConfigFile{ConfigFile: effectiveConfig()})
+	} else {
+		filename := configFile(c)
+		if filename == "" {
+			return errors.New("No config file, use --effective to show the configuration")
+		}
+		data, err = ioutil.ReadFile(filename)
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	}
+	if err != nil {
+		return err
+	}
+
+	var values interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(redactSecrets(values))
+}
+
+// effectiveConfig returns a copy of the loaded configuration with the genesis
+// blocks of the side nodes in the byte order of the config file, they are
+// reversed when loaded.
+func effectiveConfig() config.Configuration {
+	conf := *config.Parameters.Configuration
+	conf.SideNodeList = make([]*config.SideNodeConfig, 0, len(config.Parameters.SideNodeList))
+	for _, node := range config.Parameters.SideNodeList {
+		copied := *node
+		if genesis, err := common.HexStringToBytes(node.GenesisBlock); err == nil {
+			copied.GenesisBlock = common.BytesToHexString(common.BytesReverse(genesis))
+		}
+		conf.SideNodeList = append(conf.SideNodeList, &copied)
+	}
+	return conf
+}
+
+// redactSecrets replaces the values of secretKeys in the decoded JSON.
+func redactSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if _, ok := secretKeys[key]; ok {
+				if s, ok := item.(string); ok && s != "" {
+					v[key] = redacted
+				}
+				continue
+			}
+			v[key] = redactSecrets(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactSecrets(item)
+		}
+	}
+	return value
+}
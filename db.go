@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
+
+	"github.com/urfave/cli"
+)
+
+func newDBCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "db",
+		Usage: "Back up, restore and export the databases",
+		Before: func(c *cli.Context) error {
+			if err := setupConfig(c); err != nil {
+				return err
+			}
+			setupLog()
+			return checkSqliteBackend("db")
+		},
+		Subcommands: []cli.Command{
+			{
+				Name:  "backup",
+				Usage: "Copy consistent snapshots of the databases while the node runs",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "out",
+						Usage: "`<directory>` to write the backups into",
+						Value: "arbiter-backup-" + time.Now().Format("20060102-150405"),
+					},
+				},
+				Action: dbBackup,
+			},
+			{
+				Name:  "restore",
+				Usage: "Restore the databases from a backup, the node must be stopped",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "from",
+						Usage: "`<directory>` of the backups",
+					},
+				},
+				Action: dbRestore,
+			},
+			{
+				Name:  "export",
+				Usage: "Write the pending and finished cross-chain transactions as JSON or CSV",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "format",
+						Usage: "output `<format>`, json or csv",
+						Value: "json",
+					},
+					cli.StringFlag{
+						Name:  "out",
+						Usage: "`<file>` to write into, stdout if empty",
+					},
+				},
+				Action: dbExport,
+			},
+		},
+	}
+}
+
+func dbBackup(c *cli.Context) error {
+	backups, err := store.BackupAll(c.String("out"))
+	if err != nil {
+		return fmt.Errorf("Backup error: %v", err)
+	}
+	for _, backup := range backups {
+		fmt.Println("Backup", backup)
+	}
+	return nil
+}
+
+func dbRestore(c *cli.Context) error {
+	from := c.String("from")
+	if from == "" {
+		return cli.NewExitError("Restore error: -from is required", 2)
+	}
+	lock, err := store.LockDataDir()
+	if err == store.ErrDataDirLocked {
+		return fmt.Errorf("Restore error: the arbiter is running, %s is locked, stop it first", store.LockFileName)
+	}
+	if err != nil {
+		return fmt.Errorf("Restore error: %v", err)
+	}
+	defer lock.Release()
+
+	restored, err := store.RestoreAll(from)
+	for _, path := range restored {
+		fmt.Println("Restored", path)
+	}
+	if err != nil {
+		return fmt.Errorf("Restore error: %v", err)
+	}
+	return nil
+}
+
+func dbExport(c *cli.Context) error {
+	format := c.String("format")
+	if format != "json" && format != "csv" {
+		return cli.NewExitError("Export error: format should be json or csv", 2)
+	}
+
+	txs, err := exportTransactions()
+	if err != nil {
+		return fmt.Errorf("Export error: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if out := c.String("out"); out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			return fmt.Errorf("Export error: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if format == "json" {
+		err = writeTransactionsJSON(w, txs)
+	} else {
+		err = writeTransactionsCSV(w, txs)
+	}
+	if err != nil {
+		return fmt.Errorf("Export error: %v", err)
+	}
+	return nil
+}
+
+// exportTransactions reads the transactions from the snapshots of the
+// databases, so they are consistent while the node runs.
+func exportTransactions() ([]*store.ExportedTx, error) {
+	dir, err := ioutil.TempDir("", "arbiter-export")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err = store.BackupAll(dir); err != nil {
+		return nil, err
+	}
+	return store.ExportTransactions(filepath.Join(dir, filepath.Base(store.DBNameMainChain)),
+		filepath.Join(dir, filepath.Base(store.DBNameSideChain)),
+		filepath.Join(dir, filepath.Base(store.FinishedTxsDBName)))
+}
+
+func writeTransactionsJSON(w io.Writer, txs []*store.ExportedTx) error {
+	if txs == nil {
+		txs = make([]*store.ExportedTx, 0)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(txs)
+}
+
+func writeTransactionsCSV(w io.Writer, txs []*store.ExportedTx) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"Type", "State", "TransactionHash", "GenesisBlockAddress", "BlockHeight", "RecordTime"})
+	for _, tx := range txs {
+		height := ""
+		if tx.BlockHeight != 0 {
+			height = strconv.FormatUint(uint64(tx.BlockHeight), 10)
+		}
+		writer.Write([]string{tx.Type, tx.State, tx.TransactionHash, tx.GenesisBlockAddress, height, tx.RecordTime})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// checkSqliteBackend returns an error if the storage backend is not sqlite,
+// the database commands only work on the sqlite files.
+func checkSqliteBackend(command string) error {
+	backend := config.Parameters.StorageBackend
+	if backend == "" || backend == store.BackendSqlite {
+		return nil
+	}
+	return fmt.Errorf("The %s command only supports the %s storage backend, not %s",
+		command, store.BackendSqlite, backend)
+}
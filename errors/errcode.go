@@ -6,9 +6,17 @@ const (
 	Error                   ErrCode = -1
 	Success                 ErrCode = 0
 
+	// error codes defined by the JSON-RPC 2.0 specification
+	ParseError              ErrCode = -32700
+	InvalidRequest          ErrCode = -32600
+	MethodNotFound          ErrCode = -32601
+	RPCInvalidParams        ErrCode = -32602
+	RPCInternalError        ErrCode = -32603
+
 	InvalidMethod           ErrCode = 42001
 	InvalidParams           ErrCode = 42002
 	InvalidToken            ErrCode = 42003
+	PermissionDenied        ErrCode = 42004
 	InvalidTransaction      ErrCode = 43001
 	UnknownTransaction      ErrCode = 44001
 	UnknownBlock            ErrCode = 44003
@@ -18,9 +26,15 @@ const (
 var ErrMap = map[ErrCode]string{
 	Error:                   "Unclassified error",
 	Success:                 "Success",
+	ParseError:              "Parse error",
+	InvalidRequest:          "Invalid request",
+	MethodNotFound:          "Method not found",
+	RPCInvalidParams:        "Invalid params",
+	RPCInternalError:        "Internal error",
 	InvalidMethod:           "Invalid method",
 	InvalidParams:           "Invalid Params",
 	InvalidToken:            "Verify token error",
+	PermissionDenied:        "Permission denied",
 	InvalidTransaction:      "Invalid transaction",
 	UnknownTransaction:      "Unknown Transaction",
 	UnknownBlock:            "Unknown Block",
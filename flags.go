@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+
+	"github.com/urfave/cli"
+)
+
+var (
+	configFileFlag = cli.StringFlag{
+		Name:   "conf",
+		Usage:  "config `<file>` path",
+		Value:  config.DefaultConfigFilename,
+		EnvVar: config.EnvConfigFile,
+	}
+	setFlag = cli.StringSliceFlag{
+		Name:  "set",
+		Usage: "override the config value at `<path=value>` like SideNodeList.0.Rpc.HttpJsonPort=20606",
+	}
+	accountPasswordFlag = cli.StringFlag{
+		Name:  "password, p",
+		Usage: "wallet password, it is visible in the process list, use --password-source instead",
+	}
+	passwordSourceFlag = cli.StringFlag{
+		Name: "password-source",
+		Usage: "`<source>` of the wallet password: prompt, file:<path>, env[:<name>], fd:<number> or cmd:<command>, " +
+			"PasswordSource of the config if not given",
+	}
+
+	// Flags overriding the values of the config file.
+	magicFlag = cli.UintFlag{
+		Name:  "magic",
+		Usage: "magic `<number>` of the arbiter network",
+	}
+	nodePortFlag = cli.UintFlag{
+		Name:  "nodeport",
+		Usage: "arbiter P2P listening port `<number>`",
+	}
+	rpcPortFlag = cli.UintFlag{
+		Name:  "rpcport",
+		Usage: "JSON-RPC server listening port `<number>`",
+	}
+	restPortFlag = cli.UintFlag{
+		Name:  "restport",
+		Usage: "RESTful server listening port `<number>`, 0 disables it",
+	}
+	printLevelFlag = cli.UintFlag{
+		Name:  "printlevel",
+		Usage: "log `<level>` of the arbiter, 0 is debug and 4 is fatal",
+	}
+	spvPrintLevelFlag = cli.UintFlag{
+		Name:  "spvprintlevel",
+		Usage: "log `<level>` of the spv module",
+	}
+	storageBackendFlag = cli.StringFlag{
+		Name:  "storage",
+		Usage: "storage `<backend>` of the caches, sqlite or leveldb",
+	}
+
+	// RPC flags of the commands querying a running node.
+	rpcURLFlag = cli.StringFlag{
+		Name:  "rpcurl",
+		Usage: "JSON-RPC `<url>` of the node, from the config file if not given",
+	}
+	rpcUserFlag = cli.StringFlag{
+		Name:  "rpcuser",
+		Usage: "username for JSON-RPC connections",
+	}
+	rpcPasswordFlag = cli.StringFlag{
+		Name:  "rpcpassword",
+		Usage: "password for JSON-RPC connections",
+	}
+	rpcInsecureFlag = cli.BoolFlag{
+		Name:  "insecure",
+		Usage: "skip verifying the TLS certificate of the node",
+	}
+)
+
+// configFlags are the flags overriding the values of the config file, they
+// are accepted both before and after the run command.
+var configFlags = []cli.Flag{
+	configFileFlag,
+	setFlag,
+	magicFlag,
+	nodePortFlag,
+	rpcPortFlag,
+	restPortFlag,
+	printLevelFlag,
+	spvPrintLevelFlag,
+	storageBackendFlag,
+}
+
+// flagContext returns the context of the command or its parents which the
+// flag is set in, nil if it is not set.
+func flagContext(c *cli.Context, name string) *cli.Context {
+	for ; c != nil; c = c.Parent() {
+		if c.IsSet(name) {
+			return c
+		}
+	}
+	return nil
+}
+
+func flagString(c *cli.Context, name string) string {
+	if set := flagContext(c, name); set != nil {
+		return set.String(name)
+	}
+	return ""
+}
+
+// overrideFlags are the flags overriding single config values, they are
+// applied after the --set flags.
+var overrideFlags = []struct {
+	flag cli.Flag
+	path string
+}{
+	{magicFlag, "Magic"},
+	{nodePortFlag, "NodePort"},
+	{rpcPortFlag, "HttpJsonPort"},
+	{restPortFlag, "HttpRestPort"},
+	{printLevelFlag, "PrintLevel"},
+	{spvPrintLevelFlag, "SPVPrintLevel"},
+	{storageBackendFlag, "StorageBackend"},
+}
+
+// setupConfig loads the configuration in layers: the defaults of the active
+// net, the config file, the ARBITER_* environment variables, then the flags.
+// The default config file is optional, so the configuration can be given by
+// the environment variables only.
+func setupConfig(c *cli.Context) error {
+	overrides := config.EnvOverrides(os.Environ())
+	if set := flagContext(c, setFlag.Name); set != nil {
+		for _, value := range set.StringSlice(setFlag.Name) {
+			o, ok := config.ParseOverride("--"+setFlag.Name, value)
+			if !ok {
+				return fmt.Errorf("--%s %s should be like Path=value", setFlag.Name, value)
+			}
+			overrides = append(overrides, o)
+		}
+	}
+	for _, f := range overrideFlags {
+		name := f.flag.GetName()
+		set := flagContext(c, name)
+		if set == nil {
+			continue
+		}
+		overrides = append(overrides, config.Override{
+			Name:  "--" + name,
+			Path:  []string{f.path},
+			Value: set.String(name),
+		})
+	}
+	return config.Load(configFile(c), overrides...)
+}
+
+// configFile returns the config file given by --conf, or the default one if
+// it exists.
+func configFile(c *cli.Context) string {
+	if set := flagContext(c, configFileFlag.Name); set != nil {
+		return set.String(configFileFlag.Name)
+	}
+	if _, err := os.Stat(config.DefaultConfigFilename); os.IsNotExist(err) {
+		return ""
+	}
+	return config.DefaultConfigFilename
+}
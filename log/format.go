@@ -0,0 +1,120 @@
+package log
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// missingValue is the value of a key given without a value.
+const missingValue = "(MISSING)"
+
+// writeText writes a line like
+// 2020/09/08 10:00:00.000000 [INF] GID 12, cs: message tx=... peer=...
+func writeText(buf *bytes.Buffer, now time.Time, level uint8, gid uint64,
+	module, msg string, fields []interface{}) {
+	buf.WriteString(now.Format("2006/01/02 15:04:05.000000 "))
+	buf.WriteString(levelName(level))
+	buf.WriteString(" GID ")
+	buf.WriteString(strconv.FormatUint(gid, 10))
+	buf.WriteString(", ")
+	if module != "" {
+		buf.WriteString(module)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(msg)
+	for i := 0; i+1 < len(fields); i += 2 {
+		buf.WriteByte(' ')
+		buf.WriteString(fieldKey(fields[i]))
+		buf.WriteByte('=')
+		buf.WriteString(textValue(fieldValue(fields[i+1])))
+	}
+	buf.WriteByte('\n')
+}
+
+// writeJSON writes a JSON object like
+// {"time":"...","level":"info","gid":12,"module":"cs","msg":"message","tx":"..."}
+func writeJSON(buf *bytes.Buffer, now time.Time, level uint8, gid uint64,
+	module, msg string, fields []interface{}) {
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, now.Format("2006-01-02T15:04:05.000000Z07:00"))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, jsonLevelName(level))
+	buf.WriteString(`,"gid":`)
+	buf.WriteString(strconv.FormatUint(gid, 10))
+	if module != "" {
+		buf.WriteString(`,"module":`)
+		writeJSONValue(buf, module)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, msg)
+	for i := 0; i+1 < len(fields); i += 2 {
+		buf.WriteByte(',')
+		writeJSONValue(buf, fieldKey(fields[i]))
+		buf.WriteByte(':')
+		writeJSONValue(buf, fieldValue(fields[i+1]))
+	}
+	buf.WriteString("}\n")
+}
+
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(data)
+}
+
+func fieldKey(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// fieldValue converts the hashes and the other types printed by their String
+// methods, like common.Uint256, and the errors to strings, byte slices to
+// hex strings.
+func fieldValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case []byte:
+		return hex.EncodeToString(v)
+	}
+	return value
+}
+
+// textValue quotes the value if it is empty or has spaces, quotes, equal
+// signs or control characters.
+func textValue(value interface{}) string {
+	s, ok := value.(string)
+	if !ok {
+		s = fmt.Sprint(value)
+		if value == nil {
+			return s
+		}
+	}
+	if s == "" || strings.IndexFunc(s, needsQuote) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needsQuote(r rune) bool {
+	return r == '"' || r == '=' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+}
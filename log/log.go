@@ -2,13 +2,17 @@ package log
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/elastos/Elastos.ELA/utils/elalog"
 )
@@ -43,9 +47,51 @@ var (
 		fatalLog:   Color(Red, "[FAT]"),
 		disableLog: "DISABLED",
 	}
+	// jsonLevels are the levels in the JSON lines.
+	jsonLevels = []string{
+		debugLog:   "debug",
+		infoLog:    "info",
+		warnLog:    "warn",
+		errorLog:   "error",
+		fatalLog:   "fatal",
+		disableLog: "disabled",
+	}
 	Stdout = os.Stdout
 )
 
+// Formats of the log lines.
+const (
+	// FormatText writes a line like
+	// 2020/09/08 10:00:00.000000 [INF] GID 12, cs: message tx=... peer=...
+	FormatText = "text"
+
+	// FormatJSON writes a JSON object a line with the fields as keys.
+	FormatJSON = "json"
+)
+
+// Keys of the fields, the log lines of a cross-chain transaction are
+// correlated by them.
+const (
+	// KeyTx is the hash of a main chain or side chain transaction.
+	KeyTx = "tx"
+
+	// KeySideChain is the genesis block address of a side chain.
+	KeySideChain = "sidechain"
+
+	// KeyHeight is a block height.
+	KeyHeight = "height"
+
+	// KeyProposal is the hash of a withdraw transaction proposed to be
+	// signed by the arbiters, which is sent to the main chain once signed.
+	KeyProposal = "proposal"
+
+	// KeyPeer is the public key or the address of a peer.
+	KeyPeer = "peer"
+
+	// KeyError is an error.
+	KeyError = "err"
+)
+
 const (
 	calldepth             = 2
 	KBSize                = int64(1024)
@@ -53,6 +99,10 @@ const (
 	GBSize                = MBSize * 1024
 	defaultPerLogFileSize = 20 * MBSize
 	defaultLogsFolderSize = 5 * GBSize
+
+	// followRoot is the level of a module logger following the level of the
+	// root logger.
+	followRoot = -1
 )
 
 func GetGID() uint64 {
@@ -64,6 +114,8 @@ func GetGID() uint64 {
 	return n
 }
 
+// logger is the root logger initialized by Init, the module loggers write to
+// it.
 var logger *Logger
 
 func levelName(level uint8) string {
@@ -73,9 +125,28 @@ func levelName(level uint8) string {
 	return levels[int(level)]
 }
 
+func jsonLevelName(level uint8) string {
+	if int(level) >= len(jsonLevels) {
+		return fmt.Sprintf("level%d", level)
+	}
+	return jsonLevels[int(level)]
+}
+
+// output writes the lines of a root logger and the loggers derived from it.
+type output struct {
+	mux    sync.Mutex
+	writer io.Writer
+	format string
+}
+
+// Logger writes the log lines of the levels not lower than its print level.
+// A module logger returned by Module writes to the root logger initialized by
+// Init, and follows its level until SetPrintLevel is called.
 type Logger struct {
-	level  uint8 // The log print level
-	logger *log.Logger
+	level  *int32 // The log print level, shared by the loggers of With
+	module string
+	fields []interface{}
+	out    *output // nil for the module loggers
 }
 
 func NewLogger(outputPath string, level uint8, maxPerLogSizeMb, maxLogsSizeMb int64) *Logger {
@@ -91,10 +162,14 @@ func NewLogger(outputPath string, level uint8, maxPerLogSizeMb, maxLogsSizeMb in
 
 	writer := elalog.NewFileWriter(outputPath, perLogFileSize, logsFolderSize)
 
+	return newLogger(io.MultiWriter(os.Stdout, writer), level)
+}
+
+func newLogger(writer io.Writer, level uint8) *Logger {
+	l := int32(level)
 	return &Logger{
-		level: level,
-		logger: log.New(io.MultiWriter(os.Stdout, writer), "",
-			log.Ldate|log.Lmicroseconds),
+		level: &l,
+		out:   &output{writer: writer, format: FormatText},
 	}
 }
 
@@ -102,97 +177,214 @@ func Init(outputPath string, level uint8, maxPerLogSizeMb, maxLogsSizeMb int64)
 	logger = NewLogger(outputPath, level, maxPerLogSizeMb, maxLogsSizeMb)
 }
 
+// SetFormat sets the format of the lines of the logger and the loggers
+// derived from it, FormatText or FormatJSON.
+func (l *Logger) SetFormat(format string) error {
+	out := l.output()
+	if out == nil {
+		return errors.New("logger is not initialized")
+	}
+	switch format {
+	case FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("unknown log format %q, should be %s or %s", format, FormatText, FormatJSON)
+	}
+	out.mux.Lock()
+	out.format = format
+	out.mux.Unlock()
+	return nil
+}
+
 func (l *Logger) SetPrintLevel(level uint8) {
-	l.level = level
+	atomic.StoreInt32(l.level, int32(level))
 }
 
-func (l *Logger) Output(level uint8, a ...interface{}) {
-	if l.level <= level {
-		gidStr := strconv.FormatUint(GetGID(), 10)
-		a = append([]interface{}{levelName(level), "GID", gidStr + ","}, a...)
-		l.logger.Output(calldepth, fmt.Sprintln(a...))
+// PrintLevel returns the print level of the logger, the level of the root
+// logger if it follows it.
+func (l *Logger) PrintLevel() uint8 {
+	if l == nil {
+		return disableLog
+	}
+	level := atomic.LoadInt32(l.level)
+	if level == followRoot {
+		if logger == nil {
+			return disableLog
+		}
+		return logger.PrintLevel()
 	}
+	return uint8(level)
 }
 
-func (l *Logger) Outputf(level uint8, format string, v ...interface{}) {
-	if l.level <= level {
-		v = append([]interface{}{levelName(level), "GID", GetGID()}, v...)
-		l.logger.Output(calldepth, fmt.Sprintf("%s %s %d, "+format+"\n", v...))
+// With returns a logger adding the key/value pairs to the fields of the
+// lines. It shares the print level of l.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	if l == nil {
+		return nil
+	}
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals)+1)
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+	if len(keyvals)%2 != 0 {
+		fields = append(fields, missingValue)
 	}
+	return &Logger{level: l.level, module: l.module, fields: fields, out: l.out}
 }
 
-func (l *Logger) Debug(a ...interface{}) {
-	if l.level > debugLog {
-		return
+// output returns where the lines are written, nil if the root logger is not
+// initialized.
+func (l *Logger) output() *output {
+	if l == nil {
+		return nil
+	}
+	if l.out != nil {
+		return l.out
 	}
+	if root := logger; root != nil {
+		return root.out
+	}
+	return nil
+}
+
+func (l *Logger) enabled(level uint8) bool {
+	return l != nil && level >= l.PrintLevel()
+}
 
-	pc, file, line, ok := runtime.Caller(calldepth)
-	if !ok {
+// write writes a line, depth is the number of the frames from write to the
+// caller logging it.
+func (l *Logger) write(depth int, level uint8, msg string, keyvals []interface{}) {
+	out := l.output()
+	if out == nil {
 		return
 	}
 
-	fn := runtime.FuncForPC(pc)
-	a = append([]interface{}{fn.Name(), filepath.Base(file) + ":" + strconv.Itoa(line)}, a...)
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals)+5)
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+	if len(keyvals)%2 != 0 {
+		fields = append(fields, missingValue)
+	}
+	if level == debugLog {
+		if pc, file, line, ok := runtime.Caller(depth); ok {
+			fields = append(fields, "func", runtime.FuncForPC(pc).Name(),
+				"caller", filepath.Base(file)+":"+strconv.Itoa(line))
+		}
+	}
+
+	now := time.Now()
+	gid := GetGID()
+	out.mux.Lock()
+	defer out.mux.Unlock()
 
-	l.Output(debugLog, a...)
+	var buf bytes.Buffer
+	if out.format == FormatJSON {
+		writeJSON(&buf, now, level, gid, l.module, msg, fields)
+	} else {
+		writeText(&buf, now, level, gid, l.module, msg, fields)
+	}
+	out.writer.Write(buf.Bytes())
 }
 
-func (l *Logger) Debugf(format string, a ...interface{}) {
-	if l.level > debugLog {
-		return
+func (l *Logger) print(level uint8, a []interface{}) {
+	if l.enabled(level) {
+		l.write(calldepth+1, level, strings.TrimSuffix(fmt.Sprintln(a...), "\n"), nil)
 	}
+}
 
-	pc, file, line, ok := runtime.Caller(calldepth)
-	if !ok {
-		return
+func (l *Logger) printf(level uint8, format string, a []interface{}) {
+	if l.enabled(level) {
+		l.write(calldepth+1, level, fmt.Sprintf(format, a...), nil)
+	}
+}
+
+func (l *Logger) printw(level uint8, msg string, keyvals []interface{}) {
+	if l.enabled(level) {
+		l.write(calldepth+1, level, msg, keyvals)
 	}
+}
+
+func (l *Logger) Output(level uint8, a ...interface{}) {
+	l.print(level, a)
+}
+
+func (l *Logger) Outputf(level uint8, format string, v ...interface{}) {
+	l.printf(level, format, v)
+}
 
-	fn := runtime.FuncForPC(pc)
-	a = append([]interface{}{fn.Name(), filepath.Base(file), line}, a...)
+func (l *Logger) Debug(a ...interface{}) {
+	l.print(debugLog, a)
+}
+
+func (l *Logger) Debugf(format string, a ...interface{}) {
+	l.printf(debugLog, format, a)
+}
 
-	l.Outputf(debugLog, format, a...)
+// Debugw writes the message with the key/value pairs as fields.
+func (l *Logger) Debugw(msg string, keyvals ...interface{}) {
+	l.printw(debugLog, msg, keyvals)
 }
 
 func (l *Logger) Info(a ...interface{}) {
-	l.Output(infoLog, a...)
+	l.print(infoLog, a)
 }
 
 func (l *Logger) Infof(format string, a ...interface{}) {
-	l.Outputf(infoLog, format, a...)
+	l.printf(infoLog, format, a)
+}
+
+// Infow writes the message with the key/value pairs as fields.
+func (l *Logger) Infow(msg string, keyvals ...interface{}) {
+	l.printw(infoLog, msg, keyvals)
 }
 
 func (l *Logger) Warn(a ...interface{}) {
-	l.Output(warnLog, a...)
+	l.print(warnLog, a)
 }
 
 func (l *Logger) Warnf(format string, a ...interface{}) {
-	l.Outputf(warnLog, format, a...)
+	l.printf(warnLog, format, a)
+}
+
+// Warnw writes the message with the key/value pairs as fields.
+func (l *Logger) Warnw(msg string, keyvals ...interface{}) {
+	l.printw(warnLog, msg, keyvals)
 }
 
 func (l *Logger) Error(a ...interface{}) {
-	if l.level <= errorLog {
-		l.Output(errorLog, a...)
-	}
+	l.print(errorLog, a)
 }
 
 func (l *Logger) Errorf(format string, a ...interface{}) {
-	l.Outputf(errorLog, format, a...)
+	l.printf(errorLog, format, a)
+}
+
+// Errorw writes the message with the key/value pairs as fields.
+func (l *Logger) Errorw(msg string, keyvals ...interface{}) {
+	l.printw(errorLog, msg, keyvals)
 }
 
 func (l *Logger) Fatal(a ...interface{}) {
-	l.Output(fatalLog, a...)
+	l.print(fatalLog, a)
 }
 
 func (l *Logger) Fatalf(format string, a ...interface{}) {
-	l.Outputf(fatalLog, format, a...)
+	l.printf(fatalLog, format, a)
+}
+
+// Fatalw writes the message with the key/value pairs as fields.
+func (l *Logger) Fatalw(msg string, keyvals ...interface{}) {
+	l.printw(fatalLog, msg, keyvals)
 }
 
 func Debug(a ...interface{}) {
-	logger.Debug(a...)
+	if logger.enabled(debugLog) {
+		logger.write(calldepth, debugLog, strings.TrimSuffix(fmt.Sprintln(a...), "\n"), nil)
+	}
 }
 
 func Debugf(format string, a ...interface{}) {
-	logger.Debugf("%s %s:%d "+format, a...)
+	if logger.enabled(debugLog) {
+		logger.write(calldepth, debugLog, fmt.Sprintf(format, a...), nil)
+	}
 }
 
 func Info(a ...interface{}) {
@@ -230,3 +422,9 @@ func Fatalf(format string, a ...interface{}) {
 func SetPrintLevel(level uint8) {
 	logger.SetPrintLevel(level)
 }
+
+// SetFormat sets the format of the lines of the root logger and the module
+// loggers.
+func SetFormat(format string) error {
+	return logger.SetFormat(format)
+}
@@ -0,0 +1,197 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type hash [2]byte
+
+func (h hash) String() string {
+	return "0a0b"
+}
+
+func TestLogger_Text(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger(&buf, infoLog)
+
+	l.With(KeySideChain, "XKUh4GLhFJ").Infow("withdraw sent", KeyTx, hash{}, "result", "a b", KeyHeight, 10)
+	line := buf.String()
+	if !strings.Contains(line, Color(Pink, "[INF]")+" GID ") ||
+		!strings.HasSuffix(line, ", withdraw sent sidechain=XKUh4GLhFJ tx=0a0b result=\"a b\" height=10\n") {
+		t.Errorf("unexpected line %q", line)
+	}
+
+	buf.Reset()
+	l.Debug("not written")
+	l.Warnw("odd", KeyError)
+	if line := buf.String(); !strings.HasSuffix(line, ", odd err="+missingValue+"\n") {
+		t.Errorf("unexpected line %q", line)
+	}
+}
+
+func TestLogger_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	root := newLogger(&buf, debugLog)
+	if err := root.SetFormat("xml"); err == nil {
+		t.Error("unknown format is set")
+	}
+	if err := root.SetFormat(FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	defer func(l *Logger) { logger = l }(logger)
+	logger = root
+
+	cs := Module("test-json")
+	cs.Debugw("signature received", KeyPeer, []byte{2, 0xaa}, KeyError, errors.New("failed"))
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", buf.String(), err)
+	}
+	expected := map[string]interface{}{
+		"level":  "debug",
+		"module": "test-json",
+		"msg":    "signature received",
+		KeyPeer:  "02aa",
+		KeyError: "failed",
+	}
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("%s is %v, expected %v", key, line[key], value)
+		}
+	}
+	if caller, _ := line["caller"].(string); !strings.HasPrefix(caller, "log_test.go:") {
+		t.Errorf("caller is %q", caller)
+	}
+}
+
+func TestModule_Level(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l *Logger) { logger = l }(logger)
+	logger = nil
+
+	m := Module("test-level")
+	m.Info("dropped before the root logger is initialized")
+	if Module("test-level") != m {
+		t.Error("module logger is not reused")
+	}
+
+	logger = newLogger(&buf, warnLog)
+	m.Info("below the root level")
+	if buf.Len() != 0 {
+		t.Errorf("unexpected line %q", buf.String())
+	}
+	if err := SetModuleLevel("test-level", debugLog); err != nil {
+		t.Fatal(err)
+	}
+	m.Info("module level")
+	Info("below the root level")
+	if line := buf.String(); strings.Count(line, "\n") != 1 || !strings.Contains(line, ", test-level: module level") {
+		t.Errorf("unexpected lines %q", line)
+	}
+	if err := SetModuleLevel("unknown", debugLog); err == nil {
+		t.Error("level of unknown module is set")
+	}
+}
+
+type testLeveler struct {
+	level uint8
+}
+
+func (l *testLeveler) PrintLevel() uint8 {
+	return l.level
+}
+
+func (l *testLeveler) SetPrintLevel(level uint8) {
+	l.level = level
+}
+
+func TestModule_Register(t *testing.T) {
+	logger = newLogger(new(bytes.Buffer), infoLog)
+	defer func() { logger = nil }()
+
+	spv := &testLeveler{level: warnLog}
+	RegisterModule("test-spv", spv)
+	Module("test-follow")
+	if err := SetModuleLevel("test-spv", errorLog); err != nil || spv.level != errorLog {
+		t.Errorf("unexpected level %d, err %v", spv.level, err)
+	}
+	if err := SetModuleLevel("test-spv", disableLog+1); err == nil {
+		t.Error("invalid level is set")
+	}
+
+	if err := SetModuleLevel(ModuleRoot, warnLog); err != nil {
+		t.Fatal(err)
+	}
+	if level, err := ModuleLevel("test-follow"); err != nil || level != warnLog {
+		t.Errorf("expected the root level %d, got %d, err %v", warnLog, level, err)
+	}
+	if _, err := ModuleLevel("unknown"); err == nil {
+		t.Error("level of unknown module is returned")
+	}
+
+	names := strings.Join(Modules(), ",")
+	if !strings.Contains(names, ModuleRoot) || !strings.Contains(names, "test-spv") {
+		t.Errorf("unexpected modules %s", names)
+	}
+}
+
+func TestModule_Inherit(t *testing.T) {
+	logger = newLogger(new(bytes.Buffer), infoLog)
+	defer func() { logger = nil }()
+
+	RegisterModule("test-inherit-spv", &testLeveler{level: warnLog})
+	Module("test-inherit")
+	if err := SetModuleLevel("test-inherit", errorLog); err != nil {
+		t.Fatal(err)
+	}
+	logger.SetPrintLevel(debugLog)
+	if level, _ := ModuleLevel("test-inherit"); level != errorLog {
+		t.Errorf("expected the level set %d, got %d", errorLog, level)
+	}
+
+	if err := InheritModuleLevel("test-inherit"); err != nil {
+		t.Fatal(err)
+	}
+	if level, _ := ModuleLevel("test-inherit"); level != debugLog {
+		t.Errorf("expected the root level %d, got %d", debugLog, level)
+	}
+	logger.SetPrintLevel(warnLog)
+	if level, _ := ModuleLevel("test-inherit"); level != warnLog {
+		t.Errorf("expected the root level %d after it changed, got %d", warnLog, level)
+	}
+
+	for _, name := range []string{ModuleRoot, "test-inherit-spv", "unknown"} {
+		if err := InheritModuleLevel(name); err == nil {
+			t.Errorf("%s should not inherit a level", name)
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	var buf bytes.Buffer
+	logger = newLogger(&buf, infoLog)
+	defer func() { logger = nil }()
+
+	if err := SetModuleLevel(ModuleRoot, disableLog); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetModuleLevel(ModuleAudit, disableLog); err == nil {
+		t.Error("level of the audit logger should not be set")
+	}
+	if strings.Contains(strings.Join(Modules(), ","), ModuleAudit) {
+		t.Error("audit logger should not be listed")
+	}
+	Audit().Infow("rpc call", "method", "setloglevel")
+	if line := buf.String(); !strings.HasSuffix(line, ", audit: rpc call method=setloglevel\n") {
+		t.Errorf("audit line should be written whatever the root level, got %q", line)
+	}
+	Audit().Debug("not written")
+	if strings.Contains(buf.String(), "not written") {
+		t.Error("audit logger should not write debug lines")
+	}
+}
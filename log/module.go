@@ -0,0 +1,152 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Names of the module loggers of the packages.
+const (
+	ModuleArbitrator = "arbitrator"
+	ModuleCS         = "cs"
+	ModuleMainChain  = "mainchain"
+	ModuleSideChain  = "sidechain"
+	ModuleSideAuxPow = "sideauxpow"
+	ModuleStore      = "store"
+	ModuleRpc        = "rpc"
+	ModuleServers    = "servers"
+	ModuleSigner     = "signer"
+	// ModuleSPV is the logger of the spv module, it is registered by the
+	// arbiter with RegisterModule.
+	ModuleSPV = "spv"
+	// ModuleRoot is the root logger, the module loggers follow its level
+	// until their levels are set.
+	ModuleRoot = "root"
+	// ModuleAudit is the logger of the admin actions, it is not listed by
+	// Modules and its level can not be changed.
+	ModuleAudit = "audit"
+)
+
+// LevelInherit stands for the level of a module logger following the root
+// logger, like the level -1 of the setloglevel JSON-RPC method.
+const LevelInherit = followRoot
+
+// Leveler is a logger whose print level can be changed at runtime.
+type Leveler interface {
+	PrintLevel() uint8
+	SetPrintLevel(level uint8)
+}
+
+var (
+	modulesMux sync.Mutex
+	modules    = make(map[string]Leveler)
+
+	auditLevel  = int32(infoLog)
+	auditLogger = &Logger{level: &auditLevel, module: ModuleAudit}
+)
+
+// Audit returns the logger of the admin actions. It writes to the root
+// logger at the info level whatever the levels of the root logger and the
+// modules are.
+func Audit() *Logger {
+	return auditLogger
+}
+
+// Module returns the logger of the module, the lines are tagged by the name
+// and written to the root logger. It follows the print level of the root
+// logger until SetModuleLevel is called.
+func Module(name string) *Logger {
+	modulesMux.Lock()
+	defer modulesMux.Unlock()
+
+	if l, ok := modules[name].(*Logger); ok {
+		return l
+	}
+	level := int32(followRoot)
+	l := &Logger{level: &level, module: name}
+	modules[name] = l
+	return l
+}
+
+// RegisterModule adds a logger not created by Module, such as the logger of
+// the spv module, so its level can be changed by SetModuleLevel.
+func RegisterModule(name string, l Leveler) {
+	modulesMux.Lock()
+	modules[name] = l
+	modulesMux.Unlock()
+}
+
+func moduleLeveler(name string) (Leveler, error) {
+	if name == ModuleRoot {
+		if logger == nil {
+			return nil, fmt.Errorf("log is not initialized")
+		}
+		return logger, nil
+	}
+	modulesMux.Lock()
+	l, ok := modules[name]
+	modulesMux.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown log module %q", name)
+	}
+	return l, nil
+}
+
+// ModuleLevel returns the print level of the module logger, the level of
+// the root logger if the module follows it.
+func ModuleLevel(name string) (uint8, error) {
+	l, err := moduleLeveler(name)
+	if err != nil {
+		return 0, err
+	}
+	return l.PrintLevel(), nil
+}
+
+// SetModuleLevel sets the print level of the module logger, levels above
+// the fatal level disable the logger.
+func SetModuleLevel(name string, level uint8) error {
+	if level > disableLog {
+		return fmt.Errorf("log level should be between 0 and %d", disableLog)
+	}
+	l, err := moduleLeveler(name)
+	if err != nil {
+		return err
+	}
+	l.SetPrintLevel(level)
+	return nil
+}
+
+// InheritModuleLevel makes the module logger follow the level of the root
+// logger again after SetModuleLevel.
+func InheritModuleLevel(name string) error {
+	if name == ModuleRoot {
+		return fmt.Errorf("%s logger can not inherit a level", ModuleRoot)
+	}
+	l, err := moduleLeveler(name)
+	if err != nil {
+		return err
+	}
+	ml, ok := l.(*Logger)
+	if !ok {
+		return fmt.Errorf("log module %q can not follow the %s logger", name, ModuleRoot)
+	}
+	atomic.StoreInt32(ml.level, followRoot)
+	return nil
+}
+
+// Modules returns the names of the module loggers and the root logger in
+// order.
+func Modules() []string {
+	modulesMux.Lock()
+	defer modulesMux.Unlock()
+
+	names := make([]string, 0, len(modules)+1)
+	names = append(names, ModuleRoot)
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
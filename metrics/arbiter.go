@@ -0,0 +1,70 @@
+package metrics
+
+// Metrics of the arbiter, side chains are labeled by their genesis block
+// addresses.
+var (
+	DepositsSeen = NewCounterVec("arbiter_deposits_seen_total",
+		"Deposit transactions going to be sent to side chains.", "chain")
+	DepositsSent = NewCounterVec("arbiter_deposits_sent_total",
+		"Deposit transactions sent to side chain nodes.", "chain")
+	DepositsSucceeded = NewCounterVec("arbiter_deposits_succeeded_total",
+		"Deposit transactions accepted by side chains.", "chain")
+	DepositsFailed = NewCounterVec("arbiter_deposits_failed_total",
+		"Deposit transactions rejected by side chains.", "chain")
+
+	PendingMainChainTxs = NewGauge("arbiter_pending_mainchain_txs",
+		"Rows of MainChainTxs waiting to be sent to side chains.")
+	PendingSideChainTxs = NewGauge("arbiter_pending_sidechain_txs",
+		"Rows of SideChainTxs waiting to be withdrawn.")
+
+	ProposalsCreated = NewCounterVec("arbiter_proposals_created_total",
+		"Proposals created by this arbiter, kind is withdraw or illegal.", "kind")
+	ProposalSignatures = NewCounterVec("arbiter_proposal_signatures_received_total",
+		"Signatures of proposals received from other arbiters.", "kind")
+	ProposalSubmissions = NewCounterVec("arbiter_proposal_submissions_total",
+		"Proposals submitted to the main chain, result is succeeded or failed.", "kind", "result")
+	ProposalTimeToThreshold = NewHistogramVec("arbiter_proposal_time_to_threshold_seconds",
+		"Seconds from creating a proposal to receiving enough signatures.",
+		[]float64{.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}, "kind")
+
+	MainChainHeight = NewGauge("arbiter_mainchain_height",
+		"Block height of the main chain node.")
+	SPVHeight = NewGauge("arbiter_spv_height",
+		"Best header height of the spv module.")
+	SPVLag = NewGauge("arbiter_spv_lag_blocks",
+		"Blocks the spv module is behind the main chain node.")
+	SideChainHeight = NewGaugeVec("arbiter_sidechain_height",
+		"Block height of the side chain node.", "chain")
+	SideChainSyncedHeight = NewGaugeVec("arbiter_sidechain_synced_height",
+		"Side chain height synced by the arbiter.", "chain")
+	SideChainLag = NewGaugeVec("arbiter_sidechain_lag_blocks",
+		"Blocks the arbiter is behind the side chain node.", "chain")
+
+	OnDuty = NewGauge("arbiter_on_duty",
+		"1 if the arbiter is on duty of the main chain, otherwise 0.")
+	ConnectedPeers = NewGauge("arbiter_connected_peers",
+		"Arbiter peers connected.")
+
+	AuxpowSubmissions = NewCounterVec("arbiter_auxpow_submissions_total",
+		"Side auxpow blocks submitted to side chains, result is succeeded or failed.", "chain", "result")
+	MiningAccountBalance = NewGaugeVec("arbiter_mining_account_balance_ela",
+		"Available balance of the side chain mining accounts.", "address")
+
+	RPCDuration = NewHistogramVec("arbiter_rpc_request_duration_seconds",
+		"Latency of json rpc calls by method.", nil, "method")
+	HTTPDuration = NewHistogramVec("arbiter_http_request_duration_seconds",
+		"Latency of http requests by server and endpoint.", nil, "server", "endpoint")
+)
+
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+)
+
+// Result returns the result label of an operation.
+func Result(err error) string {
+	if err != nil {
+		return ResultFailed
+	}
+	return ResultSucceeded
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func writeText(t *testing.T) string {
+	var buf bytes.Buffer
+	if err := DefaultRegistry.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func checkLines(t *testing.T, text string, lines ...string) {
+	for _, line := range lines {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, text)
+		}
+	}
+}
+
+func TestCounter(t *testing.T) {
+	counter := NewCounterVec("test_counter_total", "A test counter.", "chain", "result")
+	counter.With("a", "succeeded").Inc()
+	counter.With("a", "succeeded").Add(2)
+	counter.With("b", Result(errors.New("failed"))).Inc()
+	counter.With("b", "failed").Add(-1)
+
+	checkLines(t, writeText(t),
+		"# HELP test_counter_total A test counter.",
+		"# TYPE test_counter_total counter",
+		`test_counter_total{chain="a",result="succeeded"} 3`,
+		`test_counter_total{chain="b",result="failed"} 1`)
+}
+
+func TestGauge(t *testing.T) {
+	gauge := NewGauge("test_gauge", "A test gauge.\nWith two lines.")
+	gauge.Set(5)
+	gauge.Dec()
+	gauge.Add(0.5)
+	vec := NewGaugeVec("test_gauge_vec", "A test gauge vector.", "address")
+	vec.With(`a"b\c`).SetBool(true)
+
+	checkLines(t, writeText(t),
+		`# HELP test_gauge A test gauge.\nWith two lines.`,
+		"# TYPE test_gauge gauge",
+		"test_gauge 4.5",
+		`test_gauge_vec{address="a\"b\\c"} 1`)
+
+	vec.Reset()
+	if strings.Contains(writeText(t), "test_gauge_vec{") {
+		t.Error("gauges should be removed by reset")
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	histogram := NewHistogramVec("test_histogram_seconds", "A test histogram.", []float64{1, 0.1}, "method")
+	for _, v := range []float64{0.05, 0.5, 0.5, 3} {
+		histogram.With("getinfo").Observe(v)
+	}
+
+	checkLines(t, writeText(t),
+		"# TYPE test_histogram_seconds histogram",
+		`test_histogram_seconds_bucket{method="getinfo",le="0.1"} 1`,
+		`test_histogram_seconds_bucket{method="getinfo",le="1"} 3`,
+		`test_histogram_seconds_bucket{method="getinfo",le="+Inf"} 4`,
+		`test_histogram_seconds_sum{method="getinfo"} 4.05`,
+		`test_histogram_seconds_count{method="getinfo"} 4`)
+}
+
+func TestRegistry(t *testing.T) {
+	NewCounter("test_duplicated_total", "")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("duplicate metric should panic")
+			}
+		}()
+		NewGauge("test_duplicated_total", "")
+	}()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("wrong label count should panic")
+			}
+		}()
+		NewCounterVec("test_labels_total", "", "a", "b").With("a")
+	}()
+
+	w := httptest.NewRecorder()
+	DefaultRegistry.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Header().Get("Content-Type") != ContentType {
+		t.Error("wrong content type:", w.Header().Get("Content-Type"))
+	}
+	checkLines(t, w.Body.String(), "# TYPE arbiter_deposits_seen_total counter")
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
+
+	"github.com/urfave/cli"
+)
+
+func newMigrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Migrate the databases offline to the latest schema versions",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "print the pending migrations without applying them",
+			},
+		},
+		Action: migrate,
+	}
+}
+
+// migrate migrates the databases offline, with --dry-run it only prints the
+// pending migrations.
+func migrate(c *cli.Context) error {
+	if err := setupConfig(c); err != nil {
+		return err
+	}
+	setupLog()
+	if err := checkSqliteBackend("migrate"); err != nil {
+		return err
+	}
+
+	plans, err := store.PlanMigrations()
+	if err != nil {
+		return fmt.Errorf("Plan migrations error: %v", err)
+	}
+	pending := 0
+	for _, plan := range plans {
+		switch {
+		case !plan.Exists:
+			fmt.Printf("%s: not created yet, will be created in schema version %d\n",
+				plan.Path, plan.LatestVersion)
+			continue
+		case len(plan.Pending) == 0:
+			fmt.Printf("%s: up to date in schema version %d\n", plan.Path, plan.Version)
+			continue
+		}
+		fmt.Printf("%s: schema version %d -> %d\n", plan.Path, plan.Version, plan.LatestVersion)
+		for _, m := range plan.Pending {
+			fmt.Printf("  %d: %s\n", m.Version, m.Description)
+		}
+		pending += len(plan.Pending)
+	}
+	if c.Bool("dry-run") || pending == 0 {
+		return nil
+	}
+
+	if err := store.Migrate(); err != nil {
+		return fmt.Errorf("Migrate error: %v", err)
+	}
+	fmt.Println("Migrated, the databases are backed up next to them before changed.")
+	return nil
+}
@@ -0,0 +1,131 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// allowAll is the legacy rule which allows all ips.
+const allowAll = "0.0.0.0"
+
+// Filter decides whether a client ip is allowed by allow and deny rules, the
+// rules are single ips or CIDR ranges of IPv4 and IPv6.
+type Filter struct {
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	proxies []*net.IPNet
+}
+
+// New creates a filter from the allow, deny and trusted proxy rules, empty
+// rules are ignored and "0.0.0.0" in allow rules allows all ips.
+func New(allow, deny, proxies []string) (*Filter, error) {
+	var f Filter
+	var err error
+	if f.allow, err = parseRules(allow, true); err != nil {
+		return nil, fmt.Errorf("WhiteIPList: %v", err)
+	}
+	if f.deny, err = parseRules(deny, false); err != nil {
+		return nil, fmt.Errorf("DenyIPList: %v", err)
+	}
+	if f.proxies, err = parseRules(proxies, false); err != nil {
+		return nil, fmt.Errorf("TrustedProxyList: %v", err)
+	}
+	return &f, nil
+}
+
+func parseRules(rules []string, legacy bool) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if legacy && rule == allowAll {
+			_, v4, _ := net.ParseCIDR("0.0.0.0/0")
+			_, v6, _ := net.ParseCIDR("::/0")
+			nets = append(nets, v4, v6)
+			continue
+		}
+		ipNet, err := ParseRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// ParseRule parses a single ip or a CIDR range.
+func ParseRule(rule string) (*net.IPNet, error) {
+	if strings.Contains(rule, "/") {
+		_, ipNet, err := net.ParseCIDR(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", rule)
+		}
+		return ipNet, nil
+	}
+	ip := net.ParseIP(rule)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip %q", rule)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed returns if the ip is allowed, deny rules take precedence over the
+// allow rules, and loopback ips are allowed unless they are denied.
+func (f *Filter) Allowed(ip net.IP) bool {
+	if contains(f.deny, ip) {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+	return contains(f.allow, ip)
+}
+
+// ClientIP returns the ip of the client which sends the request. If the
+// request comes from a trusted proxy, the X-Forwarded-For header is walked
+// from right to left and the first ip which is not a trusted proxy is taken.
+func (f *Filter) ClientIP(r *http.Request) (net.IP, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote address %q", r.RemoteAddr)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid remote ip %q", host)
+	}
+	if !contains(f.proxies, ip) {
+		return ip, nil
+	}
+
+	var forwarded []string
+	for _, header := range r.Header["X-Forwarded-For"] {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIP := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if forwardedIP == nil {
+			return nil, fmt.Errorf("invalid X-Forwarded-For ip %q", forwarded[i])
+		}
+		ip = forwardedIP
+		if !contains(f.proxies, ip) {
+			break
+		}
+	}
+	return ip, nil
+}
@@ -0,0 +1,113 @@
+package ipfilter
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	for _, rules := range [][]string{{"10.0.0.1"}, {"10.0.0.0/8", "fd00::/8"}, {"::1", ""}, {"0.0.0.0"}} {
+		if _, err := New(rules, nil, nil); err != nil {
+			t.Error("rules", rules, "should be valid:", err)
+		}
+	}
+	for _, rules := range [][]string{{"10.0.0"}, {"10.0.0.0/33"}, {"fd00::/129"}, {"localhost"}} {
+		if _, err := New(rules, nil, nil); err == nil {
+			t.Error("rules", rules, "should be invalid")
+		}
+		if _, err := New(nil, rules, nil); err == nil {
+			t.Error("deny rules", rules, "should be invalid")
+		}
+		if _, err := New(nil, nil, rules); err == nil {
+			t.Error("proxy rules", rules, "should be invalid")
+		}
+	}
+	// 0.0.0.0 only means allow all in the allow rules
+	if _, err := New(nil, []string{"0.0.0.0"}, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFilter_Allowed(t *testing.T) {
+	f, err := New(
+		[]string{"10.0.0.0/8", "192.168.1.5", "fd00::/16"},
+		[]string{"10.1.0.0/16", "fd00::5", "127.0.0.2"},
+		nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"10.2.3.4":         true,
+		"10.1.2.3":         false,
+		"192.168.1.5":      true,
+		"192.168.1.6":      false,
+		"::ffff:10.2.3.4":  true,
+		"fd00::1":          true,
+		"fd00::5":          false,
+		"fe80::1":          false,
+		"127.0.0.1":        true,
+		"::1":              true,
+		"127.0.0.2":        false,
+		"::ffff:10.1.0.1":  false,
+		"::ffff:192.0.2.1": false,
+	}
+	for ip, allowed := range cases {
+		if f.Allowed(net.ParseIP(ip)) != allowed {
+			t.Error(ip, "expected allowed:", allowed)
+		}
+	}
+
+	f, _ = New([]string{"0.0.0.0"}, []string{"2001:db8::/32"}, nil)
+	if !f.Allowed(net.ParseIP("8.8.8.8")) || !f.Allowed(net.ParseIP("2001:db9::1")) {
+		t.Error("0.0.0.0 should allow all ips")
+	}
+	if f.Allowed(net.ParseIP("2001:db8::1")) {
+		t.Error("denied ip should not be allowed")
+	}
+}
+
+func TestFilter_ClientIP(t *testing.T) {
+	f, err := New(nil, nil, []string{"10.0.0.1", "172.16.0.0/12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		remote    string
+		forwarded []string
+		expected  string
+	}{
+		// X-Forwarded-For from untrusted client is ignored
+		{"192.0.2.1:1000", []string{"1.2.3.4"}, "192.0.2.1"},
+		{"10.0.0.1:1000", nil, "10.0.0.1"},
+		{"10.0.0.1:1000", []string{"1.2.3.4"}, "1.2.3.4"},
+		// spoofed ips before the first untrusted one are ignored
+		{"10.0.0.1:1000", []string{"5.6.7.8, 1.2.3.4", "172.16.0.3"}, "1.2.3.4"},
+		{"10.0.0.1:1000", []string{"172.16.0.2, 172.16.0.3"}, "172.16.0.2"},
+		{"[::1]:1000", []string{"1.2.3.4"}, "::1"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = c.remote
+		for _, header := range c.forwarded {
+			r.Header.Add("X-Forwarded-For", header)
+		}
+		ip, err := f.ClientIP(r)
+		if err != nil {
+			t.Error(c.remote, c.forwarded, err)
+			continue
+		}
+		if !ip.Equal(net.ParseIP(c.expected)) {
+			t.Error(c.remote, c.forwarded, "expected", c.expected, "got", ip)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1000"
+	r.Header.Set("X-Forwarded-For", "not-an-ip")
+	if _, err := f.ClientIP(r); err == nil {
+		t.Error("invalid X-Forwarded-For should be rejected")
+	}
+}
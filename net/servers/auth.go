@@ -0,0 +1,144 @@
+package servers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/ipfilter"
+)
+
+// Role is the permission level of a rpc credential, a caller can call the
+// methods which require the same or a lower role.
+type Role int
+
+const (
+	RoleReadOnly Role = iota
+	RoleOperator
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleReadOnly: "readonly",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+// ParseRole returns the role of the name, the name is case insensitive.
+func ParseRole(name string) (Role, error) {
+	for role, roleName := range roleNames {
+		if strings.EqualFold(name, roleName) {
+			return role, nil
+		}
+	}
+	return RoleReadOnly, fmt.Errorf("unknown rpc role %q", name)
+}
+
+// Caller is the authenticated client of a rpc request.
+type Caller struct {
+	User       string
+	Role       Role
+	RemoteAddr string
+}
+
+// Authenticate checks the basic authorization of the request against the
+// credentials of RpcConfiguration and returns the caller. The legacy User and
+// Pass have the admin role. If no credential is set all requests pass as
+// operator, the admin methods always require a credential of the admin role.
+func Authenticate(r *http.Request) (*Caller, bool) {
+	tempRpcConf := config.Parameters.RpcConfiguration
+	caller := &Caller{RemoteAddr: r.RemoteAddr, Role: RoleAdmin}
+	legacy := (tempRpcConf.User != "") || (tempRpcConf.Pass != "")
+	if !legacy && len(tempRpcConf.Users) == 0 {
+		caller.Role = RoleOperator
+		return caller, true
+	}
+	authHeader := r.Header["Authorization"]
+	if len(authHeader) <= 0 {
+		return nil, false
+	}
+	authSha256 := sha256.Sum256([]byte(authHeader[0]))
+
+	if legacy && matchAuth(authSha256, tempRpcConf.User, tempRpcConf.Pass) {
+		caller.User = tempRpcConf.User
+		return caller, true
+	}
+	for _, user := range tempRpcConf.Users {
+		if !matchAuth(authSha256, user.User, user.Pass) {
+			continue
+		}
+		role, err := ParseRole(user.Role)
+		if err != nil {
+			log.Warn("rpc user ", user.User, " denied: ", err)
+			return nil, false
+		}
+		caller.User = user.User
+		caller.Role = role
+		return caller, true
+	}
+
+	// Request's auth doesn't match any user
+	return nil, false
+}
+
+// CheckAuth checks the request is authorized by any credential of
+// RpcConfiguration.
+func CheckAuth(r *http.Request) bool {
+	_, ok := Authenticate(r)
+	return ok
+}
+
+func matchAuth(authSha256 [sha256.Size]byte, user, pass string) bool {
+	login := user + ":" + pass
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
+	cfgAuthSha256 := sha256.Sum256([]byte(auth))
+
+	return subtle.ConstantTimeCompare(authSha256[:], cfgAuthSha256[:]) == 1
+}
+
+var (
+	// clientFilter is built from RpcConfiguration by LoadClientFilter, before
+	// it is loaded only loopback clients are allowed.
+	clientFilter    = &ipfilter.Filter{}
+	clientFilterMux sync.RWMutex
+)
+
+// LoadClientFilter builds the client ip filter from the WhiteIPList,
+// DenyIPList and TrustedProxyList of conf. It should be called whenever the
+// configuration is loaded, so that bad rules are reported at that time
+// instead of on every request.
+func LoadClientFilter(conf config.RpcConfiguration) error {
+	filter, err := ipfilter.New(conf.WhiteIPList, conf.DenyIPList, conf.TrustedProxyList)
+	if err != nil {
+		return err
+	}
+	clientFilterMux.Lock()
+	clientFilter = filter
+	clientFilterMux.Unlock()
+	return nil
+}
+
+// ClientAllowed checks the client ip of the request by the filter loaded with
+// LoadClientFilter, requests from loopback are allowed unless they are denied.
+func ClientAllowed(r *http.Request) bool {
+	clientFilterMux.RLock()
+	filter := clientFilter
+	clientFilterMux.RUnlock()
+
+	remoteIp, err := filter.ClientIP(r)
+	if err != nil {
+		log.Errorf("clientAllowed %s failure: %v", r.RemoteAddr, err)
+		return false
+	}
+	return filter.Allowed(remoteIp)
+}
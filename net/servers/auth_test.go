@@ -0,0 +1,32 @@
+package servers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+)
+
+func TestLoadClientFilter(t *testing.T) {
+	defer LoadClientFilter(config.RpcConfiguration{})
+
+	if err := LoadClientFilter(config.RpcConfiguration{WhiteIPList: []string{"10.0.0.0/33"}}); err == nil {
+		t.Fatal("invalid rule should be rejected")
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:30000"
+	if ClientAllowed(r) {
+		t.Error("client should not be allowed before a filter is loaded")
+	}
+
+	// the filter is built once and kept until it is loaded again
+	conf := config.RpcConfiguration{WhiteIPList: []string{"10.0.0.0/8"}}
+	if err := LoadClientFilter(conf); err != nil {
+		t.Fatal(err)
+	}
+	conf.WhiteIPList[0] = "192.168.0.0/16"
+	if !ClientAllowed(r) {
+		t.Error("client should be allowed by the loaded filter")
+	}
+}
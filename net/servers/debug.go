@@ -0,0 +1,94 @@
+package servers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/cs"
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+)
+
+// QueueDepth is the number of the items waiting in a queue and its capacity.
+type QueueDepth struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+}
+
+// QueuesReport is the body of /debug/queues.
+type QueuesReport struct {
+	SpvListeners    []arbitrator.ListenerQueue `json:"spvlisteners"`
+	ArbiterMessages *QueueDepth                `json:"arbitermessages,omitempty"`
+}
+
+// DebugHandler serves pprof, the goroutine dump and the queue depths, only
+// the admin callers of the rpc servers passing the whitelist are allowed.
+// The command line is not served as it may have the wallet password.
+func DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/goroutines", goroutinesHandler)
+	mux.HandleFunc("/debug/queues", queuesHandler)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ClientAllowed(r) {
+			http.Error(w, "Client ip is not allowd", http.StatusForbidden)
+			return
+		}
+		caller, ok := Authenticate(r)
+		if !ok {
+			http.Error(w, "client authenticate failed", http.StatusUnauthorized)
+			return
+		}
+		if caller.Role < RoleAdmin {
+			log.Warnf("debug %s denied for user %q with role %s from %s",
+				r.URL.Path, caller.User, caller.Role, caller.RemoteAddr)
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// StartDebugServer serves DebugHandler on the address until it fails, it
+// has no write timeout as the profiles run for the seconds requested. It is
+// not started without an admin credential in RpcConfiguration.
+func StartDebugServer(address string) {
+	if !config.Parameters.RpcConfiguration.HasAdminCredential() {
+		log.Error("Debug server is not started, it requires an admin user in RpcConfiguration")
+		return
+	}
+	listener, err := ListenAddress(address)
+	if err != nil {
+		log.Error("Debug server listen error: ", err)
+		return
+	}
+	log.Info("Debug server listens on ", address)
+	server := &http.Server{Handler: DebugHandler()}
+	if err := server.Serve(listener); err != nil {
+		log.Warnf("StartDebugServer : %v", err)
+	}
+}
+
+func goroutinesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+func queuesHandler(w http.ResponseWriter, r *http.Request) {
+	report := QueuesReport{SpvListeners: arbitrator.ListenerQueues()}
+	if cs.P2PClientSingleton != nil {
+		length, capacity := cs.P2PClientSingleton.MessageQueueDepth()
+		report.ArbiterMessages = &QueueDepth{Length: length, Capacity: capacity}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Warn("write debug queues failed: ", err)
+	}
+}
@@ -0,0 +1,132 @@
+package servers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/errors"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+)
+
+func TestDebugHandler(t *testing.T) {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{
+		WhiteIPList: []string{"127.0.0.1"},
+		Users: []config.RpcUser{
+			{User: "reader", Pass: "r", Role: "readonly"},
+			{User: "admin", Pass: "a", Role: "admin"},
+		},
+	}
+	defer func() { config.Parameters.RpcConfiguration = config.RpcConfiguration{} }()
+
+	handler := DebugHandler()
+	get := func(path, remoteAddr, user, pass string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.RemoteAddr = remoteAddr
+		if user != "" {
+			r.SetBasicAuth(user, pass)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	cases := []struct {
+		remoteAddr, user, pass string
+		code                   int
+	}{
+		{"10.0.0.1:30000", "admin", "a", http.StatusForbidden},
+		{"127.0.0.1:30000", "", "", http.StatusUnauthorized},
+		{"127.0.0.1:30000", "reader", "r", http.StatusForbidden},
+		{"127.0.0.1:30000", "admin", "a", http.StatusOK},
+	}
+	for _, c := range cases {
+		if w := get("/debug/queues", c.remoteAddr, c.user, c.pass); w.Code != c.code {
+			t.Errorf("%s from %s: expected %d, got %d", c.user, c.remoteAddr, c.code, w.Code)
+		}
+	}
+
+	w := get("/debug/queues", "127.0.0.1:30000", "admin", "a")
+	var report QueuesReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || report.SpvListeners == nil {
+		t.Errorf("unexpected queues %s, err %v", w.Body.String(), err)
+	}
+	w = get("/debug/goroutines", "127.0.0.1:30000", "admin", "a")
+	if !strings.Contains(w.Body.String(), "goroutine ") {
+		t.Errorf("unexpected goroutine dump %q", w.Body.String())
+	}
+
+	// the command line may have the wallet password
+	w = get("/debug/pprof/cmdline", "127.0.0.1:30000", "admin", "a")
+	if w.Code == http.StatusOK || strings.Contains(w.Body.String(), os.Args[0]) {
+		t.Errorf("command line should not be served, got %d %q", w.Code, w.Body.String())
+	}
+
+	// callers are not admin without credentials configured
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{WhiteIPList: []string{"127.0.0.1"}}
+	for _, path := range []string{"/debug/queues", "/debug/pprof/", "/debug/pprof/cmdline"} {
+		if w := get(path, "127.0.0.1:30000", "", ""); w.Code != http.StatusUnauthorized && w.Code != http.StatusForbidden {
+			t.Errorf("%s without credentials: expected 401 or 403, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestStartDebugServer_NoAdmin(t *testing.T) {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{
+		Users: []config.RpcUser{{User: "reader", Pass: "r", Role: "readonly"}},
+	}
+	defer func() { config.Parameters.RpcConfiguration = config.RpcConfiguration{} }()
+
+	done := make(chan struct{})
+	go func() {
+		StartDebugServer("127.0.0.1:0")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("debug server should not start without an admin user")
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	defer log.InheritModuleLevel(log.ModuleCS)
+
+	resp := SetLogLevel(Params{"module": log.ModuleCS, "level": float64(0)})
+	if resp["Error"] != errors.Success {
+		t.Fatal("set log level failed:", resp)
+	}
+	levels := GetLogLevels(Params{})["Result"].(map[string]uint8)
+	if levels[log.ModuleCS] != 0 || levels[log.ModuleRoot] != 5 {
+		t.Errorf("unexpected levels %v", levels)
+	}
+
+	// the module follows the root module again
+	resp = SetLogLevel(Params{"module": log.ModuleCS, "level": float64(log.LevelInherit)})
+	if resp["Error"] != errors.Success {
+		t.Fatal("inherit log level failed:", resp)
+	}
+	log.SetModuleLevel(log.ModuleRoot, 4)
+	defer log.SetModuleLevel(log.ModuleRoot, 5)
+	levels = GetLogLevels(Params{})["Result"].(map[string]uint8)
+	if levels[log.ModuleCS] != 4 {
+		t.Errorf("module should follow the root level, got %v", levels)
+	}
+
+	for _, params := range []Params{
+		{"module": "p2p", "level": float64(0)},
+		{"module": log.ModuleCS, "level": float64(6)},
+		{"module": log.ModuleCS, "level": float64(-2)},
+		{"module": log.ModuleRoot, "level": float64(log.LevelInherit)},
+		{"level": float64(0)},
+	} {
+		if resp := SetLogLevel(params); resp["Error"] != errors.InvalidParams {
+			t.Errorf("%v should be invalid, got %v", params, resp)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package servers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
+)
+
+const (
+	DefaultFinishedTxsLimit = 100
+	MaxFinishedTxsLimit     = 1000
+)
+
+// parseFinishedTxsQuery parses the filters and the page of the finished
+// transactions, all of them are optional.
+func parseFinishedTxsQuery(param Params) (*store.FinishedTxsQuery, error) {
+	query := &store.FinishedTxsQuery{Limit: DefaultFinishedTxsLimit}
+
+	if _, ok := param["succeed"]; ok {
+		succeed, ok := param.Bool("succeed")
+		if !ok {
+			value, _ := param.String("succeed")
+			var err error
+			if succeed, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("invalid succeed %v", param["succeed"])
+			}
+		}
+		query.Succeed = &succeed
+	}
+
+	var err error
+	if query.StartTime, err = parseRecordTime(param, "starttime"); err != nil {
+		return nil, err
+	}
+	if query.EndTime, err = parseRecordTime(param, "endtime"); err != nil {
+		return nil, err
+	}
+
+	if prefix, ok := param.String("hashprefix"); ok && prefix != "" {
+		prefix = strings.ToLower(prefix)
+		if len(prefix) > 64 || !isHex(prefix) {
+			return nil, fmt.Errorf("invalid hashprefix %s", prefix)
+		}
+		query.HashPrefix = prefix
+	}
+
+	query.Cursor, _ = param.String("cursor")
+
+	if _, ok := param["limit"]; ok {
+		limit, ok := param.Int("limit")
+		if !ok || limit <= 0 || limit > MaxFinishedTxsLimit {
+			return nil, fmt.Errorf("limit should be between 1 and %d", MaxFinishedTxsLimit)
+		}
+		query.Limit = int(limit)
+	}
+
+	return query, nil
+}
+
+// parseRecordTime parses a time in the format of RecordTime, RFC3339 or unix
+// seconds, and returns it in the format of RecordTime.
+func parseRecordTime(param Params, key string) (string, error) {
+	if _, ok := param[key]; !ok {
+		return "", nil
+	}
+	if value, ok := param.String(key); ok {
+		if t, err := time.ParseInLocation(store.RecordTimeFormat, value, time.Local); err == nil {
+			return t.Format(store.RecordTimeFormat), nil
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t.Local().Format(store.RecordTimeFormat), nil
+		}
+	}
+	if seconds, ok := param.Int(key); ok && seconds >= 0 {
+		return time.Unix(seconds, 0).Format(store.RecordTimeFormat), nil
+	}
+	return "", fmt.Errorf("invalid %s %v, should be %s, RFC3339 or unix seconds",
+		key, param[key], store.RecordTimeFormat)
+}
+
+func isHex(s string) bool {
+	if len(s)%2 == 1 {
+		s += "0"
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,150 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/errors"
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
+)
+
+func TestParseFinishedTxsQuery(t *testing.T) {
+	query, err := parseFinishedTxsQuery(Params{})
+	if err != nil || query.Succeed != nil || query.Limit != DefaultFinishedTxsLimit {
+		t.Errorf("unexpected default query %+v, %v", query, err)
+	}
+
+	start := time.Date(2020, 9, 10, 8, 0, 0, 0, time.Local)
+	query, err = parseFinishedTxsQuery(Params{
+		"succeed":    "false",
+		"starttime":  start.Format(time.RFC3339),
+		"endtime":    float64(start.Add(time.Hour).Unix()),
+		"hashprefix": "AB12",
+		"cursor":     "next",
+		"limit":      "20",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Succeed == nil || *query.Succeed || query.StartTime != "2020-09-10_08.00.00" ||
+		query.EndTime != "2020-09-10_09.00.00" || query.HashPrefix != "ab12" ||
+		query.Cursor != "next" || query.Limit != 20 {
+		t.Errorf("unexpected query %+v", query)
+	}
+
+	for _, params := range []Params{
+		{"succeed": "maybe"},
+		{"starttime": "yesterday"},
+		{"hashprefix": "xyz"},
+		{"limit": float64(0)},
+		{"limit": float64(MaxFinishedTxsLimit + 1)},
+	} {
+		if _, err := parseFinishedTxsQuery(params); err == nil {
+			t.Errorf("params %v should be rejected", params)
+		}
+	}
+}
+
+type finishedTxsPage struct {
+	Transactions []struct {
+		Hash                string
+		GenesisBlockAddress string
+		Succeed             bool
+	}
+	NextCursor string
+}
+
+func getFinishedTxsPage(t *testing.T, handler func(Params) map[string]interface{}, params Params) finishedTxsPage {
+	resp := handler(params)
+	if resp["Error"] != errors.Success {
+		t.Fatal("unexpected error", resp["Result"])
+	}
+	data, _ := json.Marshal(resp["Result"])
+	var page finishedTxsPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+func TestGetFinishedDepositTxs(t *testing.T) {
+	defer openTestFinishedTxsStore(t)()
+
+	store.FinishedTxsDbCache.AddSucceedDepositTxs(context.Background(), []string{"aa01", "aa02"}, []string{"sideA", "sideB"})
+	store.FinishedTxsDbCache.AddFailedDepositTxs(context.Background(), []string{"aa03"}, []string{"sideA"})
+
+	page := getFinishedTxsPage(t, GetFinishedDepositTxs, Params{"genesisaddress": "sideA", "limit": float64(1)})
+	if len(page.Transactions) != 1 || page.Transactions[0].Hash != "aa01" || page.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	page = getFinishedTxsPage(t, GetFinishedDepositTxs, Params{"genesisaddress": "sideA", "limit": float64(1),
+		"cursor": page.NextCursor})
+	if len(page.Transactions) != 1 || page.Transactions[0].Hash != "aa03" ||
+		page.Transactions[0].Succeed || page.NextCursor != "" {
+		t.Fatalf("unexpected last page %+v", page)
+	}
+
+	resp := GetFinishedDepositTxs(Params{"cursor": "invalid"})
+	if resp["Error"] != errors.InvalidParams {
+		t.Error("invalid cursor should be rejected, got", resp["Error"])
+	}
+}
+
+func TestGetFinishedWithdrawTxs(t *testing.T) {
+	defer openTestFinishedTxsStore(t)()
+
+	store.FinishedTxsDbCache.AddSucceedWithdrawTxs(context.Background(), []string{"aa01", "bb02"})
+
+	page := getFinishedTxsPage(t, GetFinishedWithdrawTxs, Params{"succeed": true, "hashprefix": "bb"})
+	if len(page.Transactions) != 1 || page.Transactions[0].Hash != "bb02" || page.NextCursor != "" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+}
+
+func TestPruneFinishedTxs(t *testing.T) {
+	defer openTestFinishedTxsStore(t)()
+
+	retention := config.Parameters.FinishedTxsRetention
+	defer func() { config.Parameters.FinishedTxsRetention = retention }()
+
+	config.Parameters.FinishedTxsRetention = config.FinishedTxsRetentionConfiguration{}
+	if resp := PruneFinishedTxs(Params{}); resp["Error"] != errors.InvalidParams {
+		t.Error("prune without retention should be rejected, got", resp["Error"])
+	}
+
+	config.Parameters.FinishedTxsRetention.SucceedDays = 30
+	store.FinishedTxsDbCache.AddSucceedWithdrawTxs(context.Background(), []string{"aa01", "bb02"})
+	_, err := store.FinishedTxsDbCache.(*store.FinishedTxsDataStoreImpl).Exec(
+		"UPDATE WithdrawTransactions SET RecordTime=? WHERE TransactionHash=?", "2000-01-01_00.00.00", "aa01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := PruneFinishedTxs(Params{"dryrun": "no"}); resp["Error"] != errors.InvalidParams {
+		t.Error("invalid dryrun should be rejected, got", resp["Error"])
+	}
+
+	resp := PruneFinishedTxs(Params{})
+	report, ok := resp["Result"].(*store.PruneReport)
+	if resp["Error"] != errors.Success || !ok || !report.DryRun || report.WithdrawTxs != 1 {
+		t.Fatalf("unexpected dry run response %+v", resp)
+	}
+	if finished, _ := store.FinishedTxsDbCache.HasWithdrawTx(context.Background(), "aa01"); !finished {
+		t.Error("dry run should not prune transactions")
+	}
+
+	resp = PruneFinishedTxs(Params{"dryrun": false})
+	report, ok = resp["Result"].(*store.PruneReport)
+	if resp["Error"] != errors.Success || !ok || report.DryRun || report.WithdrawTxs != 1 {
+		t.Fatalf("unexpected prune response %+v", resp)
+	}
+	if finished, _ := store.FinishedTxsDbCache.HasWithdrawTx(context.Background(), "aa01"); finished {
+		t.Error("expired transaction should be pruned")
+	}
+	if finished, _ := store.FinishedTxsDbCache.HasWithdrawTx(context.Background(), "bb02"); !finished {
+		t.Error("transaction in the retention should be kept")
+	}
+}
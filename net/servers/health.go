@@ -0,0 +1,273 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/cs"
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
+
+	"github.com/elastos/Elastos.ELA/dpos/p2p"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	// DefaultHealthCheckTimeout is used when HealthCheck.CheckTimeout is not
+	// configured.
+	DefaultHealthCheckTimeout = 5 * time.Second
+)
+
+// ComponentStatus is the readiness of a component, Message tells the heights
+// checked or why the check failed.
+type ComponentStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// ReadinessReport is the body of /readyz.
+type ReadinessReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+type healthCheck struct {
+	name  string
+	check func() (string, error)
+}
+
+// HealthzHandler tells the process is alive, it does not check any component.
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	if !ClientAllowed(r) {
+		http.Error(w, "Client ip is not allowd", http.StatusForbidden)
+		return
+	}
+	writeHealth(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// ReadyzHandler checks the components the arbiter depends on, it responds 503
+// if any of them fails. It does not require authorization, so the probes of
+// orchestrators can call it.
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	if !ClientAllowed(r) {
+		http.Error(w, "Client ip is not allowd", http.StatusForbidden)
+		return
+	}
+	report := CheckReadiness()
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, report)
+}
+
+func writeHealth(w http.ResponseWriter, code int, body interface{}) {
+	data, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+// CheckReadiness runs the readiness checks concurrently, a check not finished
+// within HealthCheck.CheckTimeout fails.
+func CheckReadiness() ReadinessReport {
+	conf := config.Parameters.HealthCheck
+	timeout := conf.CheckTimeout * time.Millisecond
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	return runChecks(readinessChecks(conf), timeout)
+}
+
+func runChecks(checks []healthCheck, timeout time.Duration) ReadinessReport {
+	type result struct {
+		name   string
+		status ComponentStatus
+	}
+	results := make(chan result, len(checks))
+	for _, c := range checks {
+		go func(c healthCheck) {
+			message, err := c.check()
+			if err != nil {
+				results <- result{c.name, ComponentStatus{StatusFail, err.Error()}}
+				return
+			}
+			results <- result{c.name, ComponentStatus{StatusOK, message}}
+		}(c)
+	}
+
+	report := ReadinessReport{
+		Status:     StatusOK,
+		Components: make(map[string]ComponentStatus, len(checks)),
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for range checks {
+		select {
+		case res := <-results:
+			report.Components[res.name] = res.status
+		case <-timer.C:
+			for _, c := range checks {
+				if _, ok := report.Components[c.name]; !ok {
+					report.Components[c.name] = ComponentStatus{StatusFail,
+						fmt.Sprintf("timeout after %v", timeout)}
+				}
+			}
+		}
+		if len(report.Components) == len(checks) {
+			break
+		}
+	}
+	for _, status := range report.Components {
+		if status.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// mainHeight queries the main node at most once, so the checks depending on
+// the main chain height share the same rpc call.
+type mainHeight struct {
+	once   sync.Once
+	height uint32
+	err    error
+}
+
+func (m *mainHeight) get() (uint32, error) {
+	m.once.Do(func() {
+		if config.Parameters.MainNode == nil || config.Parameters.MainNode.Rpc == nil {
+			m.err = errors.New("main node is not configured")
+			return
+		}
+		m.height, m.err = rpc.GetCurrentHeight(config.Parameters.MainNode.Rpc)
+	})
+	return m.height, m.err
+}
+
+func lag(target, current uint32) uint32 {
+	if target <= current {
+		return 0
+	}
+	return target - current
+}
+
+func readinessChecks(conf config.HealthCheckConfiguration) []healthCheck {
+	main := &mainHeight{}
+	checks := []healthCheck{
+		{"mainchainstore", func() (string, error) {
+			if store.DbCache.MainChainStore == nil {
+				return "", errors.New("store is not opened")
+			}
+			count, err := store.DbCache.MainChainStore.GetMainChainTxsCount(context.Background())
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d pending txs", count), nil
+		}},
+		{"sidechainstore", func() (string, error) {
+			if store.DbCache.SideChainStore == nil {
+				return "", errors.New("store is not opened")
+			}
+			count, err := store.DbCache.SideChainStore.GetSideChainTxsCount(context.Background())
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d pending txs", count), nil
+		}},
+		{"mainnode", func() (string, error) {
+			height, err := main.get()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("height %d", height), nil
+		}},
+		{"spv", func() (string, error) {
+			if arbitrator.SpvService == nil {
+				return "", errors.New("spv service is not started")
+			}
+			best, err := arbitrator.SpvService.HeaderStore().GetBest()
+			if err != nil {
+				return "", err
+			}
+			height, err := main.get()
+			if err != nil {
+				return "", fmt.Errorf("main node unreachable: %v", err)
+			}
+			if behind := lag(height, best.Height); behind > conf.MaxSPVLag {
+				return "", fmt.Errorf("height %d is %d blocks behind main node height %d",
+					best.Height, behind, height)
+			}
+			return fmt.Sprintf("height %d", best.Height), nil
+		}},
+		{"peers", func() (string, error) {
+			if cs.P2PClientSingleton == nil {
+				return "", errors.New("p2p client is not started")
+			}
+			var connected int
+			for _, peer := range cs.P2PClientSingleton.DumpArbiterPeersInfo() {
+				if peer.State != p2p.CSNoneConnection {
+					connected++
+				}
+			}
+			if connected < conf.MinConnectedPeers {
+				return "", fmt.Errorf("%d peers connected, need %d",
+					connected, conf.MinConnectedPeers)
+			}
+			return fmt.Sprintf("%d peers connected", connected), nil
+		}},
+		{"arbitergroup", func() (string, error) {
+			group := arbitrator.ArbitratorGroupSingleton
+			if group == nil {
+				return "", errors.New("arbitrator group is not initialized")
+			}
+			if len(group.GetAllArbitrators()) == 0 {
+				return "", errors.New("arbitrators are not synced")
+			}
+			height, err := main.get()
+			if err != nil {
+				return "", fmt.Errorf("main node unreachable: %v", err)
+			}
+			synced := group.GetCurrentHeight()
+			if behind := lag(height, synced); behind > conf.MaxMainChainLag {
+				return "", fmt.Errorf("height %d is %d blocks behind main node height %d",
+					synced, behind, height)
+			}
+			return fmt.Sprintf("height %d", synced), nil
+		}},
+	}
+
+	for _, node := range config.SideNodes() {
+		node := node
+		checks = append(checks, healthCheck{"sidenode:" + node.GenesisBlockAddress, func() (string, error) {
+			if node.Rpc == nil {
+				return "", errors.New("rpc is not configured")
+			}
+			height, err := rpc.GetCurrentHeight(node.Rpc)
+			if err != nil {
+				return "", err
+			}
+			if store.DbCache.SideChainStore == nil {
+				return "", errors.New("side chain store is not opened")
+			}
+			synced := store.DbCache.SideChainStore.CurrentSideHeight(context.Background(),
+				node.GenesisBlockAddress, store.QueryHeightCode)
+			if behind := lag(height, synced); behind > conf.MaxSideChainLag {
+				return "", fmt.Errorf("synced height %d is %d blocks behind node height %d",
+					synced, behind, height)
+			}
+			return fmt.Sprintf("height %d, synced height %d", height, synced), nil
+		}})
+	}
+	return checks
+}
@@ -0,0 +1,111 @@
+package servers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+)
+
+func TestMain(m *testing.M) {
+	logDir, _ := ioutil.TempDir("", "arbiter-servers-test")
+	log.Init(filepath.Join(logDir, "logs"), 5, 0, 0)
+	if config.Parameters.Configuration == nil {
+		config.Parameters.Configuration = &config.Configuration{}
+	}
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+func TestHealthzHandler(t *testing.T) {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{WhiteIPList: []string{"127.0.0.1"}}
+	defer func() { config.Parameters.RpcConfiguration = config.RpcConfiguration{} }()
+
+	r := httptest.NewRequest("GET", "/healthz", nil)
+	r.RemoteAddr = "127.0.0.1:30000"
+	w := httptest.NewRecorder()
+	HealthzHandler(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != `{"status":"ok"}` {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	r.RemoteAddr = "10.0.0.1:30000"
+	w = httptest.NewRecorder()
+	HealthzHandler(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{WhiteIPList: []string{"127.0.0.1"}}
+	defer func() { config.Parameters.RpcConfiguration = config.RpcConfiguration{} }()
+
+	r := httptest.NewRequest("GET", "/readyz", nil)
+	r.RemoteAddr = "127.0.0.1:30000"
+	w := httptest.NewRecorder()
+	ReadyzHandler(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	var report ReadinessReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != StatusFail {
+		t.Errorf("expected status %s, got %s", StatusFail, report.Status)
+	}
+	for _, name := range []string{"mainchainstore", "sidechainstore", "mainnode",
+		"spv", "peers", "arbitergroup"} {
+		status, ok := report.Components[name]
+		if !ok {
+			t.Errorf("missing component %s", name)
+			continue
+		}
+		if status.Status != StatusFail || status.Message == "" {
+			t.Errorf("component %s should fail with a message, got %+v", name, status)
+		}
+	}
+}
+
+func TestRunChecks(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	report := runChecks([]healthCheck{
+		{"ok", func() (string, error) { return "fine", nil }},
+		{"fail", func() (string, error) { return "", errors.New("broken") }},
+		{"slow", func() (string, error) { <-block; return "", nil }},
+	}, 50*time.Millisecond)
+
+	if report.Status != StatusFail {
+		t.Errorf("expected status %s, got %s", StatusFail, report.Status)
+	}
+	expected := map[string]ComponentStatus{
+		"ok":   {StatusOK, "fine"},
+		"fail": {StatusFail, "broken"},
+		"slow": {StatusFail, "timeout after 50ms"},
+	}
+	for name, status := range expected {
+		if report.Components[name] != status {
+			t.Errorf("component %s expected %+v, got %+v", name, status, report.Components[name])
+		}
+	}
+
+	report = runChecks([]healthCheck{
+		{"ok", func() (string, error) { return "", nil }},
+	}, time.Second)
+	if report.Status != StatusOK {
+		t.Errorf("expected status %s, got %s", StatusOK, report.Status)
+	}
+}
@@ -0,0 +1,203 @@
+package httpjsonrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/errors"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/servers"
+)
+
+func init() {
+	if config.Parameters.Configuration == nil {
+		config.Parameters.Configuration = &config.Configuration{}
+	}
+}
+
+func initTestMux() {
+	mainMux = map[string]method{
+		"echo": {func(params servers.Params) map[string]interface{} {
+			return servers.ResponsePack(errors.Success, map[string]interface{}(params))
+		}, []string{"a", "b"}, servers.RoleReadOnly},
+		"fail": {func(params servers.Params) map[string]interface{} {
+			return servers.ResponsePack(errors.InvalidParams, "need a parameter named a")
+		}, nil, servers.RoleReadOnly},
+		"panic": {func(params servers.Params) map[string]interface{} {
+			panic("unexpected")
+		}, nil, servers.RoleReadOnly},
+	}
+}
+
+func doRequest(t *testing.T, body string) *httptest.ResponseRecorder {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{}
+	initTestMux()
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.RemoteAddr = "127.0.0.1:20336"
+	w := httptest.NewRecorder()
+	Handle(w, r)
+	return w
+}
+
+func doSingleRequest(t *testing.T, body string) map[string]interface{} {
+	w := doRequest(t, body)
+	if w.Code != http.StatusOK {
+		t.Fatal("unexpected status:", w.Code)
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal("invalid response:", w.Body.String())
+	}
+	if resp["jsonrpc"] != "2.0" {
+		t.Error("response without jsonrpc version:", resp)
+	}
+	return resp
+}
+
+func checkErrorCode(t *testing.T, resp map[string]interface{}, code errors.ErrCode) {
+	if _, ok := resp["result"]; ok {
+		t.Error("result should not exist in error response:", resp)
+	}
+	e, ok := resp["error"].(map[string]interface{})
+	if !ok {
+		t.Fatal("error expected:", resp)
+	}
+	if e["code"] != float64(code) {
+		t.Error("expected error code", code, "got", e["code"])
+	}
+	if _, ok := e["id"]; ok {
+		t.Error("id should not be inside the error object:", resp)
+	}
+}
+
+func TestHandle_ParseError(t *testing.T) {
+	for _, body := range []string{``, `{"jsonrpc": "2.0", "method": "echo"`, `[{"method": "echo"},`} {
+		resp := doSingleRequest(t, body)
+		checkErrorCode(t, resp, errors.ParseError)
+		if resp["id"] != nil {
+			t.Error("id should be null:", resp)
+		}
+	}
+}
+
+func TestHandle_InvalidRequest(t *testing.T) {
+	for _, body := range []string{
+		`1`,
+		`"echo"`,
+		`[]`,
+		`{"jsonrpc": "2.0", "method": 1, "id": 1}`,
+		`{"jsonrpc": "2.0", "id": 1}`,
+		`{"jsonrpc": "1.0", "method": "echo", "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "echo", "id": {}}`,
+	} {
+		checkErrorCode(t, doSingleRequest(t, body), errors.InvalidRequest)
+	}
+}
+
+func TestHandle_Result(t *testing.T) {
+	resp := doSingleRequest(t, `{"jsonrpc": "2.0", "method": "echo", "params": {"a": 1}, "id": "abc"}`)
+	if _, ok := resp["error"]; ok {
+		t.Error("error should not exist in result response:", resp)
+	}
+	if resp["id"] != "abc" {
+		t.Error("wrong id:", resp["id"])
+	}
+	result, _ := resp["result"].(map[string]interface{})
+	if result["a"] != float64(1) {
+		t.Error("wrong result:", resp["result"])
+	}
+
+	// large ids are returned without loss of precision
+	w := doRequest(t, `{"jsonrpc": "2.0", "method": "echo", "id": 9007199254740993}`)
+	if !strings.Contains(w.Body.String(), `"id":9007199254740993`) {
+		t.Error("wrong id:", w.Body.String())
+	}
+}
+
+func TestHandle_PositionalParams(t *testing.T) {
+	resp := doSingleRequest(t, `{"jsonrpc": "2.0", "method": "echo", "params": ["x", true], "id": 1}`)
+	result, _ := resp["result"].(map[string]interface{})
+	if result["a"] != "x" || result["b"] != true {
+		t.Error("wrong result:", resp["result"])
+	}
+
+	resp = doSingleRequest(t, `{"jsonrpc": "2.0", "method": "echo", "params": ["x", true, 3], "id": 1}`)
+	checkErrorCode(t, resp, errors.RPCInvalidParams)
+
+	resp = doSingleRequest(t, `{"jsonrpc": "2.0", "method": "echo", "params": "x", "id": 1}`)
+	checkErrorCode(t, resp, errors.RPCInvalidParams)
+}
+
+func TestHandle_Errors(t *testing.T) {
+	resp := doSingleRequest(t, `{"jsonrpc": "2.0", "method": "unknown", "id": 1}`)
+	checkErrorCode(t, resp, errors.MethodNotFound)
+	if resp["id"] != float64(1) {
+		t.Error("wrong id:", resp["id"])
+	}
+
+	resp = doSingleRequest(t, `{"jsonrpc": "2.0", "method": "fail", "id": 2}`)
+	checkErrorCode(t, resp, errors.RPCInvalidParams)
+	if e := resp["error"].(map[string]interface{}); e["message"] != "need a parameter named a" {
+		t.Error("wrong error message:", e["message"])
+	}
+
+	resp = doSingleRequest(t, `{"jsonrpc": "2.0", "method": "panic", "id": 3}`)
+	checkErrorCode(t, resp, errors.RPCInternalError)
+}
+
+func TestHandle_Notification(t *testing.T) {
+	w := doRequest(t, `{"jsonrpc": "2.0", "method": "echo", "params": [1]}`)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Error("notification should not be answered:", w.Code, w.Body.String())
+	}
+
+	// errors of notifications are not reported either
+	w = doRequest(t, `{"jsonrpc": "2.0", "method": "unknown"}`)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Error("notification should not be answered:", w.Code, w.Body.String())
+	}
+
+	// a null id is not a notification
+	resp := doSingleRequest(t, `{"jsonrpc": "2.0", "method": "echo", "id": null}`)
+	if _, ok := resp["result"]; !ok || resp["id"] != nil {
+		t.Error("request with null id should be answered:", resp)
+	}
+
+	// JSON-RPC 1.0 requests without id are still answered
+	resp = doSingleRequest(t, `{"method": "echo"}`)
+	if _, ok := resp["result"]; !ok {
+		t.Error("JSON-RPC 1.0 request should be answered:", resp)
+	}
+}
+
+func TestHandle_Batch(t *testing.T) {
+	w := doRequest(t, `[
+		{"jsonrpc": "2.0", "method": "echo", "params": ["x"], "id": 1},
+		{"jsonrpc": "2.0", "method": "echo", "params": ["y"]},
+		{"jsonrpc": "2.0", "method": "unknown", "id": 2},
+		1
+	]`)
+	var resps []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resps); err != nil {
+		t.Fatal("invalid batch response:", w.Body.String())
+	}
+	if len(resps) != 3 {
+		t.Fatal("wrong response count:", len(resps))
+	}
+	result, _ := resps[0]["result"].(map[string]interface{})
+	if resps[0]["id"] != float64(1) || result["a"] != "x" {
+		t.Error("wrong response:", resps[0])
+	}
+	checkErrorCode(t, resps[1], errors.MethodNotFound)
+	checkErrorCode(t, resps[2], errors.InvalidRequest)
+
+	// nothing is returned for a batch of notifications
+	w = doRequest(t, `[{"jsonrpc": "2.0", "method": "echo"}, {"jsonrpc": "2.0", "method": "fail"}]`)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Error("batch of notifications should not be answered:", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,113 @@
+package httpjsonrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/errors"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/servers"
+)
+
+func callWithRole(t *testing.T, user, pass, methodName string) (int, map[string]interface{}) {
+	success := func(params servers.Params) map[string]interface{} {
+		return servers.ResponsePack(errors.Success, "")
+	}
+	mainMux = map[string]method{
+		"read":    {success, nil, servers.RoleReadOnly},
+		"operate": {success, nil, servers.RoleOperator},
+		"admin":   {success, nil, servers.RoleAdmin},
+	}
+
+	r := httptest.NewRequest("POST", "/",
+		strings.NewReader(`{"jsonrpc": "2.0", "method": "`+methodName+`", "id": 1}`))
+	r.RemoteAddr = "127.0.0.1:20336"
+	r.SetBasicAuth(user, pass)
+	w := httptest.NewRecorder()
+	Handle(w, r)
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func TestHandle_Roles(t *testing.T) {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{
+		Users: []config.RpcUser{
+			{User: "reader", Pass: "r", Role: "readonly"},
+			{User: "operator", Pass: "o", Role: "Operator"},
+			{User: "admin", Pass: "a", Role: "admin"},
+			{User: "nobody", Pass: "n", Role: "unknown"},
+		},
+	}
+	defer func() { config.Parameters.RpcConfiguration = config.RpcConfiguration{} }()
+
+	cases := []struct {
+		user, pass string
+		allowed    []string
+		denied     []string
+	}{
+		{"reader", "r", []string{"read"}, []string{"operate", "admin"}},
+		{"operator", "o", []string{"read", "operate"}, []string{"admin"}},
+		{"admin", "a", []string{"read", "operate", "admin"}, nil},
+	}
+	for _, c := range cases {
+		for _, m := range c.allowed {
+			if _, resp := callWithRole(t, c.user, c.pass, m); resp["error"] != nil {
+				t.Error(c.user, "should be allowed to call", m, resp)
+			}
+		}
+		for _, m := range c.denied {
+			_, resp := callWithRole(t, c.user, c.pass, m)
+			checkErrorCode(t, resp, errors.PermissionDenied)
+		}
+	}
+
+	if status, _ := callWithRole(t, "reader", "wrong", "read"); status != http.StatusUnauthorized {
+		t.Error("wrong password should be unauthorized, got:", status)
+	}
+	if status, _ := callWithRole(t, "nobody", "n", "read"); status != http.StatusUnauthorized {
+		t.Error("user with unknown role should be unauthorized, got:", status)
+	}
+}
+
+func TestHandle_LegacyUserIsAdmin(t *testing.T) {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{
+		User:  "ElaUser",
+		Pass:  "Ela123",
+		Users: []config.RpcUser{{User: "reader", Pass: "r", Role: "readonly"}},
+	}
+	defer func() { config.Parameters.RpcConfiguration = config.RpcConfiguration{} }()
+
+	if _, resp := callWithRole(t, "ElaUser", "Ela123", "admin"); resp["error"] != nil {
+		t.Error("legacy user should be admin:", resp)
+	}
+	_, resp := callWithRole(t, "reader", "r", "admin")
+	checkErrorCode(t, resp, errors.PermissionDenied)
+}
+
+func TestHandle_NoCredentialIsNotAdmin(t *testing.T) {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{}
+	initMux()
+	defer func() { mainMux = nil }()
+
+	for _, methodName := range []string{"prunefinishedtxs", "reloadsidechains", "setloglevel"} {
+		r := httptest.NewRequest("POST", "/",
+			strings.NewReader(`{"jsonrpc": "2.0", "method": "`+methodName+`", "params": {"dryrun": false}, "id": 1}`))
+		r.RemoteAddr = "127.0.0.1:20336"
+		w := httptest.NewRecorder()
+		Handle(w, r)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		checkErrorCode(t, resp, errors.PermissionDenied)
+	}
+
+	// the operator methods are kept for the nodes without credentials
+	if caller, ok := servers.Authenticate(httptest.NewRequest("POST", "/", nil)); !ok || caller.Role != servers.RoleOperator {
+		t.Error("caller without credentials should be operator, got", caller)
+	}
+}
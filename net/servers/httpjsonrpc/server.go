@@ -1,44 +1,63 @@
 package httpjsonrpc
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha256"
-	"crypto/subtle"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/errors"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/metrics"
 	"github.com/elastos/Elastos.ELA.Arbiter/net/servers"
 )
 
+// method is a json rpc method which can be called through the server, params
+// holds the parameter names in order, so that positional params can be mapped
+// to named ones, role is the lowest role of the callers allowed.
+type method struct {
+	handler func(servers.Params) map[string]interface{}
+	params  []string
+	role    servers.Role
+}
+
 //an instance of the multiplexer
-var mainMux map[string]func(servers.Params) map[string]interface{}
+var mainMux map[string]method
+
+func initMux() {
+	mainMux = map[string]method{
+		"submitcomplain":          {servers.SubmitComplain, []string{"fromaddress", "transactionhash", "chaingenesisblockhash"}, servers.RoleOperator},
+		"getcomplainstatus":       {servers.GetComplainStatus, []string{"transactionhash"}, servers.RoleReadOnly},
+		"getinfo":                 {servers.GetInfo, nil, servers.RoleReadOnly},
+		"getsidemininginfo":       {servers.GetSideMiningInfo, []string{"hash"}, servers.RoleReadOnly},
+		"getmainchainblockheight": {servers.GetMainChainBlockHeight, nil, servers.RoleReadOnly},
+		"getsidechainblockheight": {servers.GetSideChainBlockHeight, []string{"hash"}, servers.RoleReadOnly},
+		"getfinisheddeposittxs":   {servers.GetFinishedDepositTxs, []string{"succeed", "genesisaddress", "starttime", "endtime", "hashprefix", "cursor", "limit"}, servers.RoleReadOnly},
+		"getfinishedwithdrawtxs":  {servers.GetFinishedWithdrawTxs, []string{"succeed", "starttime", "endtime", "hashprefix", "cursor", "limit"}, servers.RoleReadOnly},
+		"getgitversion":           {servers.GetGitVersion, nil, servers.RoleReadOnly},
+		"getspvheight":            {servers.GetSPVHeight, nil, servers.RoleReadOnly},
+		"getarbiterpeersinfo":     {servers.GetArbiterPeersInfo, nil, servers.RoleReadOnly},
+		"gettransactionstatus":    {servers.GetTransactionStatus, []string{"hash"}, servers.RoleReadOnly},
+		"prunefinishedtxs":        {servers.PruneFinishedTxs, []string{"dryrun"}, servers.RoleAdmin},
+		"reloadsidechains":        {servers.ReloadSideChains, nil, servers.RoleAdmin},
+		"getauditlog":             {servers.GetAuditLog, []string{"count"}, servers.RoleReadOnly},
+		"getloglevels":            {servers.GetLogLevels, nil, servers.RoleReadOnly},
+		"setloglevel":             {servers.SetLogLevel, []string{"module", "level"}, servers.RoleAdmin},
+	}
+}
 
 func StartRPCServer(pServer *http.Server) {
-	mainMux = make(map[string]func(servers.Params) map[string]interface{})
-
-	mainMux["submitcomplain"] = servers.SubmitComplain
-	mainMux["getcomplainstatus"] = servers.GetComplainStatus
-	mainMux["getinfo"] = servers.GetInfo
-	mainMux["getsidemininginfo"] = servers.GetSideMiningInfo
-	mainMux["getmainchainblockheight"] = servers.GetMainChainBlockHeight
-	mainMux["getsidechainblockheight"] = servers.GetSideChainBlockHeight
-	mainMux["getfinisheddeposittxs"] = servers.GetFinishedDepositTxs
-	mainMux["getfinishedwithdrawtxs"] = servers.GetFinishedWithdrawTxs
-	mainMux["getgitversion"] = servers.GetGitVersion
-	mainMux["getspvheight"] = servers.GetSPVHeight
-	mainMux["getarbiterpeersinfo"] = servers.GetArbiterPeersInfo
+	initMux()
 
 	rpcServeMux := http.NewServeMux()
 	rpcServeMux.HandleFunc("/", Handle)
+	rpcServeMux.HandleFunc("/metrics", servers.MetricsHandler)
+	rpcServeMux.HandleFunc("/healthz", servers.HealthzHandler)
+	rpcServeMux.HandleFunc("/readyz", servers.ReadyzHandler)
 	if pServer == nil {
 		pServer = &http.Server{}
 	}
@@ -48,7 +67,7 @@ func StartRPCServer(pServer *http.Server) {
 		pServer.WriteTimeout = 15 * time.Second
 	}
 
-	listerner, err := net.Listen("tcp4", ":"+strconv.Itoa(config.Parameters.HttpJsonPort))
+	listerner, err := servers.Listen(config.Parameters.HttpJsonPort)
 	if err != nil {
 		log.Fatal("Listen error: ", err.Error())
 		return
@@ -70,7 +89,9 @@ func Stop(s *http.Server) error {
 //this is the funciton that should be called in order to answer an rpc call
 //should be registered like "http.AddMethod("/", httpjsonrpc.Handle)"
 func Handle(w http.ResponseWriter, r *http.Request) {
-	isClientAllowed := clientAllowed(r)
+	defer metrics.HTTPDuration.With("jsonrpc", "/").ObserveSince(time.Now())
+
+	isClientAllowed := servers.ClientAllowed(r)
 	if !isClientAllowed {
 		log.Warn("HTTP Client ip is not allowd")
 		http.Error(w, "Client ip is not allowd", http.StatusForbidden)
@@ -83,169 +104,216 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	//check if there is Request Body to read
-	if r.Body == nil {
-		log.Warn("HTTP JSON RPC Handle - Request body is nil")
-		return
-	}
-
-	isCheckAuthOk := checkAuth(r)
+	caller, isCheckAuthOk := servers.Authenticate(r)
 	if !isCheckAuthOk {
 		//log.Warn("client authenticate failed")
 		http.Error(w, "client authenticate failed", http.StatusUnauthorized)
 		return
 	}
 
+	//check if there is Request Body to read
+	if r.Body == nil {
+		log.Warn("HTTP JSON RPC Handle - Request body is nil")
+		writeResponse(w, errorResponse(nil, errors.ParseError, ""))
+		return
+	}
+
 	//read the body of the request
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		log.Error("HTTP JSON RPC Handle - ioutil.ReadAll: ", err)
+		writeResponse(w, errorResponse(nil, errors.ParseError, ""))
 		return
 	}
-	request := make(map[string]interface{})
-	err = json.Unmarshal(body, &request)
-	if err != nil {
-		log.Error("HTTP JSON RPC Handle - json.Unmarshal: ", err)
+	body = bytes.TrimSpace(body)
+	if !json.Valid(body) {
+		log.Warn("HTTP JSON RPC Handle - invalid json: ", string(body))
+		writeResponse(w, errorResponse(nil, errors.ParseError, ""))
 		return
 	}
 
-	//get the corresponding function
-	function, method, ok := checkMethod(request)
-
-	if !ok {
-		Error(w, errors.InvalidMethod, method)
+	if body[0] != '[' {
+		response := handleRequest(caller, body)
+		if response == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeResponse(w, response)
 		return
 	}
 
-	params, ok := checkParams(request)
-	if !ok {
-		Error(w, errors.InvalidParams, method)
+	//batch request, an empty array is an invalid request
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+		writeResponse(w, errorResponse(nil, errors.InvalidRequest, ""))
 		return
 	}
-
-	response := function(params)
-	var data []byte
-	if response["Error"] != errors.ErrCode(0) {
-		data, _ = json.Marshal(map[string]interface{}{
-			"jsonrpc": "2.0",
-			"result":  nil,
-			"error": map[string]interface{}{
-				"code":    response["Error"],
-				"message": response["Result"],
-				"id":      request["id"],
-			},
-		})
-
-	} else {
-		data, _ = json.Marshal(map[string]interface{}{
-			"jsonrpc": "2.0",
-			"result":  response["Result"],
-			"id":      request["id"],
-			"error":   nil,
-		})
+	responses := make([]map[string]interface{}, 0, len(batch))
+	for _, raw := range batch {
+		if response := handleRequest(caller, raw); response != nil {
+			responses = append(responses, response)
+		}
 	}
-	w.Header().Set("Content-type", "application/json")
-	w.Write(data)
+	//nothing should be returned if all requests are notifications
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeResponse(w, responses)
 }
 
-func checkAuth(r *http.Request) bool {
-	tempRpcConf := config.Parameters.RpcConfiguration
-	if (tempRpcConf.User == tempRpcConf.Pass) && (len(tempRpcConf.User) == 0) {
-		return true
-	}
-	authHeader := r.Header["Authorization"]
-	if len(authHeader) <= 0 {
-		return false
+// handleRequest answers a single json rpc request, nil will be returned if
+// the request is a notification.
+func handleRequest(caller *servers.Caller, raw json.RawMessage) map[string]interface{} {
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return errorResponse(nil, errors.InvalidRequest, "")
 	}
 
-	authSha256 := sha256.Sum256([]byte(authHeader[0]))
+	id, hasID := request["id"]
+	if hasID && !validID(id) {
+		return errorResponse(nil, errors.InvalidRequest, "invalid id")
+	}
+	if !hasID {
+		id = nil
+	}
 
-	login := tempRpcConf.User + ":" + tempRpcConf.Pass
-	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-	cfgAuthSha256 := sha256.Sum256([]byte(auth))
+	//"jsonrpc" is absent in JSON-RPC 1.0 requests, which are always answered
+	var version string
+	if v, ok := request["jsonrpc"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil || version != "2.0" {
+			return errorResponse(id, errors.InvalidRequest, "invalid jsonrpc version")
+		}
+	}
+	isNotification := version == "2.0" && !hasID
 
-	resultCmp := subtle.ConstantTimeCompare(authSha256[:], cfgAuthSha256[:])
-	if resultCmp == 1 {
-		return true
+	var methodName string
+	if err := json.Unmarshal(request["method"], &methodName); err != nil {
+		return errorResponse(id, errors.InvalidRequest, "invalid method")
 	}
 
-	// Request's auth doesn't match  user
-	return false
+	response := callMethod(caller, methodName, request["params"])
+	if isNotification {
+		return nil
+	}
+	if code := response["Error"].(errors.ErrCode); code != errors.Success {
+		message, _ := response["Result"].(string)
+		return errorResponse(id, code, message)
+	}
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"result":  response["Result"],
+	}
 }
 
-func clientAllowed(r *http.Request) bool {
-	log.Debugf("clientAllowed RpcConfiguration %v", config.Parameters.RpcConfiguration)
-	//this ipAbbr  may be  ::1 when request is localhost
-	ipAbbr, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		log.Errorf("RemoteAddr clientAllowed SplitHostPort failure %s \n", r.RemoteAddr)
-		return false
+func callMethod(caller *servers.Caller, methodName string, rawParams json.RawMessage) (response map[string]interface{}) {
+	m, ok := mainMux[methodName]
+	if !ok {
+		log.Warn("HTTP JSON RPC Handle - No function to call for ", methodName)
+		return servers.ResponsePack(errors.MethodNotFound, "")
+	}
 
+	// admin calls are audited whether they succeed or not, denied calls and
+	// calls with bad params included
+	if m.role == servers.RoleAdmin {
+		defer func() {
+			log.Audit().Infow("rpc call", "user", caller.User, "role", caller.Role, "remote", caller.RemoteAddr,
+				"method", methodName, "params", string(rawParams), "result", response["Error"])
+		}()
 	}
-	//after ParseIP ::1 chg to 0:0:0:0:0:0:0:1 the true ip
-	remoteIp := net.ParseIP(ipAbbr)
 
-	if remoteIp == nil {
-		log.Errorf("clientAllowed ParseIP ipAbbr %s failure  \n", ipAbbr)
-		return false
+	defer metrics.RPCDuration.With(methodName).ObserveSince(time.Now())
+
+	if caller.Role < m.role {
+		log.Warnf("HTTP JSON RPC Handle - %s denied for user %q with role %s from %s",
+			methodName, caller.User, caller.Role, caller.RemoteAddr)
+		return servers.ResponsePack(errors.PermissionDenied, "")
 	}
 
-	if remoteIp.IsLoopback() {
-		//log.Debugf("remoteIp %s IsLoopback\n", remoteIp)
-		return true
+	params, ok := checkParams(rawParams, m.params)
+	if !ok {
+		return servers.ResponsePack(errors.InvalidParams, "")
 	}
 
-	for _, cfgIp := range config.Parameters.RpcConfiguration.WhiteIPList {
-		//WhiteIPList have 0.0.0.0  allow all ip in
-		if cfgIp == "0.0.0.0" {
-			return true
-		}
-		if cfgIp == remoteIp.String() {
-			return true
+	defer func() {
+		if err := recover(); err != nil {
+			log.Error("HTTP JSON RPC Handle - ", methodName, " panic: ", err)
+			response = servers.ResponsePack(errors.InternalError, "")
 		}
+	}()
+	return m.handler(params)
+}
 
+// checkParams accepts params by name as an object, or by position as an
+// array which will be mapped to the parameter names of the method.
+func checkParams(rawParams json.RawMessage, names []string) (servers.Params, bool) {
+	var params interface{}
+	if len(rawParams) > 0 {
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return nil, false
+		}
+	}
+	switch p := params.(type) {
+	case nil:
+		return servers.Params{}, true
+	case map[string]interface{}:
+		return p, true
+	case []interface{}:
+		if len(p) > len(names) {
+			return nil, false
+		}
+		return servers.FromArray(p, names...), true
+	default:
+		return nil, false
 	}
-	return false
 }
 
-func checkMethod(request map[string]interface{}) (func(servers.Params) map[string]interface{}, interface{}, bool) {
-	method := request["method"]
-	if method == nil {
-		return nil, method, false
+// validID checks the id is a string, a number or null
+func validID(id json.RawMessage) bool {
+	var value interface{}
+	if err := json.Unmarshal(id, &value); err != nil {
+		return false
 	}
-	switch method.(type) {
-	case string:
+	switch value.(type) {
+	case nil, string, float64:
+		return true
 	default:
-		return nil, method, false
-	}
-	function, ok := mainMux[request["method"].(string)]
-	if !ok {
-		return nil, method, false
+		return false
 	}
-	return function, nil, true
 }
 
-func checkParams(request map[string]interface{}) (map[string]interface{}, bool) {
-	params := request["params"]
-	if params == nil {
-		return map[string]interface{}{}, true
+// jsonrpcCodes maps the arbiter error codes which have an equivalent in the
+// json rpc 2.0 specification to the standard codes.
+var jsonrpcCodes = map[errors.ErrCode]errors.ErrCode{
+	errors.InvalidParams: errors.RPCInvalidParams,
+	errors.InternalError: errors.RPCInternalError,
+}
+
+func errorResponse(id json.RawMessage, code errors.ErrCode, message string) map[string]interface{} {
+	if message == "" {
+		message = code.Message()
 	}
-	switch params.(type) {
-	case map[string]interface{}:
-		return params.(map[string]interface{}), true
-	default:
-		return nil, false
+	if c, ok := jsonrpcCodes[code]; ok {
+		code = c
+	}
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
 	}
-	return nil, false
 }
 
-func Error(w http.ResponseWriter, code errors.ErrCode, method interface{}) {
-	//if the function does not exist
-	log.Warn("HTTP JSON RPC Handle - No function to call for ", method)
-	data, _ := json.Marshal(map[string]interface{}{
-		"jsonpc": "2.0",
-		"code":   code,
-		"result": code.Message(),
-	})
+func writeResponse(w http.ResponseWriter, response interface{}) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-type", "application/json")
 	w.Write(data)
 }
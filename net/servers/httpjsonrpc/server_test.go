@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/servers"
 	"github.com/elastos/Elastos.ELA/utils/test"
 )
 
@@ -46,12 +50,18 @@ func isRunServer() bool {
 	return bRunServer
 }
 func init() {
-
-	log.Init("./Elastos", 1, 0, 0)
 	initUrl()
 	initReqObject()
 }
 
+func TestMain(m *testing.M) {
+	logDir, _ := ioutil.TempDir("", "arbiter-jsonrpc-test")
+	log.Init(filepath.Join(logDir, "Elastos"), 1, 0, 0)
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
 func InitNewServer(conf config.RpcConfiguration) {
 	pServer = new(http.Server)
 	InitConf(conf)
@@ -168,6 +178,7 @@ func Wait() {
 }
 func InitConf(conf config.RpcConfiguration) {
 	config.Parameters.RpcConfiguration = conf
+	servers.LoadClientFilter(conf)
 }
 func TestServer_NotInitRpcConf(t *testing.T) {
 
@@ -0,0 +1,190 @@
+package httprestful
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/errors"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/metrics"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/servers"
+)
+
+const ApiPrefix = "/api/v1"
+
+// route maps a resource path to a handler, path segments starting with ":"
+// are captured as named params, queries are the query-string filters passed
+// to the handler as strings.
+type route struct {
+	path     string
+	segments []string
+	handler  func(servers.Params) map[string]interface{}
+	queries  []string
+}
+
+var routes = []route{
+	newRoute("/info", servers.GetInfo),
+	newRoute("/version", servers.GetGitVersion),
+	newRoute("/mainchain/height", servers.GetMainChainBlockHeight),
+	newRoute("/sidechains/:hash/height", servers.GetSideChainBlockHeight),
+	newRoute("/sidechains/:hash/mininginfo", servers.GetSideMiningInfo),
+	newRoute("/spv/height", servers.GetSPVHeight),
+	newRoute("/deposits", servers.GetFinishedDepositTxs, "genesisaddress", "succeed",
+		"starttime", "endtime", "hashprefix", "cursor", "limit"),
+	newRoute("/withdraws", servers.GetFinishedWithdrawTxs, "succeed",
+		"starttime", "endtime", "hashprefix", "cursor", "limit"),
+	newRoute("/peers", servers.GetArbiterPeersInfo),
+	newRoute("/complains/:transactionhash", servers.GetComplainStatus),
+	newRoute("/transactions/:hash", servers.GetTransactionStatus),
+}
+
+func newRoute(path string, handler func(servers.Params) map[string]interface{}, queries ...string) route {
+	return route{
+		path:     ApiPrefix + path,
+		segments: splitPath(ApiPrefix + path),
+		handler:  handler,
+		queries:  queries,
+	}
+}
+
+// match returns the params captured from the path if it matches the route.
+func (r route) match(segments []string) (servers.Params, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+	params := make(servers.Params)
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, ":") {
+			params[segment[1:]] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func StartRESTServer(pServer *http.Server) {
+	restServeMux := http.NewServeMux()
+	restServeMux.HandleFunc("/", Handle)
+	restServeMux.HandleFunc("/metrics", servers.MetricsHandler)
+	restServeMux.HandleFunc("/healthz", servers.HealthzHandler)
+	restServeMux.HandleFunc("/readyz", servers.ReadyzHandler)
+	if pServer == nil {
+		pServer = &http.Server{}
+	}
+	pServer.Handler = restServeMux
+	pServer.ReadTimeout = 15 * time.Second
+	pServer.WriteTimeout = 15 * time.Second
+
+	listener, err := servers.Listen(int(config.Parameters.HttpRestPort))
+	if err != nil {
+		log.Fatal("Listen error: ", err.Error())
+		return
+	}
+	err = pServer.Serve(listener)
+	if err != nil {
+		log.Warnf("StartRESTServer : %v", err.Error())
+	}
+}
+
+func Stop(s *http.Server) error {
+	if s != nil {
+		return s.Shutdown(context.Background())
+	}
+	return fmt.Errorf("server not started")
+}
+
+// Handle answers the resource requests, it should be registered like
+// "http.HandleFunc("/", httprestful.Handle)"
+func Handle(w http.ResponseWriter, r *http.Request) {
+	if !servers.ClientAllowed(r) {
+		log.Warn("HTTP Client ip is not allowd")
+		http.Error(w, "Client ip is not allowd", http.StatusForbidden)
+		return
+	}
+	if !servers.CheckAuth(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="arbiter"`)
+		http.Error(w, "client authenticate failed", http.StatusUnauthorized)
+		return
+	}
+
+	segments := splitPath(r.URL.Path)
+	for _, rt := range routes {
+		params, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		defer metrics.HTTPDuration.With("rest", rt.path).ObserveSince(time.Now())
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeResponse(w, http.StatusMethodNotAllowed,
+				servers.ResponsePack(errors.InvalidMethod, "REST API only allows GET method"))
+			return
+		}
+		parseQueries(r, rt, params)
+		response := callHandler(rt, params)
+		writeResponse(w, httpStatus(response["Error"].(errors.ErrCode)), response)
+		return
+	}
+	writeResponse(w, http.StatusNotFound, servers.ResponsePack(errors.InvalidMethod, "resource not found"))
+}
+
+func parseQueries(r *http.Request, rt route, params servers.Params) {
+	query := r.URL.Query()
+	for _, key := range rt.queries {
+		if value := query.Get(key); value != "" {
+			params[key] = value
+		}
+	}
+}
+
+func callHandler(rt route, params servers.Params) (response map[string]interface{}) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Error("HTTP REST Handle - panic: ", err)
+			response = servers.ResponsePack(errors.InternalError, "")
+		}
+	}()
+	return rt.handler(params)
+}
+
+func httpStatus(code errors.ErrCode) int {
+	switch code {
+	case errors.Success:
+		return http.StatusOK
+	case errors.InvalidParams, errors.InvalidTransaction:
+		return http.StatusBadRequest
+	case errors.UnknownTransaction, errors.UnknownBlock:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeResponse(w http.ResponseWriter, status int, response map[string]interface{}) {
+	code := response["Error"].(errors.ErrCode)
+	data, err := json.Marshal(map[string]interface{}{
+		"Desc":   code.Message(),
+		"Error":  code,
+		"Result": response["Result"],
+	})
+	if err != nil {
+		log.Error("HTTP REST Handle - json.Marshal: ", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
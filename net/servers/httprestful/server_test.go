@@ -0,0 +1,117 @@
+package httprestful
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/errors"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/servers"
+)
+
+func TestMain(m *testing.M) {
+	logDir, _ := ioutil.TempDir("", "arbiter-rest-test")
+	log.Init(filepath.Join(logDir, "logs"), 5, 0, 0)
+	if config.Parameters.Configuration == nil {
+		config.Parameters.Configuration = &config.Configuration{}
+	}
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+func doRequest(t *testing.T, method, url, remoteAddr string, auth bool) (int, map[string]interface{}) {
+	r := httptest.NewRequest(method, url, nil)
+	r.RemoteAddr = remoteAddr
+	if auth {
+		r.SetBasicAuth("ElaUser", "Ela123")
+	}
+	w := httptest.NewRecorder()
+	Handle(w, r)
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func TestHandle(t *testing.T) {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{}
+	config.Parameters.Version = 7
+
+	status, resp := doRequest(t, "GET", ApiPrefix+"/info", "127.0.0.1:1000", false)
+	if status != http.StatusOK {
+		t.Fatal("unexpected status:", status)
+	}
+	result, _ := resp["Result"].(map[string]interface{})
+	if resp["Error"] != float64(errors.Success) || result["version"] != float64(7) {
+		t.Error("wrong info response:", resp)
+	}
+
+	status, _ = doRequest(t, "GET", ApiPrefix+"/unknown", "127.0.0.1:1000", false)
+	if status != http.StatusNotFound {
+		t.Error("expect not found, got:", status)
+	}
+
+	status, _ = doRequest(t, "POST", ApiPrefix+"/info", "127.0.0.1:1000", false)
+	if status != http.StatusMethodNotAllowed {
+		t.Error("expect method not allowed, got:", status)
+	}
+
+	status, resp = doRequest(t, "GET", ApiPrefix+"/sidechains/zz/height", "127.0.0.1:1000", false)
+	if status != http.StatusBadRequest || resp["Error"] != float64(errors.InvalidParams) {
+		t.Error("expect bad request, got:", status, resp)
+	}
+
+	status, _ = doRequest(t, "GET", ApiPrefix+"/withdraws?succeed=maybe", "127.0.0.1:1000", false)
+	if status != http.StatusBadRequest {
+		t.Error("expect bad request, got:", status)
+	}
+}
+
+func TestHandle_AuthAndWhiteList(t *testing.T) {
+	config.Parameters.RpcConfiguration = config.RpcConfiguration{
+		User:        "ElaUser",
+		Pass:        "Ela123",
+		WhiteIPList: []string{"192.168.0.2"},
+	}
+	if err := servers.LoadClientFilter(config.Parameters.RpcConfiguration); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		config.Parameters.RpcConfiguration = config.RpcConfiguration{}
+		servers.LoadClientFilter(config.Parameters.RpcConfiguration)
+	}()
+
+	if status, _ := doRequest(t, "GET", ApiPrefix+"/version", "127.0.0.1:1000", false); status != http.StatusUnauthorized {
+		t.Error("expect unauthorized, got:", status)
+	}
+	if status, _ := doRequest(t, "GET", ApiPrefix+"/version", "127.0.0.1:1000", true); status != http.StatusOK {
+		t.Error("expect ok, got:", status)
+	}
+	if status, _ := doRequest(t, "GET", ApiPrefix+"/version", "192.168.0.2:1000", true); status != http.StatusOK {
+		t.Error("expect ok, got:", status)
+	}
+	if status, _ := doRequest(t, "GET", ApiPrefix+"/version", "192.168.0.3:1000", true); status != http.StatusForbidden {
+		t.Error("expect forbidden, got:", status)
+	}
+}
+
+func TestRoute_Match(t *testing.T) {
+	rt := newRoute("/sidechains/:hash/height", nil)
+	params, ok := rt.match(splitPath(ApiPrefix + "/sidechains/abcd/height/"))
+	if !ok || params["hash"] != "abcd" {
+		t.Error("route should match:", params)
+	}
+	if _, ok := rt.match(splitPath(ApiPrefix + "/sidechains/abcd")); ok {
+		t.Error("route should not match")
+	}
+	if _, ok := rt.match(splitPath(ApiPrefix + "/sidechains/abcd/mininginfo")); ok {
+		t.Error("route should not match")
+	}
+}
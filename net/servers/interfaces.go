@@ -1,15 +1,21 @@
 package servers
 
 import (
+	"context"
 	"encoding/hex"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/complain"
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/cs"
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/sidechain"
+	"github.com/elastos/Elastos.ELA.Arbiter/audit"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/errors"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
 	"github.com/elastos/Elastos.ELA.Arbiter/sideauxpow"
 	"github.com/elastos/Elastos.ELA.Arbiter/store"
 
@@ -94,6 +100,7 @@ func GetInfo(param Params) map[string]interface{} {
 		SideAuxPowFee                int           `json:"SideAuxPowFee"`
 		MinThreshold                 int           `json:"MinThreshold"`
 		DepositAmount                int           `json:"DepositAmount"`
+		RpcTLS                       rpcTLSInfo    `json:"RpcTLS"`
 	}{
 		Version:                      config.Parameters.Version,
 		SideChainMonitorScanInterval: config.Parameters.SideChainMonitorScanInterval,
@@ -103,10 +110,29 @@ func GetInfo(param Params) map[string]interface{} {
 		SideAuxPowFee:                config.Parameters.SideAuxPowFee,
 		MinThreshold:                 config.Parameters.MinThreshold,
 		DepositAmount:                config.Parameters.DepositAmount,
+		RpcTLS:                       getRpcTLSInfo(),
 	}
 	return ResponsePack(errors.Success, &Info)
 }
 
+type rpcTLSInfo struct {
+	Enabled    bool   `json:"Enabled"`
+	MinVersion string `json:"MinVersion"`
+	ClientAuth bool   `json:"ClientAuth"`
+}
+
+func getRpcTLSInfo() rpcTLSInfo {
+	tlsConf := config.Parameters.RpcConfiguration.TLS
+	if !TLSEnabled(tlsConf) {
+		return rpcTLSInfo{}
+	}
+	return rpcTLSInfo{
+		Enabled:    true,
+		MinVersion: TLSMinVersion(tlsConf),
+		ClientAuth: tlsConf.ClientCAFile != "",
+	}
+}
+
 func GetSideMiningInfo(param Params) map[string]interface{} {
 	genesisBlockHashStr, ok := param.String("hash")
 	if !ok {
@@ -147,7 +173,7 @@ func GetSideMiningInfo(param Params) map[string]interface{} {
 }
 
 func GetMainChainBlockHeight(param Params) map[string]interface{} {
-	return ResponsePack(errors.Success, store.DbCache.MainChainStore.CurrentHeight(0))
+	return ResponsePack(errors.Success, store.DbCache.MainChainStore.CurrentHeight(context.Background(), 0))
 }
 
 func GetSideChainBlockHeight(param Params) map[string]interface{} {
@@ -170,31 +196,40 @@ func GetSideChainBlockHeight(param Params) map[string]interface{} {
 		return ResponsePack(errors.InvalidParams, "invalid genesis block hash")
 	}
 
-	return ResponsePack(errors.Success, store.DbCache.SideChainStore.CurrentSideHeight(address, 0))
+	return ResponsePack(errors.Success, store.DbCache.SideChainStore.CurrentSideHeight(context.Background(), address, 0))
 }
 
 func GetFinishedDepositTxs(param Params) map[string]interface{} {
-	succeed, ok := param.Bool("succeed")
-	if !ok {
-		return ResponsePack(errors.InvalidParams, "need a bool parameter named succeed")
+	query, err := parseFinishedTxsQuery(param)
+	if err != nil {
+		return ResponsePack(errors.InvalidParams, err.Error())
 	}
-	txHashes, genesisAddresses, err := store.FinishedTxsDbCache.GetDepositTxs(succeed)
+	query.GenesisBlockAddress, _ = param.String("genesisaddress")
+	txs, nextCursor, err := store.FinishedTxsDbCache.QueryDepositTxs(context.Background(), query)
 	if err != nil {
-		return ResponsePack(errors.InvalidParams, "get deposit transactions from finished dbcache failed")
+		return ResponsePack(errors.InvalidParams, "get deposit transactions from finished dbcache failed: "+err.Error())
 	}
 	type depositTx struct {
 		Hash                string
 		GenesisBlockAddress string
+		Succeed             bool
+		RecordTime          string
 	}
 	depositTxs := struct {
 		Transactions []depositTx
-	}{}
+		NextCursor   string
+	}{
+		Transactions: make([]depositTx, 0, len(txs)),
+		NextCursor:   nextCursor,
+	}
 
-	for i := 0; i < len(txHashes); i++ {
+	for _, tx := range txs {
 		depositTxs.Transactions = append(depositTxs.Transactions,
 			depositTx{
-				Hash:                txHashes[i],
-				GenesisBlockAddress: genesisAddresses[i],
+				Hash:                tx.TransactionHash,
+				GenesisBlockAddress: tx.GenesisBlockAddress,
+				Succeed:             tx.Succeed,
+				RecordTime:          tx.RecordTime,
 			})
 	}
 
@@ -202,23 +237,133 @@ func GetFinishedDepositTxs(param Params) map[string]interface{} {
 }
 
 func GetFinishedWithdrawTxs(param Params) map[string]interface{} {
-	succeed, ok := param.Bool("succeed")
-	if !ok {
-		return ResponsePack(errors.InvalidParams, "need a bool parameter named succeed")
+	query, err := parseFinishedTxsQuery(param)
+	if err != nil {
+		return ResponsePack(errors.InvalidParams, err.Error())
 	}
-	txHashes, err := store.FinishedTxsDbCache.GetWithdrawTxs(succeed)
+	txs, nextCursor, err := store.FinishedTxsDbCache.QueryWithdrawTxs(context.Background(), query)
 	if err != nil {
-		return ResponsePack(errors.InvalidParams, "get withdraw transactions from finished dbcache failed")
+		return ResponsePack(errors.InvalidParams, "get withdraw transactions from finished dbcache failed: "+err.Error())
+	}
+	type withdrawTx struct {
+		Hash       string
+		Succeed    bool
+		RecordTime string
+	}
+	withdrawTxs := struct {
+		Transactions []withdrawTx
+		NextCursor   string
+	}{
+		Transactions: make([]withdrawTx, 0, len(txs)),
+		NextCursor:   nextCursor,
 	}
-	withdrawTxs := struct{ Transactions []string }{}
 
-	for _, hash := range txHashes {
-		withdrawTxs.Transactions = append(withdrawTxs.Transactions, hash)
+	for _, tx := range txs {
+		withdrawTxs.Transactions = append(withdrawTxs.Transactions,
+			withdrawTx{
+				Hash:       tx.TransactionHash,
+				Succeed:    tx.Succeed,
+				RecordTime: tx.RecordTime,
+			})
 	}
 
 	return ResponsePack(errors.Success, &withdrawTxs)
 }
 
+// PruneFinishedTxs prunes the finished transactions by the retention
+// configuration, it is a dry run unless dryrun is false.
+func PruneFinishedTxs(param Params) map[string]interface{} {
+	dryRun := true
+	if _, ok := param["dryrun"]; ok {
+		if dryRun, ok = param.Bool("dryrun"); !ok {
+			return ResponsePack(errors.InvalidParams, "dryrun should be a boolean")
+		}
+	}
+	retention := config.Parameters.FinishedTxsRetention
+	if retention.SucceedDays <= 0 && retention.FailedDays <= 0 {
+		return ResponsePack(errors.InvalidParams, "retention of finished transactions is not configured")
+	}
+
+	policy := store.NewPrunePolicy(retention, time.Now())
+	policy.DryRun = dryRun
+	report, err := store.FinishedTxsDbCache.Prune(context.Background(), policy)
+	if err != nil {
+		return ResponsePack(errors.InternalError, "prune finished transactions failed: "+err.Error())
+	}
+	return ResponsePack(errors.Success, report)
+}
+
+// ReloadSideChains reloads the side chains from the configuration, like
+// SIGHUP does.
+func ReloadSideChains(param Params) map[string]interface{} {
+	result, err := sidechain.Reload()
+	if err != nil {
+		return ResponsePack(errors.InternalError, "reload side chains failed: "+err.Error())
+	}
+	return ResponsePack(errors.Success, result)
+}
+
+const (
+	DefaultAuditLogCount = 20
+	MaxAuditLogCount     = 1000
+)
+
+// GetAuditLog returns the latest entries of the audit log of the signatures,
+// the latest first.
+func GetAuditLog(param Params) map[string]interface{} {
+	count := int64(DefaultAuditLogCount)
+	if _, ok := param["count"]; ok {
+		if count, ok = param.Int("count"); !ok || count <= 0 || count > MaxAuditLogCount {
+			return ResponsePack(errors.InvalidParams,
+				fmt.Sprintf("count should be between 1 and %d", MaxAuditLogCount))
+		}
+	}
+	entries, err := audit.Recent(int(count))
+	if err != nil {
+		return ResponsePack(errors.InternalError, "get audit log failed: "+err.Error())
+	}
+	return ResponsePack(errors.Success, entries)
+}
+
+// GetLogLevels returns the print levels of the log modules, the modules not
+// set return the level of the root logger.
+func GetLogLevels(param Params) map[string]interface{} {
+	levels := make(map[string]uint8)
+	for _, name := range log.Modules() {
+		level, err := log.ModuleLevel(name)
+		if err != nil {
+			return ResponsePack(errors.InternalError, "get log levels failed: "+err.Error())
+		}
+		levels[name] = level
+	}
+	return ResponsePack(errors.Success, levels)
+}
+
+// SetLogLevel sets the print level of a log module at runtime, the root
+// module sets the level of the modules not set. The level LevelInherit makes
+// a module follow the root module again.
+func SetLogLevel(param Params) map[string]interface{} {
+	module, ok := param.String("module")
+	if !ok {
+		return ResponsePack(errors.InvalidParams, "module should be a string")
+	}
+	level, ok := param.Int("level")
+	if !ok || level < log.LevelInherit || level > math.MaxUint8 {
+		return ResponsePack(errors.InvalidParams, "level should be a number")
+	}
+	var err error
+	if level == log.LevelInherit {
+		err = log.InheritModuleLevel(module)
+	} else {
+		err = log.SetModuleLevel(module, uint8(level))
+	}
+	if err != nil {
+		return ResponsePack(errors.InvalidParams, err.Error())
+	}
+	log.Infof("log level of %s is set to %d", module, level)
+	return ResponsePack(errors.Success, true)
+}
+
 func GetGitVersion(param Params) map[string]interface{} {
 	return ResponsePack(errors.Success, config.Version)
 }
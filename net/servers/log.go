@@ -0,0 +1,6 @@
+package servers
+
+import "github.com/elastos/Elastos.ELA.Arbiter/log"
+
+// logger is the module logger of the package.
+var logger = log.Module(log.ModuleServers)
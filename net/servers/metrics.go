@@ -0,0 +1,72 @@
+package servers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/cs"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/metrics"
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
+
+	"github.com/elastos/Elastos.ELA/dpos/p2p"
+)
+
+// MetricsHandler serves the metrics in the prometheus text format, it follows
+// the same auth and whitelist rules as the rpc servers.
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if !ClientAllowed(r) {
+		http.Error(w, "Client ip is not allowd", http.StatusForbidden)
+		return
+	}
+	if !CheckAuth(r) {
+		http.Error(w, "client authenticate failed", http.StatusUnauthorized)
+		return
+	}
+	collectMetrics()
+	metrics.DefaultRegistry.Handler().ServeHTTP(w, r)
+}
+
+// collectMetrics updates the gauges which are cheap to read from local
+// states, so they are always fresh when scraped.
+func collectMetrics() {
+	if store.DbCache.MainChainStore != nil {
+		if count, err := store.DbCache.MainChainStore.GetMainChainTxsCount(context.Background()); err == nil {
+			metrics.PendingMainChainTxs.Set(float64(count))
+		} else {
+			logger.Warnw("count main chain txs failed", log.KeyError, err)
+		}
+	}
+	if store.DbCache.SideChainStore != nil {
+		if count, err := store.DbCache.SideChainStore.GetSideChainTxsCount(context.Background()); err == nil {
+			metrics.PendingSideChainTxs.Set(float64(count))
+		} else {
+			logger.Warnw("count side chain txs failed", log.KeyError, err)
+		}
+	}
+
+	var mainHeight uint32
+	if arbitrator.ArbitratorGroupSingleton != nil {
+		mainHeight = arbitrator.ArbitratorGroupSingleton.GetCurrentHeight()
+		metrics.MainChainHeight.Set(float64(mainHeight))
+		if current := arbitrator.ArbitratorGroupSingleton.GetCurrentArbitrator(); current != nil {
+			metrics.OnDuty.SetBool(current.IsOnDutyOfMain())
+		}
+	}
+	if arbitrator.SpvService != nil {
+		if best, err := arbitrator.SpvService.HeaderStore().GetBest(); err == nil {
+			metrics.SPVHeight.Set(float64(best.Height))
+			metrics.SPVLag.Set(float64(mainHeight) - float64(best.Height))
+		}
+	}
+	if cs.P2PClientSingleton != nil {
+		var connected int
+		for _, peer := range cs.P2PClientSingleton.DumpArbiterPeersInfo() {
+			if peer.State != p2p.CSNoneConnection {
+				connected++
+			}
+		}
+		metrics.ConnectedPeers.Set(float64(connected))
+	}
+}
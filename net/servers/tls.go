@@ -0,0 +1,98 @@
+package servers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// DefaultTLSMinVersion is used if MinVersion is not set in the TLS config.
+const DefaultTLSMinVersion = "1.2"
+
+// TLSEnabled returns if the rpc servers should serve with TLS.
+func TLSEnabled(conf config.RpcTLSConfiguration) bool {
+	return conf.CertFile != "" || conf.KeyFile != ""
+}
+
+// TLSMinVersion returns the minimum TLS version of the config.
+func TLSMinVersion(conf config.RpcTLSConfiguration) string {
+	if conf.MinVersion == "" {
+		return DefaultTLSMinVersion
+	}
+	return conf.MinVersion
+}
+
+// NewTLSConfig creates the TLS config of the rpc servers, client
+// certificates are required and verified if ClientCAFile is set.
+func NewTLSConfig(conf config.RpcTLSConfiguration) (*tls.Config, error) {
+	if conf.CertFile == "" || conf.KeyFile == "" {
+		return nil, errors.New("both CertFile and KeyFile are needed by TLS")
+	}
+	minVersion, ok := tlsVersions[TLSMinVersion(conf)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TLS MinVersion %q", conf.MinVersion)
+	}
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS certificate failed: %v", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   minVersion,
+	}
+	if conf.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(conf.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read TLS ClientCAFile failed: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in TLS ClientCAFile %s", conf.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// Listen listens on the port of both IPv4 and IPv6, the listener is wrapped
+// with TLS if it is enabled in RpcConfiguration.
+func Listen(port int) (net.Listener, error) {
+	return ListenAddress(":" + strconv.Itoa(port))
+}
+
+// ListenAddress listens on the address, the listener is wrapped with TLS if
+// it is enabled in RpcConfiguration.
+func ListenAddress(address string) (net.Listener, error) {
+	tlsConf := config.Parameters.RpcConfiguration.TLS
+	var tlsConfig *tls.Config
+	if TLSEnabled(tlsConf) {
+		var err error
+		if tlsConfig, err = NewTLSConfig(tlsConf); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		return tls.NewListener(listener, tlsConfig), nil
+	}
+	return listener, nil
+}
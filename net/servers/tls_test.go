@@ -0,0 +1,168 @@
+package servers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, name string, isCA bool, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	cert, _ := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	return cert
+}
+
+// startTLSServer starts a server with the TLS config and returns its port.
+func startTLSServer(t *testing.T, conf config.RpcTLSConfiguration) (*http.Server, int) {
+	if config.Parameters.Configuration == nil {
+		config.Parameters.Configuration = &config.Configuration{}
+	}
+	config.Parameters.RpcConfiguration.TLS = conf
+	listener, err := Listen(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})}
+	go server.Serve(listener)
+	return server, listener.Addr().(*net.TCPAddr).Port
+}
+
+func get(host string, port int, ca *testCert, client *testCert, maxVersion uint16) error {
+	tlsConfig := &tls.Config{RootCAs: x509.NewCertPool(), MaxVersion: maxVersion}
+	tlsConfig.RootCAs.AddCert(ca.cert)
+	if client != nil {
+		tlsConfig.Certificates = []tls.Certificate{client.tlsCertificate()}
+	}
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	resp, err := httpClient.Get("https://" + net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestListen_TLS(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "arbiter-tls-test")
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", true, nil)
+	serverCert := newTestCert(t, "server", false, ca)
+	clientCert := newTestCert(t, "client", false, ca)
+	otherCA := newTestCert(t, "other", true, nil)
+	otherClientCert := newTestCert(t, "other client", false, otherCA)
+
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	ioutil.WriteFile(certFile, serverCert.certPEM, 0600)
+	ioutil.WriteFile(keyFile, serverCert.keyPEM, 0600)
+	ioutil.WriteFile(caFile, ca.certPEM, 0600)
+
+	// dual-stack listening with TLS
+	server, port := startTLSServer(t, config.RpcTLSConfiguration{CertFile: certFile, KeyFile: keyFile})
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		if err := get(host, port, ca, nil, 0); err != nil {
+			t.Error("TLS request to", host, "failed:", err)
+		}
+	}
+	if err := get("127.0.0.1", port, ca, nil, tls.VersionTLS11); err == nil {
+		t.Error("TLS 1.1 should be rejected by default")
+	}
+	server.Close()
+
+	server, port = startTLSServer(t, config.RpcTLSConfiguration{
+		CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"})
+	if err := get("127.0.0.1", port, ca, nil, tls.VersionTLS12); err == nil {
+		t.Error("TLS 1.2 should be rejected with MinVersion 1.3")
+	}
+	if err := get("127.0.0.1", port, ca, nil, 0); err != nil {
+		t.Error("TLS 1.3 request failed:", err)
+	}
+	server.Close()
+
+	// mutual TLS
+	server, port = startTLSServer(t, config.RpcTLSConfiguration{
+		CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile})
+	if err := get("127.0.0.1", port, ca, clientCert, 0); err != nil {
+		t.Error("mTLS request failed:", err)
+	}
+	if err := get("127.0.0.1", port, ca, nil, 0); err == nil {
+		t.Error("request without client certificate should be rejected")
+	}
+	if err := get("127.0.0.1", port, ca, otherClientCert, 0); err == nil {
+		t.Error("request with untrusted client certificate should be rejected")
+	}
+	server.Close()
+	config.Parameters.RpcConfiguration.TLS = config.RpcTLSConfiguration{}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	for _, conf := range []config.RpcTLSConfiguration{
+		{CertFile: "server.crt"},
+		{KeyFile: "server.key"},
+		{CertFile: "server.crt", KeyFile: "server.key", MinVersion: "1.4"},
+		{CertFile: "not-exist.crt", KeyFile: "not-exist.key"},
+	} {
+		if _, err := NewTLSConfig(conf); err == nil {
+			t.Error("invalid TLS config should fail:", conf)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package servers
+
+import (
+	"context"
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/errors"
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// States of cross-chain transactions.
+const (
+	TxStatePending   = "pending"
+	TxStateSucceeded = "succeeded"
+	TxStateFailed    = "failed"
+)
+
+type transactionEvent struct {
+	Event        string
+	Height       uint32 `json:",omitempty"`
+	Source       string `json:",omitempty"`
+	ProposalHash string `json:",omitempty"`
+	Signatures   int    `json:",omitempty"`
+	ResultHash   string `json:",omitempty"`
+	Reason       string `json:",omitempty"`
+	RecordTime   string
+}
+
+// transactionStatus is the lifecycle of a deposit transaction to a side chain
+// or a withdraw transaction of a side chain.
+type transactionStatus struct {
+	TransactionHash     string
+	Type                string
+	GenesisBlockAddress string
+	State               string
+	Cached              bool
+	DetectedHeight      uint32
+	DetectedBy          string
+	DetectedTime        string
+	ProposalHash        string
+	Signatures          int
+	ResultHash          string
+	Reason              string
+	FinishedTime        string
+	Events              []transactionEvent
+}
+
+// transactionStatuses collects the statuses of a transaction hash, deposits
+// are told apart by side chains, a withdraw transaction only belongs to one
+// side chain.
+type transactionStatuses struct {
+	hash     string
+	statuses []*transactionStatus
+}
+
+func (s *transactionStatuses) get(txType, genesisAddress string) *transactionStatus {
+	for _, status := range s.statuses {
+		if status.Type != txType {
+			continue
+		}
+		if txType == store.TxTypeWithdraw || status.GenesisBlockAddress == genesisAddress {
+			if status.GenesisBlockAddress == "" {
+				status.GenesisBlockAddress = genesisAddress
+			}
+			return status
+		}
+	}
+	status := &transactionStatus{
+		TransactionHash:     s.hash,
+		Type:                txType,
+		GenesisBlockAddress: genesisAddress,
+		State:               TxStatePending,
+		Events:              make([]transactionEvent, 0),
+	}
+	s.statuses = append(s.statuses, status)
+	return status
+}
+
+func (s *transactionStatuses) applyEvent(e *store.TransactionEvent) {
+	status := s.get(e.Type, e.GenesisBlockAddress)
+	status.Events = append(status.Events, transactionEvent{
+		Event:        e.Event,
+		Height:       e.Height,
+		Source:       e.Source,
+		ProposalHash: e.ProposalHash,
+		Signatures:   e.Signatures,
+		ResultHash:   e.ResultHash,
+		Reason:       e.Reason,
+		RecordTime:   e.RecordTime,
+	})
+
+	switch e.Event {
+	case store.TxEventDetected:
+		status.DetectedHeight = e.Height
+		status.DetectedBy = e.Source
+		status.DetectedTime = e.RecordTime
+	case store.TxEventProposed, store.TxEventSigned:
+		status.ProposalHash = e.ProposalHash
+		status.Signatures = e.Signatures
+	case store.TxEventSucceeded, store.TxEventFailed:
+		status.State = TxStateSucceeded
+		if e.Event == store.TxEventFailed {
+			status.State = TxStateFailed
+		}
+		if e.ResultHash != "" {
+			status.ResultHash = e.ResultHash
+		}
+		status.Reason = e.Reason
+		status.FinishedTime = e.RecordTime
+	}
+}
+
+// applyFinished sets the state from the finished transactions tables, which
+// also covers the transactions finished before the events are recorded.
+func (s *transactionStatuses) applyFinished(txType, genesisAddress string, succeed bool) {
+	status := s.get(txType, genesisAddress)
+	if status.State != TxStatePending {
+		return
+	}
+	status.State = TxStateFailed
+	if succeed {
+		status.State = TxStateSucceeded
+	}
+}
+
+func getTransactionStatuses(hash string) ([]*transactionStatus, error) {
+	statuses := &transactionStatuses{hash: hash}
+
+	if store.FinishedTxsDbCache != nil {
+		events, err := store.FinishedTxsDbCache.GetTransactionEvents(context.Background(), hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			statuses.applyEvent(e)
+		}
+	}
+
+	if store.DbCache.MainChainStore != nil {
+		addresses := make(map[string]bool)
+		for _, node := range config.SideNodes() {
+			addresses[node.GenesisBlockAddress] = true
+		}
+		for _, status := range statuses.statuses {
+			if status.Type == store.TxTypeDeposit {
+				addresses[status.GenesisBlockAddress] = true
+			}
+		}
+		for address := range addresses {
+			cached, err := store.DbCache.MainChainStore.HasMainChainTx(context.Background(), hash, address)
+			if err != nil {
+				return nil, err
+			}
+			if cached {
+				statuses.get(store.TxTypeDeposit, address).Cached = true
+			}
+		}
+	}
+	if store.DbCache.SideChainStore != nil {
+		cached, err := store.DbCache.SideChainStore.HasSideChainTx(context.Background(), hash)
+		if err != nil {
+			return nil, err
+		}
+		if cached {
+			statuses.get(store.TxTypeWithdraw, "").Cached = true
+		}
+	}
+
+	if store.FinishedTxsDbCache != nil {
+		succeedList, genesisAddresses, err := store.FinishedTxsDbCache.GetDepositTxByHash(context.Background(), hash)
+		if err != nil {
+			return nil, err
+		}
+		for i, succeed := range succeedList {
+			statuses.applyFinished(store.TxTypeDeposit, genesisAddresses[i], succeed)
+		}
+
+		finished, err := store.FinishedTxsDbCache.HasWithdrawTx(context.Background(), hash)
+		if err != nil {
+			return nil, err
+		}
+		if finished {
+			succeed, _, err := store.FinishedTxsDbCache.GetWithdrawTxByHash(context.Background(), hash)
+			if err != nil {
+				return nil, err
+			}
+			statuses.applyFinished(store.TxTypeWithdraw, "", succeed)
+		}
+	}
+
+	return statuses.statuses, nil
+}
+
+// GetTransactionStatus returns the lifecycle of a deposit transaction of the
+// main chain or a withdraw transaction of a side chain.
+func GetTransactionStatus(param Params) map[string]interface{} {
+	hash, ok := param.String("hash")
+	if !ok {
+		return ResponsePack(errors.InvalidParams, "need a string parameter named hash")
+	}
+	if _, err := common.Uint256FromHexString(hash); err != nil {
+		return ResponsePack(errors.InvalidParams, "invalid transaction hash")
+	}
+
+	statuses, err := getTransactionStatuses(hash)
+	if err != nil {
+		return ResponsePack(errors.InternalError, "get transaction status failed: "+err.Error())
+	}
+	if len(statuses) == 0 {
+		return ResponsePack(errors.UnknownTransaction, "")
+	}
+	return ResponsePack(errors.Success, statuses)
+}
@@ -0,0 +1,102 @@
+package servers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/errors"
+	"github.com/elastos/Elastos.ELA.Arbiter/store"
+)
+
+const (
+	testDepositHash  = "a3c455a90843db2acd22554f2768a8d4233fafbf8dd549e6b261c2786993be56"
+	testWithdrawHash = "b3c455a90843db2acd22554f2768a8d4233fafbf8dd549e6b261c2786993be56"
+	testMainHash     = "c3c455a90843db2acd22554f2768a8d4233fafbf8dd549e6b261c2786993be56"
+)
+
+func openTestFinishedTxsStore(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "arbiter-txstatus-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	documentName, dbName := store.DBDocumentNAME, store.FinishedTxsDBName
+	store.DBDocumentNAME = dir
+	store.FinishedTxsDBName = filepath.Join(dir, "finishedTxs.db")
+	store.FinishedTxsDbCache, err = store.OpenFinishedTxsDataStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		store.FinishedTxsDbCache = nil
+		store.DBDocumentNAME, store.FinishedTxsDBName = documentName, dbName
+		os.RemoveAll(dir)
+	}
+}
+
+func TestGetTransactionStatus(t *testing.T) {
+	defer openTestFinishedTxsStore(t)()
+
+	resp := GetTransactionStatus(Params{"hash": "xyz"})
+	if resp["Error"] != errors.InvalidParams {
+		t.Error("invalid hash should be rejected, got", resp["Error"])
+	}
+	resp = GetTransactionStatus(Params{"hash": testDepositHash})
+	if resp["Error"] != errors.UnknownTransaction {
+		t.Error("expected unknown transaction, got", resp["Error"])
+	}
+
+	store.RecordTransactionEvents([]*store.TransactionEvent{
+		{TransactionHash: testDepositHash, GenesisBlockAddress: "sideA", Type: store.TxTypeDeposit,
+			Event: store.TxEventDetected, Height: 100, Source: store.TxSourceDepositListener},
+		{TransactionHash: testDepositHash, GenesisBlockAddress: "sideA", Type: store.TxTypeDeposit,
+			Event: store.TxEventSent},
+		{TransactionHash: testDepositHash, GenesisBlockAddress: "sideA", Type: store.TxTypeDeposit,
+			Event: store.TxEventSucceeded, ResultHash: "sideTxHash"},
+		{TransactionHash: testDepositHash, GenesisBlockAddress: "sideB", Type: store.TxTypeDeposit,
+			Event: store.TxEventFailed, Reason: "invalid deposit"},
+	})
+	resp = GetTransactionStatus(Params{"hash": testDepositHash})
+	if resp["Error"] != errors.Success {
+		t.Fatal("unexpected error", resp["Result"])
+	}
+	statuses := resp["Result"].([]*transactionStatus)
+	if len(statuses) != 2 {
+		t.Fatal("expected statuses of two side chains, got", len(statuses))
+	}
+	if s := statuses[0]; s.GenesisBlockAddress != "sideA" || s.State != TxStateSucceeded ||
+		s.DetectedHeight != 100 || s.DetectedBy != store.TxSourceDepositListener ||
+		s.ResultHash != "sideTxHash" || len(s.Events) != 3 || s.FinishedTime == "" {
+		t.Errorf("unexpected status %+v", *s)
+	}
+	if s := statuses[1]; s.GenesisBlockAddress != "sideB" || s.State != TxStateFailed ||
+		s.Reason != "invalid deposit" {
+		t.Errorf("unexpected status %+v", *s)
+	}
+
+	store.RecordTransactionEvents(store.NewTransactionEvents([]string{testWithdrawHash}, store.TransactionEvent{
+		GenesisBlockAddress: "sideA", Type: store.TxTypeWithdraw, Event: store.TxEventProposed,
+		ProposalHash: testMainHash, Signatures: 1}))
+	store.RecordTransactionEvents(store.NewTransactionEvents([]string{testWithdrawHash}, store.TransactionEvent{
+		GenesisBlockAddress: "sideA", Type: store.TxTypeWithdraw, Event: store.TxEventSigned,
+		ProposalHash: testMainHash, Signatures: 3}))
+	resp = GetTransactionStatus(Params{"hash": testWithdrawHash})
+	statuses = resp["Result"].([]*transactionStatus)
+	if len(statuses) != 1 {
+		t.Fatal("expected one status, got", len(statuses))
+	}
+	if s := statuses[0]; s.Type != store.TxTypeWithdraw || s.State != TxStatePending ||
+		s.ProposalHash != testMainHash || s.Signatures != 3 {
+		t.Errorf("unexpected status %+v", *s)
+	}
+
+	// transactions finished before the events are recorded
+	store.FinishedTxsDbCache.AddSucceedWithdrawTxs(context.Background(), []string{testMainHash})
+	resp = GetTransactionStatus(Params{"hash": testMainHash})
+	statuses = resp["Result"].([]*transactionStatus)
+	if len(statuses) != 1 || statuses[0].State != TxStateSucceeded || len(statuses[0].Events) != 0 {
+		t.Errorf("unexpected statuses %+v", statuses)
+	}
+}
@@ -1,7 +1,6 @@
 package password
 
 import (
-	"flag"
 	"fmt"
 	"os"
 
@@ -10,12 +9,18 @@ import (
 
 // GetPassword gets password from user input
 func GetPassword() ([]byte, error) {
-	fmt.Printf("Password:")
-	passwd, err := gopass.GetPasswd()
+	return GetSecret("Password")
+}
+
+// GetSecret gets a secret like a private key from user input, the input is
+// not echoed.
+func GetSecret(prompt string) ([]byte, error) {
+	fmt.Printf("%s:", prompt)
+	secret, err := gopass.GetPasswd()
 	if err != nil {
 		return nil, err
 	}
-	return passwd, nil
+	return secret, nil
 }
 
 // GetConfirmedPassword gets double confirmed password from user input
@@ -43,25 +48,12 @@ func GetConfirmedPassword() ([]byte, error) {
 	return first, nil
 }
 
-// GetPassword gets node's wallet password from command line or user input
-func GetAccountPassword() ([]byte, error) {
-	var passwd []byte
-	var err error
-	if len(os.Args) == 1 {
-		passwd, err = GetPassword()
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		var pstr string
-		flag.StringVar(&pstr, "p", "", "wallet password")
-		flag.Parse()
-		if pstr == "" {
-			fmt.Println("Invaild parameter, use '-p <password>' to specify a not nil wallet password.")
-			os.Exit(1)
-		}
-		passwd = []byte(pstr)
+// GetAccountPassword gets node's wallet password from the source like
+// file:/etc/arbiter/password, or user input if it is empty.
+func GetAccountPassword(source string) ([]byte, error) {
+	s, err := ParseSource(source)
+	if err != nil {
+		return nil, err
 	}
-
-	return passwd, nil
+	return s.Read()
 }
@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package password
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkFilePermission checks the password file is a regular file owned by
+// the user or root, and the group and the others can not access it.
+func checkFilePermission(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("password file %s is not a regular file", path)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return fmt.Errorf("password file %s can be accessed by the group or the others, "+
+			"its permissions %04o should be 0600 or 0400", path, perm)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		if uid := int(stat.Uid); uid != os.Getuid() && uid != 0 {
+			return fmt.Errorf("password file %s should be owned by the user running the arbiter or root", path)
+		}
+	}
+	return nil
+}
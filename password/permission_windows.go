@@ -0,0 +1,19 @@
+package password
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkFilePermission checks the password file is a regular file, the
+// permissions of the file are not checked on windows.
+func checkFilePermission(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("password file %s is not a regular file", path)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package password
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SourcePrompt reads the password from the terminal.
+	SourcePrompt = "prompt"
+
+	// SourceFile reads the password from a file only the owner can access,
+	// like file:/etc/arbiter/password.
+	SourceFile = "file"
+
+	// SourceEnv reads the password from an environment variable and removes
+	// it from the environment, like env:WALLET_PASSWORD, DefaultEnv if the
+	// name is not given.
+	SourceEnv = "env"
+
+	// SourceFd reads the password from an inherited file descriptor until
+	// EOF, like fd:3.
+	SourceFd = "fd"
+
+	// SourceCommand runs a command and reads the password from its output,
+	// like cmd:/usr/local/bin/get-password arbiter. The command is not run by
+	// a shell.
+	SourceCommand = "cmd"
+)
+
+// DefaultEnv is the environment variable of the env source if the name is
+// not given.
+const DefaultEnv = "ARBITER_PASSWORD"
+
+// commandTimeout is the time the command of SourceCommand is given to print
+// the password.
+const commandTimeout = 30 * time.Second
+
+// Source is where the wallet password is read from.
+type Source struct {
+	Kind  string
+	Value string
+}
+
+// ParseSource parses a source given like kind:value, an empty source is the
+// terminal.
+func ParseSource(source string) (*Source, error) {
+	kind, value := source, ""
+	if i := strings.Index(source, ":"); i >= 0 {
+		kind, value = source[:i], source[i+1:]
+	}
+
+	switch kind {
+	case "", SourcePrompt:
+		if value != "" {
+			return nil, fmt.Errorf("password source %s does not take a value", SourcePrompt)
+		}
+		return &Source{Kind: SourcePrompt}, nil
+
+	case SourceEnv:
+		if value == "" {
+			value = DefaultEnv
+		}
+		return &Source{Kind: SourceEnv, Value: value}, nil
+
+	case SourceFile, SourceCommand:
+		if strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("password source %s should be like %s:<value>", kind, kind)
+		}
+		return &Source{Kind: kind, Value: value}, nil
+
+	case SourceFd:
+		if fd, err := strconv.ParseUint(value, 10, 31); err != nil {
+			return nil, fmt.Errorf("password source fd should be like fd:3, not fd:%s", value)
+		} else if fd == 1 || fd == 2 {
+			return nil, errors.New("password source fd can not be stdout or stderr")
+		}
+		return &Source{Kind: SourceFd, Value: value}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown password source %q, should be %s, %s:<path>, %s[:<name>], %s:<number> or %s:<command>",
+			kind, SourcePrompt, SourceFile, SourceEnv, SourceFd, SourceCommand)
+	}
+}
+
+func (s *Source) String() string {
+	if s.Kind == SourcePrompt {
+		return s.Kind
+	}
+	return s.Kind + ":" + s.Value
+}
+
+// Read reads the password from the source, the trailing line break is
+// removed. The caller should Wipe the password after use.
+func (s *Source) Read() ([]byte, error) {
+	switch s.Kind {
+	case SourcePrompt:
+		return GetPassword()
+	case SourceFile:
+		return readFile(s.Value)
+	case SourceEnv:
+		return readEnv(s.Value)
+	case SourceFd:
+		return readFd(s.Value)
+	case SourceCommand:
+		return readCommand(s.Value)
+	}
+	return nil, fmt.Errorf("unknown password source %q", s.Kind)
+}
+
+func readFile(path string) ([]byte, error) {
+	if err := checkFilePermission(path); err != nil {
+		return nil, err
+	}
+	passwd, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return trimLineBreak(passwd)
+}
+
+// readEnv reads the environment variable and removes it, so the processes
+// started later do not inherit it. The environment the process is started
+// with, which the same user can read in /proc, is not changed.
+func readEnv(name string) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	if err := os.Unsetenv(name); err != nil {
+		return nil, err
+	}
+	return trimLineBreak([]byte(value))
+}
+
+func readFd(value string) ([]byte, error) {
+	fd, err := strconv.ParseUint(value, 10, 31)
+	if err != nil {
+		return nil, err
+	}
+	file := os.NewFile(uintptr(fd), "password-fd-"+value)
+	if file == nil {
+		return nil, fmt.Errorf("invalid file descriptor %s", value)
+	}
+	defer file.Close()
+	passwd, err := ioutil.ReadAll(file)
+	if err != nil {
+		Wipe(passwd)
+		return nil, fmt.Errorf("read file descriptor %s error: %v", value, err)
+	}
+	return trimLineBreak(passwd)
+}
+
+func readCommand(command string) ([]byte, error) {
+	args := strings.Fields(command)
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		Wipe(stdout.Bytes())
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("password command %s timed out", args[0])
+		}
+		return nil, fmt.Errorf("password command %s error: %v", args[0], err)
+	}
+	return trimLineBreak(stdout.Bytes())
+}
+
+// trimLineBreak removes the line break ending the password, an empty
+// password is an error.
+func trimLineBreak(passwd []byte) ([]byte, error) {
+	n := len(passwd)
+	if n > 0 && passwd[n-1] == '\n' {
+		n--
+		if n > 0 && passwd[n-1] == '\r' {
+			n--
+		}
+	}
+	Wipe(passwd[n:])
+	if n == 0 {
+		return nil, errors.New("password is empty")
+	}
+	return passwd[:n], nil
+}
+
+// Wipe overwrites the password in memory.
+func Wipe(passwd []byte) {
+	for i := range passwd {
+		passwd[i] = 0
+	}
+}
@@ -0,0 +1,104 @@
+package password
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	valid := map[string]string{
+		"":                         "prompt",
+		"prompt":                   "prompt",
+		"env":                      "env:" + DefaultEnv,
+		"env:WALLET_PASSWORD":      "env:WALLET_PASSWORD",
+		"file:/etc/arbiter/pass":   "file:/etc/arbiter/pass",
+		"fd:3":                     "fd:3",
+		"cmd:get-password arbiter": "cmd:get-password arbiter",
+	}
+	for s, expected := range valid {
+		source, err := ParseSource(s)
+		if err != nil {
+			t.Errorf("Parse source %q error: %v", s, err)
+		} else if source.String() != expected {
+			t.Errorf("Parse source %q, expected %s, got %s", s, expected, source)
+		}
+	}
+
+	for _, s := range []string{"prompt:x", "file:", "cmd: ", "fd:", "fd:-1", "fd:2", "vault:x", "password"} {
+		if _, err := ParseSource(s); err == nil {
+			t.Errorf("Parse source %q should fail", s)
+		}
+	}
+}
+
+func TestSource_Read(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions and shell scripts are not supported")
+	}
+	dir, err := ioutil.TempDir("", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(file, []byte("secret\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetAccountPassword("file:" + file); err == nil {
+		t.Error("Password file readable by the others should be rejected")
+	}
+	os.Chmod(file, 0600)
+	if passwd, err := GetAccountPassword("file:" + file); err != nil || string(passwd) != "secret" {
+		t.Error("Read password file error:", string(passwd), err)
+	}
+
+	os.Setenv("TEST_WALLET_PASSWORD", "secret\n")
+	if passwd, err := GetAccountPassword("env:TEST_WALLET_PASSWORD"); err != nil || string(passwd) != "secret" {
+		t.Error("Read password environment variable error:", string(passwd), err)
+	}
+	if _, ok := os.LookupEnv("TEST_WALLET_PASSWORD"); ok {
+		t.Error("Password environment variable should be removed")
+	}
+	if _, err := GetAccountPassword("env:TEST_WALLET_PASSWORD"); err == nil {
+		t.Error("Read of missing environment variable should fail")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("secret"))
+	w.Close()
+	if passwd, err := GetAccountPassword("fd:" + strconv.Itoa(int(r.Fd()))); err != nil || string(passwd) != "secret" {
+		t.Error("Read password file descriptor error:", string(passwd), err)
+	}
+
+	script := filepath.Join(dir, "get-password")
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh\necho \"$1\"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if passwd, err := GetAccountPassword("cmd:" + script + " secret"); err != nil || string(passwd) != "secret" {
+		t.Error("Read password command error:", string(passwd), err)
+	}
+	if _, err := GetAccountPassword("cmd:" + script); err == nil {
+		t.Error("Empty password should be rejected")
+	}
+	if _, err := GetAccountPassword("cmd:" + filepath.Join(dir, "missing")); err == nil {
+		t.Error("Missing password command should fail")
+	}
+}
+
+func TestWipe(t *testing.T) {
+	passwd := []byte("secret")
+	Wipe(passwd)
+	for _, b := range passwd {
+		if b != 0 {
+			t.Fatal("Password not wiped:", passwd)
+		}
+	}
+}
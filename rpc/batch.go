@@ -0,0 +1,200 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"sync"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+)
+
+// MaxBatchSize is the max count of calls sent to a node in one batch request,
+// larger batches will be split into several requests.
+const MaxBatchSize = 100
+
+// invalidRequestCode is the json rpc 2.0 error code a node may answer a batch
+// request with if it does not support batches.
+const invalidRequestCode = -32600
+
+var errBatchRejected = errors.New("batch request rejected")
+
+var (
+	// unbatchableNodes records the nodes which have explicitly rejected a
+	// batch request, calls to these nodes will be sent one by one directly.
+	unbatchableNodes   = make(map[string]bool)
+	unbatchableNodesMu sync.RWMutex
+)
+
+type batchCall struct {
+	method string
+	params map[string]interface{}
+}
+
+// Batch collects json rpc calls to the same node, and sends them in one batch
+// request to reduce round trips. If the node does not support batch requests,
+// the calls will be sent sequentially instead.
+type Batch struct {
+	calls []batchCall
+}
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Add appends a call to the batch and returns the batch itself, so calls can
+// be chained like NewBatch().Add(...).Add(...).
+func (b *Batch) Add(method string, params map[string]interface{}) *Batch {
+	b.calls = append(b.calls, batchCall{method: method, params: params})
+	return b
+}
+
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// Call sends all calls of the batch to the node, the responses are returned
+// in the same order as the calls were added.
+func (b *Batch) Call(config *config.RpcConfig) ([]Response, error) {
+	responses := make([]Response, 0, len(b.calls))
+	for start := 0; start < len(b.calls); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(b.calls) {
+			end = len(b.calls)
+		}
+		resps, err := callBatch(b.calls[start:end], config)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, resps...)
+	}
+	return responses, nil
+}
+
+// CallAndUnmarshal sends all calls of the batch to the node and returns the
+// result of each call, the first error returned by the node will be returned
+// as error.
+func (b *Batch) CallAndUnmarshal(config *config.RpcConfig) ([]interface{}, error) {
+	responses, err := b.Call(config)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]interface{}, 0, len(responses))
+	for _, resp := range responses {
+		if resp.Error != nil {
+			return nil, errors.New(resp.Error.Message)
+		}
+		results = append(results, resp.Result)
+	}
+	return results, nil
+}
+
+func callBatch(calls []batchCall, config *config.RpcConfig) ([]Response, error) {
+	url := nodeUrl(config)
+	unbatchableNodesMu.RLock()
+	unbatchable := unbatchableNodes[url]
+	unbatchableNodesMu.RUnlock()
+	if unbatchable || len(calls) == 1 {
+		return callSequential(calls, config)
+	}
+
+	requests := make([]map[string]interface{}, 0, len(calls))
+	for i, call := range calls {
+		requests = append(requests, map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      i,
+			"method":  call.method,
+			"params":  call.params,
+		})
+	}
+	data, err := json.Marshal(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := post(url, "application/json", config.User, config.Pass, bytes.NewReader(data))
+	if err != nil {
+		logger.Debugw("post batch request failed", "node", url, log.KeyError, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := parseBatchResponse(body, len(calls))
+	switch err {
+	case nil:
+		return responses, nil
+	case errBatchRejected:
+		logger.Infow("node does not support batch request, fall back to sequential calls", "node", url)
+		unbatchableNodesMu.Lock()
+		unbatchableNodes[url] = true
+		unbatchableNodesMu.Unlock()
+	default:
+		// a proxy error page or a truncated body says nothing about the batch
+		// support of the node, so only this batch falls back.
+		logger.Warnw("invalid batch response, fall back to sequential calls", "node", url, log.KeyError, err)
+	}
+	return callSequential(calls, config)
+}
+
+// parseBatchResponse matches the responses of a batch request to the calls by
+// id. errBatchRejected is returned if the node answered with something other
+// than an array, or with a single "invalid request" error, which is how nodes
+// without batch support reply.
+func parseBatchResponse(body []byte, count int) ([]Response, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '[' {
+		return nil, errBatchRejected
+	}
+
+	var resps []Response
+	if err := json.Unmarshal(raw, &resps); err != nil {
+		return nil, err
+	}
+	if len(resps) == 1 && count > 1 && resps[0].Error != nil &&
+		resps[0].Error.Code == invalidRequestCode {
+		return nil, errBatchRejected
+	}
+	if len(resps) != count {
+		return nil, fmt.Errorf("got %d responses for %d calls", len(resps), count)
+	}
+
+	responses := make([]Response, count)
+	filled := make([]bool, count)
+	for _, resp := range resps {
+		if resp.ID < 0 || resp.ID >= int64(count) || filled[resp.ID] {
+			return nil, fmt.Errorf("unexpected response id %d", resp.ID)
+		}
+		responses[resp.ID] = resp
+		filled[resp.ID] = true
+	}
+	return responses, nil
+}
+
+func callSequential(calls []batchCall, config *config.RpcConfig) ([]Response, error) {
+	responses := make([]Response, 0, len(calls))
+	for _, call := range calls {
+		resp, err := CallAndUnmarshalResponse(call.method, call.params, config)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+func nodeUrl(config *config.RpcConfig) string {
+	return "http://" + config.IpAddress + ":" + strconv.Itoa(config.HttpJsonPort)
+}
@@ -0,0 +1,201 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+)
+
+func TestMain(m *testing.M) {
+	logDir, _ := ioutil.TempDir("", "arbiter-rpc-test")
+	log.Init(filepath.Join(logDir, "logs"), 5, 0, 0)
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+// newTestNode starts a node which answers "echo" calls with the "value"
+// parameter, if supportBatch is false batch requests will be rejected.
+func newTestNode(t *testing.T, supportBatch bool, requests *int) (*httptest.Server, *config.RpcConfig) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		answer := func(req map[string]interface{}) map[string]interface{} {
+			params, _ := req["params"].(map[string]interface{})
+			return map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      req["id"],
+				"result":  params["value"],
+				"error":   nil,
+			}
+		}
+
+		var batch []map[string]interface{}
+		if err := json.Unmarshal(body, &batch); err == nil {
+			if !supportBatch {
+				data, _ := json.Marshal(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      nil,
+					"error":   map[string]interface{}{"code": -32600, "message": "invalid request"},
+				})
+				w.Write(data)
+				return
+			}
+			// answer in reversed order to check the responses are matched by id
+			var resps []map[string]interface{}
+			for i := len(batch) - 1; i >= 0; i-- {
+				resps = append(resps, answer(batch[i]))
+			}
+			data, _ := json.Marshal(resps)
+			w.Write(data)
+			return
+		}
+
+		var req map[string]interface{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatal("invalid request body:", string(body))
+		}
+		data, _ := json.Marshal(answer(req))
+		w.Write(data)
+	}))
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return server, &config.RpcConfig{IpAddress: host, HttpJsonPort: p}
+}
+
+func TestBatch_Call(t *testing.T) {
+	var requests int
+	server, cfg := newTestNode(t, true, &requests)
+	defer server.Close()
+
+	batch := NewBatch().Add("echo", Param("value", "a")).Add("echo", Param("value", "b"))
+	batch.Add("echo", Param("value", "c"))
+	if batch.Len() != 3 {
+		t.Fatal("wrong batch length:", batch.Len())
+	}
+
+	results, err := batch.CallAndUnmarshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results[0] != "a" || results[1] != "b" || results[2] != "c" {
+		t.Error("wrong batch results:", results)
+	}
+	if requests != 1 {
+		t.Error("batch should be sent in one request, sent:", requests)
+	}
+}
+
+func TestBatch_CallSplit(t *testing.T) {
+	var requests int
+	server, cfg := newTestNode(t, true, &requests)
+	defer server.Close()
+
+	batch := NewBatch()
+	for i := 0; i < MaxBatchSize+1; i++ {
+		batch.Add("echo", Param("value", i))
+	}
+	results, err := batch.CallAndUnmarshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if result != strconv.Itoa(i) {
+			t.Fatal("wrong result at", i, ":", result)
+		}
+	}
+	if requests != 2 {
+		t.Error("batch should be split into 2 requests, sent:", requests)
+	}
+}
+
+func TestBatch_CallFallback(t *testing.T) {
+	var requests int
+	server, cfg := newTestNode(t, false, &requests)
+	defer server.Close()
+
+	batch := NewBatch().Add("echo", Param("value", "a")).Add("echo", Param("value", "b"))
+	results, err := batch.CallAndUnmarshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0] != "a" || results[1] != "b" {
+		t.Error("wrong fallback results:", results)
+	}
+	// one rejected batch request and two single requests
+	if requests != 3 {
+		t.Error("wrong request count:", requests)
+	}
+
+	// the node is remembered as unbatchable
+	requests = 0
+	if _, err := batch.Call(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Error("batch should not be retried on unbatchable node, sent:", requests)
+	}
+}
+
+func TestBatch_CallTransientError(t *testing.T) {
+	var requests int
+	node, cfg := newTestNode(t, true, &requests)
+	defer node.Close()
+
+	// a proxy in front of the node fails the first batch request with an
+	// html error page
+	failed := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !failed && len(body) > 0 && body[0] == '[' {
+			failed = true
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("<html><body>502 Bad Gateway</body></html>"))
+			return
+		}
+		resp, err := http.Post(node.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		w.Write(data)
+	}))
+	defer proxy.Close()
+	host, port, _ := net.SplitHostPort(proxy.Listener.Addr().String())
+	cfg.IpAddress = host
+	cfg.HttpJsonPort, _ = strconv.Atoi(port)
+
+	batch := NewBatch().Add("echo", Param("value", "a")).Add("echo", Param("value", "b"))
+	results, err := batch.CallAndUnmarshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0] != "a" || results[1] != "b" {
+		t.Error("wrong fallback results:", results)
+	}
+	// the failed batch is sent again as two single requests
+	if requests != 2 {
+		t.Error("wrong request count:", requests)
+	}
+
+	// the node is still batched after the transient error
+	requests = 0
+	if _, err := batch.Call(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Error("batch should be retried after a transient error, sent:", requests)
+	}
+}
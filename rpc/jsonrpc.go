@@ -9,7 +9,6 @@ import (
 	"io/ioutil"
 	"net/http"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -183,10 +182,10 @@ func GetWithdrawTransactionByHeight(height uint32, config *config.RpcConfig) ([]
 	}
 	txs := make([]*base.WithdrawTxInfo, 0)
 	if err = Unmarshal(&resp, &txs); err != nil {
-		log.Error("[GetWithdrawTransactionByHeight] received invalid response")
+		logger.Errorw("received invalid withdraw transactions", log.KeyHeight, height, log.KeyError, err)
 		return nil, err
 	}
-	log.Debug("[GetWithdrawTransactionByHeight] len transactions:", len(txs), "transactions:", txs)
+	logger.Debugw("received withdraw transactions", log.KeyHeight, height, "count", len(txs))
 
 	return txs, nil
 }
@@ -198,7 +197,7 @@ func GetIllegalEvidenceByHeight(height uint32, config *config.RpcConfig) ([]*bas
 	}
 	evidences := make([]*base.SidechainIllegalDataInfo, 0)
 	if err = Unmarshal(&resp, &evidences); err != nil {
-		log.Error("[GetIllegalEvidenceByHeight] received invalid response")
+		logger.Errorw("received invalid illegal evidences", log.KeyHeight, height, log.KeyError, err)
 		return nil, err
 	}
 
@@ -213,7 +212,7 @@ func CheckIllegalEvidence(evidence *base.SidechainIllegalDataInfo, config *confi
 	}
 	result := false
 	if err = Unmarshal(&resp, &result); err != nil {
-		log.Error("[CheckIllegalEvidence] received invalid response")
+		logger.Errorw("received invalid illegal evidence check result", log.KeyError, err)
 		return false, err
 	}
 
@@ -240,6 +239,76 @@ func GetTransactionInfoByHash(transactionHash string, config *config.RpcConfig)
 	return tx, nil
 }
 
+func GetTransactionInfosByHashes(transactionHashes []string, config *config.RpcConfig) ([]*base.WithdrawTxInfo, error) {
+	batch := NewBatch()
+	for _, transactionHash := range transactionHashes {
+		hashBytes, err := common.HexStringToBytes(transactionHash)
+		if err != nil {
+			return nil, err
+		}
+		reversedHashBytes := common.BytesReverse(hashBytes)
+		reversedHashStr := common.BytesToHexString(reversedHashBytes)
+		batch.Add("getwithdrawtransaction", Param("txid", reversedHashStr))
+	}
+
+	results, err := batch.CallAndUnmarshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]*base.WithdrawTxInfo, 0, len(results))
+	for _, result := range results {
+		tx := &base.WithdrawTxInfo{}
+		if err := Unmarshal(&result, tx); err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// GetWithdrawTransactionsAndEvidencesByHeights gets the withdraw transactions
+// at withdrawHeights and the illegal evidences at evidenceHeights from a side
+// node in one batch request, the results are in the same order as the heights.
+func GetWithdrawTransactionsAndEvidencesByHeights(withdrawHeights, evidenceHeights []uint32,
+	config *config.RpcConfig) ([][]*base.WithdrawTxInfo, [][]*base.SidechainIllegalDataInfo, error) {
+	batch := NewBatch()
+	for _, height := range withdrawHeights {
+		batch.Add("getwithdrawtransactionsbyheight", Param("height", height))
+	}
+	for _, height := range evidenceHeights {
+		batch.Add("getillegalevidencebyheight", Param("height", height))
+	}
+
+	results, err := batch.CallAndUnmarshal(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	withdrawTxs := make([][]*base.WithdrawTxInfo, 0, len(withdrawHeights))
+	for i, result := range results[:len(withdrawHeights)] {
+		txs := make([]*base.WithdrawTxInfo, 0)
+		if err = Unmarshal(&result, &txs); err != nil {
+			logger.Errorw("received invalid withdraw transactions", log.KeyHeight, withdrawHeights[i],
+				log.KeyError, err)
+			return nil, nil, err
+		}
+		withdrawTxs = append(withdrawTxs, txs)
+	}
+	evidences := make([][]*base.SidechainIllegalDataInfo, 0, len(evidenceHeights))
+	for i, result := range results[len(withdrawHeights):] {
+		es := make([]*base.SidechainIllegalDataInfo, 0)
+		if err = Unmarshal(&result, &es); err != nil {
+			logger.Errorw("received invalid illegal evidences", log.KeyHeight, evidenceHeights[i],
+				log.KeyError, err)
+			return nil, nil, err
+		}
+		evidences = append(evidences, es)
+	}
+
+	return withdrawTxs, evidences, nil
+}
+
 func GetExistWithdrawTransactions(txs []string) ([]string, error) {
 	parameter := make(map[string]interface{})
 	parameter["txs"] = txs
@@ -345,7 +414,7 @@ func post(url string, contentType string, user string, pass string, body io.Read
 }
 
 func Call(method string, params map[string]interface{}, config *config.RpcConfig) ([]byte, error) {
-	url := "http://" + config.IpAddress + ":" + strconv.Itoa(config.HttpJsonPort)
+	url := nodeUrl(config)
 	data, err := json.Marshal(map[string]interface{}{
 		"method": method,
 		"params": params,
@@ -356,7 +425,7 @@ func Call(method string, params map[string]interface{}, config *config.RpcConfig
 
 	resp, err := post(url, "application/json", config.User, config.Pass, strings.NewReader(string(data)))
 	if err != nil {
-		log.Debug("POST requset err:", err)
+		logger.Debugw("post request failed", "node", url, "method", method, log.KeyError, err)
 		return nil, err
 	}
 	defer resp.Body.Close()
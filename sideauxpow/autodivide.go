@@ -8,7 +8,9 @@ import (
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/metrics"
 	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
+	"github.com/elastos/Elastos.ELA.Arbiter/signer"
 
 	"github.com/elastos/Elastos.ELA/core/types"
 	"github.com/elastos/Elastos.ELA/core/types/payload"
@@ -40,6 +42,8 @@ func checkSideChainPowAccounts(addresses []string, minThreshold int) ([]*SideCha
 			}
 		}
 
+		metrics.MiningAccountBalance.With(addr).Set(float64(available) / 1e8)
+
 		if available < common.Fixed64(minThreshold) {
 			warnAddresses = append(warnAddresses, &SideChainPowAccount{
 				Address:          addr,
@@ -57,7 +61,7 @@ func checkSideChainPowAccounts(addresses []string, minThreshold int) ([]*SideCha
 			warningStr += " "
 		}
 
-		log.Info("Warning side chain mining account: ", warningStr)
+		logger.Infow("side chain mining accounts below threshold", "accounts", warningStr)
 
 		return warnAddresses, nil
 	}
@@ -68,10 +72,10 @@ func checkSideChainPowAccounts(addresses []string, minThreshold int) ([]*SideCha
 func divideTransfer(name string, outputs []*Transfer) error {
 	// create transaction
 	fee := common.Fixed64(100000)
-	mainAccount:= client.GetMainAccount()
-
-	from := mainAccount.Address
-	script := mainAccount.RedeemScript
+	from, script, err := signer.MainAccount(keySigner)
+	if err != nil {
+		return err
+	}
 
 	txType := types.TransferAsset
 	txPayload := &payload.TransferAsset{}
@@ -81,13 +85,13 @@ func divideTransfer(name string, outputs []*Transfer) error {
 		return errors.New("create divide transaction failed: " + err.Error())
 	}
 
-	txnSigned, err := client.Sign(txn)
+	txnSigned, err := signTransaction(txn, "")
 	if err != nil {
 		return err
 	}
 	program := txnSigned.Programs[0]
 	haveSign, needSign, _ := crypto.GetSignStatus(program.Code, program.Parameter)
-	log.Debug("Divide transaction successfully signed: ", haveSign, needSign)
+	logger.Debugw("divide transaction signed", log.KeyTx, txn.Hash(), "signed", haveSign, "required", needSign)
 
 	buf := new(bytes.Buffer)
 	txn.Serialize(buf)
@@ -98,7 +102,7 @@ func divideTransfer(name string, outputs []*Transfer) error {
 	if err != nil {
 		return err
 	}
-	log.Debug("Send divide transaction: ", result)
+	logger.Debugw("divide transaction sent", log.KeyTx, txn.Hash(), "result", result)
 
 	return nil
 }
@@ -108,12 +112,12 @@ func SidechainAccountDivide() {
 		select {
 		case <-time.After(time.Second * 60):
 			miningAddresses := make([]string, 0)
-			for _, sideNode := range config.Parameters.SideNodeList {
+			for _, sideNode := range config.SideNodes() {
 				miningAddresses = append(miningAddresses, sideNode.MiningAddr)
 			}
 			warningAccounts, err := checkSideChainPowAccounts(miningAddresses, config.Parameters.MinThreshold)
 			if err != nil {
-				log.Error("Check side chain pow err", err)
+				logger.Errorw("check side chain pow accounts failed", log.KeyError, err)
 			}
 			if len(warningAccounts) > 0 {
 				var outputs []*Transfer
@@ -2,16 +2,20 @@ package sideauxpow
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/arbitrator"
+	"github.com/elastos/Elastos.ELA.Arbiter/audit"
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
 	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
+	"github.com/elastos/Elastos.ELA.Arbiter/signer"
 
-	"github.com/elastos/Elastos.ELA/account"
 	"github.com/elastos/Elastos.ELA/common"
 	"github.com/elastos/Elastos.ELA/core/types"
 	"github.com/elastos/Elastos.ELA/core/types/payload"
@@ -20,7 +24,7 @@ import (
 
 var (
 	lock                          sync.RWMutex
-	client                        *account.Client
+	keySigner                     signer.Signer
 	lastSendSideMiningHeightMap   map[common.Uint256]uint32
 	lastNotifySideMiningHeightMap map[common.Uint256]uint32
 	lastSubmitAuxpowHeightMap     map[common.Uint256]uint32
@@ -73,18 +77,19 @@ func unmarshal(result interface{}, target interface{}) error {
 }
 
 func sideChainPowTransfer(sideNode *config.SideNodeConfig) error {
-	log.Info("[sideChainPowTransfer] start")
+	scLogger := logger.With(log.KeySideChain, sideNode.GenesisBlockAddress)
+	scLogger.Info("side chain pow transfer start")
 
 	if sideNode.PayToAddr == "" {
 		return errors.New("[sideChainPowTransfer] has no side aux pow paytoaddr")
 	}
 	resp, err := rpc.CallAndUnmarshal("createauxblock", rpc.Param("paytoaddress", sideNode.PayToAddr), sideNode.Rpc)
 	if err != nil {
-		log.Errorf("[sideChainPowTransfer] create aux block failed: %s", err)
+		scLogger.Errorw("create aux block failed", log.KeyError, err)
 		return err
 	}
 	if resp == nil {
-		log.Info("[sideChainPowTransfer] create auxblock, nil ")
+		scLogger.Info("create aux block returned nil")
 		return nil
 	}
 
@@ -111,7 +116,8 @@ func sideChainPowTransfer(sideNode *config.SideNodeConfig) error {
 	sideGenesisHash, _ := common.Uint256FromBytes(sideGenesisHashData)
 	sideBlockHash, _ := common.Uint256FromBytes(sideBlockHashData)
 
-	log.Info("sideGenesisHash:", sideGenesisHash, "sideBlockHash:", sideBlockHash)
+	scLogger.Infow("aux block created", "sidegenesishash", sideGenesisHash, "sideblockhash", sideBlockHash,
+		log.KeyHeight, sideAuxBlock.Height)
 	// Create payload
 	txPayload := &payload.SideChainPow{
 		BlockHeight:     sideAuxBlock.Height,
@@ -120,11 +126,23 @@ func sideChainPowTransfer(sideNode *config.SideNodeConfig) error {
 	}
 
 	buf := new(bytes.Buffer)
-	txPayload.Serialize(buf, payload.SideChainPowVersion)
-	txPayload.Signature, err = arbitrator.ArbitratorGroupSingleton.GetCurrentArbitrator().Sign(buf.Bytes()[0:68])
+	txPayload.SerializeUnsigned(buf, payload.SideChainPowVersion)
+	txPayload.Signature, err = keySigner.SignAuxPow(txPayload)
 	if err != nil {
 		return err
 	}
+	if _, err = audit.Append(&audit.Record{
+		ContentType: audit.ContentAuxpow,
+		ContentHash: audit.HashData(buf.Bytes()),
+		Summary: audit.Summary{
+			SideChain: sideNode.GenesisBlockAddress,
+			Height:    sideAuxBlock.Height,
+			Detail:    "side block " + sideBlockHash.String(),
+		},
+		Proposer: signerPublicKey(),
+	}); err != nil {
+		return err
+	}
 
 	// create transaction
 	if config.Parameters.SideAuxPowFee <= 0 {
@@ -141,13 +159,14 @@ func sideChainPowTransfer(sideNode *config.SideNodeConfig) error {
 		return errors.New("[sideChainPowTransfer] invalid miningAddr")
 	}
 	codeHash := programHash.ToCodeHash()
-	miningAccount := client.GetAccountByCodeHash(codeHash)
-	if miningAccount == nil {
+	script, err := keySigner.RedeemScript(codeHash)
+	if err == signer.ErrNoAccount {
 		return errors.New("[sideChainPowTransfer] not found miningAddr in keystore")
+	} else if err != nil {
+		return errors.New("[sideChainPowTransfer] get redeem script of miningAddr failed: " + err.Error())
 	}
 
 	from := sideNode.MiningAddr
-	script := miningAccount.RedeemScript
 
 	txn, err := createAuxpowTransaction(txType, txPayload, from, &fee, script,
 		arbitrator.ArbitratorGroupSingleton.GetCurrentHeight())
@@ -155,44 +174,100 @@ func sideChainPowTransfer(sideNode *config.SideNodeConfig) error {
 		return errors.New("[sideChainPowTransfer] create transaction failed: " + err.Error())
 	}
 
-	txnSigned, err := client.Sign(txn)
+	txnSigned, err := signTransaction(txn, sideNode.GenesisBlockAddress)
 	if err != nil {
 		return err
 	}
 	program := txnSigned.Programs[0]
 	haveSign, needSign, _ := crypto.GetSignStatus(program.Code, program.Parameter)
-	log.Debug("[sideChainPowTransfer] transaction successfully signed: ", haveSign, needSign)
+	scLogger.Debugw("side chain pow transaction signed", log.KeyTx, txn.Hash(), "signed", haveSign, "required", needSign)
 
 	sideChainPowBuf := new(bytes.Buffer)
 	txn.Serialize(sideChainPowBuf)
 	content := common.BytesToHexString(sideChainPowBuf.Bytes())
-	// log.Debug("Raw Sidemining transaction: ", content)
+	// logger.Debug("Raw Sidemining transaction: ", content)
 
 	// send transaction
 	result, err := rpc.CallAndUnmarshal("sendrawtransaction", rpc.Param("data", content), config.Parameters.MainNode.Rpc)
 	if err != nil {
 		return errors.New("[SendSideChainMining] sendrawtransaction failed: " + err.Error())
 	}
-	log.Info("[SendSideChainMining] End send Sidemining transaction:  genesis address [", sideNode.GenesisBlockAddress, "], result: ", result)
+	scLogger.Infow("side chain pow transaction sent", log.KeyTx, txn.Hash(), log.KeyHeight, sideAuxBlock.Height,
+		"result", result)
 
 	lock.Lock()
 	defer lock.Unlock()
 	lastSendSideMiningHeightMap[*sideGenesisHash] =
 		arbitrator.ArbitratorGroupSingleton.GetCurrentHeight()
 
-	log.Info("[sideChainPowTransfer] end")
+	scLogger.Info("side chain pow transfer end")
 	return nil
 }
 
 func StartSideChainMining(sideNode *config.SideNodeConfig) {
 	err := sideChainPowTransfer(sideNode)
 	if err != nil {
-		log.Warn(err)
+		logger.Warnw("side chain mining failed", log.KeySideChain, sideNode.GenesisBlockAddress, log.KeyError, err)
+	}
+}
+
+// CheckMiningAccounts checks the mining accounts of the side nodes are in
+// the keystore, the missing ones are listed in the error.
+func CheckMiningAccounts(nodes []*config.SideNodeConfig) error {
+	var missing []string
+	for _, node := range nodes {
+		if node.MiningAddr == "" {
+			continue
+		}
+		programHash, err := common.Uint168FromAddress(node.MiningAddr)
+		if err != nil {
+			return fmt.Errorf("invalid MiningAddr %s of side chain %s", node.MiningAddr, node.GenesisBlockAddress)
+		}
+		if _, err := keySigner.RedeemScript(programHash.ToCodeHash()); err == signer.ErrNoAccount {
+			missing = append(missing, node.MiningAddr+" of side chain "+node.GenesisBlockAddress)
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("MiningAddr not found in the keystore: %s, add them by ./arbiter wallet add or import",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// signTransaction signs the transaction and appends the signature to the
+// audit log.
+func signTransaction(txn *types.Transaction, sideChain string) (*types.Transaction, error) {
+	txnSigned, err := keySigner.SignTransaction(txn)
+	if err != nil {
+		return nil, err
+	}
+	summary := audit.TransactionSummary(txn)
+	summary.SideChain = sideChain
+	summary.Detail = txn.TxType.Name()
+	if _, err := audit.Append(&audit.Record{
+		ContentType: audit.ContentTransaction,
+		ContentHash: txn.Hash().String(),
+		Summary:     summary,
+		Proposer:    signerPublicKey(),
+	}); err != nil {
+		return nil, err
+	}
+	return txnSigned, nil
+}
+
+// signerPublicKey returns the public key of the main account in hex.
+func signerPublicKey() string {
+	publicKey, err := keySigner.PublicKey().EncodePoint(true)
+	if err != nil {
+		return ""
 	}
+	return hex.EncodeToString(publicKey)
 }
 
-func Init(c *account.Client) {
-	client = c
+func Init(s signer.Signer) {
+	keySigner = s
 	lastSendSideMiningHeightMap = make(map[common.Uint256]uint32)
 	lastNotifySideMiningHeightMap = make(map[common.Uint256]uint32)
 	lastSubmitAuxpowHeightMap = make(map[common.Uint256]uint32)
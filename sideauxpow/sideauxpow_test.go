@@ -0,0 +1,60 @@
+package sideauxpow
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/signer"
+
+	"github.com/elastos/Elastos.ELA/account"
+)
+
+func TestCheckMiningAccounts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arbiter-sideauxpow-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, err := account.Create(filepath.Join(dir, "keystore.dat"), []byte("passwd"))
+	if err != nil {
+		t.Fatal("Create wallet error:", err)
+	}
+	mining, err := client.CreateAccount()
+	if err != nil {
+		t.Fatal("Add account error:", err)
+	}
+	missing, err := account.NewAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	Init(signer.NewLocal(client))
+
+	nodes := []*config.SideNodeConfig{
+		{GenesisBlockAddress: "side1", MiningAddr: client.GetMainAccount().Address},
+		{GenesisBlockAddress: "side2", MiningAddr: mining.Address},
+		{GenesisBlockAddress: "side3"},
+	}
+	if err := CheckMiningAccounts(nodes); err != nil {
+		t.Error("Mining accounts in the keystore should pass:", err)
+	}
+
+	nodes = append(nodes, &config.SideNodeConfig{GenesisBlockAddress: "side4", MiningAddr: missing.Address})
+	err = CheckMiningAccounts(nodes)
+	if err == nil {
+		t.Fatal("Missing mining account should fail.")
+	}
+	if !strings.Contains(err.Error(), missing.Address+" of side chain side4") ||
+		strings.Contains(err.Error(), mining.Address) {
+		t.Error("Only the missing mining account should be listed, got", err)
+	}
+
+	nodes = append(nodes, &config.SideNodeConfig{GenesisBlockAddress: "side5", MiningAddr: "invalid"})
+	if err := CheckMiningAccounts(nodes); err == nil || !strings.Contains(err.Error(), "invalid MiningAddr") {
+		t.Error("Invalid mining address should fail, got", err)
+	}
+}
@@ -2,17 +2,17 @@ package sideauxpow
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/elastos/Elastos.ELA.Arbiter/config"
 	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/metrics"
 	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
 )
 
 func SubmitAuxpow(genesishash string, blockhash string, submitauxpow string) error {
-	log.Info("submitsideauxblock")
-
 	var sideNode *config.SideNodeConfig
-	for _, node := range config.Parameters.SideNodeList {
+	for _, node := range config.SideNodes() {
 		if node.GenesisBlock == genesishash {
 			sideNode = node
 		}
@@ -25,15 +25,18 @@ func SubmitAuxpow(genesishash string, blockhash string, submitauxpow string) err
 	params["blockhash"] = blockhash
 	params["sideauxpow"] = submitauxpow
 
-	log.Info("[SubmitAuxpow] Submit auxblock sideNode.Rpc：", sideNode.Rpc.IpAddress, ":", sideNode.Rpc.HttpJsonPort)
+	node := fmt.Sprintf("%s:%d", sideNode.Rpc.IpAddress, sideNode.Rpc.HttpJsonPort)
+	scLogger := logger.With(log.KeySideChain, sideNode.GenesisBlockAddress, "sideblockhash", blockhash)
+	scLogger.Infow("submit aux block", "node", node)
 	resp, err := rpc.CallAndUnmarshal("submitsideauxblock", params, sideNode.Rpc)
+	metrics.AuxpowSubmissions.With(sideNode.GenesisBlockAddress, metrics.Result(err)).Inc()
 	if err != nil {
 		return err
 	}
 	if resp != nil {
-		log.Info("[SubmitAuxpow] Submit auxblock resp: ", resp)
+		scLogger.Infow("aux block submitted", "result", resp)
 	} else {
-		log.Warn("submitauxblock but resp is nil, sideNode.Rpc:", sideNode.Rpc.IpAddress, ":", sideNode.Rpc.HttpJsonPort)
+		scLogger.Warnw("aux block submitted but result is nil", "node", node)
 	}
 	return nil
 }
@@ -0,0 +1,69 @@
+package signer
+
+import (
+	"github.com/elastos/Elastos.ELA/account"
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+// Local signs with the accounts of a keystore opened in the process.
+type Local struct {
+	client *account.Client
+}
+
+// NewLocal returns the signer of the opened keystore.
+func NewLocal(client *account.Client) *Local {
+	return &Local{client: client}
+}
+
+func (l *Local) PublicKey() *crypto.PublicKey {
+	return l.client.GetMainAccount().PubKey()
+}
+
+func (l *Local) RedeemScript(codeHash common.Uint160) ([]byte, error) {
+	acc := l.client.GetAccountByCodeHash(codeHash)
+	if acc == nil {
+		return nil, ErrNoAccount
+	}
+	return acc.RedeemScript, nil
+}
+
+func (l *Local) SignTransaction(txn *types.Transaction) (*types.Transaction, error) {
+	return l.client.Sign(txn)
+}
+
+func (l *Local) SignWithdrawProposal(txn *types.Transaction) ([]byte, error) {
+	return l.signContent(withdrawProposalData(txn))
+}
+
+func (l *Local) SignIllegalEvidence(evidence *payload.SidechainIllegalData) ([]byte, error) {
+	return l.signContent(illegalEvidenceData(evidence))
+}
+
+func (l *Local) SignAuxPow(pow *payload.SideChainPow) ([]byte, error) {
+	return l.signContent(auxPowData(pow))
+}
+
+func (l *Local) SignHandshake(nonce []byte) ([]byte, error) {
+	return l.signContent(handshakeData(nonce))
+}
+
+// sign signs the data by the main account.
+func (l *Local) sign(data []byte) ([]byte, error) {
+	return l.client.GetMainAccount().Sign(data)
+}
+
+// hasAccount tells if the keystore has the account of the program hash.
+func (l *Local) hasAccount(programHash common.Uint168) bool {
+	return l.client.GetAccountByCodeHash(programHash.ToCodeHash()) != nil
+}
+
+// signContent signs the data of a content unless it failed to be encoded.
+func (l *Local) signContent(data []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return l.sign(data)
+}
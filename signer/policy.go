@@ -0,0 +1,223 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+// Policy is what the signer daemon agrees to sign.
+type Policy struct {
+	// TxTypes are the names of the transaction types the daemon signs, like
+	// SideChainPow.
+	TxTypes []string
+
+	// OutputAddresses are the addresses besides the accounts of the keystore
+	// the signed transactions can pay to.
+	OutputAddresses []string
+
+	// SideGenesisHashes are the genesis hashes of the side chains the daemon
+	// signs auxpow for, all the side chains if empty.
+	SideGenesisHashes []string
+
+	// MaxSignsPerMinute limits the data and the transactions signed in a
+	// minute, 0 is no limit.
+	MaxSignsPerMinute int
+}
+
+// DefaultPolicy signs the auxpow of the side chains, the withdraw proposals,
+// the illegal side chain evidences and the transfers between the accounts of
+// the keystore.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		TxTypes: []string{types.SideChainPow.Name(), types.TransferAsset.Name(),
+			types.WithdrawFromSideChain.Name(), types.IllegalSidechainEvidence.Name()},
+		MaxSignsPerMinute: 120,
+	}
+}
+
+// LoadPolicy reads the policy from a JSON file, the fields not in the file
+// are taken from DefaultPolicy.
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	policy := DefaultPolicy()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(policy); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %v", file, err)
+	}
+	return policy, nil
+}
+
+// enforcer checks the requests against the policy.
+type enforcer struct {
+	txTypes    map[types.TxType]struct{}
+	outputs    map[common.Uint168]struct{}
+	sideChains map[common.Uint256]struct{}
+	limit      int
+
+	mux   sync.Mutex
+	signs []time.Time
+}
+
+func newEnforcer(policy *Policy) (*enforcer, error) {
+	names := make(map[string]types.TxType)
+	for i := 0; i <= 0xff; i++ {
+		txType := types.TxType(i)
+		if name := txType.Name(); name != "Unknown" {
+			names[name] = txType
+		}
+	}
+
+	e := &enforcer{
+		txTypes:    make(map[types.TxType]struct{}),
+		outputs:    make(map[common.Uint168]struct{}),
+		sideChains: make(map[common.Uint256]struct{}),
+		limit:      policy.MaxSignsPerMinute,
+	}
+	for _, name := range policy.TxTypes {
+		txType, ok := names[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown transaction type %q in policy", name)
+		}
+		e.txTypes[txType] = struct{}{}
+	}
+	for _, address := range policy.OutputAddresses {
+		programHash, err := common.Uint168FromAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output address %q in policy: %v", address, err)
+		}
+		e.outputs[*programHash] = struct{}{}
+	}
+	for _, hash := range policy.SideGenesisHashes {
+		genesisHash, err := common.Uint256FromHexString(hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid side genesis hash %q in policy: %v", hash, err)
+		}
+		e.sideChains[*genesisHash] = struct{}{}
+	}
+	if policy.MaxSignsPerMinute < 0 {
+		return nil, fmt.Errorf("invalid MaxSignsPerMinute %d in policy", policy.MaxSignsPerMinute)
+	}
+	return e, nil
+}
+
+func (e *enforcer) checkTxType(txType types.TxType) error {
+	if _, ok := e.txTypes[txType]; !ok {
+		return fmt.Errorf("transaction type %s is not allowed", txType.Name())
+	}
+	return nil
+}
+
+// checkTransaction checks the type and the outputs of the transaction, the
+// outputs can pay to the accounts of the keystore.
+func (e *enforcer) checkTransaction(txn *types.Transaction, keystore keystore) error {
+	if err := e.checkTxType(txn.TxType); err != nil {
+		return err
+	}
+	for _, output := range txn.Outputs {
+		if _, ok := e.outputs[output.ProgramHash]; ok {
+			continue
+		}
+		if keystore.hasAccount(output.ProgramHash) {
+			continue
+		}
+		address, _ := output.ProgramHash.ToAddress()
+		return fmt.Errorf("output address %s is not allowed", address)
+	}
+	return nil
+}
+
+// checkWithdrawProposal checks the transaction is a withdraw from a side
+// chain spending from a cross chain or multi sign account of the main
+// account.
+func (e *enforcer) checkWithdrawProposal(txn *types.Transaction, keystore keystore) error {
+	if err := e.checkTxType(txn.TxType); err != nil {
+		return err
+	}
+	if txn.TxType != types.WithdrawFromSideChain {
+		return fmt.Errorf("transaction type %s is not a withdraw", txn.TxType.Name())
+	}
+	if _, ok := txn.Payload.(*payload.WithdrawFromSideChain); !ok {
+		return errors.New("invalid withdraw payload")
+	}
+	if len(txn.Programs) == 0 {
+		return errors.New("withdraw transaction has no program")
+	}
+	publicKey, err := keystore.PublicKey().EncodePoint(true)
+	if err != nil {
+		return err
+	}
+	for _, p := range txn.Programs {
+		publicKeys, err := crypto.ParseCrossChainScript(p.Code)
+		if err != nil {
+			if publicKeys, err = crypto.ParseMultisigScript(p.Code); err != nil {
+				return fmt.Errorf("invalid withdraw program: %v", err)
+			}
+		}
+		found := false
+		for _, pk := range publicKeys {
+			if bytes.Equal(pk[1:], publicKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("withdraw program is not signed by the main account")
+		}
+	}
+	return nil
+}
+
+// checkIllegalEvidence checks illegal side chain evidences are allowed.
+func (e *enforcer) checkIllegalEvidence() error {
+	return e.checkTxType(types.IllegalSidechainEvidence)
+}
+
+// checkAuxPow checks the auxpow is allowed for the side chain.
+func (e *enforcer) checkAuxPow(pow *payload.SideChainPow) error {
+	if err := e.checkTxType(types.SideChainPow); err != nil {
+		return err
+	}
+	if len(e.sideChains) == 0 {
+		return nil
+	}
+	if _, ok := e.sideChains[pow.SideGenesisHash]; !ok {
+		return fmt.Errorf("side chain %s is not allowed", pow.SideGenesisHash)
+	}
+	return nil
+}
+
+// allowSign counts a signature, false if the limit of the last minute is
+// reached.
+func (e *enforcer) allowSign(now time.Time) bool {
+	if e.limit == 0 {
+		return true
+	}
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	start := now.Add(-time.Minute)
+	i := 0
+	for i < len(e.signs) && !e.signs[i].After(start) {
+		i++
+	}
+	e.signs = e.signs[i:]
+	if len(e.signs) >= e.limit {
+		return false
+	}
+	e.signs = append(e.signs, now)
+	return true
+}
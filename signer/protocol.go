@@ -0,0 +1,48 @@
+package signer
+
+// The remote signer talks newline delimited JSON over a Unix socket, each
+// request is answered by a response of the same id. The data and the results
+// are hex encoded.
+const (
+	// methodPublicKey returns the encoded public key of the main account.
+	methodPublicKey = "publickey"
+
+	// methodRedeemScript returns the redeem script of the account of the
+	// code hash in the data.
+	methodRedeemScript = "redeemscript"
+
+	// methodSignTransaction signs the serialized transaction in the data and
+	// returns the serialized signed transaction.
+	methodSignTransaction = "signtransaction"
+
+	// methodSignWithdrawProposal signs the unsigned data of the serialized
+	// withdraw transaction in the data by the main account.
+	methodSignWithdrawProposal = "signwithdrawproposal"
+
+	// methodSignIllegalEvidence signs the unsigned serialized illegal side
+	// chain evidence in the data by the main account.
+	methodSignIllegalEvidence = "signillegalevidence"
+
+	// methodSignAuxPow signs the unsigned data of the serialized side chain
+	// pow payload in the data by the main account.
+	methodSignAuxPow = "signauxpow"
+
+	// methodSignHandshake signs the P2P handshake nonce in the data by the
+	// main account.
+	methodSignHandshake = "signhandshake"
+)
+
+// errNoAccount is the error message of ErrNoAccount in the responses.
+const errNoAccount = "no account"
+
+type request struct {
+	ID     uint64 `json:"id"`
+	Method string `json:"method"`
+	Data   string `json:"data,omitempty"`
+}
+
+type response struct {
+	ID     uint64 `json:"id"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
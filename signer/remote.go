@@ -0,0 +1,194 @@
+package signer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+// DefaultTimeout is the time a request to the remote signer is given if the
+// timeout is not set.
+const DefaultTimeout = 10 * time.Second
+
+// Remote signs with a signer daemon reached over a Unix socket, the keys are
+// kept in the daemon. The connection is opened again if a request fails.
+type Remote struct {
+	socket  string
+	timeout time.Duration
+
+	mux    sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID uint64
+
+	publicKey *crypto.PublicKey
+}
+
+// NewRemote connects to the signer daemon listening on the socket and gets
+// the public key of its main account.
+func NewRemote(socket string, timeout time.Duration) (*Remote, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r := &Remote{socket: socket, timeout: timeout}
+	data, err := r.call(methodPublicKey, nil)
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("connect signer %s error: %v", socket, err)
+	}
+	r.publicKey, err = crypto.DecodePoint(data)
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("invalid public key from signer %s: %v", socket, err)
+	}
+	return r, nil
+}
+
+func (r *Remote) PublicKey() *crypto.PublicKey {
+	return r.publicKey
+}
+
+func (r *Remote) RedeemScript(codeHash common.Uint160) ([]byte, error) {
+	return r.call(methodRedeemScript, codeHash.Bytes())
+}
+
+// SignTransaction sends the transaction to the daemon and copies the signed
+// programs back to the transaction.
+func (r *Remote) SignTransaction(txn *types.Transaction) (*types.Transaction, error) {
+	buf := new(bytes.Buffer)
+	if err := txn.Serialize(buf); err != nil {
+		return nil, err
+	}
+	data, err := r.call(methodSignTransaction, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var signed types.Transaction
+	if err := signed.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("invalid transaction from signer: %v", err)
+	}
+	if signed.Hash() != txn.Hash() {
+		return nil, errors.New("signer returned a different transaction")
+	}
+	txn.Programs = signed.Programs
+	return txn, nil
+}
+
+// SignWithdrawProposal sends the whole transaction, so the daemon can check
+// the programs as well as the signed data.
+func (r *Remote) SignWithdrawProposal(txn *types.Transaction) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := txn.Serialize(buf); err != nil {
+		return nil, err
+	}
+	return r.call(methodSignWithdrawProposal, buf.Bytes())
+}
+
+func (r *Remote) SignIllegalEvidence(evidence *payload.SidechainIllegalData) ([]byte, error) {
+	data, err := illegalEvidenceData(evidence)
+	if err != nil {
+		return nil, err
+	}
+	return r.call(methodSignIllegalEvidence, data)
+}
+
+func (r *Remote) SignAuxPow(pow *payload.SideChainPow) ([]byte, error) {
+	data, err := auxPowData(pow)
+	if err != nil {
+		return nil, err
+	}
+	return r.call(methodSignAuxPow, data)
+}
+
+func (r *Remote) SignHandshake(nonce []byte) ([]byte, error) {
+	data, err := handshakeData(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return r.call(methodSignHandshake, data)
+}
+
+// Close closes the connection to the daemon.
+func (r *Remote) Close() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.closeConn()
+}
+
+func (r *Remote) closeConn() error {
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn, r.reader = nil, nil
+	return err
+}
+
+// call sends a request to the daemon and waits for its response, the
+// connection is closed if it fails so the next call connects again.
+func (r *Remote) call(method string, data []byte) ([]byte, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.conn == nil {
+		conn, err := net.DialTimeout("unix", r.socket, r.timeout)
+		if err != nil {
+			return nil, err
+		}
+		r.conn, r.reader = conn, bufio.NewReader(conn)
+	}
+
+	r.nextID++
+	resp, err := r.roundTrip(&request{
+		ID:     r.nextID,
+		Method: method,
+		Data:   hex.EncodeToString(data),
+	})
+	if err != nil {
+		r.closeConn()
+		return nil, err
+	}
+	if resp.Error == errNoAccount {
+		return nil, ErrNoAccount
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("signer refused %s: %s", method, resp.Error)
+	}
+	return hex.DecodeString(resp.Result)
+}
+
+func (r *Remote) roundTrip(req *request) (*response, error) {
+	if err := r.conn.SetDeadline(time.Now().Add(r.timeout)); err != nil {
+		return nil, err
+	}
+	msg, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.conn.Write(append(msg, '\n')); err != nil {
+		return nil, err
+	}
+	line, err := r.reader.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ID != req.ID {
+		return nil, fmt.Errorf("response id %d does not match request id %d", resp.ID, req.ID)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,304 @@
+package signer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+)
+
+// maxRequestSize limits the size of a request line.
+const maxRequestSize = 4 * 1024 * 1024
+
+// Server is the signer daemon, it signs with a local keystore the requests
+// the policy allows. The socket is accessible to its owner only, the
+// directory of the socket should be too.
+type Server struct {
+	keystore keystore
+	enforcer *enforcer
+
+	mux      sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// keystore is the signer of the daemon, which knows the accounts of the
+// keystore and signs the data of the contents the daemon checked.
+type keystore interface {
+	Signer
+	sign(data []byte) ([]byte, error)
+	hasAccount(programHash common.Uint168) bool
+}
+
+// NewServer returns the daemon signing with the keystore by the policy.
+func NewServer(local *Local, policy *Policy) (*Server, error) {
+	return newServer(local, policy)
+}
+
+func newServer(keystore keystore, policy *Policy) (*Server, error) {
+	enforcer, err := newEnforcer(policy)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		keystore: keystore,
+		enforcer: enforcer,
+		conns:    make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// Listen listens on the socket, a socket file left by a daemon before is
+// removed. The socket is accessible to the owner only from its creation.
+func (s *Server) Listen(socket string) error {
+	if info, err := os.Lstat(socket); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("%s exists and is not a socket", socket)
+		}
+		if err := os.Remove(socket); err != nil {
+			return err
+		}
+	}
+
+	// the socket is created in a new directory only the owner can access,
+	// and moved to its path after its mode is set, so no other user can
+	// connect before
+	dir, err := ioutil.TempDir(filepath.Dir(socket), ".signer")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "signer.sock")
+	listener, err := net.Listen("unix", tmp)
+	if err != nil {
+		return err
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+	if err := os.Rename(tmp, socket); err != nil {
+		listener.Close()
+		return err
+	}
+	s.mux.Lock()
+	s.listener = listener
+	s.mux.Unlock()
+	return nil
+}
+
+// Serve accepts the connections until the daemon is closed.
+func (s *Server) Serve() error {
+	s.mux.Lock()
+	listener := s.listener
+	s.mux.Unlock()
+	if listener == nil {
+		return errors.New("signer is not listening")
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mux.Lock()
+			closed := s.listener == nil
+			s.mux.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		s.mux.Lock()
+		s.conns[conn] = struct{}{}
+		s.mux.Unlock()
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops the daemon and waits for the connections to be closed.
+func (s *Server) Close() error {
+	s.mux.Lock()
+	listener := s.listener
+	s.listener = nil
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mux.Unlock()
+
+	var err error
+	if listener != nil {
+		err = listener.Close()
+	}
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.mux.Lock()
+		delete(s.conns, conn)
+		s.mux.Unlock()
+		s.wg.Done()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxRequestSize)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			logger.Warnw("invalid request", log.KeyError, err)
+			return
+		}
+		resp := &response{ID: req.ID}
+		result, err := s.handle(&req)
+		if err == ErrNoAccount {
+			resp.Error = errNoAccount
+		} else if err != nil {
+			logger.Warnw("request refused", "method", req.Method, log.KeyError, err)
+			resp.Error = err.Error()
+		} else {
+			resp.Result = hex.EncodeToString(result)
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(req *request) ([]byte, error) {
+	data, err := hex.DecodeString(req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data: %v", err)
+	}
+
+	switch req.Method {
+	case methodPublicKey:
+		return s.keystore.PublicKey().EncodePoint(true)
+
+	case methodRedeemScript:
+		codeHash, err := common.Uint160FromBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		return s.keystore.RedeemScript(codeHash)
+
+	case methodSignTransaction:
+		var txn types.Transaction
+		if err := txn.Deserialize(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("invalid transaction: %v", err)
+		}
+		if err := s.enforcer.checkTransaction(&txn, s.keystore); err != nil {
+			return nil, err
+		}
+		if !s.enforcer.allowSign(time.Now()) {
+			return nil, errors.New("too many signatures")
+		}
+		logger.Infow("sign transaction", log.KeyTx, txn.Hash(), "txtype", txn.TxType.Name())
+		if _, err := s.keystore.SignTransaction(&txn); err != nil {
+			return nil, err
+		}
+		buf := new(bytes.Buffer)
+		if err := txn.Serialize(buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	case methodSignWithdrawProposal:
+		var txn types.Transaction
+		if err := deserializeAll(data, txn.Deserialize); err != nil {
+			return nil, fmt.Errorf("invalid transaction: %v", err)
+		}
+		if err := s.enforcer.checkWithdrawProposal(&txn, s.keystore); err != nil {
+			return nil, err
+		}
+		logger.Infow("sign withdraw proposal", log.KeyProposal, txn.Hash())
+		return s.signContent(withdrawProposalData(&txn))
+
+	case methodSignIllegalEvidence:
+		var evidence payload.SidechainIllegalData
+		err := deserializeAll(data, func(r io.Reader) error {
+			return evidence.DeserializeUnsigned(r, payload.SidechainIllegalDataVersion)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid illegal evidence: %v", err)
+		}
+		if err := s.enforcer.checkIllegalEvidence(); err != nil {
+			return nil, err
+		}
+		logger.Infow("sign illegal evidence", "evidence", evidence.Hash())
+		return s.signContent(illegalEvidenceData(&evidence))
+
+	case methodSignAuxPow:
+		var pow payload.SideChainPow
+		if err := deserializeAll(data, func(r io.Reader) error {
+			return deserializeAuxPow(r, &pow)
+		}); err != nil {
+			return nil, fmt.Errorf("invalid auxpow: %v", err)
+		}
+		if err := s.enforcer.checkAuxPow(&pow); err != nil {
+			return nil, err
+		}
+		logger.Infow("sign auxpow", "sidegenesishash", pow.SideGenesisHash, log.KeyHeight, pow.BlockHeight)
+		return s.signContent(auxPowData(&pow))
+
+	case methodSignHandshake:
+		return s.signContent(handshakeData(data))
+	}
+	return nil, fmt.Errorf("unknown method %q", req.Method)
+}
+
+// signContent signs the data of a checked content by the main account, if
+// the rate limit allows.
+func (s *Server) signContent(data []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	if !s.enforcer.allowSign(time.Now()) {
+		return nil, errors.New("too many signatures")
+	}
+	return s.keystore.sign(data)
+}
+
+// deserializeAll deserializes the data and checks nothing is left, so the
+// signed content is exactly what was checked.
+func deserializeAll(data []byte, deserialize func(r io.Reader) error) error {
+	r := bytes.NewReader(data)
+	if err := deserialize(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("%d unexpected bytes at the end", r.Len())
+	}
+	return nil
+}
+
+// deserializeAuxPow reads the unsigned side chain pow payload.
+func deserializeAuxPow(r io.Reader, pow *payload.SideChainPow) error {
+	if err := pow.SideBlockHash.Deserialize(r); err != nil {
+		return err
+	}
+	if err := pow.SideGenesisHash.Deserialize(r); err != nil {
+		return err
+	}
+	var err error
+	pow.BlockHeight, err = common.ReadUint32(r)
+	return err
+}
@@ -0,0 +1,99 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/contract"
+	"github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+// HandshakeNonceSize is the size of the nonce of a P2P handshake.
+const HandshakeNonceSize = 16
+
+// ErrNoAccount is returned if the signer does not have the account.
+var ErrNoAccount = errors.New("account not found in the signer")
+
+// Signer signs with the keys of the arbiter, the keys can be kept out of the
+// arbiter process. There is no method to sign arbitrary data, each method
+// signs a kind of content the signer can check by itself.
+type Signer interface {
+	// PublicKey returns the public key of the main account.
+	PublicKey() *crypto.PublicKey
+
+	// RedeemScript returns the redeem script of the account of the code
+	// hash, ErrNoAccount if the signer does not have it.
+	RedeemScript(codeHash common.Uint160) ([]byte, error)
+
+	// SignTransaction signs the programs of the transaction by the accounts
+	// of the signer, the transaction is changed in place and returned.
+	SignTransaction(txn *types.Transaction) (*types.Transaction, error)
+
+	// SignWithdrawProposal returns the signature of the main account on the
+	// withdraw transaction proposed to the arbiters.
+	SignWithdrawProposal(txn *types.Transaction) ([]byte, error)
+
+	// SignIllegalEvidence returns the signature of the main account on the
+	// evidence of an illegal side chain block.
+	SignIllegalEvidence(evidence *payload.SidechainIllegalData) ([]byte, error)
+
+	// SignAuxPow returns the signature of the main account on the side
+	// chain pow payload.
+	SignAuxPow(pow *payload.SideChainPow) ([]byte, error)
+
+	// SignHandshake returns the signature of the main account on the nonce
+	// of a P2P handshake.
+	SignHandshake(nonce []byte) ([]byte, error)
+}
+
+// MainAccount returns the address and the redeem script of the main account
+// of the signer.
+func MainAccount(s Signer) (string, []byte, error) {
+	ct, err := contract.CreateStandardContract(s.PublicKey())
+	if err != nil {
+		return "", nil, err
+	}
+	address, err := ct.ToProgramHash().ToAddress()
+	if err != nil {
+		return "", nil, err
+	}
+	return address, ct.Code, nil
+}
+
+// withdrawProposalData returns the data signed for a withdraw proposal.
+func withdrawProposalData(txn *types.Transaction) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := txn.SerializeUnsigned(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// illegalEvidenceData returns the data signed for an illegal evidence.
+func illegalEvidenceData(evidence *payload.SidechainIllegalData) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := evidence.SerializeUnsigned(buf, payload.SidechainIllegalDataVersion); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// auxPowData returns the data signed for a side chain pow payload.
+func auxPowData(pow *payload.SideChainPow) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := pow.SerializeUnsigned(buf, payload.SideChainPowVersion); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// handshakeData checks the size of the nonce of a handshake.
+func handshakeData(nonce []byte) ([]byte, error) {
+	if len(nonce) != HandshakeNonceSize {
+		return nil, errors.New("invalid handshake nonce size")
+	}
+	return nonce, nil
+}
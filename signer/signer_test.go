@@ -0,0 +1,347 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+
+	"github.com/elastos/Elastos.ELA/account"
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/contract/program"
+	"github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+// fakeKeystore has the accounts of a keystore, the signatures are the hashes
+// of the data, so the tests do not depend on the signing of the keys.
+type fakeKeystore struct {
+	*Local
+}
+
+func (k *fakeKeystore) sign(data []byte) ([]byte, error) {
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+func (k *fakeKeystore) SignTransaction(txn *types.Transaction) (*types.Transaction, error) {
+	buf := new(bytes.Buffer)
+	if err := txn.SerializeUnsigned(buf); err != nil {
+		return nil, err
+	}
+	for _, program := range txn.Programs {
+		program.Parameter, _ = k.sign(buf.Bytes())
+	}
+	return txn, nil
+}
+
+// startServer serves a new keystore by the policy on a socket in dir.
+func startServer(t *testing.T, dir string, policy *Policy) (*fakeKeystore, string, *Server) {
+	client, err := account.Create(filepath.Join(dir, "keystore.dat"), []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystore := &fakeKeystore{NewLocal(client)}
+	server, err := newServer(keystore, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "signer.sock")
+	if err := server.Listen(socket); err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve()
+	return keystore, socket, server
+}
+
+func newTransaction(txType types.TxType, code []byte, to common.Uint168) *types.Transaction {
+	var p types.Payload = &payload.TransferAsset{}
+	if txType == types.Record {
+		p = &payload.Record{}
+	}
+	return &types.Transaction{
+		TxType:  txType,
+		Payload: p,
+		Inputs: []*types.Input{{
+			Previous: types.OutPoint{TxID: common.Uint256{1}, Index: 0},
+		}},
+		Outputs: []*types.Output{{
+			AssetID:     common.Uint256{2},
+			Value:       common.Fixed64(100),
+			ProgramHash: to,
+		}},
+		Programs: []*program.Program{{Code: code}},
+	}
+}
+
+func TestRemote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log.Init(filepath.Join(dir, "logs"), 5, 0, 0)
+
+	keystore, socket, server := startServer(t, dir, DefaultPolicy())
+	defer server.Close()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode %v, expected 0600", info.Mode().Perm())
+	}
+	if dirs, _ := filepath.Glob(filepath.Join(dir, ".signer*")); len(dirs) != 0 {
+		t.Errorf("directories of the socket creation are left: %v", dirs)
+	}
+
+	remote, err := NewRemote(socket, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+
+	if !crypto.Equal(remote.PublicKey(), keystore.PublicKey()) {
+		t.Fatal("public key of the remote signer is different")
+	}
+
+	nonce := make([]byte, HandshakeNonceSize)
+	signature, err := remote.SignHandshake(nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := keystore.sign(nonce); !bytes.Equal(signature, expected) {
+		t.Errorf("signature %x, expected %x", signature, expected)
+	}
+
+	address, code, err := MainAccount(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	programHash, err := common.Uint168FromAddress(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := remote.RedeemScript(programHash.ToCodeHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(script, code) {
+		t.Errorf("redeem script %x, expected %x", script, code)
+	}
+	if _, err := remote.RedeemScript(common.Uint160{1}); err != ErrNoAccount {
+		t.Errorf("redeem script of unknown account error %v, expected ErrNoAccount", err)
+	}
+
+	txn := newTransaction(types.TransferAsset, code, *programHash)
+	if _, err := remote.SignTransaction(txn); err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.Programs) != 1 || len(txn.Programs[0].Parameter) == 0 {
+		t.Fatal("transaction is not signed")
+	}
+
+	refused := []struct {
+		name string
+		txn  *types.Transaction
+		err  string
+	}{
+		{"type", newTransaction(types.Record, code, *programHash), "type Record is not allowed"},
+		{"output", newTransaction(types.TransferAsset, code, common.Uint168{0x21, 1}), "is not allowed"},
+	}
+	for _, test := range refused {
+		if _, err := remote.SignTransaction(test.txn); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, expected %q", test.name, err, test.err)
+		}
+	}
+
+	// The remote signer connects again after the daemon is restarted.
+	server.Close()
+	if _, err := remote.SignHandshake(nonce); err == nil {
+		t.Error("sign succeeded after the daemon is closed")
+	}
+	server, err = newServer(keystore, DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Listen(socket); err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve()
+	defer server.Close()
+	if _, err := remote.SignHandshake(nonce); err != nil {
+		t.Errorf("sign after the daemon is restarted error: %v", err)
+	}
+}
+
+func TestEnforcer_AllowSign(t *testing.T) {
+	e, err := newEnforcer(&Policy{MaxSignsPerMinute: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if !e.allowSign(now) || !e.allowSign(now.Add(time.Second)) {
+		t.Fatal("signs under the limit are refused")
+	}
+	if e.allowSign(now.Add(2 * time.Second)) {
+		t.Error("sign over the limit is allowed")
+	}
+	if !e.allowSign(now.Add(time.Minute + time.Millisecond)) {
+		t.Error("sign after a minute is refused")
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"default", `{}`, ""},
+		{"types", `{"TxTypes": ["SideChainPow"], "MaxSignsPerMinute": 10}`, ""},
+		{"unknown field", `{"TxType": ["SideChainPow"]}`, "unknown field"},
+		{"unknown type", `{"TxTypes": ["Transfer"]}`, "unknown transaction type"},
+		{"address", `{"OutputAddresses": ["invalid"]}`, "invalid output address"},
+		{"limit", `{"MaxSignsPerMinute": -1}`, "invalid MaxSignsPerMinute"},
+	}
+	for i, test := range tests {
+		file := filepath.Join(dir, "policy"+string(rune('0'+i))+".json")
+		if err := ioutil.WriteFile(file, []byte(test.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		policy, err := LoadPolicy(file)
+		if err == nil {
+			_, err = newEnforcer(policy)
+		}
+		if test.err == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		} else if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: error %v, expected %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestServer_TypedRequests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log.Init(filepath.Join(dir, "logs"), 5, 0, 0)
+
+	sideChain := common.Uint256{3}
+	policy := DefaultPolicy()
+	policy.SideGenesisHashes = []string{common.BytesToHexString(sideChain.Bytes())}
+	keystore, socket, server := startServer(t, dir, policy)
+	defer server.Close()
+	remote, err := NewRemote(socket, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+
+	_, other, _ := crypto.GenerateKeyPair()
+	_, another, _ := crypto.GenerateKeyPair()
+	withdrawCode, err := base.CreateWithdrawRedeemScript(1, []*crypto.PublicKey{keystore.PublicKey(), other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCode, err := base.CreateWithdrawRedeemScript(1, []*crypto.PublicKey{other, another})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newWithdraw := func(code []byte) *types.Transaction {
+		txn := newTransaction(types.WithdrawFromSideChain, code, common.Uint168{0x21, 1})
+		txn.Payload = &payload.WithdrawFromSideChain{SideChainTransactionHashes: []common.Uint256{{4}}}
+		return txn
+	}
+
+	withdraw := newWithdraw(withdrawCode)
+	signature, err := remote.SignWithdrawProposal(withdraw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := withdrawProposalData(withdraw)
+	if expected, _ := keystore.sign(data); !bytes.Equal(signature, expected) {
+		t.Errorf("withdraw signature %x, expected %x", signature, expected)
+	}
+
+	pow := &payload.SideChainPow{SideBlockHash: common.Uint256{5}, SideGenesisHash: sideChain, BlockHeight: 10}
+	signature, err = remote.SignAuxPow(pow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = auxPowData(pow)
+	if expected, _ := keystore.sign(data); len(data) != 68 || !bytes.Equal(signature, expected) {
+		t.Errorf("auxpow signature %x, expected %x", signature, expected)
+	}
+
+	if _, err := remote.SignIllegalEvidence(&payload.SidechainIllegalData{IllegalType: payload.SidechainIllegalProposal}); err != nil {
+		t.Error(err)
+	}
+
+	refused := []struct {
+		name string
+		sign func() error
+		err  string
+	}{
+		{"withdraw of other arbiters", func() error {
+			_, err := remote.SignWithdrawProposal(newWithdraw(otherCode))
+			return err
+		}, "not signed by the main account"},
+		{"withdraw of standard account", func() error {
+			_, err := remote.SignWithdrawProposal(newWithdraw(keystore.mainCode(t)))
+			return err
+		}, "invalid withdraw program"},
+		{"transfer as withdraw", func() error {
+			_, err := remote.SignWithdrawProposal(newTransaction(types.TransferAsset, withdrawCode, common.Uint168{}))
+			return err
+		}, "not a withdraw"},
+		{"auxpow of other side chain", func() error {
+			_, err := remote.SignAuxPow(&payload.SideChainPow{SideGenesisHash: common.Uint256{6}})
+			return err
+		}, "is not allowed"},
+		{"handshake size", func() error {
+			_, err := remote.call(methodSignHandshake, make([]byte, 32))
+			return err
+		}, "invalid handshake nonce size"},
+		{"trailing data", func() error {
+			data, _ := auxPowData(pow)
+			_, err := remote.call(methodSignAuxPow, append(data, 0))
+			return err
+		}, "unexpected bytes"},
+		{"raw data", func() error {
+			_, err := remote.call("sign", []byte("data to sign"))
+			return err
+		}, "unknown method"},
+	}
+	for _, test := range refused {
+		if err := test.sign(); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, expected %q", test.name, err, test.err)
+		}
+	}
+}
+
+// mainCode returns the redeem script of the main account.
+func (k *fakeKeystore) mainCode(t *testing.T) []byte {
+	_, code, err := MainAccount(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+	"github.com/elastos/Elastos.ELA.Arbiter/signer"
+
+	"github.com/urfave/cli"
+)
+
+var (
+	signerSocketFlag = cli.StringFlag{
+		Name:  "socket",
+		Usage: "Unix socket `<path>` to listen on, its directory should be accessible to the owner only",
+	}
+	signerPolicyFlag = cli.StringFlag{
+		Name:  "policy",
+		Usage: "policy `<file>` of what is signed, the default policy if not given",
+	}
+	signerPrintLevelFlag = cli.UintFlag{
+		Name:  "printlevel",
+		Usage: "log `<level>` of the signer, 0 is debug and 4 is fatal",
+		Value: 1,
+	}
+)
+
+func newSignerCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "signer",
+		Usage: "Run the signer daemon keeping the keystore out of the arbiter",
+		Flags: []cli.Flag{
+			signerSocketFlag,
+			signerPolicyFlag,
+			walletFileFlag,
+			accountPasswordFlag,
+			passwordSourceFlag,
+			signerPrintLevelFlag,
+		},
+		Action: runSigner,
+	}
+}
+
+// runSigner serves the signing requests of the arbiter until it is killed.
+func runSigner(c *cli.Context) error {
+	socket := c.String(signerSocketFlag.Name)
+	if socket == "" {
+		return errors.New("--socket is required")
+	}
+	policy := signer.DefaultPolicy()
+	if file := c.String(signerPolicyFlag.Name); file != "" {
+		var err error
+		if policy, err = signer.LoadPolicy(file); err != nil {
+			return err
+		}
+	}
+
+	client, err := openKeystore(c, c.String("wallet"), "")
+	if err != nil {
+		return err
+	}
+	server, err := signer.NewServer(signer.NewLocal(client), policy)
+	if err != nil {
+		return err
+	}
+
+	log.Init(filepath.Join(LogsPath, "signer"), uint8(c.Uint(signerPrintLevelFlag.Name)),
+		defaultArbiterMaxPerLogFileSize, defaultArbiterMaxLogsFolderSize)
+	if err := server.Listen(socket); err != nil {
+		return err
+	}
+	log.Info("Signer listening on", socket, "transaction types:", policy.TxTypes)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		log.Info("Signer stopping")
+		server.Close()
+	}()
+	err = server.Serve()
+	os.Remove(socket)
+	return err
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/net/servers"
+	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
+
+	"github.com/elastos/Elastos.ELA/dpos/p2p"
+	"github.com/urfave/cli"
+)
+
+// nodeStatus is the status of a running node printed by the status command.
+type nodeStatus struct {
+	URL             string                  `json:"URL"`
+	Version         string                  `json:"Version"`
+	SPVHeight       uint32                  `json:"SPVHeight"`
+	MainChainHeight uint32                  `json:"MainChainHeight"`
+	ArbiterPeers    int                     `json:"ArbiterPeers"`
+	ConnectedPeers  int                     `json:"ConnectedPeers"`
+	Readiness       servers.ReadinessReport `json:"Readiness"`
+}
+
+func newStatusCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "status",
+		Usage: "Show the status of a running node by its JSON-RPC server",
+		Flags: []cli.Flag{
+			rpcURLFlag,
+			rpcUserFlag,
+			rpcPasswordFlag,
+			rpcInsecureFlag,
+		},
+		Action: showStatus,
+	}
+}
+
+func showStatus(c *cli.Context) error {
+	// the config file is only needed for the values not given by the flags
+	loadErr := setupConfig(c)
+	url := c.String(rpcURLFlag.Name)
+	if url == "" {
+		if loadErr != nil {
+			return loadErr
+		}
+		url = nodeRPCURL()
+	}
+	client := &statusClient{
+		url:  strings.TrimSuffix(url, "/"),
+		user: c.String(rpcUserFlag.Name),
+		pass: c.String(rpcPasswordFlag.Name),
+		http: &http.Client{Timeout: 10 * time.Second},
+	}
+	if loadErr == nil && !c.IsSet(rpcUserFlag.Name) && !c.IsSet(rpcPasswordFlag.Name) {
+		client.user, client.pass = configCredential(&config.Parameters.RpcConfiguration)
+	}
+	if c.Bool(rpcInsecureFlag.Name) {
+		client.http.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+
+	status := nodeStatus{URL: client.url}
+	if err := client.call("getgitversion", &status.Version); err != nil {
+		return err
+	}
+	if err := client.call("getspvheight", &status.SPVHeight); err != nil {
+		return err
+	}
+	if err := client.call("getmainchainblockheight", &status.MainChainHeight); err != nil {
+		return err
+	}
+	var peers []struct {
+		ConnState string `json:"connstate"`
+	}
+	if err := client.call("getarbiterpeersinfo", &peers); err != nil {
+		return err
+	}
+	status.ArbiterPeers = len(peers)
+	for _, p := range peers {
+		if p.ConnState != p2p.CSNoneConnection.String() {
+			status.ConnectedPeers++
+		}
+	}
+	if err := client.get("/readyz", &status.Readiness); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(status)
+}
+
+// configCredential returns the credential of the config the status command
+// calls the node by, the legacy User and Pass or the first of Users.
+func configCredential(conf *config.RpcConfiguration) (string, string) {
+	if conf.User != "" || conf.Pass != "" || len(conf.Users) == 0 {
+		return conf.User, conf.Pass
+	}
+	return conf.Users[0].User, conf.Users[0].Pass
+}
+
+// nodeRPCURL returns the url of the JSON-RPC server of the node on this host.
+func nodeRPCURL() string {
+	scheme := "http"
+	if config.Parameters.RpcConfiguration.TLS.CertFile != "" {
+		scheme = "https"
+	}
+	return scheme + "://127.0.0.1:" + strconv.Itoa(config.Parameters.HttpJsonPort)
+}
+
+// statusClient queries the JSON-RPC server of a running node.
+type statusClient struct {
+	url  string
+	user string
+	pass string
+	http *http.Client
+}
+
+func (c *statusClient) call(method string, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"method": method,
+		"params": map[string]interface{}{},
+	})
+	if err != nil {
+		return err
+	}
+	data, _, err := c.do("POST", "", body)
+	if err != nil {
+		return fmt.Errorf("%s error: %v", method, err)
+	}
+
+	var resp rpc.Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("%s error: %s", method, strings.TrimSpace(string(data)))
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s error: %s", method, resp.Error.Message)
+	}
+	return rpc.Unmarshal(resp.Result, result)
+}
+
+func (c *statusClient) get(path string, result interface{}) error {
+	data, code, err := c.do("GET", path, nil)
+	if err != nil {
+		return fmt.Errorf("%s error: %v", path, err)
+	}
+	// the readiness report is the body of 503 too
+	if code != http.StatusOK && code != http.StatusServiceUnavailable {
+		return fmt.Errorf("%s error: %s", path, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, result)
+}
+
+func (c *statusClient) do(method, path string, body []byte) ([]byte, int, error) {
+	req, err := http.NewRequest(method, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.user != "" || c.pass != "" {
+		req.SetBasicAuth(c.user, c.pass)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, resp.StatusCode, fmt.Errorf("%s, give a credential of the node by --%s and --%s",
+			resp.Status, rpcUserFlag.Name, rpcPasswordFlag.Name)
+	}
+	return data, resp.StatusCode, nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+)
+
+func TestConfigCredential(t *testing.T) {
+	cases := []struct {
+		conf       config.RpcConfiguration
+		user, pass string
+	}{
+		{config.RpcConfiguration{}, "", ""},
+		{config.RpcConfiguration{User: "legacy", Pass: "l",
+			Users: []config.RpcUser{{User: "reader", Pass: "r", Role: "readonly"}}}, "legacy", "l"},
+		{config.RpcConfiguration{Users: []config.RpcUser{
+			{User: "reader", Pass: "r", Role: "readonly"},
+			{User: "admin", Pass: "a", Role: "admin"},
+		}}, "reader", "r"},
+	}
+	for _, c := range cases {
+		if user, pass := configCredential(&c.conf); user != c.user || pass != c.pass {
+			t.Errorf("expected %s:%s, got %s:%s", c.user, c.pass, user, pass)
+		}
+	}
+}
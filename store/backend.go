@@ -0,0 +1,36 @@
+package store
+
+import (
+	"fmt"
+)
+
+// Storage backends of the data stores, sqlite needs cgo while leveldb is
+// pure Go.
+const (
+	BackendSqlite  = "sqlite"
+	BackendLevelDB = "leveldb"
+)
+
+// OpenStores opens the data stores of the caches and the finished
+// transactions on the storage backend, sqlite if it is empty.
+func OpenStores(backend string) (*DataStoreImpl, FinishedTransactionsDataStore, error) {
+	switch backend {
+	case "", BackendSqlite:
+		dataStore, err := OpenDataStore()
+		if err != nil {
+			return nil, nil, err
+		}
+		finishedDataStore, err := OpenFinishedTxsDataStore()
+		if err != nil {
+			return nil, nil, err
+		}
+		return dataStore, finishedDataStore, nil
+	case BackendLevelDB:
+		if err := checkAndCreateArbiterDataDir(); err != nil {
+			return nil, nil, err
+		}
+		return OpenLevelDBDataStores(LevelDBName)
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
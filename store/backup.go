@@ -0,0 +1,219 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+)
+
+// DatabasePaths returns the paths of the databases of the arbiter.
+func DatabasePaths() []string {
+	var paths []string
+	for _, d := range databases() {
+		paths = append(paths, d.path())
+	}
+	return paths
+}
+
+// BackupManifestName is the file of a backup directory which lists the
+// databases of the backup set. It is written after all the databases are
+// copied, so an interrupted backup is never restored.
+const BackupManifestName = "manifest.json"
+
+// BackupManifest describes a backup set, Databases maps the file names of the
+// backups to their schema versions.
+type BackupManifest struct {
+	Time      time.Time
+	Databases map[string]int
+}
+
+// BackupAll copies the existing databases into dir with their file names and
+// writes the manifest of the set, it returns the paths of the copies.
+func BackupAll(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	manifestPath := filepath.Join(dir, BackupManifestName)
+	if exists, _ := PathExists(manifestPath); exists {
+		return nil, fmt.Errorf("%s already exists", manifestPath)
+	}
+	manifest := BackupManifest{Time: time.Now(), Databases: make(map[string]int)}
+	var backups []string
+	for _, d := range databases() {
+		path := d.path()
+		exists, err := PathExists(path)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		dest := filepath.Join(dir, filepath.Base(path))
+		if exists, _ := PathExists(dest); exists {
+			return nil, fmt.Errorf("%s already exists", dest)
+		}
+		if err = BackupDB(path, dest); err != nil {
+			return nil, fmt.Errorf("backup %s failed: %s", path, err)
+		}
+		version, err := CheckBackup(dest, d.migrations)
+		if err != nil {
+			return nil, fmt.Errorf("check %s failed: %s", dest, err)
+		}
+		manifest.Databases[filepath.Base(dest)] = version
+		backups = append(backups, dest)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(manifestPath, data, 0600); err != nil {
+		return nil, err
+	}
+	return backups, nil
+}
+
+// ReadBackupManifest reads the manifest of the backup set in dir.
+func ReadBackupManifest(dir string) (*BackupManifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, BackupManifestName))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s is not a complete backup set, %s is missing", dir, BackupManifestName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest BackupManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", BackupManifestName, err)
+	}
+	return &manifest, nil
+}
+
+// CheckBackup verifies the integrity and the schema version of the backup of
+// a database, it returns the schema version. Backups of older versions are
+// migrated when the arbiter starts.
+func CheckBackup(path string, migrations []Migration) (int, error) {
+	db, err := sql.Open(DriverName, "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var result string
+	if err = db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return 0, err
+	}
+	if result != "ok" {
+		return 0, fmt.Errorf("integrity check failed: %s", result)
+	}
+	version, err := schemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = pendingMigrations(version, migrations); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// RestoreAll checks the backup set in dir is complete and every backup has
+// the schema version recorded in the manifest, then replaces the databases
+// with the backups. Databases which are not in the set are removed, so the
+// databases never mix different backups. The arbiter must be stopped, it
+// returns the paths of the restored databases.
+func RestoreAll(dir string) ([]string, error) {
+	manifest, err := ReadBackupManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Databases) == 0 {
+		return nil, fmt.Errorf("no backup found in %s", dir)
+	}
+
+	type restore struct{ src, dest string }
+	var restores []restore
+	var removes []string
+	known := make(map[string]bool)
+	for _, d := range databases() {
+		name := filepath.Base(d.path())
+		known[name] = true
+		version, ok := manifest.Databases[name]
+		if !ok {
+			removes = append(removes, d.path())
+			continue
+		}
+		src := filepath.Join(dir, name)
+		exists, err := PathExists(src)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("%s is not a complete backup set, %s is missing", dir, name)
+		}
+		backupVersion, err := CheckBackup(src, d.migrations)
+		if err != nil {
+			return nil, fmt.Errorf("check %s failed: %s", src, err)
+		}
+		if backupVersion != version {
+			return nil, fmt.Errorf("%s has schema version %d, but the manifest records %d",
+				src, backupVersion, version)
+		}
+		restores = append(restores, restore{src, d.path()})
+	}
+	for name := range manifest.Databases {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown database %s in the manifest", name)
+		}
+	}
+
+	// copy all the backups beside the databases first, nothing is replaced
+	// unless all the copies succeed
+	var staged []string
+	for _, r := range restores {
+		if err := os.MkdirAll(filepath.Dir(r.dest), 0740); err != nil {
+			removeFiles(staged)
+			return nil, err
+		}
+		tmp := r.dest + ".restore"
+		os.Remove(tmp)
+		if err := BackupDB(r.src, tmp); err != nil {
+			removeFiles(append(staged, tmp))
+			return nil, fmt.Errorf("restore %s failed: %s", r.dest, err)
+		}
+		staged = append(staged, tmp)
+	}
+
+	var restored []string
+	for i, r := range restores {
+		// a journal left by the replaced database would be rolled back into
+		// the restored one
+		removeFiles(sqliteJournals(r.dest))
+		if err := os.Rename(staged[i], r.dest); err != nil {
+			removeFiles(staged[i:])
+			return restored, fmt.Errorf("restore %s failed: %s", r.dest, err)
+		}
+		restored = append(restored, r.dest)
+	}
+	for _, path := range removes {
+		removeFiles(append(sqliteJournals(path), path))
+	}
+	return restored, nil
+}
+
+func sqliteJournals(path string) []string {
+	return []string{path + "-journal", path + "-wal", path + "-shm"}
+}
+
+func removeFiles(paths []string) {
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.Warnw("remove file failed", "path", path, log.KeyError, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+//go:build cgo
+// +build cgo
+
+package store
+
+import (
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// BackupDB copies a consistent snapshot of the database at src to dest by the
+// online backup API of sqlite, the database can be written meanwhile by a
+// running arbiter.
+func BackupDB(src, dest string) error {
+	driver := new(sqlite3.SQLiteDriver)
+	srcConn, err := driver.Open("file:" + src + "?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+	destConn, err := driver.Open(dest)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	backup, err := destConn.(*sqlite3.SQLiteConn).Backup("main", srcConn.(*sqlite3.SQLiteConn), "main")
+	if err != nil {
+		return err
+	}
+	// copy all the pages in one step, so the snapshot is not restarted by the
+	// writes of the arbiter
+	done, err := backup.Step(-1)
+	if err != nil {
+		backup.Finish()
+		return err
+	}
+	if !done {
+		backup.Finish()
+		return errors.New("backup is not completed")
+	}
+	return backup.Finish()
+}
@@ -0,0 +1,14 @@
+//go:build !cgo
+// +build !cgo
+
+package store
+
+import (
+	"errors"
+)
+
+// BackupDB needs the online backup API of sqlite, which is only built with
+// cgo.
+func BackupDB(src, dest string) error {
+	return errors.New("backup of sqlite databases requires a build with cgo")
+}
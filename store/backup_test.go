@@ -0,0 +1,197 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arbiter-backup-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mainChain, sideChain, finishedTxs := DBNameMainChain, DBNameSideChain, FinishedTxsDBName
+	defer func() { DBNameMainChain, DBNameSideChain, FinishedTxsDBName = mainChain, sideChain, finishedTxs }()
+	DBNameMainChain = filepath.Join(dir, "data", "mainChainCache.db")
+	DBNameSideChain = filepath.Join(dir, "data", "sideChainCache.db")
+	FinishedTxsDBName = filepath.Join(dir, "data", "finishedTxs.db")
+
+	finishedStore, err := OpenFinishedTxsDataStore()
+	if err != nil {
+		t.Fatal("Open database error:", err)
+	}
+	finishedStore.AddSucceedDepositTxs(context.Background(), []string{"testHash1"}, []string{"testAddress"})
+	finishedStore.AddFailedWithdrawTxs(context.Background(), []string{"testHash2"}, []byte{1})
+
+	// the database is kept open and written as a running arbiter does
+	backupDir := filepath.Join(dir, "backup")
+	backups, err := BackupAll(backupDir)
+	if err != nil {
+		t.Fatal("Backup error:", err)
+	}
+	if len(backups) != 1 || filepath.Base(backups[0]) != "finishedTxs.db" {
+		t.Fatal("Only the existing databases should be backed up, got", backups)
+	}
+	finishedStore.AddSucceedDepositTxs(context.Background(), []string{"testHash3"}, []string{"testAddress"})
+	if _, err = BackupAll(backupDir); err == nil {
+		t.Error("Existing backups should not be overwritten.")
+	}
+
+	txs, err := ExportTransactions(DBNameMainChain, DBNameSideChain, backups[0])
+	if err != nil {
+		t.Fatal("Export error:", err)
+	}
+	if len(txs) != 2 || txs[0].TransactionHash != "testHash1" || txs[0].State != ExportStateSucceeded ||
+		txs[1].Type != TxTypeWithdraw || txs[1].State != ExportStateFailed {
+		t.Error("Export should be the snapshot of the backup.")
+	}
+
+	if _, err = RestoreAll(dir); err == nil {
+		t.Error("Restore without backups should fail.")
+	}
+
+	// a database created after the backup is not in the set and is removed,
+	// so it is not mixed with the restored ones
+	mainChainDB, err := openDB(DBNameMainChain, MainChainMigrations)
+	if err != nil {
+		t.Fatal("Open database error:", err)
+	}
+	mainChainDB.Close()
+
+	// the arbiter is stopped for the restore and started again
+	finishedStore.Close()
+	restored, err := RestoreAll(backupDir)
+	if err != nil || len(restored) != 1 || restored[0] != FinishedTxsDBName {
+		t.Fatal("Restore error:", restored, err)
+	}
+	finishedStore, err = OpenFinishedTxsDataStore()
+	if err != nil {
+		t.Fatal("Open database error:", err)
+	}
+	defer finishedStore.Close()
+	if ok, _ := finishedStore.HasDepositTx(context.Background(), "testHash3", "testAddress"); ok {
+		t.Error("Restored database should not have the transaction added after the backup.")
+	}
+	if ok, _ := finishedStore.HasDepositTx(context.Background(), "testHash1", "testAddress"); !ok {
+		t.Error("Restored database should have the transaction of the backup.")
+	}
+	if exists, _ := PathExists(DBNameMainChain); exists {
+		t.Error("Database which is not in the backup set should be removed.")
+	}
+
+	// backups of newer schema versions are refused
+	db, _ := sql.Open(DriverName, backups[0])
+	db.Exec("UPDATE Info SET Value=? WHERE Name=?", 100, SchemaVersionName)
+	db.Close()
+	if _, err = CheckBackup(backups[0], FinishedTxsMigrations); err == nil {
+		t.Error("Backup of newer schema version should be refused.")
+	}
+	if _, err = RestoreAll(backupDir); err == nil {
+		t.Error("Restore should check the backups.")
+	}
+}
+
+func TestRestoreAll_IncompleteSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arbiter-restore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mainChain, sideChain, finishedTxs := DBNameMainChain, DBNameSideChain, FinishedTxsDBName
+	defer func() { DBNameMainChain, DBNameSideChain, FinishedTxsDBName = mainChain, sideChain, finishedTxs }()
+	DBNameMainChain = filepath.Join(dir, "data", "mainChainCache.db")
+	DBNameSideChain = filepath.Join(dir, "data", "sideChainCache.db")
+	FinishedTxsDBName = filepath.Join(dir, "data", "finishedTxs.db")
+
+	for _, d := range []database{
+		{func() string { return DBNameMainChain }, MainChainMigrations},
+		{func() string { return FinishedTxsDBName }, FinishedTxsMigrations},
+	} {
+		db, err := openDB(d.path(), d.migrations)
+		if err != nil {
+			t.Fatal("Open database error:", err)
+		}
+		db.Close()
+	}
+	backupDir := filepath.Join(dir, "backup")
+	if _, err = BackupAll(backupDir); err != nil {
+		t.Fatal("Backup error:", err)
+	}
+	manifest, err := ReadBackupManifest(backupDir)
+	if err != nil || len(manifest.Databases) != 2 {
+		t.Fatal("Manifest should list the backups:", manifest, err)
+	}
+
+	// the databases are written after the backup
+	db, _ := sql.Open(DriverName, FinishedTxsDBName)
+	db.Exec("INSERT INTO Info(Name, Value) VALUES(?,?)", "afterBackup", 1)
+	db.Close()
+	checkUntouched := func() {
+		db, _ := sql.Open(DriverName, FinishedTxsDBName)
+		defer db.Close()
+		var count int
+		db.QueryRow("SELECT count(*) FROM Info WHERE Name=?", "afterBackup").Scan(&count)
+		if count != 1 {
+			t.Error("Databases should not be replaced by an incomplete restore.")
+		}
+	}
+
+	// a backup listed in the manifest is missing
+	mainBackup := filepath.Join(backupDir, filepath.Base(DBNameMainChain))
+	os.Rename(mainBackup, mainBackup+".moved")
+	if _, err = RestoreAll(backupDir); err == nil {
+		t.Error("Restore of an incomplete set should fail.")
+	}
+	checkUntouched()
+	os.Rename(mainBackup+".moved", mainBackup)
+
+	// a backup does not have the schema version of the manifest
+	manifest.Databases[filepath.Base(DBNameMainChain)]--
+	data, _ := json.Marshal(manifest)
+	ioutil.WriteFile(filepath.Join(backupDir, BackupManifestName), data, 0600)
+	if _, err = RestoreAll(backupDir); err == nil {
+		t.Error("Restore of a backup of another version should fail.")
+	}
+	checkUntouched()
+
+	// an interrupted backup has no manifest
+	os.Remove(filepath.Join(backupDir, BackupManifestName))
+	if _, err = RestoreAll(backupDir); err == nil {
+		t.Error("Restore without a manifest should fail.")
+	}
+	checkUntouched()
+}
+
+func TestLockDataDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arbiter-lock-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lockFileName := LockFileName
+	defer func() { LockFileName = lockFileName }()
+	LockFileName = filepath.Join(dir, "data", "arbiter.lock")
+
+	lock, err := LockDataDir()
+	if err != nil {
+		t.Fatal("Lock error:", err)
+	}
+	if _, err = LockDataDir(); err != ErrDataDirLocked {
+		t.Error("Lock should be held, got", err)
+	}
+	lock.Release()
+	lock, err = LockDataDir()
+	if err != nil {
+		t.Fatal("Lock should be taken again after release:", err)
+	}
+	lock.Release()
+}
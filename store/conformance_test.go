@@ -0,0 +1,454 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
+
+	"github.com/elastos/Elastos.ELA.SPV/bloom"
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+)
+
+// The conformance tests run on all of the storage backends through the
+// interfaces, so the backends behave the same to the arbiter.
+
+type conformanceStores struct {
+	main     DataStoreMainChain
+	side     DataStoreSideChain
+	finished FinishedTransactionsDataStore
+}
+
+func openSqliteStores(t *testing.T) (*conformanceStores, func()) {
+	mainChainStore, sideChainStore, finishedStore, cleanup := openTestStores(t)
+	return &conformanceStores{main: mainChainStore, side: sideChainStore, finished: finishedStore}, cleanup
+}
+
+func openLevelDBStores(t *testing.T) (*conformanceStores, func()) {
+	dir, err := ioutil.TempDir("", "leveldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataStore, finishedStore, err := OpenLevelDBDataStores(filepath.Join(dir, "leveldb"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal("Open leveldb error:", err)
+	}
+	return &conformanceStores{main: dataStore.MainChainStore, side: dataStore.SideChainStore,
+		finished: finishedStore}, func() {
+		dataStore.MainChainStore.Close()
+		dataStore.SideChainStore.Close()
+		finishedStore.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func runConformance(t *testing.T, test func(t *testing.T, s *conformanceStores)) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) (*conformanceStores, func())
+	}{
+		{BackendSqlite, openSqliteStores},
+		{BackendLevelDB, openLevelDBStores},
+	}
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			stores, cleanup := backend.open(t)
+			defer cleanup()
+			test(t, stores)
+		})
+	}
+}
+
+func newTestMainChainTx(hash, genesisAddress string) *base.MainChainTransaction {
+	tx := &types.Transaction{TxType: types.WithdrawFromSideChain, Payload: new(payload.WithdrawFromSideChain)}
+	return &base.MainChainTransaction{TransactionHash: hash, GenesisBlockAddress: genesisAddress,
+		Transaction: tx, Proof: new(bloom.MerkleProof)}
+}
+
+func newTestSideChainTx(hash, genesisAddress string, height uint32) *base.SideChainTransaction {
+	// the height tells the transactions apart
+	txid := common.Uint256{byte(height)}
+	tx := &base.WithdrawTx{Txid: &txid, WithdrawInfo: new(base.WithdrawInfo)}
+	buf := new(bytes.Buffer)
+	tx.Serialize(buf)
+	return &base.SideChainTransaction{TransactionHash: hash, GenesisBlockAddress: genesisAddress,
+		Transaction: buf.Bytes(), BlockHeight: height}
+}
+
+func TestConformance_MainChainStore(t *testing.T) {
+	runConformance(t, func(t *testing.T, s *conformanceStores) {
+		ctx := context.Background()
+		if err := s.main.AddMainChainTx(ctx, newTestMainChainTx("testHash1", "testAddress")); err != nil {
+			t.Fatal("Add main chain transaction error:", err)
+		}
+		if err := s.main.AddMainChainTx(ctx, newTestMainChainTx("testHash1", "testAddress")); err == nil {
+			t.Error("Duplicated main chain transaction should be rejected.")
+		}
+		result, err := s.main.AddMainChainTxs(ctx, []*base.MainChainTransaction{
+			newTestMainChainTx("testHash1", "testAddress"),
+			newTestMainChainTx("testHash1", "otherAddress"),
+			newTestMainChainTx("testHash2", "testAddress"),
+		})
+		if err != nil || len(result) != 3 || result[0] || !result[1] || !result[2] {
+			t.Error("Add main chain transactions error:", result, err)
+		}
+
+		if ok, _ := s.main.HasMainChainTx(ctx, "testHash1", "otherAddress"); !ok {
+			t.Error("Should have specified transaction.")
+		}
+		// the transactions of GetAll are not ordered
+		hashes, addresses, err := s.main.GetAllMainChainTxHashes(ctx)
+		keys := make([]string, 0, len(hashes))
+		for i := range hashes {
+			keys = append(keys, hashes[i]+" "+addresses[i])
+		}
+		sort.Strings(keys)
+		if err != nil || strings.Join(keys, ",") != "testHash1 otherAddress,testHash1 testAddress,testHash2 testAddress" {
+			t.Error("Get all main chain transaction hashes error:", keys, err)
+		}
+		txs, err := s.main.GetAllMainChainTxs(ctx)
+		if err != nil || len(txs) != 3 || txs[0].Transaction.TxType != types.WithdrawFromSideChain {
+			t.Error("Get all main chain transactions error:", err)
+		}
+		spvTxs, err := s.main.GetMainChainTxsFromHashes(ctx, []string{"testHash2", "testHash1", "noHash"}, "testAddress")
+		if err != nil || len(spvTxs) != 2 || spvTxs[0].Proof == nil {
+			t.Error("Get main chain transactions from hashes error:", err)
+		}
+
+		if err = s.main.RemoveMainChainTx(ctx, "testHash1", "testAddress"); err != nil {
+			t.Error("Remove main chain transaction error:", err)
+		}
+		if err = s.main.RemoveMainChainTxs(ctx, []string{"testHash2", "noHash"},
+			[]string{"testAddress", "testAddress"}); err != nil {
+			t.Error("Remove main chain transactions error:", err)
+		}
+		if count, err := s.main.GetMainChainTxsCount(ctx); err != nil || count != 1 {
+			t.Error("Get main chain transactions count error:", count, err)
+		}
+
+		if height := s.main.CurrentHeight(ctx, QueryHeightCode); height != 0 {
+			t.Error("Initial height should be 0, got", height)
+		}
+		s.main.CurrentHeight(ctx, 100)
+		if height := s.main.CurrentHeight(ctx, 50); height != 100 {
+			t.Error("Height should not go back, got", height)
+		}
+		s.main.CurrentHeight(ctx, ResetHeightCode)
+		if height := s.main.CurrentHeight(ctx, QueryHeightCode); height != 0 {
+			t.Error("Height should be reset, got", height)
+		}
+
+		if err = s.main.ResetDataStore(); err != nil {
+			t.Fatal("Reset data store error:", err)
+		}
+		if count, _ := s.main.GetMainChainTxsCount(ctx); count != 0 {
+			t.Error("Reset data store should remove the transactions.")
+		}
+	})
+}
+
+func TestConformance_SideChainStore(t *testing.T) {
+	runConformance(t, func(t *testing.T, s *conformanceStores) {
+		ctx := context.Background()
+		if err := s.side.AddSideChainTx(ctx, newTestSideChainTx("testHash2", "testAddress", 10)); err != nil {
+			t.Fatal("Add side chain transaction error:", err)
+		}
+		if err := s.side.AddSideChainTx(ctx, newTestSideChainTx("testHash2", "otherAddress", 11)); err == nil {
+			t.Error("Duplicated side chain transaction should be rejected.")
+		}
+		err := s.side.AddSideChainTxs(ctx, []*base.SideChainTransaction{
+			newTestSideChainTx("testHash2", "testAddress", 10),
+			newTestSideChainTx("testHash1", "testAddress", 12),
+			newTestSideChainTx("testHash3", "otherAddress", 13),
+		})
+		if err != nil {
+			t.Error("Add side chain transactions error:", err)
+		}
+
+		if ok, _ := s.side.HasSideChainTx(ctx, "testHash3"); !ok {
+			t.Error("Should have specified transaction.")
+		}
+		hashes, err := s.side.GetAllSideChainTxHashes(ctx)
+		sort.Strings(hashes)
+		if err != nil || strings.Join(hashes, ",") != "testHash1,testHash2,testHash3" {
+			t.Error("Get all side chain transaction hashes error:", hashes, err)
+		}
+		hashes, heights, err := s.side.GetAllSideChainTxHashesAndHeights(ctx, "testAddress")
+		if err != nil || len(hashes) != 2 || hashes[1] != "testHash1" || heights[1] != 12 {
+			t.Error("Get side chain transaction hashes and heights error:", hashes, heights, err)
+		}
+		txs, err := s.side.GetSideChainTxsFromHashes(ctx, []string{"testHash2", "testHash1", "testHash2"})
+		if err != nil || len(txs) != 2 || txs[0].Txid[0] != 12 {
+			t.Error("Get side chain transactions from hashes error:", err)
+		}
+		txs, err = s.side.GetSideChainTxsFromHashesAndGenesisAddress(ctx,
+			[]string{"testHash3", "testHash1"}, "testAddress")
+		if err != nil || len(txs) != 1 || txs[0].Txid[0] != 12 {
+			t.Error("Get side chain transactions from hashes and genesis address error:", err)
+		}
+
+		if err = s.side.RemoveSideChainTxs(ctx, []string{"testHash1", "noHash"}); err != nil {
+			t.Error("Remove side chain transactions error:", err)
+		}
+		if count, err := s.side.GetSideChainTxsCount(ctx); err != nil || count != 2 {
+			t.Error("Get side chain transactions count error:", count, err)
+		}
+
+		// heights are only stored for the side chains in the configuration
+		if height := s.side.CurrentSideHeight(ctx, "unknownAddress", 100); height != 100 {
+			t.Error("Current side height error, got", height)
+		}
+		if height := s.side.CurrentSideHeight(ctx, "unknownAddress", QueryHeightCode); height != 0 {
+			t.Error("Height of unknown side chain should not be stored, got", height)
+		}
+
+		// side chains added later store their heights
+		if err := s.side.AddSideChain(ctx, "addedAddress"); err != nil {
+			t.Error("Add side chain error:", err)
+		}
+		s.side.CurrentSideHeight(ctx, "addedAddress", 100)
+		if err := s.side.AddSideChain(ctx, "addedAddress"); err != nil {
+			t.Error("Add side chain again error:", err)
+		}
+		if height := s.side.CurrentSideHeight(ctx, "addedAddress", QueryHeightCode); height != 100 {
+			t.Error("Height of added side chain should be stored, got", height)
+		}
+	})
+}
+
+func TestConformance_FinishedTxsStore(t *testing.T) {
+	runConformance(t, func(t *testing.T, s *conformanceStores) {
+		ctx := context.Background()
+		s.finished.AddSucceedDepositTxs(ctx, []string{"aa01", "aa01"}, []string{"testAddress2", "testAddress1"})
+		s.finished.AddFailedDepositTxs(ctx, []string{"aa01", "aa02"}, []string{"testAddress1", "testAddress1"})
+
+		succeed, addresses, err := s.finished.GetDepositTxByHash(ctx, "aa01")
+		if err != nil || len(succeed) != 2 || addresses[0] != "testAddress1" || !succeed[0] {
+			t.Error("Get deposit transaction by hash error:", succeed, addresses, err)
+		}
+		if ok, _ := s.finished.GetDepositTxByHashAndGenesisAddress(ctx, "aa02", "testAddress1"); ok {
+			t.Error("Failed deposit transaction should not be succeeded.")
+		}
+		if ok, _ := s.finished.HasDepositTx(ctx, "aa02", "testAddress2"); ok {
+			t.Error("Should not have specified transaction.")
+		}
+		if hashes, addresses, err := s.finished.GetDepositTxs(ctx, false); err != nil || len(hashes) != 1 ||
+			hashes[0] != "aa02" || addresses[0] != "testAddress1" {
+			t.Error("Get failed deposit transactions error:", hashes, err)
+		}
+
+		s.finished.AddSucceedWithdrawTxs(ctx, []string{"bb01"})
+		s.finished.AddFailedWithdrawTxs(ctx, []string{"bb02", "bb01"}, []byte{1, 2, 3})
+		if ok, data, err := s.finished.GetWithdrawTxByHash(ctx, "bb01"); err != nil || !ok || data != nil {
+			t.Error("Get succeed withdraw transaction error:", ok, data, err)
+		}
+		if ok, data, err := s.finished.GetWithdrawTxByHash(ctx, "bb02"); err != nil || ok || !bytes.Equal(data, []byte{1, 2, 3}) {
+			t.Error("Get failed withdraw transaction error:", ok, data, err)
+		}
+		if _, _, err := s.finished.GetWithdrawTxByHash(ctx, "noHash"); err == nil {
+			t.Error("Get missing withdraw transaction should fail.")
+		}
+		if hashes, err := s.finished.GetWithdrawTxs(ctx, true); err != nil || len(hashes) != 1 || hashes[0] != "bb01" {
+			t.Error("Get succeed withdraw transactions error:", hashes, err)
+		}
+		if ok, _ := s.finished.HasWithdrawTx(ctx, "bb02"); !ok {
+			t.Error("Should have specified transaction.")
+		}
+
+		if err = s.finished.AddSideChainTx(ctx, []byte{4, 5}); err != nil {
+			t.Error("Add side chain transaction error:", err)
+		}
+		if data, err := s.finished.GetSideChainTx(ctx, 2); err != nil || !bytes.Equal(data, []byte{4, 5}) {
+			t.Error("Get side chain transaction error:", data, err)
+		}
+		if _, err := s.finished.GetSideChainTx(ctx, 100); err == nil {
+			t.Error("Get missing side chain transaction should fail.")
+		}
+
+		s.finished.AddTransactionEvents(ctx, []*TransactionEvent{
+			{TransactionHash: "aa01", GenesisBlockAddress: "testAddress1", Type: TxTypeDeposit, Event: TxEventDetected},
+			{TransactionHash: "aa010", Type: TxTypeDeposit, Event: TxEventDetected},
+			{TransactionHash: "aa01", GenesisBlockAddress: "testAddress1", Type: TxTypeDeposit, Event: TxEventFailed,
+				Reason: "test"},
+		})
+		events, err := s.finished.GetTransactionEvents(ctx, "aa01")
+		if err != nil || len(events) != 2 || events[1].Event != TxEventFailed || events[1].Reason != "test" ||
+			events[1].RecordTime == "" {
+			t.Error("Get transaction events error:", events, err)
+		}
+
+		if err = s.finished.ResetDataStore(); err != nil {
+			t.Fatal("Reset data store error:", err)
+		}
+		if ok, _ := s.finished.HasWithdrawTx(ctx, "bb02"); ok {
+			t.Error("Reset data store should remove the transactions.")
+		}
+	})
+}
+
+func TestConformance_QueryFinishedTxs(t *testing.T) {
+	runConformance(t, func(t *testing.T, s *conformanceStores) {
+		ctx := context.Background()
+		s.finished.AddSucceedDepositTxs(ctx, []string{"aa01", "aa02", "bb03", "aa04"},
+			[]string{"testAddress1", "testAddress1", "testAddress1", "testAddress2"})
+		s.finished.AddFailedDepositTxs(ctx, []string{"aa05"}, []string{"testAddress1"})
+		s.finished.AddSucceedWithdrawTxs(ctx, []string{"cc01", "cc02"})
+
+		var hashes []string
+		query := &FinishedTxsQuery{GenesisBlockAddress: "testAddress1", Limit: 3}
+		for page := 0; page < 3; page++ {
+			txs, nextCursor, err := s.finished.QueryDepositTxs(ctx, query)
+			if err != nil {
+				t.Fatal("Query deposit transactions error:", err)
+			}
+			for _, tx := range txs {
+				hashes = append(hashes, tx.TransactionHash)
+			}
+			if nextCursor == "" {
+				break
+			}
+			query.Cursor = nextCursor
+		}
+		if len(hashes) != 4 || hashes[0] != "aa01" || hashes[2] != "bb03" || hashes[3] != "aa05" {
+			t.Error("Query deposit transactions error, got:", hashes)
+		}
+
+		succeed := true
+		txs, _, err := s.finished.QueryDepositTxs(ctx, &FinishedTxsQuery{Succeed: &succeed, HashPrefix: "aa", Limit: 10})
+		if err != nil || len(txs) != 3 || txs[2].GenesisBlockAddress != "testAddress2" {
+			t.Error("Query deposit transactions with filters error:", err)
+		}
+		txs, _, err = s.finished.QueryWithdrawTxs(ctx, &FinishedTxsQuery{StartTime: "2000-01-01_00.00.00", Limit: 10})
+		if err != nil || len(txs) != 2 || txs[0].GenesisBlockAddress != "" {
+			t.Error("Query withdraw transactions error:", err)
+		}
+
+		if _, _, err = s.finished.QueryWithdrawTxs(ctx, &FinishedTxsQuery{GenesisBlockAddress: "testAddress1",
+			Limit: 10}); err == nil {
+			t.Error("Withdraw transactions should not be queried by genesis block address.")
+		}
+		if _, _, err = s.finished.QueryDepositTxs(ctx, &FinishedTxsQuery{Cursor: "invalid", Limit: 10}); err == nil {
+			t.Error("Invalid cursor should be rejected.")
+		}
+		if _, _, err = s.finished.QueryDepositTxs(ctx, &FinishedTxsQuery{}); err == nil {
+			t.Error("Query without limit should be rejected.")
+		}
+	})
+}
+
+func TestConformance_MoveFinishedTxs(t *testing.T) {
+	runConformance(t, func(t *testing.T, s *conformanceStores) {
+		ctx := context.Background()
+		s.main.AddMainChainTxs(ctx, []*base.MainChainTransaction{
+			newTestMainChainTx("testHash1", "testAddress"), newTestMainChainTx("testHash2", "testAddress")})
+		s.side.AddSideChainTxs(ctx, []*base.SideChainTransaction{
+			newTestSideChainTx("testHash3", "testAddress", 10), newTestSideChainTx("testHash4", "testAddress", 10)})
+
+		if err := s.finished.MoveSucceedDepositTxs(ctx, []string{"testHash1"}, []string{"testAddress"}); err != nil {
+			t.Fatal("Move succeed deposit transactions error:", err)
+		}
+		if err := s.finished.MoveFailedDepositTxs(ctx, []string{"testHash2"}, []string{"testAddress"}); err != nil {
+			t.Fatal("Move failed deposit transactions error:", err)
+		}
+		if err := s.finished.MoveSucceedWithdrawTxs(ctx, []string{"testHash3"}); err != nil {
+			t.Fatal("Move succeed withdraw transactions error:", err)
+		}
+		if err := s.finished.MoveFailedWithdrawTxs(ctx, []string{"testHash4"}, []byte{1}); err != nil {
+			t.Fatal("Move failed withdraw transactions error:", err)
+		}
+
+		if count, _ := s.main.GetMainChainTxsCount(ctx); count != 0 {
+			t.Error("Moved deposit transactions should be removed from the cache.")
+		}
+		if count, _ := s.side.GetSideChainTxsCount(ctx); count != 0 {
+			t.Error("Moved withdraw transactions should be removed from the cache.")
+		}
+		if ok, _ := s.finished.GetDepositTxByHashAndGenesisAddress(ctx, "testHash1", "testAddress"); !ok {
+			t.Error("Moved deposit transaction should be succeeded.")
+		}
+		if ok, data, _ := s.finished.GetWithdrawTxByHash(ctx, "testHash4"); ok || !bytes.Equal(data, []byte{1}) {
+			t.Error("Moved withdraw transaction should be failed.")
+		}
+
+		// finished but cached again
+		s.main.AddMainChainTx(ctx, newTestMainChainTx("testHash1", "testAddress"))
+		s.side.AddSideChainTx(ctx, newTestSideChainTx("testHash3", "testAddress", 10))
+		// neither cached nor finished
+		s.finished.AddTransactionEvents(ctx, []*TransactionEvent{
+			{TransactionHash: "lostHash", GenesisBlockAddress: "testAddress", Type: TxTypeDeposit, Event: TxEventSucceeded},
+			{TransactionHash: "lostHash", Type: TxTypeWithdraw, Event: TxEventFailed},
+			{TransactionHash: "pendingHash", Type: TxTypeWithdraw, Event: TxEventDetected},
+		})
+
+		report, err := s.finished.CheckConsistency(ctx)
+		if err != nil {
+			t.Fatal("Check consistency error:", err)
+		}
+		if *report != (ConsistencyReport{StaleDepositTxs: 1, StaleWithdrawTxs: 1, LostDepositTxs: 1, LostWithdrawTxs: 1}) {
+			t.Errorf("Unexpected report %+v", *report)
+		}
+		if ok, _ := s.side.HasSideChainTx(ctx, "testHash3"); ok {
+			t.Error("Stale withdraw transaction should be removed from the cache.")
+		}
+		if ok, _ := s.finished.HasWithdrawTx(ctx, "pendingHash"); ok {
+			t.Error("Pending withdraw transaction should not be finished.")
+		}
+		if report, err = s.finished.CheckConsistency(ctx); err != nil || *report != (ConsistencyReport{}) {
+			t.Errorf("Consistent stores should not be repaired, got %+v, %v", report, err)
+		}
+	})
+}
+
+func TestConformance_PruneFinishedTxs(t *testing.T) {
+	runConformance(t, func(t *testing.T, s *conformanceStores) {
+		ctx := context.Background()
+		s.finished.AddSucceedDepositTxs(ctx, []string{"aa01"}, []string{"testAddress1"})
+		s.finished.AddFailedDepositTxs(ctx, []string{"aa02"}, []string{"testAddress1"})
+		s.finished.AddSucceedWithdrawTxs(ctx, []string{"bb01"})
+		s.finished.AddFailedWithdrawTxs(ctx, []string{"bb02"}, []byte{1, 2, 3})
+		s.finished.AddTransactionEvents(ctx, []*TransactionEvent{
+			{TransactionHash: "aa01", GenesisBlockAddress: "testAddress1", Type: TxTypeDeposit, Event: TxEventSucceeded},
+			{TransactionHash: "aa01", GenesisBlockAddress: "testAddress2", Type: TxTypeDeposit, Event: TxEventDetected},
+			{TransactionHash: "bb02", Type: TxTypeWithdraw, Event: TxEventFailed},
+		})
+
+		future := "9999-01-01_00.00.00"
+		report, err := s.finished.Prune(ctx, &PrunePolicy{SucceedBefore: future, DryRun: true})
+		if err != nil || *report != (PruneReport{DryRun: true, SucceedBefore: future, DepositTxs: 1,
+			WithdrawTxs: 1, TransactionEvents: 1}) {
+			t.Errorf("Dry run report error: %+v, %v", report, err)
+		}
+
+		archiveDir, err := ioutil.TempDir("", "prune")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(archiveDir)
+		report, err = s.finished.Prune(ctx, &PrunePolicy{FailedBefore: future, ArchiveDir: archiveDir})
+		if err != nil || report.DepositTxs != 1 || report.WithdrawTxs != 1 || report.SideChainTxs != 1 ||
+			report.TransactionEvents != 1 || report.ArchiveFile == "" {
+			t.Errorf("Prune report error: %+v, %v", report, err)
+		}
+		if ok, _ := s.finished.HasWithdrawTx(ctx, "bb02"); ok {
+			t.Error("Failed withdraw transaction should be pruned.")
+		}
+		if ok, _ := s.finished.HasDepositTx(ctx, "aa01", "testAddress1"); !ok {
+			t.Error("Succeed deposit transaction should be kept.")
+		}
+		if events, _ := s.finished.GetTransactionEvents(ctx, "aa01"); len(events) != 2 {
+			t.Error("Events of kept transactions should be kept.")
+		}
+		if err = s.finished.Vacuum(ctx); err != nil {
+			t.Error("Vacuum error:", err)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"math"
 	"os"
@@ -69,35 +70,38 @@ type AddressUTXO struct {
 
 type DataStore interface {
 	ResetDataStore() error
-	catchSystemSignals()
+	Close() error
 }
 
 type DataStoreMainChain interface {
 	DataStore
 
-	CurrentHeight(height uint32) uint32
-	AddMainChainTx(tx *base.MainChainTransaction) error
-	AddMainChainTxs(txs []*base.MainChainTransaction) ([]bool, error)
-	HasMainChainTx(transactionHash, genesisBlockAddress string) (bool, error)
-	RemoveMainChainTx(transactionHash, genesisBlockAddress string) error
-	RemoveMainChainTxs(transactionHashes, genesisBlockAddress []string) error
-	GetAllMainChainTxHashes() ([]string, []string, error)
-	GetAllMainChainTxs() ([]*base.MainChainTransaction, error)
-	GetMainChainTxsFromHashes(transactionHashes []string, genesisBlockAddresses string) ([]*base.SpvTransaction, error)
+	CurrentHeight(ctx context.Context, height uint32) uint32
+	AddMainChainTx(ctx context.Context, tx *base.MainChainTransaction) error
+	AddMainChainTxs(ctx context.Context, txs []*base.MainChainTransaction) ([]bool, error)
+	HasMainChainTx(ctx context.Context, transactionHash, genesisBlockAddress string) (bool, error)
+	RemoveMainChainTx(ctx context.Context, transactionHash, genesisBlockAddress string) error
+	RemoveMainChainTxs(ctx context.Context, transactionHashes, genesisBlockAddress []string) error
+	GetAllMainChainTxHashes(ctx context.Context) ([]string, []string, error)
+	GetMainChainTxsCount(ctx context.Context) (int, error)
+	GetAllMainChainTxs(ctx context.Context) ([]*base.MainChainTransaction, error)
+	GetMainChainTxsFromHashes(ctx context.Context, transactionHashes []string, genesisBlockAddresses string) ([]*base.SpvTransaction, error)
 }
 
 type DataStoreSideChain interface {
 	DataStore
 
-	CurrentSideHeight(genesisBlockAddress string, height uint32) uint32
-	AddSideChainTx(tx *base.SideChainTransaction) error
-	AddSideChainTxs(txs []*base.SideChainTransaction) error
-	HasSideChainTx(transactionHash string) (bool, error)
-	RemoveSideChainTxs(transactionHashes []string) error
-	GetAllSideChainTxHashes() ([]string, error)
-	GetAllSideChainTxHashesAndHeights(genesisBlockAddress string) ([]string, []uint32, error)
-	GetSideChainTxsFromHashes(transactionHashes []string) ([]*base.WithdrawTx, error)
-	GetSideChainTxsFromHashesAndGenesisAddress(transactionHashes []string, genesisBlockAddress string) ([]*base.WithdrawTx, error)
+	AddSideChain(ctx context.Context, genesisBlockAddress string) error
+	CurrentSideHeight(ctx context.Context, genesisBlockAddress string, height uint32) uint32
+	AddSideChainTx(ctx context.Context, tx *base.SideChainTransaction) error
+	AddSideChainTxs(ctx context.Context, txs []*base.SideChainTransaction) error
+	HasSideChainTx(ctx context.Context, transactionHash string) (bool, error)
+	RemoveSideChainTxs(ctx context.Context, transactionHashes []string) error
+	GetAllSideChainTxHashes(ctx context.Context) ([]string, error)
+	GetSideChainTxsCount(ctx context.Context) (int, error)
+	GetAllSideChainTxHashesAndHeights(ctx context.Context, genesisBlockAddress string) ([]string, []uint32, error)
+	GetSideChainTxsFromHashes(ctx context.Context, transactionHashes []string) ([]*base.WithdrawTx, error)
+	GetSideChainTxsFromHashesAndGenesisAddress(ctx context.Context, transactionHashes []string, genesisBlockAddress string) ([]*base.WithdrawTx, error)
 }
 
 type DataStoreImpl struct {
@@ -119,7 +123,7 @@ type DataStoreSideChainImpl struct {
 
 func OpenDataStore() (*DataStoreImpl, error) {
 	if err := checkAndCreateArbiterDataDir(); err != nil {
-		log.Errorf("create arbiter db dir error: %s\n", err)
+		logger.Errorf("create arbiter db dir error: %s\n", err)
 		return nil, err
 	}
 
@@ -131,15 +135,14 @@ func OpenDataStore() (*DataStoreImpl, error) {
 	if err != nil {
 		return nil, err
 	}
-	dataStore := &DataStoreImpl{
-		MainChainStore: &DataStoreMainChainImpl{mux: new(sync.Mutex), DB: dbMainChain},
-		SideChainStore: &DataStoreSideChainImpl{mux: new(sync.Mutex), DB: dbSideChain}}
+	mainChainStore := &DataStoreMainChainImpl{mux: new(sync.Mutex), DB: dbMainChain}
+	sideChainStore := &DataStoreSideChainImpl{mux: new(sync.Mutex), DB: dbSideChain}
 
 	// Handle system interrupt signals
-	dataStore.MainChainStore.catchSystemSignals()
-	dataStore.SideChainStore.catchSystemSignals()
+	mainChainStore.catchSystemSignals()
+	sideChainStore.catchSystemSignals()
 
-	return dataStore, nil
+	return &DataStoreImpl{MainChainStore: mainChainStore, SideChainStore: sideChainStore}, nil
 }
 
 func OpenMainChainDataStore() (*DataStoreMainChainImpl, error) {
@@ -179,23 +182,7 @@ func checkAndCreateArbiterDataDir() error {
 }
 
 func initMainChainDB() (*sql.DB, error) {
-	err := CheckAndCreateDocument(DBDocumentNAME)
-	if err != nil {
-		log.Error("create DBCache doucument error:", err)
-		return nil, err
-	}
-	db, err := sql.Open(DriverName, DBNameMainChain)
-	if err != nil {
-		log.Error("Open data db error:", err)
-		return nil, err
-	}
-	// Create info table
-	_, err = db.Exec(CreateInfoTable)
-	if err != nil {
-		return nil, err
-	}
-	// Create MainChainTxs table
-	_, err = db.Exec(CreateMainChainTxsTable)
+	db, err := openDB(DBNameMainChain, MainChainMigrations)
 	if err != nil {
 		return nil, err
 	}
@@ -208,28 +195,12 @@ func initMainChainDB() (*sql.DB, error) {
 }
 
 func initSideChainDB() (*sql.DB, error) {
-	err := CheckAndCreateDocument(DBDocumentNAME)
-	if err != nil {
-		log.Error("Create DBCache doucument error:", err)
-		return nil, err
-	}
-	db, err := sql.Open(DriverName, DBNameSideChain)
-	if err != nil {
-		log.Error("Open data db error:", err)
-		return nil, err
-	}
-	// Create SideHeightInfo table
-	_, err = db.Exec(CreateHeightInfoTable)
-	if err != nil {
-		return nil, err
-	}
-	// Create SideChainTxs table
-	_, err = db.Exec(CreateSideChainTxsTable)
+	db, err := openDB(DBNameSideChain, SideChainMigrations)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, node := range config.Parameters.SideNodeList {
+	for _, node := range config.SideNodes() {
 		stmt, err := db.Prepare("INSERT INTO SideHeightInfo(GenesisBlockAddress, Height) values(?,?)")
 		if err != nil {
 			return nil, err
@@ -261,11 +232,22 @@ func (store *DataStoreSideChainImpl) catchSystemSignals() {
 	})
 }
 
-func (store *DataStoreSideChainImpl) CurrentSideHeight(genesisBlockAddress string, height uint32) uint32 {
+// AddSideChain stores the height of a side chain added after the store is
+// opened, the height is kept if the side chain was added before.
+func (store *DataStoreSideChainImpl) AddSideChain(ctx context.Context, genesisBlockAddress string) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	row := store.QueryRow("SELECT Height FROM SideHeightInfo WHERE GenesisBlockAddress=?", genesisBlockAddress)
+	_, err := store.ExecContext(ctx, "INSERT OR IGNORE INTO SideHeightInfo(GenesisBlockAddress, Height) values(?,?)",
+		genesisBlockAddress, uint32(0))
+	return err
+}
+
+func (store *DataStoreSideChainImpl) CurrentSideHeight(ctx context.Context, genesisBlockAddress string, height uint32) uint32 {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	row := store.QueryRowContext(ctx, "SELECT Height FROM SideHeightInfo WHERE GenesisBlockAddress=?", genesisBlockAddress)
 	var storedHeight uint32
 	row.Scan(&storedHeight)
 
@@ -275,11 +257,11 @@ func (store *DataStoreSideChainImpl) CurrentSideHeight(genesisBlockAddress strin
 			height = 0
 		}
 		// Insert current height
-		stmt, err := store.Prepare("UPDATE SideHeightInfo SET Height=? WHERE GenesisBlockAddress=?")
+		stmt, err := store.PrepareContext(ctx, "UPDATE SideHeightInfo SET Height=? WHERE GenesisBlockAddress=?")
 		if err != nil {
 			return uint32(0)
 		}
-		_, err = stmt.Exec(height, genesisBlockAddress)
+		_, err = stmt.ExecContext(ctx, height, genesisBlockAddress)
 		if err != nil {
 			return uint32(0)
 		}
@@ -288,18 +270,18 @@ func (store *DataStoreSideChainImpl) CurrentSideHeight(genesisBlockAddress strin
 	return storedHeight
 }
 
-func (store *DataStoreSideChainImpl) AddSideChainTxs(txs []*base.SideChainTransaction) error {
+func (store *DataStoreSideChainImpl) AddSideChainTxs(ctx context.Context, txs []*base.SideChainTransaction) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	tx, err := store.Begin()
+	tx, err := store.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Commit()
 
 	// Prepare sql statement
-	stmt, err := tx.Prepare("INSERT INTO SideChainTxs(TransactionHash, GenesisBlockAddress, TransactionData, BlockHeight) values(?,?,?,?)")
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO SideChainTxs(TransactionHash, GenesisBlockAddress, TransactionData, BlockHeight) values(?,?,?,?)")
 	if err != nil {
 		return err
 	}
@@ -307,9 +289,10 @@ func (store *DataStoreSideChainImpl) AddSideChainTxs(txs []*base.SideChainTransa
 
 	// Do insert
 	for _, tx := range txs {
-		_, err = stmt.Exec(tx.TransactionHash, tx.GenesisBlockAddress, tx.Transaction, tx.BlockHeight)
+		_, err = stmt.ExecContext(ctx, tx.TransactionHash, tx.GenesisBlockAddress, tx.Transaction, tx.BlockHeight)
 		if err != nil {
-			log.Error("[AddSideChainTxs] err")
+			logger.Errorw("add side chain transaction failed", log.KeyTx, tx.TransactionHash,
+				log.KeySideChain, tx.GenesisBlockAddress, log.KeyError, err)
 			continue
 		}
 	}
@@ -317,30 +300,30 @@ func (store *DataStoreSideChainImpl) AddSideChainTxs(txs []*base.SideChainTransa
 	return nil
 }
 
-func (store *DataStoreSideChainImpl) AddSideChainTx(tx *base.SideChainTransaction) error {
+func (store *DataStoreSideChainImpl) AddSideChainTx(ctx context.Context, tx *base.SideChainTransaction) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
 	// Prepare sql statement
-	stmt, err := store.Prepare("INSERT INTO SideChainTxs(TransactionHash, GenesisBlockAddress, TransactionData, BlockHeight) values(?,?,?,?)")
+	stmt, err := store.PrepareContext(ctx, "INSERT INTO SideChainTxs(TransactionHash, GenesisBlockAddress, TransactionData, BlockHeight) values(?,?,?,?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
 	// Do insert
-	_, err = stmt.Exec(tx.TransactionHash, tx.GenesisBlockAddress, tx.Transaction, tx.BlockHeight)
+	_, err = stmt.ExecContext(ctx, tx.TransactionHash, tx.GenesisBlockAddress, tx.Transaction, tx.BlockHeight)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (store *DataStoreSideChainImpl) HasSideChainTx(transactionHash string) (bool, error) {
+func (store *DataStoreSideChainImpl) HasSideChainTx(ctx context.Context, transactionHash string) (bool, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT GenesisBlockAddress FROM SideChainTxs WHERE TransactionHash=?`, transactionHash)
+	rows, err := store.QueryContext(ctx, `SELECT GenesisBlockAddress FROM SideChainTxs WHERE TransactionHash=?`, transactionHash)
 	if err != nil {
 		return false, err
 	}
@@ -349,34 +332,34 @@ func (store *DataStoreSideChainImpl) HasSideChainTx(transactionHash string) (boo
 	return rows.Next(), nil
 }
 
-func (store *DataStoreSideChainImpl) RemoveSideChainTxs(transactionHashes []string) error {
+func (store *DataStoreSideChainImpl) RemoveSideChainTxs(ctx context.Context, transactionHashes []string) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	tx, err := store.Begin()
+	tx, err := store.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Commit()
 
-	stmt, err := tx.Prepare("DELETE FROM SideChainTxs WHERE TransactionHash=?")
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM SideChainTxs WHERE TransactionHash=?")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
 	for _, txHash := range transactionHashes {
-		stmt.Exec(txHash)
+		stmt.ExecContext(ctx, txHash)
 	}
 
 	return nil
 }
 
-func (store *DataStoreSideChainImpl) GetAllSideChainTxHashes() ([]string, error) {
+func (store *DataStoreSideChainImpl) GetAllSideChainTxHashes(ctx context.Context) ([]string, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT SideChainTxs.TransactionHash FROM SideChainTxs`)
+	rows, err := store.QueryContext(ctx, `SELECT SideChainTxs.TransactionHash FROM SideChainTxs`)
 	if err != nil {
 		return nil, err
 	}
@@ -394,11 +377,20 @@ func (store *DataStoreSideChainImpl) GetAllSideChainTxHashes() ([]string, error)
 	return txHashes, nil
 }
 
-func (store *DataStoreSideChainImpl) GetAllSideChainTxHashesAndHeights(genesisBlockAddress string) ([]string, []uint32, error) {
+func (store *DataStoreSideChainImpl) GetSideChainTxsCount(ctx context.Context) (int, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT SideChainTxs.TransactionHash, SideChainTxs.BlockHeight FROM SideChainTxs WHERE GenesisBlockAddress=?`, genesisBlockAddress)
+	var count int
+	err := store.QueryRowContext(ctx, `SELECT COUNT(*) FROM SideChainTxs`).Scan(&count)
+	return count, err
+}
+
+func (store *DataStoreSideChainImpl) GetAllSideChainTxHashesAndHeights(ctx context.Context, genesisBlockAddress string) ([]string, []uint32, error) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	rows, err := store.QueryContext(ctx, `SELECT SideChainTxs.TransactionHash, SideChainTxs.BlockHeight FROM SideChainTxs WHERE GenesisBlockAddress=?`, genesisBlockAddress)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -419,7 +411,7 @@ func (store *DataStoreSideChainImpl) GetAllSideChainTxHashesAndHeights(genesisBl
 	return txHashes, blockHeights, nil
 }
 
-func (store *DataStoreSideChainImpl) GetSideChainTxsFromHashes(transactionHashes []string) ([]*base.WithdrawTx, error) {
+func (store *DataStoreSideChainImpl) GetSideChainTxsFromHashes(ctx context.Context, transactionHashes []string) ([]*base.WithdrawTx, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
@@ -439,7 +431,7 @@ func (store *DataStoreSideChainImpl) GetSideChainTxsFromHashes(transactionHashes
 	}
 	buf.WriteString(" GROUP BY TransactionHash")
 
-	rows, err := store.Query(buf.String())
+	rows, err := store.QueryContext(ctx, buf.String())
 	if err != nil {
 		return nil, err
 	}
@@ -461,13 +453,13 @@ func (store *DataStoreSideChainImpl) GetSideChainTxsFromHashes(transactionHashes
 	return txs, nil
 }
 
-func (store *DataStoreSideChainImpl) GetSideChainTxsFromHashesAndGenesisAddress(transactionHashes []string, genesisBlockAddress string) ([]*base.WithdrawTx, error) {
+func (store *DataStoreSideChainImpl) GetSideChainTxsFromHashesAndGenesisAddress(ctx context.Context, transactionHashes []string, genesisBlockAddress string) ([]*base.WithdrawTx, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
 	var txs []*base.WithdrawTx
 	for _, txHash := range transactionHashes {
-		rows, err := store.Query(`SELECT SideChainTxs.TransactionData FROM SideChainTxs WHERE TransactionHash=? AND GenesisBlockAddress=?`, txHash, genesisBlockAddress)
+		rows, err := store.QueryContext(ctx, `SELECT SideChainTxs.TransactionData FROM SideChainTxs WHERE TransactionHash=? AND GenesisBlockAddress=?`, txHash, genesisBlockAddress)
 		if err != nil {
 			return nil, err
 		}
@@ -513,11 +505,11 @@ func (store *DataStoreMainChainImpl) catchSystemSignals() {
 	})
 }
 
-func (store *DataStoreMainChainImpl) CurrentHeight(height uint32) uint32 {
+func (store *DataStoreMainChainImpl) CurrentHeight(ctx context.Context, height uint32) uint32 {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	row := store.QueryRow("SELECT Value FROM Info WHERE Name=?", "Height")
+	row := store.QueryRowContext(ctx, "SELECT Value FROM Info WHERE Name=?", "Height")
 	var storedHeight uint32
 	row.Scan(&storedHeight)
 
@@ -527,11 +519,11 @@ func (store *DataStoreMainChainImpl) CurrentHeight(height uint32) uint32 {
 			height = 0
 		}
 		// Insert current height
-		stmt, err := store.Prepare("UPDATE Info SET Value=? WHERE Name=?")
+		stmt, err := store.PrepareContext(ctx, "UPDATE Info SET Value=? WHERE Name=?")
 		if err != nil {
 			return uint32(0)
 		}
-		_, err = stmt.Exec(height, "Height")
+		_, err = stmt.ExecContext(ctx, height, "Height")
 		if err != nil {
 			return uint32(0)
 		}
@@ -540,12 +532,12 @@ func (store *DataStoreMainChainImpl) CurrentHeight(height uint32) uint32 {
 	return storedHeight
 }
 
-func (store *DataStoreMainChainImpl) AddMainChainTx(tx *base.MainChainTransaction) error {
+func (store *DataStoreMainChainImpl) AddMainChainTx(ctx context.Context, tx *base.MainChainTransaction) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
 	// Prepare sql statement
-	stmt, err := store.Prepare("INSERT INTO MainChainTxs(TransactionHash, GenesisBlockAddress, TransactionData, MerkleProof) values(?,?,?,?)")
+	stmt, err := store.PrepareContext(ctx, "INSERT INTO MainChainTxs(TransactionHash, GenesisBlockAddress, TransactionData, MerkleProof) values(?,?,?,?)")
 	if err != nil {
 		return err
 	}
@@ -562,25 +554,25 @@ func (store *DataStoreMainChainImpl) AddMainChainTx(tx *base.MainChainTransactio
 	merkleProofBytes := buf.Bytes()
 
 	// Do insert
-	_, err = stmt.Exec(tx.TransactionHash, tx.GenesisBlockAddress, transactionBytes, merkleProofBytes)
+	_, err = stmt.ExecContext(ctx, tx.TransactionHash, tx.GenesisBlockAddress, transactionBytes, merkleProofBytes)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (store *DataStoreMainChainImpl) AddMainChainTxs(txs []*base.MainChainTransaction) ([]bool, error) {
+func (store *DataStoreMainChainImpl) AddMainChainTxs(ctx context.Context, txs []*base.MainChainTransaction) ([]bool, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	tx, err := store.Begin()
+	tx, err := store.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Commit()
 
 	// Prepare sql statement
-	stmt, err := tx.Prepare("INSERT INTO MainChainTxs(TransactionHash, GenesisBlockAddress, TransactionData, MerkleProof) values(?,?,?,?)")
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO MainChainTxs(TransactionHash, GenesisBlockAddress, TransactionData, MerkleProof) values(?,?,?,?)")
 	if err != nil {
 		return nil, err
 	}
@@ -599,7 +591,7 @@ func (store *DataStoreMainChainImpl) AddMainChainTxs(txs []*base.MainChainTransa
 		merkleProofBytes := buf.Bytes()
 
 		// Do insert
-		_, err = stmt.Exec(tx.TransactionHash, tx.GenesisBlockAddress, transactionBytes, merkleProofBytes)
+		_, err = stmt.ExecContext(ctx, tx.TransactionHash, tx.GenesisBlockAddress, transactionBytes, merkleProofBytes)
 		if err != nil {
 			result = append(result, false)
 		} else {
@@ -610,12 +602,12 @@ func (store *DataStoreMainChainImpl) AddMainChainTxs(txs []*base.MainChainTransa
 	return result, nil
 }
 
-func (store *DataStoreMainChainImpl) HasMainChainTx(transactionHash, genesisBlockAddress string) (bool, error) {
+func (store *DataStoreMainChainImpl) HasMainChainTx(ctx context.Context, transactionHash, genesisBlockAddress string) (bool, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
 	sql := `SELECT TransactionHash FROM MainChainTxs WHERE TransactionHash=? AND GenesisBlockAddress=?`
-	rows, err := store.Query(sql, transactionHash, genesisBlockAddress)
+	rows, err := store.QueryContext(ctx, sql, transactionHash, genesisBlockAddress)
 	if err != nil {
 		return false, err
 	}
@@ -624,17 +616,17 @@ func (store *DataStoreMainChainImpl) HasMainChainTx(transactionHash, genesisBloc
 	return rows.Next(), nil
 }
 
-func (store *DataStoreMainChainImpl) RemoveMainChainTx(transactionHash, genesisBlockAddress string) error {
+func (store *DataStoreMainChainImpl) RemoveMainChainTx(ctx context.Context, transactionHash, genesisBlockAddress string) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	stmt, err := store.Prepare("DELETE FROM MainChainTxs WHERE TransactionHash=? AND GenesisBlockAddress=?")
+	stmt, err := store.PrepareContext(ctx, "DELETE FROM MainChainTxs WHERE TransactionHash=? AND GenesisBlockAddress=?")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(transactionHash, genesisBlockAddress)
+	_, err = stmt.ExecContext(ctx, transactionHash, genesisBlockAddress)
 	if err != nil {
 		return err
 	}
@@ -642,24 +634,24 @@ func (store *DataStoreMainChainImpl) RemoveMainChainTx(transactionHash, genesisB
 	return nil
 }
 
-func (store *DataStoreMainChainImpl) RemoveMainChainTxs(transactionHashes, genesisBlockAddress []string) error {
+func (store *DataStoreMainChainImpl) RemoveMainChainTxs(ctx context.Context, transactionHashes, genesisBlockAddress []string) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	tx, err := store.Begin()
+	tx, err := store.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Commit()
 
-	stmt, err := tx.Prepare("DELETE FROM MainChainTxs WHERE TransactionHash=? AND GenesisBlockAddress=?")
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM MainChainTxs WHERE TransactionHash=? AND GenesisBlockAddress=?")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
 	for i := 0; i < len(transactionHashes); i++ {
-		_, err = stmt.Exec(transactionHashes[i], genesisBlockAddress[i])
+		_, err = stmt.ExecContext(ctx, transactionHashes[i], genesisBlockAddress[i])
 		if err != nil {
 			continue
 		}
@@ -668,11 +660,20 @@ func (store *DataStoreMainChainImpl) RemoveMainChainTxs(transactionHashes, genes
 	return nil
 }
 
-func (store *DataStoreMainChainImpl) GetAllMainChainTxHashes() ([]string, []string, error) {
+func (store *DataStoreMainChainImpl) GetMainChainTxsCount(ctx context.Context) (int, error) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	var count int
+	err := store.QueryRowContext(ctx, `SELECT COUNT(*) FROM MainChainTxs`).Scan(&count)
+	return count, err
+}
+
+func (store *DataStoreMainChainImpl) GetAllMainChainTxHashes(ctx context.Context) ([]string, []string, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT TransactionHash, GenesisBlockAddress FROM MainChainTxs`)
+	rows, err := store.QueryContext(ctx, `SELECT TransactionHash, GenesisBlockAddress FROM MainChainTxs`)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -693,11 +694,11 @@ func (store *DataStoreMainChainImpl) GetAllMainChainTxHashes() ([]string, []stri
 	return txHashes, genesisAddresses, nil
 }
 
-func (store *DataStoreMainChainImpl) GetAllMainChainTxs() ([]*base.MainChainTransaction, error) {
+func (store *DataStoreMainChainImpl) GetAllMainChainTxs(ctx context.Context) ([]*base.MainChainTransaction, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT TransactionHash, GenesisBlockAddress,
+	rows, err := store.QueryContext(ctx, `SELECT TransactionHash, GenesisBlockAddress,
  									TransactionData, MerkleProof FROM MainChainTxs`)
 	if err != nil {
 		return nil, err
@@ -729,7 +730,7 @@ func (store *DataStoreMainChainImpl) GetAllMainChainTxs() ([]*base.MainChainTran
 	return txs, nil
 }
 
-func (store *DataStoreMainChainImpl) GetMainChainTxsFromHashes(transactionHashes []string,
+func (store *DataStoreMainChainImpl) GetMainChainTxsFromHashes(ctx context.Context, transactionHashes []string,
 	genesisBlockAddresses string) ([]*base.SpvTransaction, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
@@ -738,7 +739,7 @@ func (store *DataStoreMainChainImpl) GetMainChainTxsFromHashes(transactionHashes
 
 	sql := `SELECT TransactionData, MerkleProof FROM MainChainTxs WHERE TransactionHash=? AND GenesisBlockAddress=?`
 	for i := 0; i < len(transactionHashes); i++ {
-		rows, err := store.Query(sql, transactionHashes[i], genesisBlockAddresses)
+		rows, err := store.QueryContext(ctx, sql, transactionHashes[i], genesisBlockAddresses)
 		if err != nil {
 			return nil, err
 		}
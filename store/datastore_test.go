@@ -2,6 +2,7 @@ package store
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"testing"
 
@@ -31,7 +32,7 @@ func TestDataStoreImpl_AddSideChainTx(t *testing.T) {
 	genesisBlockAddress := "testAddress"
 	txHash := "testHash"
 
-	ok, err := datastore.HasSideChainTx(txHash)
+	ok, err := datastore.HasSideChainTx(context.Background(), txHash)
 	if err != nil {
 		t.Error("Get side chain transaction error.")
 	}
@@ -42,11 +43,11 @@ func TestDataStoreImpl_AddSideChainTx(t *testing.T) {
 	tx := &types.Transaction{Payload: new(payload.WithdrawFromSideChain)}
 	buf := new(bytes.Buffer)
 	tx.Serialize(buf)
-	if err := datastore.AddSideChainTx(&base.SideChainTransaction{txHash, genesisBlockAddress, buf.Bytes(), 10}); err != nil {
+	if err := datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash, genesisBlockAddress, buf.Bytes(), 10}); err != nil {
 		t.Error("Add side chain transaction error.")
 	}
 
-	ok, err = datastore.HasSideChainTx(txHash)
+	ok, err = datastore.HasSideChainTx(context.Background(), txHash)
 	if err != nil {
 		t.Error("Get side chain transaction error.")
 	}
@@ -70,21 +71,21 @@ func TestDataStoreImpl_AddSideChainTxs(t *testing.T) {
 	txHash2 := "testHash2"
 	txHash3 := "testHash3"
 
-	ok, err := datastore.HasSideChainTx(txHash1)
+	ok, err := datastore.HasSideChainTx(context.Background(), txHash1)
 	if err != nil {
 		t.Error("Get side chain transaction error.")
 	}
 	if ok {
 		t.Error("Should not have specified transaction.")
 	}
-	ok, err = datastore.HasSideChainTx(txHash2)
+	ok, err = datastore.HasSideChainTx(context.Background(), txHash2)
 	if err != nil {
 		t.Error("Get side chain transaction error.")
 	}
 	if ok {
 		t.Error("Should not have specified transaction.")
 	}
-	ok, err = datastore.HasSideChainTx(txHash3)
+	ok, err = datastore.HasSideChainTx(context.Background(), txHash3)
 	if err != nil {
 		t.Error("Get side chain transaction error.")
 	}
@@ -95,7 +96,7 @@ func TestDataStoreImpl_AddSideChainTxs(t *testing.T) {
 	tx := &types.Transaction{Payload: new(payload.WithdrawFromSideChain)}
 	buf := new(bytes.Buffer)
 	tx.Serialize(buf)
-	err = datastore.AddSideChainTxs(
+	err = datastore.AddSideChainTxs(context.Background(),
 		[]*base.SideChainTransaction{
 			&base.SideChainTransaction{txHash1, genesisBlockAddress1, buf.Bytes(), 10},
 			&base.SideChainTransaction{txHash2, genesisBlockAddress2, buf.Bytes(), 10},
@@ -105,21 +106,21 @@ func TestDataStoreImpl_AddSideChainTxs(t *testing.T) {
 		t.Error("Add side chain transaction error.")
 	}
 
-	ok, err = datastore.HasSideChainTx(txHash1)
+	ok, err = datastore.HasSideChainTx(context.Background(), txHash1)
 	if err != nil {
 		t.Error("Get side chain transaction error.")
 	}
 	if !ok {
 		t.Error("Should have specified transaction.")
 	}
-	ok, err = datastore.HasSideChainTx(txHash2)
+	ok, err = datastore.HasSideChainTx(context.Background(), txHash2)
 	if err != nil {
 		t.Error("Get side chain transaction error.")
 	}
 	if !ok {
 		t.Error("Should have specified transaction.")
 	}
-	ok, err = datastore.HasSideChainTx(txHash3)
+	ok, err = datastore.HasSideChainTx(context.Background(), txHash3)
 	if err != nil {
 		t.Error("Get side chain transaction error.")
 	}
@@ -148,21 +149,21 @@ func TestDataStoreImpl_RemoveSideChainTxs(t *testing.T) {
 	buf2 := new(bytes.Buffer)
 	tx2.Serialize(buf2)
 
-	datastore.AddSideChainTx(&base.SideChainTransaction{txHash, genesisBlockAddress, buf.Bytes(), 10})
-	datastore.AddSideChainTx(&base.SideChainTransaction{txHash2, genesisBlockAddress2, buf2.Bytes(), 10})
+	datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash, genesisBlockAddress, buf.Bytes(), 10})
+	datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash2, genesisBlockAddress2, buf2.Bytes(), 10})
 
-	if ok, err := datastore.HasSideChainTx(txHash); !ok || err != nil {
+	if ok, err := datastore.HasSideChainTx(context.Background(), txHash); !ok || err != nil {
 		t.Error("Should have specified transaction.")
 	}
-	if ok, err := datastore.HasSideChainTx(txHash2); !ok || err != nil {
+	if ok, err := datastore.HasSideChainTx(context.Background(), txHash2); !ok || err != nil {
 		t.Error("Should have specified transaction.")
 	}
 
 	var removedHashes []string
 	removedHashes = append(removedHashes, txHash)
-	datastore.RemoveSideChainTxs(removedHashes)
+	datastore.RemoveSideChainTxs(context.Background(), removedHashes)
 
-	ok, err := datastore.HasSideChainTx(txHash)
+	ok, err := datastore.HasSideChainTx(context.Background(), txHash)
 	if err != nil {
 		t.Error("Get side chain transaction error.")
 	}
@@ -170,7 +171,7 @@ func TestDataStoreImpl_RemoveSideChainTxs(t *testing.T) {
 		t.Error("Should not have specified transaction.")
 	}
 
-	if ok, err := datastore.HasSideChainTx(txHash2); !ok || err != nil {
+	if ok, err := datastore.HasSideChainTx(context.Background(), txHash2); !ok || err != nil {
 		t.Error("Should have specified transaction.")
 	}
 
@@ -193,12 +194,12 @@ func TestDataStoreImpl_GetAllSideChainTxHashes(t *testing.T) {
 	tx := &types.Transaction{TxType: types.WithdrawFromSideChain, Payload: new(payload.WithdrawFromSideChain)}
 	buf := new(bytes.Buffer)
 	tx.Serialize(buf)
-	datastore.AddSideChainTx(&base.SideChainTransaction{txHash, genesisBlockAddress, buf.Bytes(), 10})
-	datastore.AddSideChainTx(&base.SideChainTransaction{txHash2, genesisBlockAddress, buf.Bytes(), 10})
-	datastore.AddSideChainTx(&base.SideChainTransaction{txHash3, genesisBlockAddress2, buf.Bytes(), 11})
-	datastore.AddSideChainTx(&base.SideChainTransaction{txHash3, genesisBlockAddress2, buf.Bytes(), 11})
+	datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash, genesisBlockAddress, buf.Bytes(), 10})
+	datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash2, genesisBlockAddress, buf.Bytes(), 10})
+	datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash3, genesisBlockAddress2, buf.Bytes(), 11})
+	datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash3, genesisBlockAddress2, buf.Bytes(), 11})
 
-	txHashes, err := datastore.GetAllSideChainTxHashes()
+	txHashes, err := datastore.GetAllSideChainTxHashes(context.Background())
 	if err != nil {
 		t.Error("Get all side chain transactions error.")
 	}
@@ -206,7 +207,7 @@ func TestDataStoreImpl_GetAllSideChainTxHashes(t *testing.T) {
 		t.Error("Get all side chain transactions error.")
 	}
 
-	txHashes, heights, err := datastore.GetAllSideChainTxHashesAndHeights(genesisBlockAddress)
+	txHashes, heights, err := datastore.GetAllSideChainTxHashesAndHeights(context.Background(), genesisBlockAddress)
 	if err != nil {
 		t.Error("Get all side chain transactions error.")
 	}
@@ -254,16 +255,16 @@ func TestDataStoreImpl_GetSideChainTxsFromHashes(t *testing.T) {
 	tx2.LockTime = 2
 	tx3.LockTime = 3
 
-	datastore.AddSideChainTx(&base.SideChainTransaction{txHash, genesisBlockAddress, buf1.Bytes(), 10})
-	datastore.AddSideChainTx(&base.SideChainTransaction{txHash2, genesisBlockAddress, buf2.Bytes(), 10})
-	datastore.AddSideChainTx(&base.SideChainTransaction{txHash3, genesisBlockAddress2, buf3.Bytes(), 10})
+	datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash, genesisBlockAddress, buf1.Bytes(), 10})
+	datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash2, genesisBlockAddress, buf2.Bytes(), 10})
+	datastore.AddSideChainTx(context.Background(), &base.SideChainTransaction{txHash3, genesisBlockAddress2, buf3.Bytes(), 10})
 
 	var txHashes []string
 	txHashes = append(txHashes, txHash)
 	txHashes = append(txHashes, txHash2)
 	txHashes = append(txHashes, txHash3)
 
-	txs, err := datastore.GetSideChainTxsFromHashes(txHashes)
+	txs, err := datastore.GetSideChainTxsFromHashes(context.Background(), txHashes)
 	if err != nil {
 		t.Error("Get all side chain transactions error.")
 	}
@@ -283,7 +284,7 @@ func TestDataStoreImpl_AddMainChainTx(t *testing.T) {
 	txHash := "testHash"
 	genesisAddress := "testAddress"
 
-	ok, err := datastore.HasMainChainTx(txHash, genesisAddress)
+	ok, err := datastore.HasMainChainTx(context.Background(), txHash, genesisAddress)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
@@ -293,11 +294,11 @@ func TestDataStoreImpl_AddMainChainTx(t *testing.T) {
 
 	tx := &types.Transaction{TxType: types.WithdrawFromSideChain, Payload: new(payload.WithdrawFromSideChain)}
 	mp := new(bloom.MerkleProof)
-	if err := datastore.AddMainChainTx(&base.MainChainTransaction{txHash, genesisAddress, tx, mp}); err != nil {
+	if err := datastore.AddMainChainTx(context.Background(), &base.MainChainTransaction{txHash, genesisAddress, tx, mp}); err != nil {
 		t.Error("Add main chain transaction error.")
 	}
 
-	ok, err = datastore.HasMainChainTx(txHash, genesisAddress)
+	ok, err = datastore.HasMainChainTx(context.Background(), txHash, genesisAddress)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
@@ -321,21 +322,21 @@ func TestDataStoreImpl_AddMainChainTxs(t *testing.T) {
 	genesisAddress2 := "testAddress2"
 	genesisAddress3 := "testAddress3"
 
-	ok, err := datastore.HasMainChainTx(txHash1, genesisAddress1)
+	ok, err := datastore.HasMainChainTx(context.Background(), txHash1, genesisAddress1)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
 	if ok {
 		t.Error("Should not have specified transaction.")
 	}
-	ok, err = datastore.HasMainChainTx(txHash2, genesisAddress2)
+	ok, err = datastore.HasMainChainTx(context.Background(), txHash2, genesisAddress2)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
 	if ok {
 		t.Error("Should not have specified transaction.")
 	}
-	ok, err = datastore.HasMainChainTx(txHash3, genesisAddress3)
+	ok, err = datastore.HasMainChainTx(context.Background(), txHash3, genesisAddress3)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
@@ -345,7 +346,7 @@ func TestDataStoreImpl_AddMainChainTxs(t *testing.T) {
 
 	tx := &types.Transaction{TxType: types.WithdrawFromSideChain, Payload: new(payload.WithdrawFromSideChain)}
 	mp := new(bloom.MerkleProof)
-	results, err := datastore.AddMainChainTxs(
+	results, err := datastore.AddMainChainTxs(context.Background(),
 		[]*base.MainChainTransaction{
 			&base.MainChainTransaction{txHash1, genesisAddress1, tx, mp},
 			&base.MainChainTransaction{txHash2, genesisAddress2, tx, mp},
@@ -360,21 +361,21 @@ func TestDataStoreImpl_AddMainChainTxs(t *testing.T) {
 		}
 	}
 
-	ok, err = datastore.HasMainChainTx(txHash1, genesisAddress1)
+	ok, err = datastore.HasMainChainTx(context.Background(), txHash1, genesisAddress1)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
 	if !ok {
 		t.Error("Should have specified transaction.")
 	}
-	ok, err = datastore.HasMainChainTx(txHash2, genesisAddress2)
+	ok, err = datastore.HasMainChainTx(context.Background(), txHash2, genesisAddress2)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
 	if !ok {
 		t.Error("Should have specified transaction.")
 	}
-	ok, err = datastore.HasMainChainTx(txHash3, genesisAddress3)
+	ok, err = datastore.HasMainChainTx(context.Background(), txHash3, genesisAddress3)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
@@ -399,27 +400,27 @@ func TestDataStoreImpl_RemoveMainChainTxs(t *testing.T) {
 	tx := &types.Transaction{TxType: types.WithdrawFromSideChain, Payload: new(payload.WithdrawFromSideChain)}
 	mp := new(bloom.MerkleProof)
 
-	datastore.AddMainChainTx(&base.MainChainTransaction{txHash1, genesisAddress, tx, mp})
-	datastore.AddMainChainTx(&base.MainChainTransaction{txHash2, genesisAddress, tx, mp})
-	datastore.AddMainChainTx(&base.MainChainTransaction{txHash3, genesisAddress, tx, mp})
+	datastore.AddMainChainTx(context.Background(), &base.MainChainTransaction{txHash1, genesisAddress, tx, mp})
+	datastore.AddMainChainTx(context.Background(), &base.MainChainTransaction{txHash2, genesisAddress, tx, mp})
+	datastore.AddMainChainTx(context.Background(), &base.MainChainTransaction{txHash3, genesisAddress, tx, mp})
 
-	if ok, err := datastore.HasMainChainTx(txHash1, genesisAddress); !ok || err != nil {
+	if ok, err := datastore.HasMainChainTx(context.Background(), txHash1, genesisAddress); !ok || err != nil {
 		t.Error("Should have specified transaction.")
 	}
-	if ok, err := datastore.HasMainChainTx(txHash2, genesisAddress); !ok || err != nil {
+	if ok, err := datastore.HasMainChainTx(context.Background(), txHash2, genesisAddress); !ok || err != nil {
 		t.Error("Should have specified transaction.")
 	}
 
-	datastore.RemoveMainChainTxs([]string{txHash1, txHash2}, []string{genesisAddress, genesisAddress})
+	datastore.RemoveMainChainTxs(context.Background(), []string{txHash1, txHash2}, []string{genesisAddress, genesisAddress})
 
-	ok, err := datastore.HasMainChainTx(txHash1, genesisAddress)
+	ok, err := datastore.HasMainChainTx(context.Background(), txHash1, genesisAddress)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
 	if ok {
 		t.Error("Should not have specified transaction.")
 	}
-	ok, err = datastore.HasMainChainTx(txHash2, genesisAddress)
+	ok, err = datastore.HasMainChainTx(context.Background(), txHash2, genesisAddress)
 	if err != nil {
 		t.Error("Get main chain transaction error.")
 	}
@@ -427,16 +428,16 @@ func TestDataStoreImpl_RemoveMainChainTxs(t *testing.T) {
 		t.Error("Should not have specified transaction.")
 	}
 
-	if ok, err := datastore.HasMainChainTx(txHash3, genesisAddress); !ok || err != nil {
+	if ok, err := datastore.HasMainChainTx(context.Background(), txHash3, genesisAddress); !ok || err != nil {
 		t.Error("Should have specified transaction.")
 	}
 
-	err = datastore.RemoveMainChainTx(txHash3, genesisAddress)
+	err = datastore.RemoveMainChainTx(context.Background(), txHash3, genesisAddress)
 	if err != nil {
 		t.Error("Remove main chain tx failed")
 	}
 
-	ok, err = datastore.HasMainChainTx(txHash3, genesisAddress)
+	ok, err = datastore.HasMainChainTx(context.Background(), txHash3, genesisAddress)
 	if err != nil {
 		t.Error("Remove main chain tx error.")
 	}
@@ -461,11 +462,11 @@ func TestDataStoreImpl_GetAllMainChainTxHashes(t *testing.T) {
 	tx := &types.Transaction{TxType: types.WithdrawFromSideChain, Payload: new(payload.WithdrawFromSideChain)}
 
 	mp := new(bloom.MerkleProof)
-	datastore.AddMainChainTx(&base.MainChainTransaction{txHash1, genesisAddress, tx, mp})
-	datastore.AddMainChainTx(&base.MainChainTransaction{txHash2, genesisAddress, tx, mp})
-	datastore.AddMainChainTx(&base.MainChainTransaction{txHash3, genesisAddress, tx, mp})
+	datastore.AddMainChainTx(context.Background(), &base.MainChainTransaction{txHash1, genesisAddress, tx, mp})
+	datastore.AddMainChainTx(context.Background(), &base.MainChainTransaction{txHash2, genesisAddress, tx, mp})
+	datastore.AddMainChainTx(context.Background(), &base.MainChainTransaction{txHash3, genesisAddress, tx, mp})
 
-	txHashes, genesisAddresses, err := datastore.GetAllMainChainTxHashes()
+	txHashes, genesisAddresses, err := datastore.GetAllMainChainTxHashes(context.Background())
 	if err != nil {
 		t.Error("Get all main chain transactions error.")
 	}
@@ -478,7 +479,7 @@ func TestDataStoreImpl_GetAllMainChainTxHashes(t *testing.T) {
 		}
 	}
 
-	txs, err := datastore.GetAllMainChainTxs()
+	txs, err := datastore.GetAllMainChainTxs(context.Background())
 	if err != nil {
 		t.Error("Get all main chain transactions error.")
 	}
@@ -489,7 +490,7 @@ func TestDataStoreImpl_GetAllMainChainTxHashes(t *testing.T) {
 		t.Error("Get all main chain transactions error.")
 	}
 
-	spvTxs, err := datastore.GetMainChainTxsFromHashes([]string{txHash1, txHash2, txHash3}, genesisAddress)
+	spvTxs, err := datastore.GetMainChainTxsFromHashes(context.Background(), []string{txHash1, txHash2, txHash3}, genesisAddress)
 	if err != nil {
 		t.Error("Get main chain txs from hashes error.")
 	}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"database/sql"
+)
+
+// States of the exported transactions.
+const (
+	ExportStatePending   = "pending"
+	ExportStateSucceeded = "succeeded"
+	ExportStateFailed    = "failed"
+)
+
+// ExportedTx is a pending or finished cross-chain transaction.
+type ExportedTx struct {
+	Type                string
+	State               string
+	TransactionHash     string
+	GenesisBlockAddress string
+	BlockHeight         uint32
+	RecordTime          string
+}
+
+// ExportTransactions reads the pending transactions of the caches and the
+// finished transactions, the databases are opened read only and the missing
+// ones are skipped.
+func ExportTransactions(mainChainPath, sideChainPath, finishedTxsPath string) ([]*ExportedTx, error) {
+	var txs []*ExportedTx
+	read := func(path, query string, scan func(rows *sql.Rows) (*ExportedTx, error)) error {
+		if exists, err := PathExists(path); err != nil || !exists {
+			return err
+		}
+		db, err := sql.Open(DriverName, "file:"+path+"?mode=ro")
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		rows, err := db.Query(query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			tx, err := scan(rows)
+			if err != nil {
+				return err
+			}
+			txs = append(txs, tx)
+		}
+		return rows.Err()
+	}
+	state := func(succeed bool) string {
+		if succeed {
+			return ExportStateSucceeded
+		}
+		return ExportStateFailed
+	}
+
+	err := read(mainChainPath, `SELECT TransactionHash, IFNULL(GenesisBlockAddress, '') FROM MainChainTxs ORDER BY Id`,
+		func(rows *sql.Rows) (*ExportedTx, error) {
+			tx := &ExportedTx{Type: TxTypeDeposit, State: ExportStatePending}
+			return tx, rows.Scan(&tx.TransactionHash, &tx.GenesisBlockAddress)
+		})
+	if err != nil {
+		return nil, err
+	}
+	err = read(sideChainPath, `SELECT TransactionHash, IFNULL(GenesisBlockAddress, ''), IFNULL(BlockHeight, 0)
+				FROM SideChainTxs ORDER BY Id`,
+		func(rows *sql.Rows) (*ExportedTx, error) {
+			tx := &ExportedTx{Type: TxTypeWithdraw, State: ExportStatePending}
+			return tx, rows.Scan(&tx.TransactionHash, &tx.GenesisBlockAddress, &tx.BlockHeight)
+		})
+	if err != nil {
+		return nil, err
+	}
+	err = read(finishedTxsPath, `SELECT TransactionHash, GenesisBlockAddress, Succeed, RecordTime
+				FROM DepositTransactions ORDER BY RecordTime, Id`,
+		func(rows *sql.Rows) (*ExportedTx, error) {
+			var succeed bool
+			tx := &ExportedTx{Type: TxTypeDeposit}
+			err := rows.Scan(&tx.TransactionHash, &tx.GenesisBlockAddress, &succeed, &tx.RecordTime)
+			tx.State = state(succeed)
+			return tx, err
+		})
+	if err != nil {
+		return nil, err
+	}
+	err = read(finishedTxsPath, `SELECT TransactionHash, Succeed, RecordTime
+				FROM WithdrawTransactions ORDER BY RecordTime, Id`,
+		func(rows *sql.Rows) (*ExportedTx, error) {
+			var succeed bool
+			tx := &ExportedTx{Type: TxTypeWithdraw}
+			err := rows.Scan(&tx.TransactionHash, &succeed, &tx.RecordTime)
+			tx.State = state(succeed)
+			return tx, err
+		})
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
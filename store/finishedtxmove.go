@@ -0,0 +1,243 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+)
+
+// The moves from the pending caches to the finished store attach the cache
+// database to a connection of the finished store, so the deletes and inserts
+// are committed in one transaction across both of the files. It relies on
+// the rollback journal of sqlite, attached databases in WAL mode are not
+// committed atomically.
+
+const attachedCacheName = "cache"
+
+// inAttachedTx runs f in a transaction of the finished store with the cache
+// database attached as "cache", cacheMux is the lock of the cache store.
+func (store *FinishedTxsDataStoreImpl) inAttachedTx(ctx context.Context, cachePath string, cacheMux *sync.Mutex,
+	f func(tx *sql.Tx) error) error {
+	if cacheMux != nil {
+		cacheMux.Lock()
+		defer cacheMux.Unlock()
+	}
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	conn, err := store.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+attachedCacheName, cachePath); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, "DETACH DATABASE "+attachedCacheName); err != nil {
+			logger.Warnw("detach cache database failed", log.KeyError, err)
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func mainChainCacheMux() *sync.Mutex {
+	if s, ok := DbCache.MainChainStore.(*DataStoreMainChainImpl); ok {
+		return s.mux
+	}
+	return nil
+}
+
+func sideChainCacheMux() *sync.Mutex {
+	if s, ok := DbCache.SideChainStore.(*DataStoreSideChainImpl); ok {
+		return s.mux
+	}
+	return nil
+}
+
+func (store *FinishedTxsDataStoreImpl) moveDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string, succeed bool) error {
+	if len(transactionHashes) == 0 {
+		return nil
+	}
+	return store.inAttachedTx(ctx, DBNameMainChain, mainChainCacheMux(), func(tx *sql.Tx) error {
+		remove, err := tx.PrepareContext(ctx, "DELETE FROM cache.MainChainTxs WHERE TransactionHash=? AND GenesisBlockAddress=?")
+		if err != nil {
+			return err
+		}
+		defer remove.Close()
+		// a transaction may have been finished before it is cached again
+		insert, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO main.DepositTransactions(TransactionHash,
+					GenesisBlockAddress, Succeed, RecordTime) values(?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer insert.Close()
+
+		recordTime := time.Now().Format(RecordTimeFormat)
+		for i, hash := range transactionHashes {
+			if _, err = remove.ExecContext(ctx, hash, genesisBlockAddresses[i]); err != nil {
+				return err
+			}
+			if _, err = insert.ExecContext(ctx, hash, genesisBlockAddresses[i], succeed, recordTime); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MoveFailedDepositTxs removes the deposit transactions from the main chain
+// cache and adds them as failed in one transaction.
+func (store *FinishedTxsDataStoreImpl) MoveFailedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error {
+	return store.moveDepositTxs(ctx, transactionHashes, genesisBlockAddresses, false)
+}
+
+// MoveSucceedDepositTxs removes the deposit transactions from the main chain
+// cache and adds them as succeeded in one transaction.
+func (store *FinishedTxsDataStoreImpl) MoveSucceedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error {
+	return store.moveDepositTxs(ctx, transactionHashes, genesisBlockAddresses, true)
+}
+
+func (store *FinishedTxsDataStoreImpl) moveWithdrawTxs(ctx context.Context, transactionHashes []string, succeed bool,
+	transactionByte []byte) error {
+	if len(transactionHashes) == 0 {
+		return nil
+	}
+	return store.inAttachedTx(ctx, DBNameSideChain, sideChainCacheMux(), func(tx *sql.Tx) error {
+		recordTime := time.Now().Format(RecordTimeFormat)
+		var sideChainTransactionId int64
+		if !succeed {
+			result, err := tx.ExecContext(ctx, "INSERT INTO main.SideChainTransactions(TransactionData, RecordTime) values(?,?)",
+				transactionByte, recordTime)
+			if err != nil {
+				return err
+			}
+			if sideChainTransactionId, err = result.LastInsertId(); err != nil {
+				return err
+			}
+		}
+
+		remove, err := tx.PrepareContext(ctx, "DELETE FROM cache.SideChainTxs WHERE TransactionHash=?")
+		if err != nil {
+			return err
+		}
+		defer remove.Close()
+		insert, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO main.WithdrawTransactions(TransactionHash,
+					SideChainTransactionId, Succeed, RecordTime) values(?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer insert.Close()
+
+		for _, hash := range transactionHashes {
+			if _, err = remove.ExecContext(ctx, hash); err != nil {
+				return err
+			}
+			if _, err = insert.ExecContext(ctx, hash, sideChainTransactionId, succeed, recordTime); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MoveFailedWithdrawTxs removes the withdraw transactions from the side chain
+// cache and adds them as failed with the withdraw transaction of the main
+// chain in one transaction.
+func (store *FinishedTxsDataStoreImpl) MoveFailedWithdrawTxs(ctx context.Context, transactionHashes []string, transactionByte []byte) error {
+	return store.moveWithdrawTxs(ctx, transactionHashes, false, transactionByte)
+}
+
+// MoveSucceedWithdrawTxs removes the withdraw transactions from the side
+// chain cache and adds them as succeeded in one transaction.
+func (store *FinishedTxsDataStoreImpl) MoveSucceedWithdrawTxs(ctx context.Context, transactionHashes []string) error {
+	return store.moveWithdrawTxs(ctx, transactionHashes, true, nil)
+}
+
+// ConsistencyReport counts the half-applied transitions repaired.
+type ConsistencyReport struct {
+	// StaleDepositTxs and StaleWithdrawTxs are finished but still cached,
+	// they are removed from the caches.
+	StaleDepositTxs  int64
+	StaleWithdrawTxs int64
+	// LostDepositTxs and LostWithdrawTxs are neither cached nor finished
+	// while their last events are succeeded or failed, they are added to the
+	// finished store by the events.
+	LostDepositTxs  int64
+	LostWithdrawTxs int64
+}
+
+// CheckConsistency repairs the transitions half-applied by the arbiters
+// which moved the transactions to the finished store in two writes.
+func (store *FinishedTxsDataStoreImpl) CheckConsistency(ctx context.Context) (*ConsistencyReport, error) {
+	report := new(ConsistencyReport)
+	err := store.inAttachedTx(ctx, DBNameMainChain, mainChainCacheMux(), func(tx *sql.Tx) error {
+		var err error
+		report.StaleDepositTxs, err = execRowsAffected(ctx, tx, `DELETE FROM cache.MainChainTxs WHERE EXISTS (
+					SELECT 1 FROM main.DepositTransactions d WHERE d.TransactionHash=MainChainTxs.TransactionHash
+					AND d.GenesisBlockAddress=MainChainTxs.GenesisBlockAddress)`)
+		if err != nil {
+			return err
+		}
+		report.LostDepositTxs, err = execRowsAffected(ctx, tx, `INSERT INTO main.DepositTransactions(TransactionHash,
+					GenesisBlockAddress, Succeed, RecordTime)
+					SELECT e.TransactionHash, e.GenesisBlockAddress, e.Event=?, e.RecordTime FROM main.TransactionEvents e
+					WHERE e.Type=? AND e.Event IN (?,?) AND e.Id=(SELECT MAX(x.Id) FROM main.TransactionEvents x
+						WHERE x.TransactionHash=e.TransactionHash AND x.GenesisBlockAddress=e.GenesisBlockAddress
+						AND x.Type=e.Type)
+					AND NOT EXISTS (SELECT 1 FROM main.DepositTransactions d WHERE d.TransactionHash=e.TransactionHash
+						AND d.GenesisBlockAddress=e.GenesisBlockAddress)
+					AND NOT EXISTS (SELECT 1 FROM cache.MainChainTxs m WHERE m.TransactionHash=e.TransactionHash
+						AND m.GenesisBlockAddress=e.GenesisBlockAddress)`,
+			TxEventSucceeded, TxTypeDeposit, TxEventSucceeded, TxEventFailed)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.inAttachedTx(ctx, DBNameSideChain, sideChainCacheMux(), func(tx *sql.Tx) error {
+		var err error
+		report.StaleWithdrawTxs, err = execRowsAffected(ctx, tx, `DELETE FROM cache.SideChainTxs WHERE EXISTS (
+					SELECT 1 FROM main.WithdrawTransactions w WHERE w.TransactionHash=SideChainTxs.TransactionHash)`)
+		if err != nil {
+			return err
+		}
+		// the withdraw transaction of the main chain is unknown, so the lost
+		// failed ones have no side chain transaction
+		report.LostWithdrawTxs, err = execRowsAffected(ctx, tx, `INSERT INTO main.WithdrawTransactions(TransactionHash,
+					SideChainTransactionId, Succeed, RecordTime)
+					SELECT e.TransactionHash, 0, e.Event=?, e.RecordTime FROM main.TransactionEvents e
+					WHERE e.Type=? AND e.Event IN (?,?) AND e.Id=(SELECT MAX(x.Id) FROM main.TransactionEvents x
+						WHERE x.TransactionHash=e.TransactionHash AND x.Type=e.Type)
+					AND NOT EXISTS (SELECT 1 FROM main.WithdrawTransactions w WHERE w.TransactionHash=e.TransactionHash)
+					AND NOT EXISTS (SELECT 1 FROM cache.SideChainTxs s WHERE s.TransactionHash=e.TransactionHash)`,
+			TxEventSucceeded, TxTypeWithdraw, TxEventSucceeded, TxEventFailed)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func execRowsAffected(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
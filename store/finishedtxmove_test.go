@@ -0,0 +1,139 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func openTestStores(t *testing.T) (*DataStoreMainChainImpl, *DataStoreSideChainImpl, *FinishedTxsDataStoreImpl, func()) {
+	mainChainStore, err := OpenMainChainDataStore()
+	if err != nil {
+		t.Fatal("Open main chain database error.")
+	}
+	sideChainStore, err := OpenSideChainDataStore()
+	if err != nil {
+		t.Fatal("Open side chain database error.")
+	}
+	finishedStore, err := OpenFinishedTxsDataStore()
+	if err != nil {
+		t.Fatal("Open finished database error.")
+	}
+	DbCache = DataStoreImpl{MainChainStore: mainChainStore, SideChainStore: sideChainStore}
+	return mainChainStore, sideChainStore, finishedStore.(*FinishedTxsDataStoreImpl), func() {
+		DbCache = DataStoreImpl{}
+		mainChainStore.ResetDataStore()
+		sideChainStore.ResetDataStore()
+		finishedStore.ResetDataStore()
+	}
+}
+
+func TestFinishedTxsDataStoreImpl_MoveDepositTxs(t *testing.T) {
+	mainChainStore, _, finishedStore, cleanup := openTestStores(t)
+	defer cleanup()
+
+	for _, hash := range []string{"testHash1", "testHash2"} {
+		mainChainStore.Exec("INSERT INTO MainChainTxs(TransactionHash, GenesisBlockAddress) values(?,?)",
+			hash, "testAddress")
+	}
+
+	err := finishedStore.MoveSucceedDepositTxs(context.Background(), []string{"testHash1"}, []string{"testAddress"})
+	if err != nil {
+		t.Fatal("Move succeed deposit transactions error:", err)
+	}
+	if ok, _ := mainChainStore.HasMainChainTx(context.Background(), "testHash1", "testAddress"); ok {
+		t.Error("Moved transaction should be removed from the cache.")
+	}
+	if succeed, _ := finishedStore.GetDepositTxByHashAndGenesisAddress(context.Background(), "testHash1", "testAddress"); !succeed {
+		t.Error("Moved transaction should be succeeded.")
+	}
+
+	// a failed move changes neither of the databases
+	finishedStore.Exec("DROP TABLE DepositTransactions")
+	err = finishedStore.MoveFailedDepositTxs(context.Background(), []string{"testHash2"}, []string{"testAddress"})
+	if err == nil {
+		t.Fatal("Move into a broken database should fail.")
+	}
+	if ok, _ := mainChainStore.HasMainChainTx(context.Background(), "testHash2", "testAddress"); !ok {
+		t.Error("Failed move should be rolled back.")
+	}
+}
+
+func TestFinishedTxsDataStoreImpl_MoveWithdrawTxs(t *testing.T) {
+	_, sideChainStore, finishedStore, cleanup := openTestStores(t)
+	defer cleanup()
+
+	for _, hash := range []string{"testHash1", "testHash2"} {
+		sideChainStore.Exec("INSERT INTO SideChainTxs(TransactionHash, GenesisBlockAddress) values(?,?)",
+			hash, "testAddress")
+	}
+
+	err := finishedStore.MoveFailedWithdrawTxs(context.Background(), []string{"testHash1"}, []byte{1, 2, 3})
+	if err != nil {
+		t.Fatal("Move failed withdraw transactions error:", err)
+	}
+	err = finishedStore.MoveSucceedWithdrawTxs(context.Background(), []string{"testHash2"})
+	if err != nil {
+		t.Fatal("Move succeed withdraw transactions error:", err)
+	}
+	if count, _ := sideChainStore.GetSideChainTxsCount(context.Background()); count != 0 {
+		t.Error("Moved transactions should be removed from the cache.")
+	}
+	succeed, data, err := finishedStore.GetWithdrawTxByHash(context.Background(), "testHash1")
+	if err != nil || succeed || !bytes.Equal(data, []byte{1, 2, 3}) {
+		t.Error("Moved transaction should be failed with the side chain transaction.")
+	}
+	if succeed, _, _ = finishedStore.GetWithdrawTxByHash(context.Background(), "testHash2"); !succeed {
+		t.Error("Moved transaction should be succeeded.")
+	}
+}
+
+func TestFinishedTxsDataStoreImpl_CheckConsistency(t *testing.T) {
+	mainChainStore, sideChainStore, finishedStore, cleanup := openTestStores(t)
+	defer cleanup()
+
+	// finished but still cached
+	mainChainStore.Exec("INSERT INTO MainChainTxs(TransactionHash, GenesisBlockAddress) values(?,?)",
+		"staleHash", "testAddress")
+	finishedStore.AddFailedDepositTxs(context.Background(), []string{"staleHash"}, []string{"testAddress"})
+	sideChainStore.Exec("INSERT INTO SideChainTxs(TransactionHash, GenesisBlockAddress) values(?,?)",
+		"staleHash", "testAddress")
+	finishedStore.AddSucceedWithdrawTxs(context.Background(), []string{"staleHash"})
+	// neither cached nor finished
+	finishedStore.AddTransactionEvents(context.Background(), []*TransactionEvent{
+		{TransactionHash: "lostHash", GenesisBlockAddress: "testAddress", Type: TxTypeDeposit, Event: TxEventSucceeded},
+		{TransactionHash: "lostHash", Type: TxTypeWithdraw, Event: TxEventFailed},
+		// still cached, left to the cross-chain flows
+		{TransactionHash: "pendingHash", GenesisBlockAddress: "testAddress", Type: TxTypeDeposit, Event: TxEventSucceeded},
+	})
+	mainChainStore.Exec("INSERT INTO MainChainTxs(TransactionHash, GenesisBlockAddress) values(?,?)",
+		"pendingHash", "testAddress")
+
+	report, err := finishedStore.CheckConsistency(context.Background())
+	if err != nil {
+		t.Fatal("Check consistency error:", err)
+	}
+	if *report != (ConsistencyReport{StaleDepositTxs: 1, StaleWithdrawTxs: 1, LostDepositTxs: 1, LostWithdrawTxs: 1}) {
+		t.Errorf("Unexpected report %+v", *report)
+	}
+	if ok, _ := mainChainStore.HasMainChainTx(context.Background(), "staleHash", "testAddress"); ok {
+		t.Error("Stale deposit transaction should be removed from the cache.")
+	}
+	if ok, _ := sideChainStore.HasSideChainTx(context.Background(), "staleHash"); ok {
+		t.Error("Stale withdraw transaction should be removed from the cache.")
+	}
+	if succeed, _ := finishedStore.GetDepositTxByHashAndGenesisAddress(context.Background(), "lostHash", "testAddress"); !succeed {
+		t.Error("Lost deposit transaction should be added as succeeded.")
+	}
+	if ok, _ := finishedStore.HasWithdrawTx(context.Background(), "lostHash"); !ok {
+		t.Error("Lost withdraw transaction should be added.")
+	}
+	if ok, _ := mainChainStore.HasMainChainTx(context.Background(), "pendingHash", "testAddress"); !ok {
+		t.Error("Pending deposit transaction should be kept.")
+	}
+
+	report, err = finishedStore.CheckConsistency(context.Background())
+	if err != nil || *report != (ConsistencyReport{}) {
+		t.Errorf("Consistent databases should not be repaired, got %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,378 @@
+package store
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+)
+
+// PrunePolicy tells which finished transactions are pruned, the cutoffs are
+// in RecordTimeFormat and an empty cutoff keeps the transactions forever.
+type PrunePolicy struct {
+	SucceedBefore string
+	FailedBefore  string
+
+	// ArchiveDir is where the pruned rows are archived before deleted, empty
+	// to delete them without archive.
+	ArchiveDir string
+	// DryRun only reports what would be pruned.
+	DryRun bool
+}
+
+// NewPrunePolicy returns the policy of the retention configuration at now.
+func NewPrunePolicy(conf config.FinishedTxsRetentionConfiguration, now time.Time) *PrunePolicy {
+	policy := &PrunePolicy{ArchiveDir: conf.ArchiveDir, DryRun: conf.DryRun}
+	if conf.SucceedDays > 0 {
+		policy.SucceedBefore = now.AddDate(0, 0, -conf.SucceedDays).Format(RecordTimeFormat)
+	}
+	if conf.FailedDays > 0 {
+		policy.FailedBefore = now.AddDate(0, 0, -conf.FailedDays).Format(RecordTimeFormat)
+	}
+	return policy
+}
+
+// PruneReport counts the rows pruned, or would be pruned in a dry run.
+type PruneReport struct {
+	DryRun            bool
+	SucceedBefore     string
+	FailedBefore      string
+	DepositTxs        int
+	WithdrawTxs       int
+	SideChainTxs      int
+	TransactionEvents int
+	ArchiveFile       string
+}
+
+// Total returns the count of all the rows.
+func (r *PruneReport) Total() int {
+	return r.DepositTxs + r.WithdrawTxs + r.SideChainTxs + r.TransactionEvents
+}
+
+// finishedCondition matches the rows of the deposit or withdraw transactions
+// out of the retention.
+func (p *PrunePolicy) finishedCondition(alias string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if p.SucceedBefore != "" {
+		conditions = append(conditions, "("+alias+".Succeed=1 AND "+alias+".RecordTime<?)")
+		args = append(args, p.SucceedBefore)
+	}
+	if p.FailedBefore != "" {
+		conditions = append(conditions, "("+alias+".Succeed=0 AND "+alias+".RecordTime<?)")
+		args = append(args, p.FailedBefore)
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// pruneStatement is a category of the pruned rows, selected to be counted and
+// archived, then deleted.
+type pruneStatement struct {
+	table  string
+	query  string
+	delete string
+	args   []interface{}
+	count  *int
+	scan   func(rows *sql.Rows) (map[string]interface{}, error)
+}
+
+func (p *PrunePolicy) statements(report *PruneReport) []*pruneStatement {
+	deposit, depositArgs := p.finishedCondition("d")
+	withdraw, withdrawArgs := p.finishedCondition("w")
+
+	// events of the pruned transactions, deposits are told apart by side chains
+	eventsCondition := `(e.Type='` + TxTypeDeposit + `' AND EXISTS (SELECT 1 FROM DepositTransactions d
+				WHERE d.TransactionHash=e.TransactionHash AND d.GenesisBlockAddress=e.GenesisBlockAddress AND ` +
+		deposit + `)) OR (e.Type='` + TxTypeWithdraw + `' AND EXISTS (SELECT 1 FROM WithdrawTransactions w
+				WHERE w.TransactionHash=e.TransactionHash AND ` + withdraw + `))`
+	eventsArgs := append(append([]interface{}{}, depositArgs...), withdrawArgs...)
+
+	statements := []*pruneStatement{
+		{
+			table: "TransactionEvents",
+			query: `SELECT e.Id, e.TransactionHash, e.GenesisBlockAddress, e.Type, e.Event, e.Height, e.Source,
+				e.ProposalHash, e.Signatures, e.ResultHash, e.Reason, e.RecordTime
+				FROM TransactionEvents e WHERE ` + eventsCondition,
+			delete: `DELETE FROM TransactionEvents WHERE Id IN (SELECT e.Id FROM TransactionEvents e WHERE ` +
+				eventsCondition + `)`,
+			args:  eventsArgs,
+			count: &report.TransactionEvents,
+			scan: func(rows *sql.Rows) (map[string]interface{}, error) {
+				var id int64
+				var e TransactionEvent
+				err := rows.Scan(&id, &e.TransactionHash, &e.GenesisBlockAddress, &e.Type, &e.Event, &e.Height,
+					&e.Source, &e.ProposalHash, &e.Signatures, &e.ResultHash, &e.Reason, &e.RecordTime)
+				return map[string]interface{}{"Id": id, "TransactionHash": e.TransactionHash,
+					"GenesisBlockAddress": e.GenesisBlockAddress, "Type": e.Type, "Event": e.Event,
+					"Height": e.Height, "Source": e.Source, "ProposalHash": e.ProposalHash,
+					"Signatures": e.Signatures, "ResultHash": e.ResultHash, "Reason": e.Reason,
+					"RecordTime": e.RecordTime}, err
+			},
+		},
+	}
+
+	// side chain transactions are only kept for the failed withdraw
+	// transactions, so they follow the retention of failures
+	if p.FailedBefore != "" {
+		sideChainCondition := `s.RecordTime<? AND NOT EXISTS (SELECT 1 FROM WithdrawTransactions w
+				WHERE w.SideChainTransactionId=s.Id AND NOT ` + withdraw + `)`
+		sideChainArgs := append([]interface{}{p.FailedBefore}, withdrawArgs...)
+		statements = append(statements, &pruneStatement{
+			table: "SideChainTransactions",
+			query: `SELECT s.Id, s.TransactionData, s.RecordTime FROM SideChainTransactions s WHERE ` +
+				sideChainCondition,
+			delete: `DELETE FROM SideChainTransactions WHERE Id IN (SELECT s.Id FROM SideChainTransactions s WHERE ` +
+				sideChainCondition + `)`,
+			args:  sideChainArgs,
+			count: &report.SideChainTxs,
+			scan: func(rows *sql.Rows) (map[string]interface{}, error) {
+				var id int64
+				var data []byte
+				var recordTime string
+				err := rows.Scan(&id, &data, &recordTime)
+				return map[string]interface{}{"Id": id, "TransactionData": hex.EncodeToString(data),
+					"RecordTime": recordTime}, err
+			},
+		})
+	}
+
+	return append(statements,
+		&pruneStatement{
+			table: "DepositTransactions",
+			query: `SELECT d.Id, d.TransactionHash, d.GenesisBlockAddress, d.Succeed, d.RecordTime
+				FROM DepositTransactions d WHERE ` + deposit,
+			delete: `DELETE FROM DepositTransactions WHERE Id IN (SELECT d.Id FROM DepositTransactions d WHERE ` +
+				deposit + `)`,
+			args:  depositArgs,
+			count: &report.DepositTxs,
+			scan: func(rows *sql.Rows) (map[string]interface{}, error) {
+				var id int64
+				var tx FinishedTx
+				err := rows.Scan(&id, &tx.TransactionHash, &tx.GenesisBlockAddress, &tx.Succeed, &tx.RecordTime)
+				return map[string]interface{}{"Id": id, "TransactionHash": tx.TransactionHash,
+					"GenesisBlockAddress": tx.GenesisBlockAddress, "Succeed": tx.Succeed,
+					"RecordTime": tx.RecordTime}, err
+			},
+		},
+		&pruneStatement{
+			table: "WithdrawTransactions",
+			query: `SELECT w.Id, w.TransactionHash, w.SideChainTransactionId, w.Succeed, w.RecordTime
+				FROM WithdrawTransactions w WHERE ` + withdraw,
+			delete: `DELETE FROM WithdrawTransactions WHERE Id IN (SELECT w.Id FROM WithdrawTransactions w WHERE ` +
+				withdraw + `)`,
+			args:  withdrawArgs,
+			count: &report.WithdrawTxs,
+			scan: func(rows *sql.Rows) (map[string]interface{}, error) {
+				var id int64
+				var sideChainTxId sql.NullInt64
+				var tx FinishedTx
+				err := rows.Scan(&id, &tx.TransactionHash, &sideChainTxId, &tx.Succeed, &tx.RecordTime)
+				row := map[string]interface{}{"Id": id, "TransactionHash": tx.TransactionHash,
+					"Succeed": tx.Succeed, "RecordTime": tx.RecordTime}
+				if sideChainTxId.Valid {
+					row["SideChainTransactionId"] = sideChainTxId.Int64
+				}
+				return row, err
+			},
+		})
+}
+
+// pruneArchive writes the pruned rows as gzip compressed JSON lines, each of
+// them has the name of its table in "Table".
+type pruneArchive struct {
+	path    string
+	file    *os.File
+	writer  *gzip.Writer
+	encoder *json.Encoder
+}
+
+func createPruneArchive(dir string, now time.Time) (*pruneArchive, error) {
+	if err := CheckAndCreateDocument(dir); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "finishedTxs-"+now.Format("20060102-150405")+".jsonl.gz")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	writer := gzip.NewWriter(file)
+	return &pruneArchive{path: path, file: file, writer: writer, encoder: json.NewEncoder(writer)}, nil
+}
+
+func (a *pruneArchive) write(table string, row map[string]interface{}) error {
+	row["Table"] = table
+	return a.encoder.Encode(row)
+}
+
+// close flushes the archive to the disk, so the rows are not deleted before
+// they are archived.
+func (a *pruneArchive) close() error {
+	if err := a.writer.Close(); err != nil {
+		a.file.Close()
+		return err
+	}
+	if err := a.file.Sync(); err != nil {
+		a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
+
+func (a *pruneArchive) remove() {
+	a.writer.Close()
+	a.file.Close()
+	os.Remove(a.path)
+}
+
+// Prune deletes the finished transactions out of the retention, with their
+// events and side chain transactions. The rows are archived first if the
+// policy has an ArchiveDir, and nothing is changed in a dry run.
+func (store *FinishedTxsDataStoreImpl) Prune(ctx context.Context, policy *PrunePolicy) (*PruneReport, error) {
+	report := &PruneReport{
+		DryRun:        policy.DryRun,
+		SucceedBefore: policy.SucceedBefore,
+		FailedBefore:  policy.FailedBefore,
+	}
+	if policy.SucceedBefore == "" && policy.FailedBefore == "" {
+		return report, nil
+	}
+
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	tx, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var archive *pruneArchive
+	if policy.ArchiveDir != "" && !policy.DryRun {
+		if archive, err = createPruneArchive(policy.ArchiveDir, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	statements := policy.statements(report)
+	for _, s := range statements {
+		if err = s.selectRows(ctx, tx, archive); err != nil {
+			if archive != nil {
+				archive.remove()
+			}
+			return nil, err
+		}
+	}
+
+	if archive != nil {
+		if report.Total() == 0 {
+			archive.remove()
+		} else {
+			if err = archive.close(); err != nil {
+				os.Remove(archive.path)
+				return nil, err
+			}
+			report.ArchiveFile = archive.path
+		}
+	}
+	if policy.DryRun || report.Total() == 0 {
+		return report, nil
+	}
+
+	// events and side chain transactions are matched by the finished
+	// transactions, so they are deleted first
+	for _, s := range statements {
+		if _, err = tx.ExecContext(ctx, s.delete, s.args...); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *pruneStatement) selectRows(ctx context.Context, tx *sql.Tx, archive *pruneArchive) error {
+	rows, err := tx.QueryContext(ctx, s.query, s.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		*s.count++
+		if archive == nil {
+			continue
+		}
+		row, err := s.scan(rows)
+		if err != nil {
+			return err
+		}
+		if err = archive.write(s.table, row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Vacuum rebuilds the database file to give the space of the deleted rows
+// back to the file system.
+func (store *FinishedTxsDataStoreImpl) Vacuum(ctx context.Context) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	_, err := store.ExecContext(ctx, "VACUUM")
+	return err
+}
+
+// PruneLoop prunes the finished transactions by the retention configuration
+// every PruneInterval, and vacuums the database every VacuumInterval.
+func PruneLoop(conf config.FinishedTxsRetentionConfiguration) {
+	if conf.PruneInterval <= 0 {
+		logger.Warn("prune interval should be greater than 0")
+		return
+	}
+	lastVacuum := time.Now()
+	for {
+		if FinishedTxsDbCache == nil {
+			logger.Warn("finished transactions store is not opened")
+			return
+		}
+		report, err := FinishedTxsDbCache.Prune(context.Background(), NewPrunePolicy(conf, time.Now()))
+		if err != nil {
+			logger.Warnw("prune finished transactions failed", log.KeyError, err)
+		} else {
+			logPruneReport(report)
+		}
+
+		if conf.VacuumInterval > 0 && !conf.DryRun &&
+			time.Since(lastVacuum) >= time.Millisecond*conf.VacuumInterval {
+			if err := FinishedTxsDbCache.Vacuum(context.Background()); err != nil {
+				logger.Warnw("vacuum finished transactions store failed", log.KeyError, err)
+			} else {
+				logger.Info("vacuum finished transactions store finished")
+			}
+			lastVacuum = time.Now()
+		}
+		time.Sleep(time.Millisecond * conf.PruneInterval)
+	}
+}
+
+func logPruneReport(report *PruneReport) {
+	action := "pruned"
+	if report.DryRun {
+		action = "would prune"
+	}
+	logger.Infow(action+" finished transactions", "deposit", report.DepositTxs, "withdraw", report.WithdrawTxs,
+		"sidechaintxs", report.SideChainTxs, "events", report.TransactionEvents,
+		"succeedbefore", report.SucceedBefore, "failedbefore", report.FailedBefore)
+	if report.ArchiveFile != "" {
+		logger.Infow("archived pruned rows", "file", report.ArchiveFile)
+	}
+}
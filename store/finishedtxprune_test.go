@@ -0,0 +1,98 @@
+package store
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestFinishedTxsDataStoreImpl_Prune(t *testing.T) {
+	datastore, err := OpenFinishedTxsDataStore()
+	if err != nil {
+		t.Fatal("Open database error.")
+	}
+	defer datastore.ResetDataStore()
+
+	datastore.AddSucceedDepositTxs(context.Background(), []string{"aa01"}, []string{"testAddress1"})
+	datastore.AddFailedDepositTxs(context.Background(), []string{"aa02"}, []string{"testAddress1"})
+	datastore.AddSucceedWithdrawTxs(context.Background(), []string{"bb01"})
+	datastore.AddFailedWithdrawTxs(context.Background(), []string{"bb02"}, []byte{1, 2, 3})
+	datastore.AddTransactionEvents(context.Background(), []*TransactionEvent{
+		{TransactionHash: "aa01", GenesisBlockAddress: "testAddress1", Type: TxTypeDeposit, Event: TxEventSucceeded},
+		{TransactionHash: "aa01", GenesisBlockAddress: "testAddress2", Type: TxTypeDeposit, Event: TxEventDetected},
+		{TransactionHash: "bb02", Type: TxTypeWithdraw, Event: TxEventFailed},
+	})
+
+	future := "9999-01-01_00.00.00"
+	report, err := datastore.Prune(context.Background(), &PrunePolicy{SucceedBefore: future, DryRun: true})
+	if err != nil {
+		t.Fatal("Prune error:", err)
+	}
+	if report.DepositTxs != 1 || report.WithdrawTxs != 1 || report.SideChainTxs != 0 ||
+		report.TransactionEvents != 1 || !report.DryRun {
+		t.Error("Dry run report error:", *report)
+	}
+	if ok, _ := datastore.HasDepositTx(context.Background(), "aa01", "testAddress1"); !ok {
+		t.Error("Dry run should not delete transactions.")
+	}
+
+	archiveDir, err := ioutil.TempDir("", "prune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(archiveDir)
+
+	report, err = datastore.Prune(context.Background(), &PrunePolicy{SucceedBefore: future, FailedBefore: future, ArchiveDir: archiveDir})
+	if err != nil {
+		t.Fatal("Prune error:", err)
+	}
+	if report.DepositTxs != 2 || report.WithdrawTxs != 2 || report.SideChainTxs != 1 ||
+		report.TransactionEvents != 2 || report.ArchiveFile == "" {
+		t.Error("Prune report error:", *report)
+	}
+	if ok, _ := datastore.HasDepositTx(context.Background(), "aa02", "testAddress1"); ok {
+		t.Error("Failed deposit transaction should be pruned.")
+	}
+	if ok, _ := datastore.HasWithdrawTx(context.Background(), "bb01"); ok {
+		t.Error("Succeed withdraw transaction should be pruned.")
+	}
+	events, _ := datastore.GetTransactionEvents(context.Background(), "aa01")
+	if len(events) != 1 || events[0].GenesisBlockAddress != "testAddress2" {
+		t.Error("Events of unfinished transactions should be kept.")
+	}
+
+	file, err := os.Open(report.ArchiveFile)
+	if err != nil {
+		t.Fatal("Open archive error:", err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal("Read archive error:", err)
+	}
+	tables := make(map[string]int)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var row map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatal("Decode archive error:", err)
+		}
+		tables[row["Table"].(string)]++
+	}
+	if tables["DepositTransactions"] != 2 || tables["WithdrawTransactions"] != 2 ||
+		tables["SideChainTransactions"] != 1 || tables["TransactionEvents"] != 2 {
+		t.Error("Archive error:", tables)
+	}
+
+	report, err = datastore.Prune(context.Background(), &PrunePolicy{})
+	if err != nil || report.Total() != 0 {
+		t.Error("Prune without retention should do nothing.")
+	}
+	if err = datastore.Vacuum(context.Background()); err != nil {
+		t.Error("Vacuum error:", err)
+	}
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	CreateDepositTransactionsTimeIndex = `CREATE INDEX IF NOT EXISTS DepositTransactionsTime
+				ON DepositTransactions (RecordTime, Id);`
+	CreateDepositTransactionsAddressIndex = `CREATE INDEX IF NOT EXISTS DepositTransactionsAddress
+				ON DepositTransactions (GenesisBlockAddress, RecordTime, Id);`
+	CreateWithdrawTransactionsTimeIndex = `CREATE INDEX IF NOT EXISTS WithdrawTransactionsTime
+				ON WithdrawTransactions (RecordTime, Id);`
+)
+
+// FinishedTxsQuery filters the finished transactions, the zero value matches
+// all of them. Results are sorted by RecordTime, StartTime and EndTime are in
+// RecordTimeFormat and EndTime is exclusive.
+type FinishedTxsQuery struct {
+	// GenesisBlockAddress only applies to deposit transactions, withdraw
+	// transactions do not record the side chain.
+	GenesisBlockAddress string
+	Succeed             *bool
+	StartTime           string
+	EndTime             string
+	HashPrefix          string
+
+	// Cursor is the NextCursor of the previous page, empty for the first page.
+	Cursor string
+	Limit  int
+}
+
+// FinishedTx is a row of DepositTransactions or WithdrawTransactions.
+type FinishedTx struct {
+	TransactionHash     string
+	GenesisBlockAddress string
+	Succeed             bool
+	RecordTime          string
+}
+
+type finishedTxsCursor struct {
+	recordTime string
+	id         int64
+}
+
+func encodeCursor(c finishedTxsCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.recordTime + "|" + strconv.FormatInt(c.id, 10)))
+}
+
+func decodeCursor(cursor string) (finishedTxsCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return finishedTxsCursor{}, errors.New("invalid cursor")
+	}
+	sep := strings.LastIndexByte(string(data), '|')
+	if sep < 0 {
+		return finishedTxsCursor{}, errors.New("invalid cursor")
+	}
+	id, err := strconv.ParseInt(string(data[sep+1:]), 10, 64)
+	if err != nil {
+		return finishedTxsCursor{}, errors.New("invalid cursor")
+	}
+	return finishedTxsCursor{recordTime: string(data[:sep]), id: id}, nil
+}
+
+// whereClause builds the conditions of the query, withAddress tells whether
+// the table has the GenesisBlockAddress column.
+func (q *FinishedTxsQuery) whereClause(withAddress bool) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	if q.GenesisBlockAddress != "" {
+		if !withAddress {
+			return "", nil, errors.New("genesis block address is not recorded")
+		}
+		conditions = append(conditions, "GenesisBlockAddress=?")
+		args = append(args, q.GenesisBlockAddress)
+	}
+	if q.Succeed != nil {
+		conditions = append(conditions, "Succeed=?")
+		args = append(args, *q.Succeed)
+	}
+	if q.StartTime != "" {
+		conditions = append(conditions, "RecordTime>=?")
+		args = append(args, q.StartTime)
+	}
+	if q.EndTime != "" {
+		conditions = append(conditions, "RecordTime<?")
+		args = append(args, q.EndTime)
+	}
+	if q.HashPrefix != "" {
+		// hashes are lower case hex, "~" is greater than all of the letters
+		conditions = append(conditions, "TransactionHash>=? AND TransactionHash<?")
+		args = append(args, q.HashPrefix, q.HashPrefix+"~")
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, "(RecordTime>? OR RecordTime=? AND Id>?)")
+		args = append(args, c.recordTime, c.recordTime, c.id)
+	}
+	if len(conditions) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+func (store *FinishedTxsDataStoreImpl) queryFinishedTxs(ctx context.Context, table string, withAddress bool,
+	query *FinishedTxsQuery) ([]*FinishedTx, string, error) {
+	where, args, err := query.whereClause(withAddress)
+	if err != nil {
+		return nil, "", err
+	}
+	if query.Limit <= 0 {
+		return nil, "", errors.New("limit should be greater than 0")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("SELECT Id, TransactionHash, ")
+	if withAddress {
+		buf.WriteString("GenesisBlockAddress, ")
+	} else {
+		buf.WriteString("'', ")
+	}
+	buf.WriteString("Succeed, RecordTime FROM ")
+	buf.WriteString(table)
+	buf.WriteString(where)
+	// query one more row to tell whether there is a next page
+	buf.WriteString(" ORDER BY RecordTime, Id LIMIT ?")
+	args = append(args, query.Limit+1)
+
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	rows, err := store.QueryContext(ctx, buf.String(), args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var txs []*FinishedTx
+	var last finishedTxsCursor
+	var hasNext bool
+	for rows.Next() {
+		if len(txs) == query.Limit {
+			hasNext = true
+			break
+		}
+		var tx FinishedTx
+		err = rows.Scan(&last.id, &tx.TransactionHash, &tx.GenesisBlockAddress, &tx.Succeed, &tx.RecordTime)
+		if err != nil {
+			return nil, "", err
+		}
+		last.recordTime = tx.RecordTime
+		txs = append(txs, &tx)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if hasNext {
+		nextCursor = encodeCursor(last)
+	}
+	return txs, nextCursor, nil
+}
+
+// QueryDepositTxs returns a page of the deposit transactions matching the
+// query, and the cursor of the next page which is empty on the last page.
+func (store *FinishedTxsDataStoreImpl) QueryDepositTxs(ctx context.Context, query *FinishedTxsQuery) ([]*FinishedTx, string, error) {
+	return store.queryFinishedTxs(ctx, "DepositTransactions", true, query)
+}
+
+// QueryWithdrawTxs returns a page of the withdraw transactions matching the
+// query, and the cursor of the next page which is empty on the last page.
+func (store *FinishedTxsDataStoreImpl) QueryWithdrawTxs(ctx context.Context, query *FinishedTxsQuery) ([]*FinishedTx, string, error) {
+	return store.queryFinishedTxs(ctx, "WithdrawTransactions", false, query)
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFinishedTxsDataStoreImpl_QueryDepositTxs(t *testing.T) {
+	datastore, err := OpenFinishedTxsDataStore()
+	if err != nil {
+		t.Fatal("Open database error.")
+	}
+	defer datastore.ResetDataStore()
+
+	datastore.AddSucceedDepositTxs(context.Background(),
+		[]string{"aa01", "aa02", "bb03", "aa04"},
+		[]string{"testAddress1", "testAddress1", "testAddress1", "testAddress2"})
+	datastore.AddFailedDepositTxs(context.Background(), []string{"aa05"}, []string{"testAddress1"})
+
+	// page through the deposit transactions to testAddress1
+	var hashes []string
+	query := &FinishedTxsQuery{GenesisBlockAddress: "testAddress1", Limit: 2}
+	for page := 0; ; page++ {
+		txs, nextCursor, err := datastore.QueryDepositTxs(context.Background(), query)
+		if err != nil {
+			t.Fatal("Query deposit transactions error:", err)
+		}
+		for _, tx := range txs {
+			hashes = append(hashes, tx.TransactionHash)
+		}
+		if nextCursor == "" {
+			break
+		}
+		if page > 2 {
+			t.Fatal("Too many pages.")
+		}
+		query.Cursor = nextCursor
+	}
+	if len(hashes) != 4 || hashes[0] != "aa01" || hashes[1] != "aa02" ||
+		hashes[2] != "bb03" || hashes[3] != "aa05" {
+		t.Error("Query deposit transactions error, got:", hashes)
+	}
+
+	succeed := true
+	txs, nextCursor, err := datastore.QueryDepositTxs(context.Background(), &FinishedTxsQuery{
+		Succeed: &succeed, HashPrefix: "aa", Limit: 10})
+	if err != nil {
+		t.Fatal("Query deposit transactions error:", err)
+	}
+	if len(txs) != 3 || nextCursor != "" || txs[2].TransactionHash != "aa04" || !txs[2].Succeed {
+		t.Error("Query deposit transactions with filters error.")
+	}
+
+	txs, _, err = datastore.QueryDepositTxs(context.Background(), &FinishedTxsQuery{EndTime: "2000-01-01_00.00.00", Limit: 10})
+	if err != nil || len(txs) != 0 {
+		t.Error("Query deposit transactions with time range error.")
+	}
+
+	if _, _, err = datastore.QueryDepositTxs(context.Background(), &FinishedTxsQuery{Cursor: "invalid", Limit: 10}); err == nil {
+		t.Error("Invalid cursor should be rejected.")
+	}
+}
+
+func TestFinishedTxsDataStoreImpl_QueryWithdrawTxs(t *testing.T) {
+	datastore, err := OpenFinishedTxsDataStore()
+	if err != nil {
+		t.Fatal("Open database error.")
+	}
+	defer datastore.ResetDataStore()
+
+	datastore.AddSucceedWithdrawTxs(context.Background(), []string{"aa01", "aa02"})
+	datastore.AddFailedWithdrawTxs(context.Background(), []string{"aa03"}, []byte{1})
+
+	succeed := false
+	txs, _, err := datastore.QueryWithdrawTxs(context.Background(), &FinishedTxsQuery{Succeed: &succeed, Limit: 10})
+	if err != nil {
+		t.Fatal("Query withdraw transactions error:", err)
+	}
+	if len(txs) != 1 || txs[0].TransactionHash != "aa03" || txs[0].Succeed {
+		t.Error("Query withdraw transactions error.")
+	}
+
+	if _, _, err = datastore.QueryWithdrawTxs(context.Background(), &FinishedTxsQuery{
+		GenesisBlockAddress: "testAddress", Limit: 10}); err == nil {
+		t.Error("Withdraw transactions can not be filtered by genesis block address.")
+	}
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"os"
@@ -46,23 +47,37 @@ var (
 )
 
 type FinishedTransactionsDataStore interface {
-	AddFailedDepositTxs(transactionHashes, genesisBlockAddresses []string) error
-	AddSucceedDepositTxs(transactionHashes, genesisBlockAddresses []string) error
-	HasDepositTx(transactionHash string, genesisBlockAddress string) (bool, error)
-	GetDepositTxByHash(transactionHash string) ([]bool, []string, error)
-	GetDepositTxByHashAndGenesisAddress(transactionHash string, genesisAddress string) (bool, error)
-	GetDepositTxs(succeed bool) ([]string, []string, error)
-
-	AddFailedWithdrawTxs(transactionHashes []string, transactionByte []byte) error
-	AddSucceedWithdrawTxs(transactionHashes []string) error
-	HasWithdrawTx(transactionHash string) (bool, error)
-	GetWithdrawTxByHash(transactionHash string) (bool, []byte, error)
-	GetWithdrawTxs(succeed bool) ([]string, error)
-
-	AddSideChainTx(transactionByte []byte) error
-	GetSideChainTx(sideChainTransactionId uint64) ([]byte, error)
-
-	ResetDataStore() error
+	AddFailedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error
+	AddSucceedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error
+	HasDepositTx(ctx context.Context, transactionHash string, genesisBlockAddress string) (bool, error)
+	GetDepositTxByHash(ctx context.Context, transactionHash string) ([]bool, []string, error)
+	GetDepositTxByHashAndGenesisAddress(ctx context.Context, transactionHash string, genesisAddress string) (bool, error)
+	GetDepositTxs(ctx context.Context, succeed bool) ([]string, []string, error)
+	QueryDepositTxs(ctx context.Context, query *FinishedTxsQuery) ([]*FinishedTx, string, error)
+
+	AddFailedWithdrawTxs(ctx context.Context, transactionHashes []string, transactionByte []byte) error
+	AddSucceedWithdrawTxs(ctx context.Context, transactionHashes []string) error
+	HasWithdrawTx(ctx context.Context, transactionHash string) (bool, error)
+	GetWithdrawTxByHash(ctx context.Context, transactionHash string) (bool, []byte, error)
+	GetWithdrawTxs(ctx context.Context, succeed bool) ([]string, error)
+	QueryWithdrawTxs(ctx context.Context, query *FinishedTxsQuery) ([]*FinishedTx, string, error)
+
+	MoveFailedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error
+	MoveSucceedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error
+	MoveFailedWithdrawTxs(ctx context.Context, transactionHashes []string, transactionByte []byte) error
+	MoveSucceedWithdrawTxs(ctx context.Context, transactionHashes []string) error
+	CheckConsistency(ctx context.Context) (*ConsistencyReport, error)
+
+	AddSideChainTx(ctx context.Context, transactionByte []byte) error
+	GetSideChainTx(ctx context.Context, sideChainTransactionId uint64) ([]byte, error)
+
+	AddTransactionEvents(ctx context.Context, events []*TransactionEvent) error
+	GetTransactionEvents(ctx context.Context, transactionHash string) ([]*TransactionEvent, error)
+
+	Prune(ctx context.Context, policy *PrunePolicy) (*PruneReport, error)
+	Vacuum(ctx context.Context) error
+
+	DataStore
 }
 
 type FinishedTxsDataStoreImpl struct {
@@ -85,33 +100,7 @@ func OpenFinishedTxsDataStore() (FinishedTransactionsDataStore, error) {
 }
 
 func initFinishedTxsDB() (*sql.DB, error) {
-	err := CheckAndCreateDocument(DBDocumentNAME)
-	if err != nil {
-		log.Error("Create DBCache doucument error:", err)
-		return nil, err
-	}
-	db, err := sql.Open(DriverName, FinishedTxsDBName)
-	if err != nil {
-		log.Error("Open data db error:", err)
-		return nil, err
-	}
-	// Create error deposit transactions table
-	_, err = db.Exec(CreateDepositTransactionsTable)
-	if err != nil {
-		return nil, err
-	}
-	// Create error withdraw transactions table
-	_, err = db.Exec(CreateWithdrawTransactionsTable)
-	if err != nil {
-		return nil, err
-	}
-	// Create error side chain transactions table
-	_, err = db.Exec(CreateSideChainTransactionsTable)
-	if err != nil {
-		return nil, err
-	}
-
-	return db, nil
+	return openDB(FinishedTxsDBName, FinishedTxsMigrations)
 }
 
 func (store *FinishedTxsDataStoreImpl) catchSystemSignals() {
@@ -136,18 +125,18 @@ func (store *FinishedTxsDataStoreImpl) ResetDataStore() error {
 	return nil
 }
 
-func (store *FinishedTxsDataStoreImpl) AddFailedDepositTxs(transactionHashes, genesisBlockAddresses []string) error {
+func (store *FinishedTxsDataStoreImpl) AddFailedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	tx, err := store.Begin()
+	tx, err := store.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Commit()
 
 	// Prepare sql statement
-	stmt, err := tx.Prepare("INSERT INTO DepositTransactions(TransactionHash, GenesisBlockAddress, Succeed, RecordTime) values(?,?,?,?)")
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO DepositTransactions(TransactionHash, GenesisBlockAddress, Succeed, RecordTime) values(?,?,?,?)")
 	if err != nil {
 		return err
 	}
@@ -155,7 +144,7 @@ func (store *FinishedTxsDataStoreImpl) AddFailedDepositTxs(transactionHashes, ge
 
 	// Do insert
 	for i := 0; i < len(transactionHashes); i++ {
-		_, err = stmt.Exec(transactionHashes[i], genesisBlockAddresses[i], false, time.Now().Format("2006-01-02_15.04.05"))
+		_, err = stmt.ExecContext(ctx, transactionHashes[i], genesisBlockAddresses[i], false, time.Now().Format("2006-01-02_15.04.05"))
 		if err != nil {
 			continue
 		}
@@ -163,18 +152,18 @@ func (store *FinishedTxsDataStoreImpl) AddFailedDepositTxs(transactionHashes, ge
 	return nil
 }
 
-func (store *FinishedTxsDataStoreImpl) AddSucceedDepositTxs(transactionHashes, genesisBlockAddresses []string) error {
+func (store *FinishedTxsDataStoreImpl) AddSucceedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	tx, err := store.Begin()
+	tx, err := store.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Commit()
 
 	// Prepare sql statement
-	stmt, err := tx.Prepare("INSERT INTO DepositTransactions(TransactionHash, GenesisBlockAddress, Succeed, RecordTime) values(?,?,?,?)")
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO DepositTransactions(TransactionHash, GenesisBlockAddress, Succeed, RecordTime) values(?,?,?,?)")
 	if err != nil {
 		return err
 	}
@@ -182,7 +171,7 @@ func (store *FinishedTxsDataStoreImpl) AddSucceedDepositTxs(transactionHashes, g
 
 	// Do insert
 	for i := 0; i < len(transactionHashes); i++ {
-		_, err = stmt.Exec(transactionHashes[i], genesisBlockAddresses[i], true, time.Now().Format("2006-01-02_15.04.05"))
+		_, err = stmt.ExecContext(ctx, transactionHashes[i], genesisBlockAddresses[i], true, time.Now().Format("2006-01-02_15.04.05"))
 		if err != nil {
 			continue
 		}
@@ -190,11 +179,11 @@ func (store *FinishedTxsDataStoreImpl) AddSucceedDepositTxs(transactionHashes, g
 	return nil
 }
 
-func (store *FinishedTxsDataStoreImpl) HasDepositTx(transactionHash string, genesisBlockAddress string) (bool, error) {
+func (store *FinishedTxsDataStoreImpl) HasDepositTx(ctx context.Context, transactionHash string, genesisBlockAddress string) (bool, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT GenesisBlockAddress FROM DepositTransactions WHERE TransactionHash=? AND GenesisBlockAddress=?`, transactionHash, genesisBlockAddress)
+	rows, err := store.QueryContext(ctx, `SELECT GenesisBlockAddress FROM DepositTransactions WHERE TransactionHash=? AND GenesisBlockAddress=?`, transactionHash, genesisBlockAddress)
 	if err != nil {
 		return false, err
 	}
@@ -203,11 +192,11 @@ func (store *FinishedTxsDataStoreImpl) HasDepositTx(transactionHash string, gene
 	return rows.Next(), nil
 }
 
-func (store *FinishedTxsDataStoreImpl) GetDepositTxByHash(transactionHash string) ([]bool, []string, error) {
+func (store *FinishedTxsDataStoreImpl) GetDepositTxByHash(ctx context.Context, transactionHash string) ([]bool, []string, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT Succeed, GenesisBlockAddress FROM DepositTransactions WHERE TransactionHash=?`, transactionHash)
+	rows, err := store.QueryContext(ctx, `SELECT Succeed, GenesisBlockAddress FROM DepositTransactions WHERE TransactionHash=?`, transactionHash)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -229,11 +218,11 @@ func (store *FinishedTxsDataStoreImpl) GetDepositTxByHash(transactionHash string
 	return succeed, genesisAddresses, nil
 }
 
-func (store *FinishedTxsDataStoreImpl) GetDepositTxByHashAndGenesisAddress(transactionHash string, genesisAddress string) (bool, error) {
+func (store *FinishedTxsDataStoreImpl) GetDepositTxByHashAndGenesisAddress(ctx context.Context, transactionHash string, genesisAddress string) (bool, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT Succeed FROM DepositTransactions WHERE TransactionHash=? AND GenesisBlockAddress=?`, transactionHash, genesisAddress)
+	rows, err := store.QueryContext(ctx, `SELECT Succeed FROM DepositTransactions WHERE TransactionHash=? AND GenesisBlockAddress=?`, transactionHash, genesisAddress)
 	if err != nil {
 		return false, err
 	}
@@ -250,11 +239,11 @@ func (store *FinishedTxsDataStoreImpl) GetDepositTxByHashAndGenesisAddress(trans
 	return suc, nil
 }
 
-func (store *FinishedTxsDataStoreImpl) GetDepositTxs(succeed bool) ([]string, []string, error) {
+func (store *FinishedTxsDataStoreImpl) GetDepositTxs(ctx context.Context, succeed bool) ([]string, []string, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT TransactionHash, GenesisBlockAddress FROM DepositTransactions WHERE Succeed=?`, succeed)
+	rows, err := store.QueryContext(ctx, `SELECT TransactionHash, GenesisBlockAddress FROM DepositTransactions WHERE Succeed=?`, succeed)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -277,12 +266,12 @@ func (store *FinishedTxsDataStoreImpl) GetDepositTxs(succeed bool) ([]string, []
 	return txHashes, genesisAddresses, nil
 }
 
-func (store *FinishedTxsDataStoreImpl) AddFailedWithdrawTxs(transactionHashes []string, transactionByte []byte) error {
+func (store *FinishedTxsDataStoreImpl) AddFailedWithdrawTxs(ctx context.Context, transactionHashes []string, transactionByte []byte) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
 	// Do insert
-	_, err := store.Exec("INSERT INTO SideChainTransactions(TransactionData, RecordTime) values(?,?)",
+	_, err := store.ExecContext(ctx, "INSERT INTO SideChainTransactions(TransactionData, RecordTime) values(?,?)",
 		transactionByte, time.Now().Format("2006-01-02_15.04.05"))
 	if err != nil {
 		return err
@@ -290,20 +279,20 @@ func (store *FinishedTxsDataStoreImpl) AddFailedWithdrawTxs(transactionHashes []
 
 	// Get id
 	var sideChainTransactionId int
-	row := store.QueryRow(`SELECT MAX(Id) FROM SideChainTransactions`)
+	row := store.QueryRowContext(ctx, `SELECT MAX(Id) FROM SideChainTransactions`)
 	err = row.Scan(&sideChainTransactionId)
 	if err != nil {
 		return err
 	}
 
-	tx, err := store.Begin()
+	tx, err := store.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Commit()
 
 	// Prepare sql statement
-	stmt2, err := tx.Prepare("INSERT INTO WithdrawTransactions(TransactionHash, SideChainTransactionId, Succeed, RecordTime) values(?,?,?,?)")
+	stmt2, err := tx.PrepareContext(ctx, "INSERT INTO WithdrawTransactions(TransactionHash, SideChainTransactionId, Succeed, RecordTime) values(?,?,?,?)")
 	if err != nil {
 		return err
 	}
@@ -311,7 +300,7 @@ func (store *FinishedTxsDataStoreImpl) AddFailedWithdrawTxs(transactionHashes []
 
 	// Do insert
 	for _, txHash := range transactionHashes {
-		_, err = stmt2.Exec(txHash, sideChainTransactionId, false, time.Now().Format("2006-01-02_15.04.05"))
+		_, err = stmt2.ExecContext(ctx, txHash, sideChainTransactionId, false, time.Now().Format("2006-01-02_15.04.05"))
 		if err != nil {
 			continue
 		}
@@ -319,18 +308,18 @@ func (store *FinishedTxsDataStoreImpl) AddFailedWithdrawTxs(transactionHashes []
 	return nil
 }
 
-func (store *FinishedTxsDataStoreImpl) AddSucceedWithdrawTxs(transactionHashes []string) error {
+func (store *FinishedTxsDataStoreImpl) AddSucceedWithdrawTxs(ctx context.Context, transactionHashes []string) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	tx, err := store.Begin()
+	tx, err := store.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Commit()
 
 	// Prepare sql statement
-	stmt, err := tx.Prepare("INSERT INTO WithdrawTransactions(TransactionHash, SideChainTransactionId, Succeed, RecordTime) values(?,?,?,?)")
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO WithdrawTransactions(TransactionHash, SideChainTransactionId, Succeed, RecordTime) values(?,?,?,?)")
 	if err != nil {
 		return err
 	}
@@ -338,18 +327,18 @@ func (store *FinishedTxsDataStoreImpl) AddSucceedWithdrawTxs(transactionHashes [
 
 	// Do insert
 	for _, txHash := range transactionHashes {
-		if _, err := stmt.Exec(txHash, 0, true, time.Now().Format("2006-01-02_15.04.05")); err != nil {
-			log.Error("[AddSucceedWithdrawTxs] txHash:", txHash, "err:", err.Error())
+		if _, err := stmt.ExecContext(ctx, txHash, 0, true, time.Now().Format("2006-01-02_15.04.05")); err != nil {
+			logger.Errorw("add succeed withdraw transaction failed", log.KeyProposal, txHash, log.KeyError, err)
 		}
 	}
 	return nil
 }
 
-func (store *FinishedTxsDataStoreImpl) HasWithdrawTx(transactionHash string) (bool, error) {
+func (store *FinishedTxsDataStoreImpl) HasWithdrawTx(ctx context.Context, transactionHash string) (bool, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT Succeed FROM WithdrawTransactions WHERE TransactionHash=?`, transactionHash)
+	rows, err := store.QueryContext(ctx, `SELECT Succeed FROM WithdrawTransactions WHERE TransactionHash=?`, transactionHash)
 	defer rows.Close()
 	if err != nil {
 		return false, err
@@ -358,11 +347,11 @@ func (store *FinishedTxsDataStoreImpl) HasWithdrawTx(transactionHash string) (bo
 	return rows.Next(), nil
 }
 
-func (store *FinishedTxsDataStoreImpl) GetWithdrawTxByHash(transactionHash string) (bool, []byte, error) {
+func (store *FinishedTxsDataStoreImpl) GetWithdrawTxByHash(ctx context.Context, transactionHash string) (bool, []byte, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT SideChainTransactionId, Succeed FROM WithdrawTransactions WHERE TransactionHash=? LIMIT 1`, transactionHash)
+	rows, err := store.QueryContext(ctx, `SELECT SideChainTransactionId, Succeed FROM WithdrawTransactions WHERE TransactionHash=? LIMIT 1`, transactionHash)
 	if err != nil {
 		return false, nil, err
 	}
@@ -381,7 +370,7 @@ func (store *FinishedTxsDataStoreImpl) GetWithdrawTxByHash(transactionHash strin
 		return true, nil, err
 	}
 
-	rowsS, err := store.Query(`SELECT TransactionData FROM SideChainTransactions WHERE Id=?`, sideChainTransactionId)
+	rowsS, err := store.QueryContext(ctx, `SELECT TransactionData FROM SideChainTransactions WHERE Id=?`, sideChainTransactionId)
 	if err != nil {
 		return false, nil, err
 	}
@@ -399,11 +388,11 @@ func (store *FinishedTxsDataStoreImpl) GetWithdrawTxByHash(transactionHash strin
 	return false, transactionBytes, nil
 }
 
-func (store *FinishedTxsDataStoreImpl) GetWithdrawTxs(succeed bool) ([]string, error) {
+func (store *FinishedTxsDataStoreImpl) GetWithdrawTxs(ctx context.Context, succeed bool) ([]string, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT TransactionHash FROM WithdrawTransactions WHERE Succeed=?`, succeed)
+	rows, err := store.QueryContext(ctx, `SELECT TransactionHash FROM WithdrawTransactions WHERE Succeed=?`, succeed)
 	if err != nil {
 		return nil, err
 	}
@@ -423,18 +412,18 @@ func (store *FinishedTxsDataStoreImpl) GetWithdrawTxs(succeed bool) ([]string, e
 	return txHashes, nil
 }
 
-func (store *FinishedTxsDataStoreImpl) AddSideChainTx(transactionByte []byte) error {
+func (store *FinishedTxsDataStoreImpl) AddSideChainTx(ctx context.Context, transactionByte []byte) error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
 	// Prepare sql statement
-	stmt, err := store.Prepare("INSERT INTO SideChainTransactions(TransactionData, RecordTime) values(?,?)")
+	stmt, err := store.PrepareContext(ctx, "INSERT INTO SideChainTransactions(TransactionData, RecordTime) values(?,?)")
 	if err != nil {
 		return err
 	}
 
 	// Do insert
-	_, err = stmt.Exec(transactionByte, time.Now().Format("2006-01-02_15.04.05"))
+	_, err = stmt.ExecContext(ctx, transactionByte, time.Now().Format("2006-01-02_15.04.05"))
 	if err != nil {
 		return err
 	}
@@ -442,18 +431,13 @@ func (store *FinishedTxsDataStoreImpl) AddSideChainTx(transactionByte []byte) er
 	return nil
 }
 
-func (store *FinishedTxsDataStoreImpl) GetSideChainTx(sideChainTransactionId uint64) ([]byte, error) {
+func (store *FinishedTxsDataStoreImpl) GetSideChainTx(ctx context.Context, sideChainTransactionId uint64) ([]byte, error) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	rows, err := store.Query(`SELECT TransactionData FROM SideChainTransactions WHERE Id=?`, sideChainTransactionId)
-	defer rows.Close()
-	if err != nil {
-		return nil, err
-	}
-
 	var transactionBytes []byte
-	err = rows.Scan(&transactionBytes)
+	err := store.QueryRowContext(ctx, `SELECT TransactionData FROM SideChainTransactions WHERE Id=?`,
+		sideChainTransactionId).Scan(&transactionBytes)
 	if err != nil {
 		return nil, err
 	}
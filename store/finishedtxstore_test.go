@@ -2,6 +2,7 @@ package store
 
 import (
 	"bytes"
+	"context"
 	"testing"
 
 	"github.com/elastos/Elastos.ELA/core/types"
@@ -17,14 +18,14 @@ func TestFinishedTxsDataStoreImpl_AddSucceedDepositTxs(t *testing.T) {
 	genesisBlockAddress1 := "testAddress1"
 	genesisBlockAddress2 := "testAddress2"
 
-	err = datastore.AddSucceedDepositTxs(
+	err = datastore.AddSucceedDepositTxs(context.Background(),
 		[]string{txHash, txHash},
 		[]string{genesisBlockAddress1, genesisBlockAddress2})
 	if err != nil {
 		t.Error("Add deposit transaction error.")
 	}
 
-	ok, err := datastore.HasDepositTx(txHash, genesisBlockAddress1)
+	ok, err := datastore.HasDepositTx(context.Background(), txHash, genesisBlockAddress1)
 	if err != nil {
 		t.Error("Check deposit transaction error.")
 	}
@@ -32,7 +33,7 @@ func TestFinishedTxsDataStoreImpl_AddSucceedDepositTxs(t *testing.T) {
 		t.Error("Check deposit transaction error.")
 	}
 
-	ok, err = datastore.HasDepositTx(txHash, genesisBlockAddress2)
+	ok, err = datastore.HasDepositTx(context.Background(), txHash, genesisBlockAddress2)
 	if err != nil {
 		t.Error("Check deposit transaction error.")
 	}
@@ -40,7 +41,7 @@ func TestFinishedTxsDataStoreImpl_AddSucceedDepositTxs(t *testing.T) {
 		t.Error("Check deposit transaction error.")
 	}
 
-	succeedList, genesisAddresses, err := datastore.GetDepositTxByHash(txHash)
+	succeedList, genesisAddresses, err := datastore.GetDepositTxByHash(context.Background(), txHash)
 	if err != nil {
 		t.Error("Get deposit transaction error.")
 	}
@@ -67,21 +68,21 @@ func TestFinishedTxsDataStoreImpl_AddSucceedDepositTx(t *testing.T) {
 	genesisBlockAddress1 := "testAddress1"
 	genesisBlockAddress2 := "testAddress2"
 
-	err = datastore.AddSucceedDepositTxs(
+	err = datastore.AddSucceedDepositTxs(context.Background(),
 		[]string{txHash, txHash},
 		[]string{genesisBlockAddress1, genesisBlockAddress2})
 	if err != nil {
 		t.Error("Add deposit transaction error.")
 	}
 
-	ok, err := datastore.HasDepositTx(txHash, genesisBlockAddress1)
+	ok, err := datastore.HasDepositTx(context.Background(), txHash, genesisBlockAddress1)
 	if err != nil {
 		t.Error("Check deposit transaction error.")
 	}
 	if !ok {
 		t.Error("Check deposit transaction error.")
 	}
-	ok, err = datastore.HasDepositTx(txHash, genesisBlockAddress2)
+	ok, err = datastore.HasDepositTx(context.Background(), txHash, genesisBlockAddress2)
 	if err != nil {
 		t.Error("Check deposit transaction error.")
 	}
@@ -89,7 +90,7 @@ func TestFinishedTxsDataStoreImpl_AddSucceedDepositTx(t *testing.T) {
 		t.Error("Check deposit transaction error.")
 	}
 
-	succeedList, genesisAddresses, err := datastore.GetDepositTxByHash(txHash)
+	succeedList, genesisAddresses, err := datastore.GetDepositTxByHash(context.Background(), txHash)
 	if err != nil {
 		t.Error("Get deposit transaction error.")
 	}
@@ -117,26 +118,26 @@ func TestFinishedTxsDataStoreImpl_GetDepositTxs(t *testing.T) {
 	genesisBlockAddress1 := "testAddress1"
 	genesisBlockAddress2 := "testAddress2"
 
-	err = datastore.AddFailedDepositTxs(
+	err = datastore.AddFailedDepositTxs(context.Background(),
 		[]string{txHash1, txHash1},
 		[]string{genesisBlockAddress1, genesisBlockAddress2})
 	if err != nil {
 		t.Error("Add deposit transaction error.")
 	}
 
-	err = datastore.AddSucceedDepositTxs(
+	err = datastore.AddSucceedDepositTxs(context.Background(),
 		[]string{txHash2},
 		[]string{genesisBlockAddress2})
 	if err != nil {
 		t.Error("Add deposit transaction error.")
 	}
 
-	failedTxs, genesisBlockAddresses, err := datastore.GetDepositTxs(false)
+	failedTxs, genesisBlockAddresses, err := datastore.GetDepositTxs(context.Background(), false)
 	if err != nil || len(failedTxs) != 2 || len(genesisBlockAddresses) != 2 {
 		t.Error("Get deposit transactions failed.")
 	}
 
-	succeedTxs, genesisBlockAddresses, err := datastore.GetDepositTxs(true)
+	succeedTxs, genesisBlockAddresses, err := datastore.GetDepositTxs(context.Background(), true)
 	if err != nil || len(succeedTxs) != 1 || len(genesisBlockAddresses) != 1 {
 		t.Error("Get deposit transactions failed.")
 	}
@@ -162,24 +163,24 @@ func TestFinishedTxsDataStoreImpl_AddWithdrawTxs(t *testing.T) {
 	buf2 := new(bytes.Buffer)
 	tx2.Serialize(buf2)
 
-	err = datastore.AddFailedWithdrawTxs([]string{txHash1, txHash2}, buf1.Bytes())
+	err = datastore.AddFailedWithdrawTxs(context.Background(), []string{txHash1, txHash2}, buf1.Bytes())
 	if err != nil {
 		t.Error("Add withdraw transaction error.")
 	}
 
-	err = datastore.AddSucceedWithdrawTxs([]string{txHash3})
+	err = datastore.AddSucceedWithdrawTxs(context.Background(), []string{txHash3})
 	if err != nil {
 		t.Error("Add withdraw transaction error.")
 	}
 
-	ok, err := datastore.HasWithdrawTx(txHash1)
+	ok, err := datastore.HasWithdrawTx(context.Background(), txHash1)
 	if err != nil {
 		t.Error("Check withdraw transaction error.")
 	}
 	if !ok {
 		t.Error("Check withdraw transaction error.")
 	}
-	ok, err = datastore.HasWithdrawTx(txHash2)
+	ok, err = datastore.HasWithdrawTx(context.Background(), txHash2)
 	if err != nil {
 		t.Error("Check withdraw transaction error.")
 	}
@@ -187,7 +188,7 @@ func TestFinishedTxsDataStoreImpl_AddWithdrawTxs(t *testing.T) {
 		t.Error("Check withdraw transaction error.")
 	}
 
-	ok, err = datastore.HasWithdrawTx(txHash4)
+	ok, err = datastore.HasWithdrawTx(context.Background(), txHash4)
 	if err != nil {
 		t.Error("Check withdraw transaction error.")
 	}
@@ -196,7 +197,7 @@ func TestFinishedTxsDataStoreImpl_AddWithdrawTxs(t *testing.T) {
 	}
 
 	// verify txhash1
-	succeed, transactionBytes, err := datastore.GetWithdrawTxByHash(txHash1)
+	succeed, transactionBytes, err := datastore.GetWithdrawTxByHash(context.Background(), txHash1)
 	if err != nil {
 		t.Error("Get withdraw transaction error.")
 	}
@@ -213,7 +214,7 @@ func TestFinishedTxsDataStoreImpl_AddWithdrawTxs(t *testing.T) {
 	}
 
 	// verify txhash2
-	succeed, transactionBytes, err = datastore.GetWithdrawTxByHash(txHash2)
+	succeed, transactionBytes, err = datastore.GetWithdrawTxByHash(context.Background(), txHash2)
 	if err != nil {
 		t.Error("Get withdraw transaction error.")
 	}
@@ -230,7 +231,7 @@ func TestFinishedTxsDataStoreImpl_AddWithdrawTxs(t *testing.T) {
 	}
 
 	// verify txhash3
-	succeed, transactionBytes, err = datastore.GetWithdrawTxByHash(txHash3)
+	succeed, transactionBytes, err = datastore.GetWithdrawTxByHash(context.Background(), txHash3)
 	if err != nil {
 		t.Error("Get withdraw transaction error.")
 	}
@@ -255,12 +256,12 @@ func TestFinishedTxsDataStoreImpl_AddSucceedWIthdrawTx(t *testing.T) {
 	txHash1 := "testHash1"
 	txHash2 := "testHash2"
 
-	err = datastore.AddSucceedWithdrawTxs([]string{txHash1, txHash2})
+	err = datastore.AddSucceedWithdrawTxs(context.Background(), []string{txHash1, txHash2})
 	if err != nil {
 		t.Error("Add withdraw transaction error.")
 	}
 
-	ok, err := datastore.HasWithdrawTx(txHash1)
+	ok, err := datastore.HasWithdrawTx(context.Background(), txHash1)
 	if err != nil {
 		t.Error("Check withdraw transaction error.")
 	}
@@ -268,7 +269,7 @@ func TestFinishedTxsDataStoreImpl_AddSucceedWIthdrawTx(t *testing.T) {
 		t.Error("Check withdraw transaction error.")
 	}
 
-	ok, err = datastore.HasWithdrawTx(txHash2)
+	ok, err = datastore.HasWithdrawTx(context.Background(), txHash2)
 	if err != nil {
 		t.Error("Check withdraw transaction error.")
 	}
@@ -277,7 +278,7 @@ func TestFinishedTxsDataStoreImpl_AddSucceedWIthdrawTx(t *testing.T) {
 	}
 
 	// verify txhash1
-	succeed, transactionBytes, err := datastore.GetWithdrawTxByHash(txHash1)
+	succeed, transactionBytes, err := datastore.GetWithdrawTxByHash(context.Background(), txHash1)
 	if err != nil {
 		t.Error("Get withdraw transaction error.")
 	}
@@ -309,22 +310,22 @@ func TestFinishedTxsDataStoreImpl_GetWithdrawTxs(t *testing.T) {
 	buf2 := new(bytes.Buffer)
 	tx2.Serialize(buf2)
 
-	err = datastore.AddFailedWithdrawTxs([]string{txHash1, txHash2}, buf1.Bytes())
+	err = datastore.AddFailedWithdrawTxs(context.Background(), []string{txHash1, txHash2}, buf1.Bytes())
 	if err != nil {
 		t.Error("Add withdraw transaction error.")
 	}
 
-	err = datastore.AddSucceedWithdrawTxs([]string{txHash3})
+	err = datastore.AddSucceedWithdrawTxs(context.Background(), []string{txHash3})
 	if err != nil {
 		t.Error("Add withdraw transaction error.")
 	}
 
-	succeedTxs, err := datastore.GetWithdrawTxs(false)
+	succeedTxs, err := datastore.GetWithdrawTxs(context.Background(), false)
 	if err != nil || len(succeedTxs) != 2 {
 		t.Error("Get withdraw transactions error.")
 	}
 
-	failedTxs, err := datastore.GetWithdrawTxs(true)
+	failedTxs, err := datastore.GetWithdrawTxs(context.Background(), true)
 	if err != nil || len(failedTxs) != 1 {
 		t.Error("Get withdraw transactions error.")
 	}
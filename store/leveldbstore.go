@@ -0,0 +1,1615 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/arbitration/base"
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+
+	"github.com/elastos/Elastos.ELA.SPV/bloom"
+	"github.com/elastos/Elastos.ELA/core/types"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// The leveldb backend keeps the main chain cache, the side chain cache and the
+// finished transactions in one pure Go database, each of them under its own
+// key prefix. Rows are JSON encoded under big endian ids, so they are iterated
+// in the order of insertion like the rows of sqlite, and the unique columns
+// are indexed by keys holding the ids. Sharing the database lets the moves
+// from the caches to the finished store be written in one batch.
+
+var LevelDBName = filepath.Join(DBDocumentNAME, "leveldb")
+
+const (
+	levelMainChainPrefix = "main/"
+	levelSideChainPrefix = "side/"
+	levelFinishedPrefix  = "finished/"
+
+	levelMainChainHeight       = levelMainChainPrefix + "height"
+	levelSideChainHeightPrefix = levelSideChainPrefix + "height/"
+)
+
+var (
+	levelMainChainTxs = levelTable{rows: levelMainChainPrefix + "txs/", index: levelMainChainPrefix + "txs-by-hash/"}
+	levelSideChainTxs = levelTable{rows: levelSideChainPrefix + "txs/", index: levelSideChainPrefix + "txs-by-hash/"}
+
+	levelDepositTxs        = levelTable{rows: levelFinishedPrefix + "deposits/", index: levelFinishedPrefix + "deposits-by-hash/"}
+	levelWithdrawTxs       = levelTable{rows: levelFinishedPrefix + "withdraws/", index: levelFinishedPrefix + "withdraws-by-hash/"}
+	levelSideChainFinished = levelTable{rows: levelFinishedPrefix + "sidechaintxs/"}
+	levelTransactionEvents = levelTable{rows: levelFinishedPrefix + "events/", index: levelFinishedPrefix + "events-by-hash/"}
+)
+
+var (
+	errLevelDuplicatedTx    = errors.New("transaction already exists")
+	errLevelSideChainTxLost = errors.New("side chain transaction not found")
+)
+
+// levelTable is a table of rows by ids, index is the prefix of the keys of
+// the indexed columns.
+type levelTable struct {
+	rows  string
+	index string
+}
+
+func (t levelTable) rowKey(id uint64) []byte {
+	key := make([]byte, len(t.rows)+8)
+	copy(key, t.rows)
+	binary.BigEndian.PutUint64(key[len(t.rows):], id)
+	return key
+}
+
+// indexKey joins the columns by zero bytes, so a column is never the prefix
+// of another one.
+func (t levelTable) indexKey(columns ...string) []byte {
+	return []byte(t.index + strings.Join(columns, "\x00"))
+}
+
+// eventIndexKey is the key of an event in the index of the transaction hash,
+// events are not unique so the id is a part of the key.
+func eventIndexKey(transactionHash string, id uint64) []byte {
+	prefix := levelTransactionEvents.indexKey(transactionHash, "")
+	return append(prefix, encodeLevelUint64(id)...)
+}
+
+func encodeLevelUint64(id uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, id)
+	return buf
+}
+
+func encodeLevelHeight(height uint32) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, height)
+	return buf
+}
+
+type levelMainChainTx struct {
+	Id                  uint64
+	TransactionHash     string
+	GenesisBlockAddress string
+	TransactionData     []byte
+	MerkleProof         []byte
+}
+
+type levelSideChainTx struct {
+	Id                  uint64
+	TransactionHash     string
+	GenesisBlockAddress string
+	TransactionData     []byte
+	BlockHeight         uint32
+}
+
+type levelFinishedTx struct {
+	Id                     uint64
+	TransactionHash        string
+	GenesisBlockAddress    string
+	SideChainTransactionId uint64
+	Succeed                bool
+	RecordTime             string
+}
+
+type levelSideChainTransaction struct {
+	Id              uint64
+	TransactionData []byte
+	RecordTime      string
+}
+
+type levelTransactionEvent struct {
+	Id uint64
+	TransactionEvent
+}
+
+type levelRow interface {
+	setId(id uint64)
+}
+
+func (r *levelMainChainTx) setId(id uint64)          { r.Id = id }
+func (r *levelSideChainTx) setId(id uint64)          { r.Id = id }
+func (r *levelFinishedTx) setId(id uint64)           { r.Id = id }
+func (r *levelSideChainTransaction) setId(id uint64) { r.Id = id }
+func (r *levelTransactionEvent) setId(id uint64)     { r.Id = id }
+
+func (r *levelMainChainTx) transactionAndProof() (*types.Transaction, *bloom.MerkleProof) {
+	var tx types.Transaction
+	tx.Deserialize(bytes.NewReader(r.TransactionData))
+	var mp bloom.MerkleProof
+	mp.Deserialize(bytes.NewReader(r.MerkleProof))
+	return &tx, &mp
+}
+
+func (r *levelSideChainTx) withdrawTx() *base.WithdrawTx {
+	tx := new(base.WithdrawTx)
+	tx.Deserialize(bytes.NewReader(r.TransactionData))
+	return tx
+}
+
+// levelReader reads the database or a batch with its pending writes.
+type levelReader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+}
+
+func getLevelRow(r levelReader, key []byte, row interface{}) (bool, error) {
+	data, err := r.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, row)
+}
+
+// getIndexedRow reads the row of the table by the key of its index.
+func getIndexedRow(r levelReader, t levelTable, indexKey []byte, row interface{}) (bool, error) {
+	id, err := r.Get(indexKey, nil)
+	if err == leveldb.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return getLevelRow(r, t.rowKey(binary.BigEndian.Uint64(id)), row)
+}
+
+// levelDB is the database shared by the stores of the leveldb backend, it is
+// closed when all of the stores are closed.
+type levelDB struct {
+	mux  *sync.Mutex
+	refs int
+
+	*leveldb.DB
+}
+
+// begin locks the database for an operation, leveldb operations can not be
+// canceled so the context is only checked before.
+func (db *levelDB) begin(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mux.Lock()
+	return nil
+}
+
+func (db *levelDB) catchSystemSignals() {
+	HandleSignal(func() {
+		db.mux.Lock()
+		db.DB.Close()
+		os.Exit(-1)
+	})
+}
+
+func (db *levelDB) Close() error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	db.refs--
+	if db.refs > 0 {
+		return nil
+	}
+	return db.DB.Close()
+}
+
+// scan calls f with the keys and values under the prefix in order, they are
+// only valid in f.
+func (db *levelDB) scan(prefix string, f func(key, value []byte) error) error {
+	it := db.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	defer it.Release()
+
+	for it.Next() {
+		if err := f(it.Key(), it.Value()); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func (db *levelDB) scanRows(t levelTable, newRow func() interface{}, f func(row interface{}) error) error {
+	return db.scan(t.rows, func(key, value []byte) error {
+		row := newRow()
+		if err := json.Unmarshal(value, row); err != nil {
+			return err
+		}
+		return f(row)
+	})
+}
+
+func (db *levelDB) count(t levelTable) (int, error) {
+	var count int
+	err := db.scan(t.rows, func(key, value []byte) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// deletePrefix deletes all of the keys under the prefix.
+func (db *levelDB) deletePrefix(prefix string) error {
+	batch := new(leveldb.Batch)
+	err := db.scan(prefix, func(key, value []byte) error {
+		batch.Delete(append([]byte(nil), key...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return db.Write(batch, nil)
+}
+
+// levelBatch collects the writes of an operation to be written atomically,
+// the reads of the batch see its pending writes.
+type levelBatch struct {
+	db      *levelDB
+	batch   *leveldb.Batch
+	pending map[string][]byte
+	ids     map[string]uint64
+}
+
+func (db *levelDB) newBatch() *levelBatch {
+	return &levelBatch{
+		db:      db,
+		batch:   new(leveldb.Batch),
+		pending: make(map[string][]byte),
+		ids:     make(map[string]uint64),
+	}
+}
+
+func (b *levelBatch) Get(key []byte, ro *opt.ReadOptions) ([]byte, error) {
+	if value, ok := b.pending[string(key)]; ok {
+		if value == nil {
+			return nil, leveldb.ErrNotFound
+		}
+		return value, nil
+	}
+	return b.db.Get(key, ro)
+}
+
+func (b *levelBatch) has(key []byte) (bool, error) {
+	_, err := b.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *levelBatch) put(key, value []byte) {
+	if value == nil {
+		value = []byte{}
+	}
+	b.batch.Put(key, value)
+	b.pending[string(key)] = value
+}
+
+func (b *levelBatch) delete(key []byte) {
+	b.batch.Delete(key)
+	b.pending[string(key)] = nil
+}
+
+// nextId returns the greatest id of the table plus one, the ids of deleted
+// rows may be reused like the rowids of sqlite.
+func (b *levelBatch) nextId(t levelTable) (uint64, error) {
+	id, ok := b.ids[t.rows]
+	if !ok {
+		it := b.db.NewIterator(util.BytesPrefix([]byte(t.rows)), nil)
+		if it.Last() {
+			id = binary.BigEndian.Uint64(it.Key()[len(t.rows):])
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return 0, err
+		}
+	}
+	id++
+	b.ids[t.rows] = id
+	return id, nil
+}
+
+// insert adds the row with a new id and its index key, it returns false
+// without writes if the index key exists.
+func (b *levelBatch) insert(t levelTable, indexKey []byte, row levelRow) (bool, error) {
+	if indexKey != nil {
+		if exists, err := b.has(indexKey); err != nil || exists {
+			return false, err
+		}
+	}
+	id, err := b.nextId(t)
+	if err != nil {
+		return false, err
+	}
+	row.setId(id)
+	data, err := json.Marshal(row)
+	if err != nil {
+		return false, err
+	}
+	b.put(t.rowKey(id), data)
+	if indexKey != nil {
+		b.put(indexKey, encodeLevelUint64(id))
+	}
+	return true, nil
+}
+
+// remove deletes the row of the index key and the key, it returns false if
+// the key does not exist.
+func (b *levelBatch) remove(t levelTable, indexKey []byte) (bool, error) {
+	id, err := b.Get(indexKey, nil)
+	if err == leveldb.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	b.delete(t.rowKey(binary.BigEndian.Uint64(id)))
+	b.delete(indexKey)
+	return true, nil
+}
+
+func (b *levelBatch) commit() error {
+	return b.db.Write(b.batch, nil)
+}
+
+type DataStoreMainChainLevelDB struct {
+	*levelDB
+}
+
+type DataStoreSideChainLevelDB struct {
+	*levelDB
+}
+
+type FinishedTxsDataStoreLevelDB struct {
+	*levelDB
+}
+
+// OpenLevelDBDataStores opens the data stores on the leveldb database at path.
+func OpenLevelDBDataStores(path string) (*DataStoreImpl, FinishedTransactionsDataStore, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	shared := &levelDB{mux: new(sync.Mutex), refs: 3, DB: db}
+	sideChainStore := &DataStoreSideChainLevelDB{shared}
+	if err = sideChainStore.initHeights(); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	// Handle system interrupt signals
+	shared.catchSystemSignals()
+
+	dataStore := &DataStoreImpl{
+		MainChainStore: &DataStoreMainChainLevelDB{shared},
+		SideChainStore: sideChainStore,
+	}
+	return dataStore, &FinishedTxsDataStoreLevelDB{shared}, nil
+}
+
+func (store *DataStoreMainChainLevelDB) ResetDataStore() error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	return store.deletePrefix(levelMainChainPrefix)
+}
+
+func (store *DataStoreMainChainLevelDB) CurrentHeight(ctx context.Context, height uint32) uint32 {
+	if err := store.begin(ctx); err != nil {
+		return uint32(0)
+	}
+	defer store.mux.Unlock()
+
+	var storedHeight uint32
+	if value, err := store.Get([]byte(levelMainChainHeight), nil); err == nil && len(value) == 4 {
+		storedHeight = binary.BigEndian.Uint32(value)
+	}
+
+	if height > storedHeight {
+		// Received reset height code
+		if height == ResetHeightCode {
+			height = 0
+		}
+		if err := store.Put([]byte(levelMainChainHeight), encodeLevelHeight(height), nil); err != nil {
+			return uint32(0)
+		}
+		return height
+	}
+	return storedHeight
+}
+
+func newLevelMainChainTx(tx *base.MainChainTransaction) *levelMainChainTx {
+	// Serialize transaction
+	buf := new(bytes.Buffer)
+	tx.Transaction.Serialize(buf)
+	transactionBytes := buf.Bytes()
+
+	// Serialize merkleProof
+	buf = new(bytes.Buffer)
+	tx.Proof.Serialize(buf)
+
+	return &levelMainChainTx{
+		TransactionHash:     tx.TransactionHash,
+		GenesisBlockAddress: tx.GenesisBlockAddress,
+		TransactionData:     transactionBytes,
+		MerkleProof:         buf.Bytes(),
+	}
+}
+
+func (store *DataStoreMainChainLevelDB) AddMainChainTx(ctx context.Context, tx *base.MainChainTransaction) error {
+	row := newLevelMainChainTx(tx)
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	ok, err := batch.insert(levelMainChainTxs, levelMainChainTxs.indexKey(tx.TransactionHash, tx.GenesisBlockAddress), row)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errLevelDuplicatedTx
+	}
+	return batch.commit()
+}
+
+func (store *DataStoreMainChainLevelDB) AddMainChainTxs(ctx context.Context, txs []*base.MainChainTransaction) ([]bool, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	var result []bool
+	for _, tx := range txs {
+		ok, err := batch.insert(levelMainChainTxs,
+			levelMainChainTxs.indexKey(tx.TransactionHash, tx.GenesisBlockAddress), newLevelMainChainTx(tx))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ok)
+	}
+	return result, batch.commit()
+}
+
+func (store *DataStoreMainChainLevelDB) HasMainChainTx(ctx context.Context, transactionHash, genesisBlockAddress string) (bool, error) {
+	if err := store.begin(ctx); err != nil {
+		return false, err
+	}
+	defer store.mux.Unlock()
+
+	return store.Has(levelMainChainTxs.indexKey(transactionHash, genesisBlockAddress), nil)
+}
+
+func (store *DataStoreMainChainLevelDB) RemoveMainChainTx(ctx context.Context, transactionHash, genesisBlockAddress string) error {
+	return store.RemoveMainChainTxs(ctx, []string{transactionHash}, []string{genesisBlockAddress})
+}
+
+func (store *DataStoreMainChainLevelDB) RemoveMainChainTxs(ctx context.Context, transactionHashes, genesisBlockAddress []string) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	for i := 0; i < len(transactionHashes); i++ {
+		if _, err := batch.remove(levelMainChainTxs,
+			levelMainChainTxs.indexKey(transactionHashes[i], genesisBlockAddress[i])); err != nil {
+			return err
+		}
+	}
+	return batch.commit()
+}
+
+func (store *DataStoreMainChainLevelDB) GetMainChainTxsCount(ctx context.Context) (int, error) {
+	if err := store.begin(ctx); err != nil {
+		return 0, err
+	}
+	defer store.mux.Unlock()
+
+	return store.count(levelMainChainTxs)
+}
+
+func (store *DataStoreMainChainLevelDB) scanMainChainTxs(f func(row *levelMainChainTx)) error {
+	return store.scanRows(levelMainChainTxs, func() interface{} { return new(levelMainChainTx) },
+		func(row interface{}) error {
+			f(row.(*levelMainChainTx))
+			return nil
+		})
+}
+
+func (store *DataStoreMainChainLevelDB) GetAllMainChainTxHashes(ctx context.Context) ([]string, []string, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, nil, err
+	}
+	defer store.mux.Unlock()
+
+	var txHashes []string
+	var genesisAddresses []string
+	err := store.scanMainChainTxs(func(row *levelMainChainTx) {
+		txHashes = append(txHashes, row.TransactionHash)
+		genesisAddresses = append(genesisAddresses, row.GenesisBlockAddress)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return txHashes, genesisAddresses, nil
+}
+
+func (store *DataStoreMainChainLevelDB) GetAllMainChainTxs(ctx context.Context) ([]*base.MainChainTransaction, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	var txs []*base.MainChainTransaction
+	err := store.scanMainChainTxs(func(row *levelMainChainTx) {
+		tx, mp := row.transactionAndProof()
+		txs = append(txs, &base.MainChainTransaction{
+			TransactionHash:     row.TransactionHash,
+			GenesisBlockAddress: row.GenesisBlockAddress,
+			Transaction:         tx,
+			Proof:               mp,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+func (store *DataStoreMainChainLevelDB) GetMainChainTxsFromHashes(ctx context.Context, transactionHashes []string,
+	genesisBlockAddresses string) ([]*base.SpvTransaction, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	var spvTxs []*base.SpvTransaction
+	for _, hash := range transactionHashes {
+		var row levelMainChainTx
+		ok, err := getIndexedRow(store, levelMainChainTxs, levelMainChainTxs.indexKey(hash, genesisBlockAddresses), &row)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		tx, mp := row.transactionAndProof()
+		spvTxs = append(spvTxs, &base.SpvTransaction{MainChainTransaction: tx, Proof: mp})
+	}
+	return spvTxs, nil
+}
+
+// initHeights adds the heights of the side chains in the configuration, the
+// heights of the other side chains are not stored like the sqlite backend.
+func (store *DataStoreSideChainLevelDB) initHeights() error {
+	batch := store.newBatch()
+	for _, node := range config.SideNodes() {
+		if err := batch.addHeight(node.GenesisBlockAddress); err != nil {
+			return err
+		}
+	}
+	return batch.commit()
+}
+
+func (b *levelBatch) addHeight(genesisBlockAddress string) error {
+	key := []byte(levelSideChainHeightPrefix + genesisBlockAddress)
+	if exists, err := b.has(key); err != nil {
+		return err
+	} else if !exists {
+		b.put(key, encodeLevelHeight(0))
+	}
+	return nil
+}
+
+// AddSideChain stores the height of a side chain added after the store is
+// opened, the height is kept if the side chain was added before.
+func (store *DataStoreSideChainLevelDB) AddSideChain(ctx context.Context, genesisBlockAddress string) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	if err := batch.addHeight(genesisBlockAddress); err != nil {
+		return err
+	}
+	return batch.commit()
+}
+
+func (store *DataStoreSideChainLevelDB) ResetDataStore() error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	if err := store.deletePrefix(levelSideChainPrefix); err != nil {
+		return err
+	}
+	return store.initHeights()
+}
+
+func (store *DataStoreSideChainLevelDB) CurrentSideHeight(ctx context.Context, genesisBlockAddress string, height uint32) uint32 {
+	if err := store.begin(ctx); err != nil {
+		return uint32(0)
+	}
+	defer store.mux.Unlock()
+
+	key := []byte(levelSideChainHeightPrefix + genesisBlockAddress)
+	value, err := store.Get(key, nil)
+	var storedHeight uint32
+	if err == nil && len(value) == 4 {
+		storedHeight = binary.BigEndian.Uint32(value)
+	}
+
+	if height > storedHeight {
+		// Received reset height code
+		if height == ResetHeightCode {
+			height = 0
+		}
+		if err == nil {
+			if err = store.Put(key, encodeLevelHeight(height), nil); err != nil {
+				return uint32(0)
+			}
+		}
+		return height
+	}
+	return storedHeight
+}
+
+func newLevelSideChainTx(tx *base.SideChainTransaction) *levelSideChainTx {
+	return &levelSideChainTx{
+		TransactionHash:     tx.TransactionHash,
+		GenesisBlockAddress: tx.GenesisBlockAddress,
+		TransactionData:     tx.Transaction,
+		BlockHeight:         tx.BlockHeight,
+	}
+}
+
+func (store *DataStoreSideChainLevelDB) AddSideChainTxs(ctx context.Context, txs []*base.SideChainTransaction) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	for _, tx := range txs {
+		ok, err := batch.insert(levelSideChainTxs, levelSideChainTxs.indexKey(tx.TransactionHash), newLevelSideChainTx(tx))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Errorw("side chain transaction already exists", log.KeyTx, tx.TransactionHash,
+				log.KeySideChain, tx.GenesisBlockAddress)
+		}
+	}
+	return batch.commit()
+}
+
+func (store *DataStoreSideChainLevelDB) AddSideChainTx(ctx context.Context, tx *base.SideChainTransaction) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	ok, err := batch.insert(levelSideChainTxs, levelSideChainTxs.indexKey(tx.TransactionHash), newLevelSideChainTx(tx))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errLevelDuplicatedTx
+	}
+	return batch.commit()
+}
+
+func (store *DataStoreSideChainLevelDB) HasSideChainTx(ctx context.Context, transactionHash string) (bool, error) {
+	if err := store.begin(ctx); err != nil {
+		return false, err
+	}
+	defer store.mux.Unlock()
+
+	return store.Has(levelSideChainTxs.indexKey(transactionHash), nil)
+}
+
+func (store *DataStoreSideChainLevelDB) RemoveSideChainTxs(ctx context.Context, transactionHashes []string) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	for _, txHash := range transactionHashes {
+		if _, err := batch.remove(levelSideChainTxs, levelSideChainTxs.indexKey(txHash)); err != nil {
+			return err
+		}
+	}
+	return batch.commit()
+}
+
+func (store *DataStoreSideChainLevelDB) scanSideChainTxs(f func(row *levelSideChainTx)) error {
+	return store.scanRows(levelSideChainTxs, func() interface{} { return new(levelSideChainTx) },
+		func(row interface{}) error {
+			f(row.(*levelSideChainTx))
+			return nil
+		})
+}
+
+func (store *DataStoreSideChainLevelDB) GetAllSideChainTxHashes(ctx context.Context) ([]string, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	var txHashes []string
+	err := store.scanSideChainTxs(func(row *levelSideChainTx) {
+		txHashes = append(txHashes, row.TransactionHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txHashes, nil
+}
+
+func (store *DataStoreSideChainLevelDB) GetSideChainTxsCount(ctx context.Context) (int, error) {
+	if err := store.begin(ctx); err != nil {
+		return 0, err
+	}
+	defer store.mux.Unlock()
+
+	return store.count(levelSideChainTxs)
+}
+
+func (store *DataStoreSideChainLevelDB) GetAllSideChainTxHashesAndHeights(ctx context.Context, genesisBlockAddress string) ([]string, []uint32, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, nil, err
+	}
+	defer store.mux.Unlock()
+
+	var txHashes []string
+	var blockHeights []uint32
+	err := store.scanSideChainTxs(func(row *levelSideChainTx) {
+		if row.GenesisBlockAddress == genesisBlockAddress {
+			txHashes = append(txHashes, row.TransactionHash)
+			blockHeights = append(blockHeights, row.BlockHeight)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return txHashes, blockHeights, nil
+}
+
+// GetSideChainTxsFromHashes returns the transactions in the order of the
+// hashes without duplicates, like the GROUP BY of the sqlite backend.
+func (store *DataStoreSideChainLevelDB) GetSideChainTxsFromHashes(ctx context.Context, transactionHashes []string) ([]*base.WithdrawTx, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	hashes := append([]string(nil), transactionHashes...)
+	sort.Strings(hashes)
+	var txs []*base.WithdrawTx
+	for i, hash := range hashes {
+		if i > 0 && hash == hashes[i-1] {
+			continue
+		}
+		var row levelSideChainTx
+		ok, err := getIndexedRow(store, levelSideChainTxs, levelSideChainTxs.indexKey(hash), &row)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			txs = append(txs, row.withdrawTx())
+		}
+	}
+	return txs, nil
+}
+
+func (store *DataStoreSideChainLevelDB) GetSideChainTxsFromHashesAndGenesisAddress(ctx context.Context, transactionHashes []string, genesisBlockAddress string) ([]*base.WithdrawTx, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	var txs []*base.WithdrawTx
+	for _, hash := range transactionHashes {
+		var row levelSideChainTx
+		ok, err := getIndexedRow(store, levelSideChainTxs, levelSideChainTxs.indexKey(hash), &row)
+		if err != nil {
+			return nil, err
+		}
+		if ok && row.GenesisBlockAddress == genesisBlockAddress {
+			txs = append(txs, row.withdrawTx())
+		}
+	}
+	return txs, nil
+}
+
+func (store *FinishedTxsDataStoreLevelDB) ResetDataStore() error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	return store.deletePrefix(levelFinishedPrefix)
+}
+
+// insertDepositTxs adds the deposit transactions into the batch, the existing
+// ones are skipped.
+func insertDepositTxs(batch *levelBatch, transactionHashes, genesisBlockAddresses []string,
+	succeed bool, recordTime string) error {
+	for i, hash := range transactionHashes {
+		_, err := batch.insert(levelDepositTxs, levelDepositTxs.indexKey(hash, genesisBlockAddresses[i]),
+			&levelFinishedTx{
+				TransactionHash:     hash,
+				GenesisBlockAddress: genesisBlockAddresses[i],
+				Succeed:             succeed,
+				RecordTime:          recordTime,
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertWithdrawTxs adds the withdraw transactions into the batch, with the
+// withdraw transaction of the main chain if they are failed. The existing
+// ones are skipped.
+func insertWithdrawTxs(batch *levelBatch, transactionHashes []string, succeed bool,
+	transactionByte []byte, recordTime string) error {
+	var sideChainTransactionId uint64
+	if !succeed {
+		row := &levelSideChainTransaction{TransactionData: transactionByte, RecordTime: recordTime}
+		if _, err := batch.insert(levelSideChainFinished, nil, row); err != nil {
+			return err
+		}
+		sideChainTransactionId = row.Id
+	}
+	for _, hash := range transactionHashes {
+		_, err := batch.insert(levelWithdrawTxs, levelWithdrawTxs.indexKey(hash), &levelFinishedTx{
+			TransactionHash:        hash,
+			SideChainTransactionId: sideChainTransactionId,
+			Succeed:                succeed,
+			RecordTime:             recordTime,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *FinishedTxsDataStoreLevelDB) addDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string, succeed bool) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	err := insertDepositTxs(batch, transactionHashes, genesisBlockAddresses, succeed,
+		time.Now().Format(RecordTimeFormat))
+	if err != nil {
+		return err
+	}
+	return batch.commit()
+}
+
+func (store *FinishedTxsDataStoreLevelDB) AddFailedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error {
+	return store.addDepositTxs(ctx, transactionHashes, genesisBlockAddresses, false)
+}
+
+func (store *FinishedTxsDataStoreLevelDB) AddSucceedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error {
+	return store.addDepositTxs(ctx, transactionHashes, genesisBlockAddresses, true)
+}
+
+func (store *FinishedTxsDataStoreLevelDB) HasDepositTx(ctx context.Context, transactionHash string, genesisBlockAddress string) (bool, error) {
+	if err := store.begin(ctx); err != nil {
+		return false, err
+	}
+	defer store.mux.Unlock()
+
+	return store.Has(levelDepositTxs.indexKey(transactionHash, genesisBlockAddress), nil)
+}
+
+func (store *FinishedTxsDataStoreLevelDB) GetDepositTxByHash(ctx context.Context, transactionHash string) ([]bool, []string, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, nil, err
+	}
+	defer store.mux.Unlock()
+
+	var succeed []bool
+	var genesisAddresses []string
+	err := store.scan(string(levelDepositTxs.indexKey(transactionHash, "")), func(key, value []byte) error {
+		var row levelFinishedTx
+		ok, err := getLevelRow(store, levelDepositTxs.rowKey(binary.BigEndian.Uint64(value)), &row)
+		if err != nil || !ok {
+			return err
+		}
+		succeed = append(succeed, row.Succeed)
+		genesisAddresses = append(genesisAddresses, row.GenesisBlockAddress)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return succeed, genesisAddresses, nil
+}
+
+func (store *FinishedTxsDataStoreLevelDB) GetDepositTxByHashAndGenesisAddress(ctx context.Context, transactionHash string, genesisAddress string) (bool, error) {
+	if err := store.begin(ctx); err != nil {
+		return false, err
+	}
+	defer store.mux.Unlock()
+
+	var row levelFinishedTx
+	_, err := getIndexedRow(store, levelDepositTxs, levelDepositTxs.indexKey(transactionHash, genesisAddress), &row)
+	return row.Succeed, err
+}
+
+func (store *FinishedTxsDataStoreLevelDB) scanFinishedTxs(t levelTable, f func(row *levelFinishedTx)) error {
+	return store.scanRows(t, func() interface{} { return new(levelFinishedTx) },
+		func(row interface{}) error {
+			f(row.(*levelFinishedTx))
+			return nil
+		})
+}
+
+func (store *FinishedTxsDataStoreLevelDB) GetDepositTxs(ctx context.Context, succeed bool) ([]string, []string, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, nil, err
+	}
+	defer store.mux.Unlock()
+
+	var txHashes []string
+	var genesisAddresses []string
+	err := store.scanFinishedTxs(levelDepositTxs, func(row *levelFinishedTx) {
+		if row.Succeed == succeed {
+			txHashes = append(txHashes, row.TransactionHash)
+			genesisAddresses = append(genesisAddresses, row.GenesisBlockAddress)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return txHashes, genesisAddresses, nil
+}
+
+func (store *FinishedTxsDataStoreLevelDB) addWithdrawTxs(ctx context.Context, transactionHashes []string, succeed bool,
+	transactionByte []byte) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	err := insertWithdrawTxs(batch, transactionHashes, succeed, transactionByte, time.Now().Format(RecordTimeFormat))
+	if err != nil {
+		return err
+	}
+	return batch.commit()
+}
+
+func (store *FinishedTxsDataStoreLevelDB) AddFailedWithdrawTxs(ctx context.Context, transactionHashes []string, transactionByte []byte) error {
+	return store.addWithdrawTxs(ctx, transactionHashes, false, transactionByte)
+}
+
+func (store *FinishedTxsDataStoreLevelDB) AddSucceedWithdrawTxs(ctx context.Context, transactionHashes []string) error {
+	return store.addWithdrawTxs(ctx, transactionHashes, true, nil)
+}
+
+func (store *FinishedTxsDataStoreLevelDB) HasWithdrawTx(ctx context.Context, transactionHash string) (bool, error) {
+	if err := store.begin(ctx); err != nil {
+		return false, err
+	}
+	defer store.mux.Unlock()
+
+	return store.Has(levelWithdrawTxs.indexKey(transactionHash), nil)
+}
+
+func (store *FinishedTxsDataStoreLevelDB) GetWithdrawTxByHash(ctx context.Context, transactionHash string) (bool, []byte, error) {
+	if err := store.begin(ctx); err != nil {
+		return false, nil, err
+	}
+	defer store.mux.Unlock()
+
+	var row levelFinishedTx
+	ok, err := getIndexedRow(store, levelWithdrawTxs, levelWithdrawTxs.indexKey(transactionHash), &row)
+	if err != nil {
+		return false, nil, err
+	}
+	if !ok {
+		return false, nil, errors.New("get withdraw transaction by hash failed")
+	}
+	if row.Succeed {
+		return true, nil, nil
+	}
+
+	var sideChainTx levelSideChainTransaction
+	ok, err = getLevelRow(store, levelSideChainFinished.rowKey(row.SideChainTransactionId), &sideChainTx)
+	if err != nil {
+		return false, nil, err
+	}
+	if !ok {
+		return false, nil, errors.New("get withdraw transaction by hash failed, SideChainTransactions table has no record of needed id")
+	}
+	return false, sideChainTx.TransactionData, nil
+}
+
+func (store *FinishedTxsDataStoreLevelDB) GetWithdrawTxs(ctx context.Context, succeed bool) ([]string, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	var txHashes []string
+	err := store.scanFinishedTxs(levelWithdrawTxs, func(row *levelFinishedTx) {
+		if row.Succeed == succeed {
+			txHashes = append(txHashes, row.TransactionHash)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txHashes, nil
+}
+
+// matches tells whether the row is in the results of the query after the
+// cursor, it follows the conditions of whereClause.
+func (q *FinishedTxsQuery) matches(row *levelFinishedTx, cursor *finishedTxsCursor) bool {
+	if q.GenesisBlockAddress != "" && row.GenesisBlockAddress != q.GenesisBlockAddress {
+		return false
+	}
+	if q.Succeed != nil && row.Succeed != *q.Succeed {
+		return false
+	}
+	if q.StartTime != "" && row.RecordTime < q.StartTime {
+		return false
+	}
+	if q.EndTime != "" && row.RecordTime >= q.EndTime {
+		return false
+	}
+	if q.HashPrefix != "" && !strings.HasPrefix(row.TransactionHash, q.HashPrefix) {
+		return false
+	}
+	if cursor != nil {
+		return row.RecordTime > cursor.recordTime ||
+			row.RecordTime == cursor.recordTime && int64(row.Id) > cursor.id
+	}
+	return true
+}
+
+func (store *FinishedTxsDataStoreLevelDB) queryFinishedTxs(ctx context.Context, t levelTable, withAddress bool,
+	query *FinishedTxsQuery) ([]*FinishedTx, string, error) {
+	if query.GenesisBlockAddress != "" && !withAddress {
+		return nil, "", errors.New("genesis block address is not recorded")
+	}
+	var cursor *finishedTxsCursor
+	if query.Cursor != "" {
+		c, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		cursor = &c
+	}
+	if query.Limit <= 0 {
+		return nil, "", errors.New("limit should be greater than 0")
+	}
+
+	if err := store.begin(ctx); err != nil {
+		return nil, "", err
+	}
+	defer store.mux.Unlock()
+
+	var rows []*levelFinishedTx
+	err := store.scanFinishedTxs(t, func(row *levelFinishedTx) {
+		if query.matches(row, cursor) {
+			rows = append(rows, row)
+		}
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].RecordTime != rows[j].RecordTime {
+			return rows[i].RecordTime < rows[j].RecordTime
+		}
+		return rows[i].Id < rows[j].Id
+	})
+
+	var nextCursor string
+	if len(rows) > query.Limit {
+		rows = rows[:query.Limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(finishedTxsCursor{recordTime: last.RecordTime, id: int64(last.Id)})
+	}
+	txs := make([]*FinishedTx, 0, len(rows))
+	for _, row := range rows {
+		tx := &FinishedTx{TransactionHash: row.TransactionHash, Succeed: row.Succeed, RecordTime: row.RecordTime}
+		if withAddress {
+			tx.GenesisBlockAddress = row.GenesisBlockAddress
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nextCursor, nil
+}
+
+func (store *FinishedTxsDataStoreLevelDB) QueryDepositTxs(ctx context.Context, query *FinishedTxsQuery) ([]*FinishedTx, string, error) {
+	return store.queryFinishedTxs(ctx, levelDepositTxs, true, query)
+}
+
+func (store *FinishedTxsDataStoreLevelDB) QueryWithdrawTxs(ctx context.Context, query *FinishedTxsQuery) ([]*FinishedTx, string, error) {
+	return store.queryFinishedTxs(ctx, levelWithdrawTxs, false, query)
+}
+
+func (store *FinishedTxsDataStoreLevelDB) moveDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string, succeed bool) error {
+	if len(transactionHashes) == 0 {
+		return nil
+	}
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	for i, hash := range transactionHashes {
+		if _, err := batch.remove(levelMainChainTxs, levelMainChainTxs.indexKey(hash, genesisBlockAddresses[i])); err != nil {
+			return err
+		}
+	}
+	// a transaction may have been finished before it is cached again
+	err := insertDepositTxs(batch, transactionHashes, genesisBlockAddresses, succeed,
+		time.Now().Format(RecordTimeFormat))
+	if err != nil {
+		return err
+	}
+	return batch.commit()
+}
+
+// MoveFailedDepositTxs removes the deposit transactions from the main chain
+// cache and adds them as failed in one batch.
+func (store *FinishedTxsDataStoreLevelDB) MoveFailedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error {
+	return store.moveDepositTxs(ctx, transactionHashes, genesisBlockAddresses, false)
+}
+
+// MoveSucceedDepositTxs removes the deposit transactions from the main chain
+// cache and adds them as succeeded in one batch.
+func (store *FinishedTxsDataStoreLevelDB) MoveSucceedDepositTxs(ctx context.Context, transactionHashes, genesisBlockAddresses []string) error {
+	return store.moveDepositTxs(ctx, transactionHashes, genesisBlockAddresses, true)
+}
+
+func (store *FinishedTxsDataStoreLevelDB) moveWithdrawTxs(ctx context.Context, transactionHashes []string, succeed bool,
+	transactionByte []byte) error {
+	if len(transactionHashes) == 0 {
+		return nil
+	}
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	for _, hash := range transactionHashes {
+		if _, err := batch.remove(levelSideChainTxs, levelSideChainTxs.indexKey(hash)); err != nil {
+			return err
+		}
+	}
+	err := insertWithdrawTxs(batch, transactionHashes, succeed, transactionByte, time.Now().Format(RecordTimeFormat))
+	if err != nil {
+		return err
+	}
+	return batch.commit()
+}
+
+// MoveFailedWithdrawTxs removes the withdraw transactions from the side chain
+// cache and adds them as failed with the withdraw transaction of the main
+// chain in one batch.
+func (store *FinishedTxsDataStoreLevelDB) MoveFailedWithdrawTxs(ctx context.Context, transactionHashes []string, transactionByte []byte) error {
+	return store.moveWithdrawTxs(ctx, transactionHashes, false, transactionByte)
+}
+
+// MoveSucceedWithdrawTxs removes the withdraw transactions from the side
+// chain cache and adds them as succeeded in one batch.
+func (store *FinishedTxsDataStoreLevelDB) MoveSucceedWithdrawTxs(ctx context.Context, transactionHashes []string) error {
+	return store.moveWithdrawTxs(ctx, transactionHashes, true, nil)
+}
+
+// CheckConsistency repairs the transitions half-applied by the arbiters
+// which moved the transactions to the finished store in two writes.
+func (store *FinishedTxsDataStoreLevelDB) CheckConsistency(ctx context.Context) (*ConsistencyReport, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	report := new(ConsistencyReport)
+	batch := store.newBatch()
+	var err error
+	report.StaleDepositTxs, err = store.removeStaleTxs(batch, levelMainChainTxs,
+		func(transactionHash, genesisBlockAddress string) ([]byte, []byte) {
+			return levelMainChainTxs.indexKey(transactionHash, genesisBlockAddress),
+				levelDepositTxs.indexKey(transactionHash, genesisBlockAddress)
+		})
+	if err != nil {
+		return nil, err
+	}
+	report.StaleWithdrawTxs, err = store.removeStaleTxs(batch, levelSideChainTxs,
+		func(transactionHash, genesisBlockAddress string) ([]byte, []byte) {
+			return levelSideChainTxs.indexKey(transactionHash), levelWithdrawTxs.indexKey(transactionHash)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	// the last events of the transactions, withdraw transactions are not told
+	// apart by side chains
+	lastEvents := make(map[string]*levelTransactionEvent)
+	err = store.scanRows(levelTransactionEvents, func() interface{} { return new(levelTransactionEvent) },
+		func(row interface{}) error {
+			e := row.(*levelTransactionEvent)
+			key := e.Type + "\x00" + e.TransactionHash
+			if e.Type == TxTypeDeposit {
+				key += "\x00" + e.GenesisBlockAddress
+			}
+			lastEvents[key] = e
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	var finished []*levelTransactionEvent
+	for _, e := range lastEvents {
+		if e.Event == TxEventSucceeded || e.Event == TxEventFailed {
+			finished = append(finished, e)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].Id < finished[j].Id })
+
+	for _, e := range finished {
+		var cacheKey, finishedKey []byte
+		var t levelTable
+		switch e.Type {
+		case TxTypeDeposit:
+			t = levelDepositTxs
+			cacheKey = levelMainChainTxs.indexKey(e.TransactionHash, e.GenesisBlockAddress)
+			finishedKey = levelDepositTxs.indexKey(e.TransactionHash, e.GenesisBlockAddress)
+		case TxTypeWithdraw:
+			// the withdraw transaction of the main chain is unknown, so the
+			// lost failed ones have no side chain transaction
+			t = levelWithdrawTxs
+			cacheKey = levelSideChainTxs.indexKey(e.TransactionHash)
+			finishedKey = levelWithdrawTxs.indexKey(e.TransactionHash)
+		default:
+			continue
+		}
+		if cached, err := batch.has(cacheKey); err != nil {
+			return nil, err
+		} else if cached {
+			continue
+		}
+		row := &levelFinishedTx{
+			TransactionHash: e.TransactionHash,
+			Succeed:         e.Event == TxEventSucceeded,
+			RecordTime:      e.RecordTime,
+		}
+		if e.Type == TxTypeDeposit {
+			row.GenesisBlockAddress = e.GenesisBlockAddress
+		}
+		inserted, err := batch.insert(t, finishedKey, row)
+		if err != nil {
+			return nil, err
+		}
+		if inserted && e.Type == TxTypeDeposit {
+			report.LostDepositTxs++
+		} else if inserted {
+			report.LostWithdrawTxs++
+		}
+	}
+
+	if err = batch.commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// removeStaleTxs removes the transactions of the cache which are finished,
+// keys returns the index keys of a cached transaction in the cache and in
+// the finished store.
+func (store *FinishedTxsDataStoreLevelDB) removeStaleTxs(batch *levelBatch, cache levelTable,
+	keys func(transactionHash, genesisBlockAddress string) ([]byte, []byte)) (int64, error) {
+	var count int64
+	err := store.scan(cache.rows, func(key, value []byte) error {
+		var row struct {
+			TransactionHash     string
+			GenesisBlockAddress string
+		}
+		if err := json.Unmarshal(value, &row); err != nil {
+			return err
+		}
+		cacheKey, finishedKey := keys(row.TransactionHash, row.GenesisBlockAddress)
+		exists, err := batch.has(finishedKey)
+		if err != nil || !exists {
+			return err
+		}
+		batch.delete(append([]byte(nil), key...))
+		batch.delete(cacheKey)
+		count++
+		return nil
+	})
+	return count, err
+}
+
+func (store *FinishedTxsDataStoreLevelDB) AddSideChainTx(ctx context.Context, transactionByte []byte) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	row := &levelSideChainTransaction{TransactionData: transactionByte, RecordTime: time.Now().Format(RecordTimeFormat)}
+	if _, err := batch.insert(levelSideChainFinished, nil, row); err != nil {
+		return err
+	}
+	return batch.commit()
+}
+
+func (store *FinishedTxsDataStoreLevelDB) GetSideChainTx(ctx context.Context, sideChainTransactionId uint64) ([]byte, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	var row levelSideChainTransaction
+	ok, err := getLevelRow(store, levelSideChainFinished.rowKey(sideChainTransactionId), &row)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errLevelSideChainTxLost
+	}
+	return row.TransactionData, nil
+}
+
+func (store *FinishedTxsDataStoreLevelDB) AddTransactionEvents(ctx context.Context, events []*TransactionEvent) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	batch := store.newBatch()
+	recordTime := time.Now().Format(RecordTimeFormat)
+	for _, e := range events {
+		if e.RecordTime == "" {
+			e.RecordTime = recordTime
+		}
+		row := &levelTransactionEvent{TransactionEvent: *e}
+		if _, err := batch.insert(levelTransactionEvents, nil, row); err != nil {
+			return err
+		}
+		batch.put(eventIndexKey(e.TransactionHash, row.Id), nil)
+	}
+	return batch.commit()
+}
+
+// GetTransactionEvents returns the events of the transaction in the order
+// they are recorded.
+func (store *FinishedTxsDataStoreLevelDB) GetTransactionEvents(ctx context.Context, transactionHash string) ([]*TransactionEvent, error) {
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	prefix := levelTransactionEvents.indexKey(transactionHash, "")
+	var events []*TransactionEvent
+	err := store.scan(string(prefix), func(key, value []byte) error {
+		var row levelTransactionEvent
+		ok, err := getLevelRow(store, levelTransactionEvents.rowKey(binary.BigEndian.Uint64(key[len(prefix):])), &row)
+		if err != nil || !ok {
+			return err
+		}
+		events = append(events, &row.TransactionEvent)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// pruned tells whether the finished transaction is out of the retention.
+func (p *PrunePolicy) pruned(row *levelFinishedTx) bool {
+	if row.Succeed {
+		return p.SucceedBefore != "" && row.RecordTime < p.SucceedBefore
+	}
+	return p.FailedBefore != "" && row.RecordTime < p.FailedBefore
+}
+
+// Prune deletes the finished transactions out of the retention, with their
+// events and side chain transactions. The rows are archived first if the
+// policy has an ArchiveDir, and nothing is changed in a dry run.
+func (store *FinishedTxsDataStoreLevelDB) Prune(ctx context.Context, policy *PrunePolicy) (*PruneReport, error) {
+	report := &PruneReport{
+		DryRun:        policy.DryRun,
+		SucceedBefore: policy.SucceedBefore,
+		FailedBefore:  policy.FailedBefore,
+	}
+	if policy.SucceedBefore == "" && policy.FailedBefore == "" {
+		return report, nil
+	}
+
+	if err := store.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer store.mux.Unlock()
+
+	type prunedRow struct {
+		table string
+		keys  [][]byte
+		row   map[string]interface{}
+	}
+	var deposits, withdraws, sideChainTxs, events []*prunedRow
+	prunedDeposits := make(map[string]bool)
+	prunedWithdraws := make(map[string]bool)
+	keptSideChainTxs := make(map[uint64]bool)
+
+	err := store.scanFinishedTxs(levelDepositTxs, func(row *levelFinishedTx) {
+		if !policy.pruned(row) {
+			return
+		}
+		prunedDeposits[row.TransactionHash+"\x00"+row.GenesisBlockAddress] = true
+		deposits = append(deposits, &prunedRow{
+			table: "DepositTransactions",
+			keys: [][]byte{levelDepositTxs.rowKey(row.Id),
+				levelDepositTxs.indexKey(row.TransactionHash, row.GenesisBlockAddress)},
+			row: map[string]interface{}{"Id": row.Id, "TransactionHash": row.TransactionHash,
+				"GenesisBlockAddress": row.GenesisBlockAddress, "Succeed": row.Succeed,
+				"RecordTime": row.RecordTime},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = store.scanFinishedTxs(levelWithdrawTxs, func(row *levelFinishedTx) {
+		if !policy.pruned(row) {
+			keptSideChainTxs[row.SideChainTransactionId] = true
+			return
+		}
+		prunedWithdraws[row.TransactionHash] = true
+		withdraws = append(withdraws, &prunedRow{
+			table: "WithdrawTransactions",
+			keys:  [][]byte{levelWithdrawTxs.rowKey(row.Id), levelWithdrawTxs.indexKey(row.TransactionHash)},
+			row: map[string]interface{}{"Id": row.Id, "TransactionHash": row.TransactionHash,
+				"SideChainTransactionId": row.SideChainTransactionId, "Succeed": row.Succeed,
+				"RecordTime": row.RecordTime},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// events of the pruned transactions, deposits are told apart by side chains
+	err = store.scanRows(levelTransactionEvents, func() interface{} { return new(levelTransactionEvent) },
+		func(row interface{}) error {
+			e := row.(*levelTransactionEvent)
+			if !(e.Type == TxTypeDeposit && prunedDeposits[e.TransactionHash+"\x00"+e.GenesisBlockAddress]) &&
+				!(e.Type == TxTypeWithdraw && prunedWithdraws[e.TransactionHash]) {
+				return nil
+			}
+			events = append(events, &prunedRow{
+				table: "TransactionEvents",
+				keys: [][]byte{levelTransactionEvents.rowKey(e.Id),
+					eventIndexKey(e.TransactionHash, e.Id)},
+				row: map[string]interface{}{"Id": e.Id, "TransactionHash": e.TransactionHash,
+					"GenesisBlockAddress": e.GenesisBlockAddress, "Type": e.Type, "Event": e.Event,
+					"Height": e.Height, "Source": e.Source, "ProposalHash": e.ProposalHash,
+					"Signatures": e.Signatures, "ResultHash": e.ResultHash, "Reason": e.Reason,
+					"RecordTime": e.RecordTime},
+			})
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	// side chain transactions are only kept for the failed withdraw
+	// transactions, so they follow the retention of failures
+	if policy.FailedBefore != "" {
+		err = store.scanRows(levelSideChainFinished, func() interface{} { return new(levelSideChainTransaction) },
+			func(row interface{}) error {
+				s := row.(*levelSideChainTransaction)
+				if s.RecordTime >= policy.FailedBefore || keptSideChainTxs[s.Id] {
+					return nil
+				}
+				sideChainTxs = append(sideChainTxs, &prunedRow{
+					table: "SideChainTransactions",
+					keys:  [][]byte{levelSideChainFinished.rowKey(s.Id)},
+					row: map[string]interface{}{"Id": s.Id, "TransactionData": hex.EncodeToString(s.TransactionData),
+						"RecordTime": s.RecordTime},
+				})
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report.TransactionEvents = len(events)
+	report.SideChainTxs = len(sideChainTxs)
+	report.DepositTxs = len(deposits)
+	report.WithdrawTxs = len(withdraws)
+	if policy.DryRun || report.Total() == 0 {
+		return report, nil
+	}
+
+	var rows []*prunedRow
+	for _, category := range [][]*prunedRow{events, sideChainTxs, deposits, withdraws} {
+		rows = append(rows, category...)
+	}
+	if policy.ArchiveDir != "" {
+		archive, err := createPruneArchive(policy.ArchiveDir, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			if err = archive.write(r.table, r.row); err != nil {
+				archive.remove()
+				return nil, err
+			}
+		}
+		if err = archive.close(); err != nil {
+			os.Remove(archive.path)
+			return nil, err
+		}
+		report.ArchiveFile = archive.path
+	}
+
+	batch := store.newBatch()
+	for _, r := range rows {
+		for _, key := range r.keys {
+			batch.delete(key)
+		}
+	}
+	if err = batch.commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Vacuum compacts the database to give the space of the deleted rows back to
+// the file system.
+func (store *FinishedTxsDataStoreLevelDB) Vacuum(ctx context.Context) error {
+	if err := store.begin(ctx); err != nil {
+		return err
+	}
+	defer store.mux.Unlock()
+
+	return store.CompactRange(util.Range{})
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrDataDirLocked is returned by LockDataDir if another process, a running
+// arbiter or a restore, holds the lock of the data directory.
+var ErrDataDirLocked = errors.New("the data directory is locked by another process")
+
+// LockFileName is the lock file in the data directory, a running arbiter
+// holds the lock on it until it exits.
+var LockFileName = filepath.Join(DBDocumentNAME, "arbiter.lock")
+
+// DataDirLock is the lock of the data directory taken by LockDataDir.
+type DataDirLock struct {
+	file *os.File
+}
+
+// LockDataDir takes the lock of the data directory, ErrDataDirLocked is
+// returned if it is held by another process. The lock is released when the
+// process exits, so it never outlives a crashed arbiter.
+func LockDataDir() (*DataDirLock, error) {
+	if err := os.MkdirAll(filepath.Dir(LockFileName), 0740); err != nil {
+		return nil, err
+	}
+	file, err := lockFile(LockFileName)
+	if err != nil {
+		return nil, err
+	}
+	return &DataDirLock{file: file}, nil
+}
+
+// Release releases the lock, the lock file is kept for the next holder.
+func (l *DataDirLock) Release() error {
+	return l.file.Close()
+}
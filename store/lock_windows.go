@@ -0,0 +1,19 @@
+package store
+
+import (
+	"os"
+)
+
+// lockFile creates the lock file exclusively and keeps it open, an open file
+// can not be removed on windows, so a lock file left by a crashed arbiter is
+// removed while a held one is not.
+func lockFile(path string) (*os.File, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, ErrDataDirLocked
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return nil, ErrDataDirLocked
+	}
+	return file, err
+}
@@ -0,0 +1,237 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SchemaVersionName is the name of the schema version in the Info table.
+const SchemaVersionName = "SchemaVersion"
+
+// Migration is a step of the schema of a database. Up runs in the same
+// transaction as the update of the schema version, and it should be
+// idempotent since the databases created before the schema versions already
+// have some of the tables.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *sql.Tx) error
+}
+
+// Migrations of the databases, in the order of versions. Never change a
+// released step, append a new one instead.
+var (
+	MainChainMigrations = []Migration{
+		{1, "create MainChainTxs table", execStatements(CreateMainChainTxsTable)},
+	}
+	SideChainMigrations = []Migration{
+		{1, "create SideHeightInfo and SideChainTxs tables",
+			execStatements(CreateHeightInfoTable, CreateSideChainTxsTable)},
+	}
+	FinishedTxsMigrations = []Migration{
+		{1, "create DepositTransactions, WithdrawTransactions and SideChainTransactions tables",
+			execStatements(CreateDepositTransactionsTable, CreateWithdrawTransactionsTable,
+				CreateSideChainTransactionsTable)},
+		{2, "create TransactionEvents table",
+			execStatements(CreateTransactionEventsTable, CreateTransactionEventsIndex)},
+		{3, "create indexes of the finished transactions queries",
+			execStatements(CreateDepositTransactionsTimeIndex, CreateDepositTransactionsAddressIndex,
+				CreateWithdrawTransactionsTimeIndex)},
+	}
+)
+
+func execStatements(statements ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// database is a sqlite database of the arbiter and the migrations of it.
+type database struct {
+	path       func() string
+	migrations []Migration
+}
+
+func databases() []database {
+	return []database{
+		{func() string { return DBNameMainChain }, MainChainMigrations},
+		{func() string { return DBNameSideChain }, SideChainMigrations},
+		{func() string { return FinishedTxsDBName }, FinishedTxsMigrations},
+	}
+}
+
+// openDB opens the database and migrates it to the latest version.
+func openDB(path string, migrations []Migration) (*sql.DB, error) {
+	err := CheckAndCreateDocument(filepath.Dir(path))
+	if err != nil {
+		logger.Error("Create DBCache doucument error:", err)
+		return nil, err
+	}
+	existed, err := PathExists(path)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(DriverName, path)
+	if err != nil {
+		logger.Error("Open data db error:", err)
+		return nil, err
+	}
+	if err = migrate(db, path, existed, migrations); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate %s failed: %s", path, err)
+	}
+	return db, nil
+}
+
+func migrate(db *sql.DB, path string, existed bool, migrations []Migration) error {
+	if _, err := db.Exec(CreateInfoTable); err != nil {
+		return err
+	}
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	pending, err := pendingMigrations(version, migrations)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	if existed {
+		backup, err := backupDB(db, path, version)
+		if err != nil {
+			return fmt.Errorf("backup failed: %s", err)
+		}
+		logger.Infof("Backup %s of schema version %d to %s", path, version, backup)
+	}
+
+	for _, m := range pending {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err = m.Up(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d %s: %s", m.Version, m.Description, err)
+		}
+		_, err = tx.Exec("INSERT OR REPLACE INTO Info(Name, Value) values(?,?)", SchemaVersionName, m.Version)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		logger.Infof("Migrated %s to schema version %d: %s", path, m.Version, m.Description)
+	}
+	return nil
+}
+
+// schemaVersion returns the schema version of the database, 0 if it is
+// created before the schema versions.
+func schemaVersion(db *sql.DB) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='Info'").Scan(&count)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+	var version int
+	err = db.QueryRow("SELECT Value FROM Info WHERE Name=?", SchemaVersionName).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+func pendingMigrations(version int, migrations []Migration) ([]Migration, error) {
+	if len(migrations) > 0 && version > migrations[len(migrations)-1].Version {
+		return nil, fmt.Errorf("schema version %d is newer than the latest known version %d",
+			version, migrations[len(migrations)-1].Version)
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// backupDB copies a consistent snapshot of the database next to it.
+func backupDB(db *sql.DB, path string, version int) (string, error) {
+	backup := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102-150405"))
+	if _, err := os.Stat(backup); err == nil {
+		return "", fmt.Errorf("%s already exists", backup)
+	}
+	_, err := db.Exec("VACUUM INTO ?", backup)
+	return backup, err
+}
+
+// MigrationPlan is the pending migrations of a database.
+type MigrationPlan struct {
+	Path          string
+	Exists        bool
+	Version       int
+	LatestVersion int
+	Pending       []Migration
+}
+
+// PlanMigrations returns the pending migrations of all the databases without
+// changing them.
+func PlanMigrations() ([]*MigrationPlan, error) {
+	var plans []*MigrationPlan
+	for _, d := range databases() {
+		plan := &MigrationPlan{Path: d.path()}
+		if len(d.migrations) > 0 {
+			plan.LatestVersion = d.migrations[len(d.migrations)-1].Version
+		}
+		exists, err := PathExists(plan.Path)
+		if err != nil {
+			return nil, err
+		}
+		plan.Exists = exists
+		if exists {
+			db, err := sql.Open(DriverName, "file:"+plan.Path+"?mode=ro")
+			if err != nil {
+				return nil, err
+			}
+			plan.Version, err = schemaVersion(db)
+			db.Close()
+			if err != nil {
+				return nil, fmt.Errorf("read schema version of %s failed: %s", plan.Path, err)
+			}
+		}
+		if plan.Pending, err = pendingMigrations(plan.Version, d.migrations); err != nil {
+			return nil, fmt.Errorf("%s: %s", plan.Path, err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// Migrate migrates all the existing databases to the latest versions, the
+// others are created in the latest versions when the arbiter starts.
+func Migrate() error {
+	for _, d := range databases() {
+		exists, err := PathExists(d.path())
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		db, err := openDB(d.path(), d.migrations)
+		if err != nil {
+			return err
+		}
+		db.Close()
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arbiter-migration-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.db")
+
+	migrations := []Migration{
+		{1, "create Test table", execStatements(`CREATE TABLE IF NOT EXISTS Test (Id INTEGER NOT NULL PRIMARY KEY)`)},
+	}
+	db, err := openDB(path, migrations)
+	if err != nil {
+		t.Fatal("Open database error:", err)
+	}
+	if version, _ := schemaVersion(db); version != 1 {
+		t.Error("Schema version should be 1, got", version)
+	}
+	db.Exec("INSERT INTO Test(Id) values(1)")
+	db.Close()
+	if backups, _ := filepath.Glob(path + ".v*.bak"); len(backups) != 0 {
+		t.Error("New database should not be backed up.")
+	}
+
+	// a failed step is rolled back with its version
+	migrations = append(migrations,
+		Migration{2, "add Name column", execStatements(`ALTER TABLE Test ADD COLUMN Name VARCHAR`)},
+		Migration{3, "fail", func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`CREATE TABLE Failed (Id INTEGER)`); err != nil {
+				return err
+			}
+			return errors.New("failed")
+		}})
+	if _, err = openDB(path, migrations); err == nil {
+		t.Fatal("Failed migration should return error.")
+	}
+	db, _ = sql.Open(DriverName, path)
+	if version, _ := schemaVersion(db); version != 2 {
+		t.Error("Schema version should be 2, got", version)
+	}
+	if _, err = db.Exec("UPDATE Test SET Name='test'"); err != nil {
+		t.Error("Column of the succeeded step should be added:", err)
+	}
+	if _, err = db.Exec("SELECT Id FROM Failed"); err == nil {
+		t.Error("Failed migration should be rolled back.")
+	}
+	db.Close()
+
+	backups, _ := filepath.Glob(path + ".v1-*.bak")
+	if len(backups) != 1 {
+		t.Fatal("Existing database should be backed up before migrated, got", backups)
+	}
+	backup, _ := sql.Open(DriverName, backups[0])
+	var count int
+	backup.QueryRow("SELECT count(*) FROM Test").Scan(&count)
+	if version, _ := schemaVersion(backup); version != 1 || count != 1 {
+		t.Error("Backup should be the database before migrated.")
+	}
+	backup.Close()
+
+	if _, err = openDB(path, migrations[:1]); err == nil {
+		t.Error("Newer schema version should be rejected.")
+	}
+}
+
+func TestPlanMigrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arbiter-migration-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mainChain, sideChain, finishedTxs := DBNameMainChain, DBNameSideChain, FinishedTxsDBName
+	defer func() { DBNameMainChain, DBNameSideChain, FinishedTxsDBName = mainChain, sideChain, finishedTxs }()
+	DBNameMainChain = filepath.Join(dir, "mainChainCache.db")
+	DBNameSideChain = filepath.Join(dir, "sideChainCache.db")
+	FinishedTxsDBName = filepath.Join(dir, "finishedTxs.db")
+
+	// a finished transactions database created before the schema versions
+	db, _ := sql.Open(DriverName, FinishedTxsDBName)
+	if _, err = db.Exec(CreateDepositTransactionsTable); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	plans, err := PlanMigrations()
+	if err != nil || len(plans) != 3 {
+		t.Fatal("Plan migrations error:", err)
+	}
+	if plans[0].Exists || plans[1].Exists {
+		t.Error("Cache databases should not exist.")
+	}
+	finished := plans[2]
+	if !finished.Exists || finished.Version != 0 || len(finished.Pending) != len(FinishedTxsMigrations) {
+		t.Errorf("Unexpected plan %+v", *finished)
+	}
+
+	if err = Migrate(); err != nil {
+		t.Fatal("Migrate error:", err)
+	}
+	if exists, _ := PathExists(DBNameMainChain); exists {
+		t.Error("Migrate should not create databases.")
+	}
+	plans, _ = PlanMigrations()
+	if len(plans[2].Pending) != 0 || plans[2].Version != plans[2].LatestVersion {
+		t.Errorf("Database should be up to date, got %+v", *plans[2])
+	}
+}
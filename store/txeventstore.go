@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/log"
+)
+
+const (
+	CreateTransactionEventsTable = `CREATE TABLE IF NOT EXISTS TransactionEvents (
+				Id INTEGER NOT NULL PRIMARY KEY,
+				TransactionHash VARCHAR,
+				GenesisBlockAddress VARCHAR(34),
+				Type VARCHAR(10),
+				Event VARCHAR(10),
+				Height INTEGER,
+				Source VARCHAR,
+				ProposalHash VARCHAR,
+				Signatures INTEGER,
+				ResultHash VARCHAR,
+				Reason TEXT,
+				RecordTime TEXT
+			);`
+	CreateTransactionEventsIndex = `CREATE INDEX IF NOT EXISTS TransactionEventsHash
+				ON TransactionEvents (TransactionHash);`
+
+	RecordTimeFormat = "2006-01-02_15.04.05"
+)
+
+// Types of cross-chain transactions.
+const (
+	TxTypeDeposit  = "deposit"
+	TxTypeWithdraw = "withdraw"
+)
+
+// Lifecycle events of cross-chain transactions.
+const (
+	// TxEventDetected is recorded when a transaction is found and cached,
+	// Height is the block height of it and Source tells who found it.
+	TxEventDetected = "detected"
+	// TxEventSent is recorded when a deposit transaction is sent to the side
+	// chain node.
+	TxEventSent = "sent"
+	// TxEventProposed is recorded when a withdraw transaction is put into a
+	// proposal, ProposalHash is the hash of the withdraw transaction of the
+	// main chain.
+	TxEventProposed = "proposed"
+	// TxEventSigned is recorded when a signature of the proposal is received,
+	// Signatures is the count of signatures collected.
+	TxEventSigned = "signed"
+	// TxEventSucceeded is recorded when a transaction is finished,
+	// ResultHash is the hash of the resulting transaction if known.
+	TxEventSucceeded = "succeeded"
+	// TxEventFailed is recorded when a transaction is given up, Reason
+	// tells why.
+	TxEventFailed = "failed"
+)
+
+// Sources of detected transactions.
+const (
+	TxSourceDepositListener  = "deposit listener"
+	TxSourceSideChainMonitor = "side chain monitor"
+)
+
+// TransactionEvent is a state transition of a cross-chain transaction, the
+// deposit transaction of the main chain or the withdraw transaction of the
+// side chain.
+type TransactionEvent struct {
+	TransactionHash     string
+	GenesisBlockAddress string
+	Type                string
+	Event               string
+	Height              uint32
+	Source              string
+	ProposalHash        string
+	Signatures          int
+	ResultHash          string
+	Reason              string
+	RecordTime          string
+}
+
+// NewTransactionEvents returns an event for each of the transactions, the
+// events are copied from the template except TransactionHash.
+func NewTransactionEvents(transactionHashes []string, template TransactionEvent) []*TransactionEvent {
+	events := make([]*TransactionEvent, 0, len(transactionHashes))
+	for _, hash := range transactionHashes {
+		event := template
+		event.TransactionHash = hash
+		events = append(events, &event)
+	}
+	return events
+}
+
+// RecordTransactionEvents adds the events into the finished transactions
+// store, errors are only logged so they never break the cross-chain flows.
+func RecordTransactionEvents(events []*TransactionEvent) {
+	if FinishedTxsDbCache == nil || len(events) == 0 {
+		return
+	}
+	if err := FinishedTxsDbCache.AddTransactionEvents(context.Background(), events); err != nil {
+		logger.Warnw("add transaction events failed", "events", len(events), log.KeyError, err)
+	}
+}
+
+func (store *FinishedTxsDataStoreImpl) AddTransactionEvents(ctx context.Context, events []*TransactionEvent) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	tx, err := store.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO TransactionEvents(TransactionHash, GenesisBlockAddress, Type,
+				Event, Height, Source, ProposalHash, Signatures, ResultHash, Reason, RecordTime)
+				values(?,?,?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	recordTime := time.Now().Format(RecordTimeFormat)
+	for _, e := range events {
+		if e.RecordTime == "" {
+			e.RecordTime = recordTime
+		}
+		_, err = stmt.ExecContext(ctx, e.TransactionHash, e.GenesisBlockAddress, e.Type, e.Event, e.Height,
+			e.Source, e.ProposalHash, e.Signatures, e.ResultHash, e.Reason, e.RecordTime)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTransactionEvents returns the events of the transaction in the order
+// they are recorded.
+func (store *FinishedTxsDataStoreImpl) GetTransactionEvents(ctx context.Context, transactionHash string) ([]*TransactionEvent, error) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	rows, err := store.QueryContext(ctx, `SELECT TransactionHash, GenesisBlockAddress, Type, Event, Height, Source,
+				ProposalHash, Signatures, ResultHash, Reason, RecordTime
+				FROM TransactionEvents WHERE TransactionHash=? ORDER BY Id`, transactionHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*TransactionEvent
+	for rows.Next() {
+		var e TransactionEvent
+		err = rows.Scan(&e.TransactionHash, &e.GenesisBlockAddress, &e.Type, &e.Event, &e.Height,
+			&e.Source, &e.ProposalHash, &e.Signatures, &e.ResultHash, &e.Reason, &e.RecordTime)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
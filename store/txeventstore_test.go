@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFinishedTxsDataStoreImpl_TransactionEvents(t *testing.T) {
+	datastore, err := OpenFinishedTxsDataStore()
+	if err != nil {
+		t.Fatal("Open database error.")
+	}
+	defer datastore.ResetDataStore()
+
+	txHash := "testHash"
+	err = datastore.AddTransactionEvents(context.Background(), []*TransactionEvent{
+		{TransactionHash: txHash, GenesisBlockAddress: "testAddress", Type: TxTypeWithdraw,
+			Event: TxEventDetected, Height: 100, Source: TxSourceSideChainMonitor},
+		{TransactionHash: "otherHash", Type: TxTypeDeposit, Event: TxEventDetected},
+	})
+	if err != nil {
+		t.Error("Add transaction events error.")
+	}
+	err = datastore.AddTransactionEvents(context.Background(), NewTransactionEvents([]string{txHash, "otherHash"},
+		TransactionEvent{Type: TxTypeWithdraw, Event: TxEventProposed, ProposalHash: "proposalHash", Signatures: 1}))
+	if err != nil {
+		t.Error("Add transaction events error.")
+	}
+
+	events, err := datastore.GetTransactionEvents(context.Background(), txHash)
+	if err != nil {
+		t.Error("Get transaction events error.")
+	}
+	if len(events) != 2 {
+		t.Fatal("Get transaction events error, count:", len(events))
+	}
+	if events[0].Event != TxEventDetected || events[0].Height != 100 ||
+		events[0].Source != TxSourceSideChainMonitor || events[0].GenesisBlockAddress != "testAddress" {
+		t.Error("Get transaction events error, unexpected detected event:", *events[0])
+	}
+	if events[1].Event != TxEventProposed || events[1].TransactionHash != txHash ||
+		events[1].ProposalHash != "proposalHash" || events[1].Signatures != 1 {
+		t.Error("Get transaction events error, unexpected proposed event:", *events[1])
+	}
+	if events[0].RecordTime == "" {
+		t.Error("Record time should be set.")
+	}
+
+	events, err = datastore.GetTransactionEvents(context.Background(), "unknownHash")
+	if err != nil || len(events) != 0 {
+		t.Error("Should not have events of unknown transaction.")
+	}
+}
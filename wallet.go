@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/elastos/Elastos.ELA.Arbiter/config"
+	"github.com/elastos/Elastos.ELA.Arbiter/password"
+	"github.com/elastos/Elastos.ELA.Arbiter/rpc"
+	"github.com/elastos/Elastos.ELA.Arbiter/sideauxpow"
+
+	"github.com/elastos/Elastos.ELA/account"
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/contract"
+	"github.com/urfave/cli"
+)
+
+var (
+	walletFileFlag = cli.StringFlag{
+		Name:  "wallet, w",
+		Usage: "wallet `<file>` path",
+		Value: sideauxpow.DefaultKeystoreFile,
+	}
+	newPasswordSourceFlag = cli.StringFlag{
+		Name:  "new-password-source",
+		Usage: "`<source>` of the new wallet password like --password-source, the terminal if not given",
+	}
+	keySourceFlag = cli.StringFlag{
+		Name:  "key-source",
+		Usage: "`<source>` of the hex private key like --password-source, the terminal if not given",
+	}
+	exportOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "`<file>` to write the public keys to, stdout if not given",
+	}
+)
+
+func newWalletCommand() *cli.Command {
+	keystoreFlags := []cli.Flag{
+		walletFileFlag,
+		accountPasswordFlag,
+		passwordSourceFlag,
+	}
+	return &cli.Command{
+		Name:  "wallet",
+		Usage: "Manage the keystore of the arbiter",
+		Subcommands: []cli.Command{
+			{
+				Name:   "create",
+				Usage:  "Create a keystore with a new main account",
+				Flags:  keystoreFlags,
+				Action: createWallet,
+			},
+			{
+				Name:    "account",
+				Aliases: []string{"a"},
+				Usage:   "Show the addresses and public keys of the accounts",
+				Flags:   keystoreFlags,
+				Action:  showAccounts,
+			},
+			{
+				Name:   "list",
+				Usage:  "List the accounts with their balances from the main node and the side chains mining by them",
+				Flags:  keystoreFlags,
+				Action: listAccounts,
+			},
+			{
+				Name:   "add",
+				Usage:  "Add a new account, like a MiningAddr of a side chain",
+				Flags:  keystoreFlags,
+				Action: addAccount,
+			},
+			{
+				Name:   "import",
+				Usage:  "Import an account by its private key, like a MiningAddr of a side chain",
+				Flags:  append([]cli.Flag{keySourceFlag}, keystoreFlags...),
+				Action: importAccount,
+			},
+			{
+				Name:   "chpwd",
+				Usage:  "Change the password of the keystore",
+				Flags:  append([]cli.Flag{newPasswordSourceFlag}, keystoreFlags...),
+				Action: changePassword,
+			},
+			{
+				Name:   "export",
+				Usage:  "Export the addresses and public keys of the accounts as JSON",
+				Flags:  append([]cli.Flag{exportOutFlag}, keystoreFlags...),
+				Action: exportPublicKeys,
+			},
+		},
+	}
+}
+
+func createWallet(c *cli.Context) error {
+	file := c.String("wallet")
+	if _, err := os.Stat(file); err == nil {
+		return fmt.Errorf("%s already exists", file)
+	}
+	passwd, err := readPassword(c, "", true)
+	if err != nil {
+		return err
+	}
+	defer password.Wipe(passwd)
+
+	client, err := account.Create(file, passwd)
+	if err != nil {
+		return err
+	}
+	return printAccount("Created", client.GetMainAccount())
+}
+
+func showAccounts(c *cli.Context) error {
+	client, err := openKeystore(c, c.String("wallet"), "")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-34s %-66s\n", "ADDRESS", "PUBLIC KEY")
+	fmt.Println(strings.Repeat("-", 34), strings.Repeat("-", 66))
+	for _, acc := range client.GetAccounts() {
+		var publicKey []byte
+		if acc.PublicKey != nil {
+			if publicKey, err = acc.PublicKey.EncodePoint(true); err != nil {
+				return err
+			}
+		}
+		addr, err := acc.ProgramHash.ToAddress()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-34s %-66s\n", addr, hex.EncodeToString(publicKey))
+	}
+	fmt.Println(strings.Repeat("-", 34), strings.Repeat("-", 66))
+	return nil
+}
+
+// listAccounts shows the balances of the accounts by the listunspent of the
+// main node, and the MiningAddr of the side chains not in the keystore.
+func listAccounts(c *cli.Context) error {
+	if err := setupConfig(c); err != nil {
+		return err
+	}
+	client, err := openKeystore(c, c.String("wallet"), "")
+	if err != nil {
+		return err
+	}
+
+	accounts := client.GetAccounts()
+	addresses := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		addr, err := acc.ProgramHash.ToAddress()
+		if err != nil {
+			return err
+		}
+		addresses = append(addresses, addr)
+	}
+	utxos, err := rpc.GetUnspentUtxo(addresses, config.Parameters.MainNode.Rpc)
+	if err != nil {
+		return fmt.Errorf("get balances from the main node failed: %v", err)
+	}
+	balances := make(map[string]common.Fixed64)
+	for _, utxo := range utxos {
+		amount, err := common.StringToFixed64(utxo.Amount)
+		if err != nil {
+			return fmt.Errorf("invalid amount %s of %s", utxo.Amount, utxo.Address)
+		}
+		balances[utxo.Address] += *amount
+	}
+
+	miningSideChains := make(map[string][]string)
+	for _, node := range config.SideNodes() {
+		if node.MiningAddr != "" {
+			miningSideChains[node.MiningAddr] = append(miningSideChains[node.MiningAddr], node.GenesisBlockAddress)
+		}
+	}
+
+	mainAccount := client.GetMainAccount()
+	fmt.Printf("%-34s %-8s %20s  %s\n", "ADDRESS", "TYPE", "BALANCE", "MINING SIDE CHAINS")
+	fmt.Println(strings.Repeat("-", 34), strings.Repeat("-", 8), strings.Repeat("-", 20), strings.Repeat("-", 34))
+	for i, acc := range accounts {
+		accountType := "sub"
+		if acc.ProgramHash == mainAccount.ProgramHash {
+			accountType = "main"
+		} else if contract.GetPrefixType(acc.ProgramHash) == contract.PrefixMultiSig {
+			accountType = "multisig"
+		}
+		addr := addresses[i]
+		fmt.Printf("%-34s %-8s %20s  %s\n", addr, accountType, balances[addr].String(),
+			strings.Join(miningSideChains[addr], ", "))
+		delete(miningSideChains, addr)
+	}
+	fmt.Println(strings.Repeat("-", 34), strings.Repeat("-", 8), strings.Repeat("-", 20), strings.Repeat("-", 34))
+
+	for addr, sideChains := range miningSideChains {
+		fmt.Printf("MiningAddr %s of side chains %s is not in the keystore\n", addr, strings.Join(sideChains, ", "))
+	}
+	return nil
+}
+
+func addAccount(c *cli.Context) error {
+	client, err := openKeystore(c, c.String("wallet"), "")
+	if err != nil {
+		return err
+	}
+	acc, err := client.CreateAccount()
+	if err != nil {
+		return err
+	}
+	return printAccount("Added", acc)
+}
+
+// importAccount imports the private key read from --key-source, the key is
+// wiped after it is saved to the keystore.
+func importAccount(c *cli.Context) error {
+	client, err := openKeystore(c, c.String("wallet"), "")
+	if err != nil {
+		return err
+	}
+
+	source, err := password.ParseSource(c.String(keySourceFlag.Name))
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %v", keySourceFlag.Name, err)
+	}
+	var secret []byte
+	if source.Kind == password.SourcePrompt {
+		secret, err = password.GetSecret("Private key")
+	} else {
+		secret, err = source.Read()
+	}
+	if err != nil {
+		return fmt.Errorf("read private key error: %v", err)
+	}
+	defer password.Wipe(secret)
+
+	privateKey := make([]byte, hex.DecodedLen(len(secret)))
+	defer password.Wipe(privateKey)
+	if _, err := hex.Decode(privateKey, secret); err != nil || len(privateKey) != 32 {
+		return errors.New("private key should be 64 hex characters")
+	}
+	acc, err := account.NewAccountWithPrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+	if client.GetAccountByCodeHash(acc.ProgramHash.ToCodeHash()) != nil {
+		return fmt.Errorf("account %s is already in the keystore", acc.Address)
+	}
+	if err := client.SaveAccount(acc); err != nil {
+		return err
+	}
+	return printAccount("Imported", acc)
+}
+
+// changePassword writes the accounts to a new keystore encrypted by the new
+// password, and replaces the keystore by it once it opens.
+func changePassword(c *cli.Context) error {
+	file := c.String("wallet")
+	client, err := openKeystore(c, file, "")
+	if err != nil {
+		return err
+	}
+	newPasswd, err := readPasswordSource(c.String(newPasswordSourceFlag.Name), true)
+	if err != nil {
+		return err
+	}
+	defer password.Wipe(newPasswd)
+
+	tmpFile := file + ".tmp"
+	if err := os.Remove(tmpFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := copyAccounts(client, tmpFile, newPasswd); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	if err := os.Rename(tmpFile, file); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	fmt.Println("Password of", file, "is changed.")
+	return nil
+}
+
+// copyAccounts creates a keystore of the accounts of the client encrypted by
+// the password, and checks it opens with all the accounts.
+func copyAccounts(client *account.Client, file string, passwd []byte) error {
+	mainAccount := client.GetMainAccount()
+	newClient, err := account.CreateFromAccount(file, passwd, mainAccount)
+	if err != nil {
+		return err
+	}
+	accounts := client.GetAccounts()
+	for _, acc := range accounts {
+		if acc.ProgramHash == mainAccount.ProgramHash {
+			continue
+		}
+		if acc.PrivateKey != nil {
+			err = newClient.SaveAccount(acc)
+		} else {
+			err = newClient.SaveAccountData(&acc.ProgramHash, acc.RedeemScript, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	reopened, err := account.Open(file, passwd)
+	if err != nil || reopened == nil {
+		return fmt.Errorf("open new keystore failed, %v", err)
+	}
+	if len(reopened.GetAccounts()) != len(accounts) ||
+		reopened.GetMainAccount().ProgramHash != mainAccount.ProgramHash {
+		return errors.New("new keystore does not have all the accounts")
+	}
+	return nil
+}
+
+// exportedAccount is an account exported by wallet export.
+type exportedAccount struct {
+	Address   string
+	PublicKey string `json:",omitempty"`
+	Main      bool
+}
+
+func exportPublicKeys(c *cli.Context) error {
+	client, err := openKeystore(c, c.String("wallet"), "")
+	if err != nil {
+		return err
+	}
+
+	mainAccount := client.GetMainAccount()
+	accounts := make([]exportedAccount, 0)
+	for _, acc := range client.GetAccounts() {
+		addr, err := acc.ProgramHash.ToAddress()
+		if err != nil {
+			return err
+		}
+		exported := exportedAccount{Address: addr, Main: acc.ProgramHash == mainAccount.ProgramHash}
+		if acc.PublicKey != nil {
+			publicKey, err := acc.PublicKey.EncodePoint(true)
+			if err != nil {
+				return err
+			}
+			exported.PublicKey = hex.EncodeToString(publicKey)
+		}
+		accounts = append(accounts, exported)
+	}
+
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if out := c.String(exportOutFlag.Name); out != "" {
+		return ioutil.WriteFile(out, data, 0644)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// printAccount prints the address and the public key of the account.
+func printAccount(action string, acc *account.Account) error {
+	publicKey, err := acc.PublicKey.EncodePoint(true)
+	if err != nil {
+		return err
+	}
+	fmt.Println(action, "account")
+	fmt.Println("Address:   ", acc.Address)
+	fmt.Println("Public key:", hex.EncodeToString(publicKey))
+	return nil
+}
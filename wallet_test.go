@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA/account"
+	"github.com/urfave/cli"
+)
+
+// walletContext returns the context of the wallet subcommand with the args.
+func walletContext(t *testing.T, name string, args ...string) *cli.Context {
+	var command *cli.Command
+	for i, sub := range newWalletCommand().Subcommands {
+		if sub.Name == name {
+			command = &newWalletCommand().Subcommands[i]
+		}
+	}
+	if command == nil {
+		t.Fatal("unknown wallet command", name)
+	}
+	set := flag.NewFlagSet(name, flag.ContinueOnError)
+	for _, f := range command.Flags {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+func newTestWallet(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "arbiter-wallet-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "keystore.dat")
+	if err := createWallet(walletContext(t, "create", "--wallet", file, "--password", "old")); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal("Create wallet error:", err)
+	}
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func TestWallet_ChangePassword(t *testing.T) {
+	file, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	if err := addAccount(walletContext(t, "add", "--wallet", file, "--password", "old")); err != nil {
+		t.Fatal("Add account error:", err)
+	}
+	client, err := account.Open(file, []byte("old"))
+	if err != nil {
+		t.Fatal("Open wallet error:", err)
+	}
+	accounts, mainAccount := client.GetAccounts(), client.GetMainAccount()
+
+	os.Setenv("ARBITER_TEST_NEW_PASSWORD", "new")
+	defer os.Unsetenv("ARBITER_TEST_NEW_PASSWORD")
+	if err := changePassword(walletContext(t, "chpwd", "--wallet", file, "--password", "wrong",
+		"--new-password-source", "env:ARBITER_TEST_NEW_PASSWORD")); err == nil {
+		t.Error("Password should not be changed by a wrong password.")
+	}
+	if err := changePassword(walletContext(t, "chpwd", "--wallet", file, "--password", "old",
+		"--new-password-source", "env:ARBITER_TEST_NEW_PASSWORD")); err != nil {
+		t.Fatal("Change password error:", err)
+	}
+	if _, err := os.Stat(file + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Temporary keystore should be removed.")
+	}
+	if _, err := account.Open(file, []byte("old")); err == nil {
+		t.Error("Keystore should not open with the old password.")
+	}
+	client, err = account.Open(file, []byte("new"))
+	if err != nil {
+		t.Fatal("Keystore should open with the new password:", err)
+	}
+	if len(client.GetAccounts()) != len(accounts) ||
+		client.GetMainAccount().ProgramHash != mainAccount.ProgramHash {
+		t.Fatal("Accounts should be kept, got", len(client.GetAccounts()), "want", len(accounts))
+	}
+	for _, acc := range accounts {
+		changed := client.GetAccountByCodeHash(acc.ProgramHash.ToCodeHash())
+		if changed == nil || !bytes.Equal(changed.PrivKey(), acc.PrivKey()) {
+			t.Errorf("Account %s should be kept.", acc.Address)
+		}
+	}
+
+	// and back again
+	os.Setenv("ARBITER_TEST_NEW_PASSWORD", "old")
+	if err := changePassword(walletContext(t, "chpwd", "--wallet", file, "--password", "new",
+		"--new-password-source", "env:ARBITER_TEST_NEW_PASSWORD")); err != nil {
+		t.Fatal("Change password back error:", err)
+	}
+	if _, err := account.Open(file, []byte("old")); err != nil {
+		t.Error("Keystore should open with the password changed back:", err)
+	}
+}
+
+func TestWallet_ImportDuplicate(t *testing.T) {
+	file, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	client, err := account.Open(file, []byte("old"))
+	if err != nil {
+		t.Fatal("Open wallet error:", err)
+	}
+	mainKey := hex.EncodeToString(client.GetMainAccount().PrivKey())
+	os.Setenv("ARBITER_TEST_KEY", mainKey)
+	defer os.Unsetenv("ARBITER_TEST_KEY")
+	if err := importAccount(walletContext(t, "import", "--wallet", file, "--password", "old",
+		"--key-source", "env:ARBITER_TEST_KEY")); err == nil {
+		t.Error("Key of the main account should not be imported again.")
+	}
+
+	imported, err := account.NewAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("ARBITER_TEST_KEY", hex.EncodeToString(imported.PrivKey()))
+	if err := importAccount(walletContext(t, "import", "--wallet", file, "--password", "old",
+		"--key-source", "env:ARBITER_TEST_KEY")); err != nil {
+		t.Fatal("Import error:", err)
+	}
+	if err := importAccount(walletContext(t, "import", "--wallet", file, "--password", "old",
+		"--key-source", "env:ARBITER_TEST_KEY")); err == nil {
+		t.Error("Imported key should not be imported again.")
+	}
+
+	os.Setenv("ARBITER_TEST_KEY", mainKey[:62])
+	if err := importAccount(walletContext(t, "import", "--wallet", file, "--password", "old",
+		"--key-source", "env:ARBITER_TEST_KEY")); err == nil {
+		t.Error("Short key should not be imported.")
+	}
+
+	client, err = account.Open(file, []byte("old"))
+	if err != nil {
+		t.Fatal("Open wallet error:", err)
+	}
+	if len(client.GetAccounts()) != 2 {
+		t.Error("Keystore should have the main and the imported accounts, got", len(client.GetAccounts()))
+	}
+}
+
+func TestWallet_Export(t *testing.T) {
+	file, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	out := filepath.Join(filepath.Dir(file), "accounts.json")
+	if err := exportPublicKeys(walletContext(t, "export", "--wallet", file, "--password", "wrong", "--out", out)); err == nil {
+		t.Error("Export with a wrong password should fail.")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("Nothing should be written with a wrong password.")
+	}
+
+	if err := exportPublicKeys(walletContext(t, "export", "--wallet", file, "--password", "old", "--out", out)); err != nil {
+		t.Fatal("Export error:", err)
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accounts []exportedAccount
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		t.Fatal("Export should be JSON:", err)
+	}
+	client, _ := account.Open(file, []byte("old"))
+	mainAccount := client.GetMainAccount()
+	publicKey, _ := mainAccount.PublicKey.EncodePoint(true)
+	if len(accounts) != 1 || !accounts[0].Main || accounts[0].Address != mainAccount.Address ||
+		accounts[0].PublicKey != hex.EncodeToString(publicKey) {
+		t.Error("Export should have the main account, got", string(data))
+	}
+}